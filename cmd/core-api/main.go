@@ -10,6 +10,7 @@ import (
 	"syscall"
 
 	"github.com/linkasu/linka.type-backend/internal/auth"
+	"github.com/linkasu/linka.type-backend/internal/blob"
 	"github.com/linkasu/linka.type-backend/internal/config"
 	"github.com/linkasu/linka.type-backend/internal/coreapi"
 	"github.com/linkasu/linka.type-backend/internal/dialoghelper"
@@ -88,12 +89,35 @@ func main() {
 		}
 	}
 
+	blobs, err := blob.New(blob.Config{
+		Backend:     cfg.Media.Backend,
+		LocalDir:    cfg.Media.LocalDir,
+		S3Endpoint:  cfg.Media.S3Endpoint,
+		S3Region:    cfg.Media.S3Region,
+		S3Bucket:    cfg.Media.S3Bucket,
+		S3AccessKey: cfg.Media.S3AccessKey,
+		S3SecretKey: cfg.Media.S3SecretKey,
+	})
+	if err != nil {
+		logger.Error("failed to init media storage", "error", err)
+		os.Exit(1)
+	}
+	if blobs == nil {
+		logger.Warn("media uploads disabled (set MEDIA_BACKEND to enable)")
+	}
+
+	ydbStore := ydbstore.New(ydbClient)
+	if cfg.Sync.ProjectEnabled {
+		ydbStore.EnableProjectionQueue()
+	}
 	svc := &service.Service{
-		Store:        ydbstore.New(ydbClient),
+		Store:        ydbStore,
 		LegacyWriter: legacyWriter,
 		LegacyReader: legacyReader,
 		Feature:      cfg.Feature,
 		DialogHelper: dialoghelper.New(cfg.Dialog.BaseURL, cfg.Dialog.APIKey, cfg.Dialog.Timeout),
+		Blobs:        blobs,
+		Media:        cfg.Media,
 	}
 
 	handler := coreapi.New(svc, verifier, fbClients.Auth, jwtManager, cfg)
@@ -109,6 +133,14 @@ func main() {
 	shutdownCh := make(chan os.Signal, 1)
 	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
 
+	shareSyncCtx, stopShareSync := context.WithCancel(ctx)
+	defer stopShareSync()
+	go func() {
+		if err := svc.RunShareSync(shareSyncCtx); err != nil && !errors.Is(err, context.Canceled) {
+			logger.Error("share sync stopped", "error", err)
+		}
+	}()
+
 	go func() {
 		logger.Info("core-api listening", "addr", cfg.HTTP.Addr)
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -119,6 +151,7 @@ func main() {
 
 	<-shutdownCh
 	logger.Info("shutdown requested")
+	stopShareSync()
 
 	ctxTimeout, cancel := context.WithTimeout(ctx, cfg.HTTP.ShutdownTimeout)
 	defer cancel()
@@ -36,7 +36,11 @@ func main() {
 	}()
 
 	gptClient := gpt.NewClient(cfg.DialogWorker.FolderID, cfg.DialogWorker.ModelURI)
-	worker := dialogworker.New(ydbstore.New(ydbClient), gptClient, logger)
+	ydbStore := ydbstore.New(ydbClient)
+	if cfg.Sync.ProjectEnabled {
+		ydbStore.EnableProjectionQueue()
+	}
+	worker := dialogworker.New(ydbStore, gptClient, logger)
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,6 +13,7 @@ import (
 	"github.com/linkasu/linka.type-backend/internal/config"
 	"github.com/linkasu/linka.type-backend/internal/firebase"
 	"github.com/linkasu/linka.type-backend/internal/logging"
+	"github.com/linkasu/linka.type-backend/internal/shard"
 	"github.com/linkasu/linka.type-backend/internal/store/legacy"
 	"github.com/linkasu/linka.type-backend/internal/store/ydbstore"
 	"github.com/linkasu/linka.type-backend/internal/syncworker"
@@ -52,7 +55,52 @@ func main() {
 		os.Exit(1)
 	}
 
-	worker := syncworker.New(ydbClient, ydbstore.New(ydbClient), fbClients.DB, legacyReader)
+	userShard, err := shard.For(cfg.Sync.ShardIndex, cfg.Sync.ShardCount)
+	if err != nil {
+		logger.Error("invalid sync shard", "error", err)
+		os.Exit(1)
+	}
+
+	ydbStore := ydbstore.New(ydbClient)
+	if cfg.Sync.ProjectEnabled {
+		ydbStore.EnableProjectionQueue()
+	}
+	worker := syncworker.New(ydbClient, ydbStore, fbClients.DB, legacyReader)
+	worker.Configure(syncworker.Options{
+		InstanceID:       cfg.Sync.InstanceID,
+		Shard:            userShard,
+		LeaseTTL:         cfg.Sync.LeaseTTL,
+		BatchSize:        cfg.Sync.BatchSize,
+		FullScanInterval: cfg.Sync.FullScanInterval,
+		SweepInterval:    cfg.Sync.SweepInterval,
+	})
+	logger.Info("sync shard configured", "instance", cfg.Sync.InstanceID, "shard", userShard.String())
+	if cfg.Sync.ProjectEnabled && !cfg.Sync.DryRun {
+		writer, err := legacy.New(fbClients.DB)
+		if err != nil {
+			logger.Error("failed to init legacy writer", "error", err)
+			os.Exit(1)
+		}
+		worker.EnableProjection(writer)
+		logger.Info("rtdb projection enabled")
+	}
+
+	if cfg.Sync.DryRun {
+		logger.Info("sync-worker dry run started", "users", len(cfg.Sync.DryRunUsers))
+		report, err := worker.DryRun(ctx, cfg.Sync.DryRunUsers)
+		if err != nil {
+			logger.Error("dry run failed", "error", err)
+			os.Exit(1)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			logger.Error("failed to write dry run report", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if cfg.Sync.StreamEnabled {
 		var tokenSource oauth2.TokenSource
 		tokenSource, err = firebase.TokenSource(ctx, cfg.Firebase)
@@ -64,10 +112,24 @@ func main() {
 		logger.Info("rtdb streaming enabled", "path", cfg.Sync.StreamPath)
 	}
 
+	statusSrv := &http.Server{
+		Addr:         cfg.Sync.StatusAddr,
+		Handler:      worker.StatusHandler(cfg.Sync.ReadyStreamDown),
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+	}
+
 	shutdownCh := make(chan os.Signal, 1)
 	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
 
 	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("sync-worker status listening", "addr", cfg.Sync.StatusAddr)
+		if err := statusSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
 	go func() {
 		logger.Info("sync-worker started", "interval", cfg.Sync.PollInterval.String())
 		if err := worker.Run(ctx, cfg.Sync.PollInterval); err != nil {
@@ -83,4 +145,10 @@ func main() {
 			logger.Error("sync-worker failed", "error", err)
 		}
 	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := statusSrv.Shutdown(ctxTimeout); err != nil {
+		logger.Error("status server shutdown failed", "error", err)
+	}
 }
@@ -0,0 +1,52 @@
+// Package blob stores uploaded files behind a small interface so core-api can
+// use the local filesystem in development and S3-compatible storage in prod.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned when a key does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps opaque blobs by key. Keys use "/" as separator.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects and configures a blob store.
+type Config struct {
+	Backend     string
+	LocalDir    string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// New returns the configured store, or nil when no backend is set.
+func New(cfg Config) (Store, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", "none":
+		return nil, nil
+	case "local":
+		if cfg.LocalDir == "" {
+			return nil, errors.New("local blob store requires a directory")
+		}
+		return NewLocal(cfg.LocalDir), nil
+	case "s3":
+		if cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+			return nil, errors.New("s3 blob store requires bucket and credentials")
+		}
+		return NewS3(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey), nil
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,187 @@
+// Package boardfile reads phrase boards exported by spreadsheets and other
+// AAC apps: CSV, plain text with headers, and Open Board Format (OBF/OBZ).
+// Every format is reduced to categories holding phrase texts.
+package boardfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Supported formats.
+const (
+	FormatCSV  = "csv"
+	FormatText = "text"
+	FormatOBF  = "obf"
+	FormatOBZ  = "obz"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported board format")
+	ErrInvalidFile       = errors.New("invalid board file")
+)
+
+// Category is a parsed category. Parent is the index of the parent category
+// in Board.Categories, or -1 for a top-level one.
+type Category struct {
+	Label   string
+	Parent  int
+	Phrases []string
+}
+
+// Board is the content of one file. Skipped counts rows or buttons that
+// could not be read as phrases.
+type Board struct {
+	Categories []Category
+	Skipped    int
+}
+
+// Parse reads data in the given format.
+func Parse(format string, data []byte) (Board, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(bytes.NewReader(data))
+	case FormatText:
+		return ParseText(bytes.NewReader(data))
+	case FormatOBF:
+		return ParseOBF(data)
+	case FormatOBZ:
+		return ParseOBZ(data)
+	default:
+		return Board{}, ErrUnsupportedFormat
+	}
+}
+
+// FormatFromName guesses the format from a file name.
+func FormatFromName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(name, ".txt"):
+		return FormatText
+	case strings.HasSuffix(name, ".obf"):
+		return FormatOBF
+	case strings.HasSuffix(name, ".obz"):
+		return FormatOBZ
+	}
+	return ""
+}
+
+// builder collects phrases into categories in order of first appearance.
+type builder struct {
+	board Board
+	index map[string]int
+}
+
+func newBuilder() *builder {
+	return &builder{index: make(map[string]int)}
+}
+
+// category returns the index of the category with label under parent,
+// adding it when missing.
+func (b *builder) category(label string, parent int) int {
+	key := strconv.Itoa(parent) + "|" + strings.ToLower(label)
+	if i, ok := b.index[key]; ok {
+		return i
+	}
+	b.board.Categories = append(b.board.Categories, Category{Label: label, Parent: parent})
+	b.index[key] = len(b.board.Categories) - 1
+	return len(b.board.Categories) - 1
+}
+
+func (b *builder) phrase(category int, text string) {
+	b.board.Categories[category].Phrases = append(b.board.Categories[category].Phrases, text)
+}
+
+// ParseCSV reads rows of category and phrase text. A first row naming the
+// columns "category" and "text" is skipped.
+func ParseCSV(r io.Reader) (Board, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	b := newBuilder()
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Board{}, ErrInvalidFile
+		}
+		if first {
+			first = false
+			if len(record) >= 2 && strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff")), "category") &&
+				strings.EqualFold(strings.TrimSpace(record[1]), "text") {
+				continue
+			}
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+		}
+		if len(record) < 2 {
+			b.board.Skipped++
+			continue
+		}
+		label, text := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if label == "" || text == "" {
+			b.board.Skipped++
+			continue
+		}
+		b.phrase(b.category(label, -1), text)
+	}
+	return b.board, nil
+}
+
+// ParseText reads one phrase per line under "# Category" headers; more
+// hashes nest a category under the previous shallower one. Phrases before
+// the first header are skipped.
+func ParseText(r io.Reader) (Board, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	b := newBuilder()
+	// path[d] is the category opened at depth d+1.
+	var path []int
+	current := -1
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			depth := len(line) - len(strings.TrimLeft(line, "#"))
+			label := strings.TrimSpace(line[depth:])
+			if label == "" {
+				continue
+			}
+			if depth > len(path)+1 {
+				depth = len(path) + 1
+			}
+			parent := -1
+			if depth > 1 {
+				parent = path[depth-2]
+			}
+			current = b.category(label, parent)
+			path = append(path[:depth-1], current)
+			continue
+		}
+		if current < 0 {
+			b.board.Skipped++
+			continue
+		}
+		b.phrase(current, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Board{}, ErrInvalidFile
+	}
+	return b.board, nil
+}
@@ -0,0 +1,74 @@
+package boardfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	board, err := ParseCSV(strings.NewReader("category,text\nFood,I want tea\nFood,\"Bread, please\"\nfood,More\n,orphan\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(board.Categories) != 1 || board.Skipped != 1 {
+		t.Fatalf("unexpected board: %+v", board)
+	}
+	got := board.Categories[0]
+	if got.Label != "Food" || strings.Join(got.Phrases, "|") != "I want tea|Bread, please|More" {
+		t.Fatalf("unexpected category: %+v", got)
+	}
+}
+
+func TestParseTextNestsHeaders(t *testing.T) {
+	board, err := ParseText(strings.NewReader("stray\n# Home\nHello\n## Kitchen\nWater\n### Deep\n# School\nBye\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(board.Categories) != 4 || board.Skipped != 1 {
+		t.Fatalf("unexpected board: %+v", board)
+	}
+	if board.Categories[1].Label != "Kitchen" || board.Categories[1].Parent != 0 {
+		t.Fatalf("unexpected kitchen: %+v", board.Categories[1])
+	}
+	if board.Categories[2].Parent != 1 || board.Categories[3].Parent != -1 {
+		t.Fatalf("unexpected parents: %+v", board.Categories)
+	}
+}
+
+func TestParseOBZFollowsLinks(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := map[string]string{
+		"manifest.json": `{"root":"boards/root.obf","paths":{"boards":{"2":"boards/food.obf"}}}`,
+		"boards/root.obf": `{"id":"1","name":"Main","buttons":[
+			{"id":"a","label":"Hi"},
+			{"id":"b","label":"Food","load_board":{"id":"2"}},
+			{"id":"c","label":"yes","vocalization":"Yes, please"}],
+			"grid":{"order":[["c","a"],["b",null]]}}`,
+		"boards/food.obf": `{"id":"2","name":"Food","buttons":[{"id":"x","label":"Apple"},{"id":"y","label":"Back","load_board":{"path":"boards/root.obf"}}]}`,
+	}
+	for name, body := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	archive.Close()
+
+	board, err := ParseOBZ(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(board.Categories) != 2 {
+		t.Fatalf("unexpected board: %+v", board)
+	}
+	if strings.Join(board.Categories[0].Phrases, "|") != "Yes, please|Hi" {
+		t.Fatalf("unexpected root phrases: %+v", board.Categories[0])
+	}
+	if board.Categories[1].Label != "Food" || board.Categories[1].Parent != 0 {
+		t.Fatalf("unexpected food board: %+v", board.Categories[1])
+	}
+}
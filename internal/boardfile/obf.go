@@ -0,0 +1,203 @@
+package boardfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxOBZEntryBytes bounds one board inside an OBZ archive.
+const maxOBZEntryBytes = 4 * 1024 * 1024
+
+// obfBoard holds the parts of an Open Board Format board that carry phrases.
+type obfBoard struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Buttons []obfButton `json:"buttons"`
+	Grid    struct {
+		Order [][]*string `json:"order"`
+	} `json:"grid"`
+}
+
+type obfButton struct {
+	ID           string   `json:"id"`
+	Label        string   `json:"label"`
+	Vocalization string   `json:"vocalization"`
+	LoadBoard    *obfLink `json:"load_board"`
+}
+
+// obfLink points at another board of the same OBZ archive.
+type obfLink struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+// buttons returns the buttons in grid order; buttons missing from the grid
+// follow in file order.
+func (b obfBoard) buttons() []obfButton {
+	byID := make(map[string]obfButton, len(b.Buttons))
+	for _, button := range b.Buttons {
+		byID[button.ID] = button
+	}
+	placed := make(map[string]bool, len(b.Buttons))
+	out := make([]obfButton, 0, len(b.Buttons))
+	for _, row := range b.Grid.Order {
+		for _, id := range row {
+			if id == nil || placed[*id] {
+				continue
+			}
+			if button, ok := byID[*id]; ok {
+				placed[*id] = true
+				out = append(out, button)
+			}
+		}
+	}
+	for _, button := range b.Buttons {
+		if !placed[button.ID] {
+			out = append(out, button)
+		}
+	}
+	return out
+}
+
+func (b obfBoard) label() string {
+	if name := strings.TrimSpace(b.Name); name != "" {
+		return name
+	}
+	if id := strings.TrimSpace(b.ID); id != "" {
+		return id
+	}
+	return "Imported"
+}
+
+// ParseOBF reads a single board. Buttons that open other boards are
+// skipped, since the linked boards are not in the file.
+func ParseOBF(data []byte) (Board, error) {
+	var board obfBoard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return Board{}, ErrInvalidFile
+	}
+	b := newBuilder()
+	category := b.category(board.label(), -1)
+	for _, button := range board.buttons() {
+		if button.LoadBoard != nil {
+			b.board.Skipped++
+			continue
+		}
+		addButton(b, category, button)
+	}
+	return b.board, nil
+}
+
+// ParseOBZ reads a board set. Each board becomes a category; a board opened
+// from another one becomes its sub-category.
+func ParseOBZ(data []byte) (Board, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Board{}, ErrInvalidFile
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[path.Clean(file.Name)] = file
+	}
+
+	var manifest struct {
+		Root  string `json:"root"`
+		Paths struct {
+			Boards map[string]string `json:"boards"`
+		} `json:"paths"`
+	}
+	raw, err := readZipEntry(files["manifest.json"])
+	if err != nil {
+		return Board{}, err
+	}
+	if err := json.Unmarshal(raw, &manifest); err != nil || manifest.Root == "" {
+		return Board{}, ErrInvalidFile
+	}
+
+	boardPath := func(link *obfLink) string {
+		if link.Path != "" {
+			return path.Clean(link.Path)
+		}
+		if p, ok := manifest.Paths.Boards[link.ID]; ok {
+			return path.Clean(p)
+		}
+		return ""
+	}
+
+	b := newBuilder()
+	type queued struct {
+		path   string
+		parent int
+	}
+	queue := []queued{{path: path.Clean(manifest.Root), parent: -1}}
+	seen := map[string]bool{queue[0].path: true}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		raw, err := readZipEntry(files[next.path])
+		if err != nil {
+			if next.parent < 0 {
+				return Board{}, err
+			}
+			b.board.Skipped++
+			continue
+		}
+		var board obfBoard
+		if err := json.Unmarshal(raw, &board); err != nil {
+			if next.parent < 0 {
+				return Board{}, ErrInvalidFile
+			}
+			b.board.Skipped++
+			continue
+		}
+		category := b.category(board.label(), next.parent)
+		for _, button := range board.buttons() {
+			if button.LoadBoard == nil {
+				addButton(b, category, button)
+				continue
+			}
+			linked := boardPath(button.LoadBoard)
+			if linked == "" {
+				b.board.Skipped++
+				continue
+			}
+			if !seen[linked] {
+				seen[linked] = true
+				queue = append(queue, queued{path: linked, parent: category})
+			}
+		}
+	}
+	return b.board, nil
+}
+
+func addButton(b *builder, category int, button obfButton) {
+	text := strings.TrimSpace(button.Vocalization)
+	if text == "" {
+		text = strings.TrimSpace(button.Label)
+	}
+	if text == "" {
+		b.board.Skipped++
+		return
+	}
+	b.phrase(category, text)
+}
+
+func readZipEntry(file *zip.File) ([]byte, error) {
+	if file == nil || file.UncompressedSize64 > maxOBZEntryBytes {
+		return nil, ErrInvalidFile
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, ErrInvalidFile
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxOBZEntryBytes+1))
+	if err != nil || len(data) > maxOBZEntryBytes {
+		return nil, ErrInvalidFile
+	}
+	return data, nil
+}
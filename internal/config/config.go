@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,6 +22,7 @@ type Config struct {
 	Dialog    DialogHelperConfig
 	DialogWorker DialogWorkerConfig
 	JWT       JWTConfig
+	Media     MediaConfig
 }
 
 // HTTPConfig controls HTTP server behavior.
@@ -50,8 +52,9 @@ type YDBConfig struct {
 
 // FeatureConfig controls rollout behavior.
 type FeatureConfig struct {
-	ReadSource    string
-	CohortPercent int
+	ReadSource        string
+	CohortPercent     int
+	MigrationCacheTTL time.Duration
 }
 
 // TTSConfig controls the optional proxy.
@@ -62,10 +65,22 @@ type TTSConfig struct {
 
 // SyncConfig controls sync-worker behavior.
 type SyncConfig struct {
-	PollInterval    time.Duration
-	StreamEnabled   bool
-	StreamPath      string
-	StreamReconnect time.Duration
+	PollInterval     time.Duration
+	StreamEnabled    bool
+	StreamPath       string
+	StreamReconnect  time.Duration
+	InstanceID       string
+	ShardIndex       int
+	ShardCount       int
+	LeaseTTL         time.Duration
+	BatchSize        int
+	FullScanInterval time.Duration
+	SweepInterval    time.Duration
+	StatusAddr       string
+	ReadyStreamDown  time.Duration
+	DryRun           bool
+	DryRunUsers      []string
+	ProjectEnabled   bool
 }
 
 // PredictorConfig controls Yandex Predictor API integration.
@@ -97,6 +112,19 @@ type JWTConfig struct {
 	CookieSecure         bool
 }
 
+// MediaConfig controls image uploads and their blob storage.
+type MediaConfig struct {
+	Backend        string
+	LocalDir       string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	MaxUploadBytes int64
+	ThumbnailSize  int
+}
+
 // Load reads config from environment variables.
 func Load() (Config, error) {
 	var cfg Config
@@ -129,8 +157,9 @@ func Load() (Config, error) {
 	}
 
 	cfg.Feature = FeatureConfig{
-		ReadSource:    getenv("FEATURE_READ_SOURCE", "firebase_only"),
-		CohortPercent: getenvInt("FEATURE_COHORT_PERCENT", 0),
+		ReadSource:        getenv("FEATURE_READ_SOURCE", "firebase_only"),
+		CohortPercent:     getenvInt("FEATURE_COHORT_PERCENT", 0),
+		MigrationCacheTTL: getenvDuration("FEATURE_MIGRATION_CACHE_TTL", 30*time.Second),
 	}
 
 	cfg.TTS = TTSConfig{
@@ -139,10 +168,22 @@ func Load() (Config, error) {
 	}
 
 	cfg.Sync = SyncConfig{
-		PollInterval:    getenvDuration("SYNC_POLL_INTERVAL", 5*time.Second),
-		StreamEnabled:   getenvBool("SYNC_STREAM_ENABLED", false),
-		StreamPath:      getenv("SYNC_STREAM_PATH", "users"),
-		StreamReconnect: getenvDuration("SYNC_STREAM_RECONNECT", 5*time.Second),
+		PollInterval:     getenvDuration("SYNC_POLL_INTERVAL", 5*time.Second),
+		StreamEnabled:    getenvBool("SYNC_STREAM_ENABLED", false),
+		StreamPath:       getenv("SYNC_STREAM_PATH", "users"),
+		StreamReconnect:  getenvDuration("SYNC_STREAM_RECONNECT", 5*time.Second),
+		InstanceID:       getenv("SYNC_INSTANCE_ID", hostname()),
+		ShardIndex:       getenvInt("SYNC_SHARD_INDEX", 0),
+		ShardCount:       getenvInt("SYNC_SHARD_COUNT", 1),
+		LeaseTTL:         getenvDuration("SYNC_LEASE_TTL", 30*time.Second),
+		BatchSize:        getenvInt("SYNC_BATCH_SIZE", 100),
+		FullScanInterval: getenvDuration("SYNC_FULL_SCAN_INTERVAL", 24*time.Hour),
+		SweepInterval:    getenvDuration("SYNC_SWEEP_INTERVAL", 15*time.Minute),
+		StatusAddr:       getenv("SYNC_STATUS_ADDR", cfg.HTTP.Addr),
+		ReadyStreamDown:  getenvDuration("SYNC_READY_STREAM_DOWN", 2*time.Minute),
+		DryRun:           getenvBool("SYNC_DRY_RUN", false),
+		DryRunUsers:      getenvList("SYNC_DRY_RUN_USERS"),
+		ProjectEnabled:   getenvBool("SYNC_PROJECT_ENABLED", false),
 	}
 
 	predictorKey := getenv("YANDEX_PREDICTOR_API_KEY", "")
@@ -174,9 +215,27 @@ func Load() (Config, error) {
 		CookieSecure:         getenvBool("JWT_COOKIE_SECURE", cfg.Env != "dev"),
 	}
 
+	cfg.Media = MediaConfig{
+		Backend:        getenv("MEDIA_BACKEND", ""),
+		LocalDir:       getenv("MEDIA_LOCAL_DIR", "./data/media"),
+		S3Endpoint:     getenv("MEDIA_S3_ENDPOINT", "https://storage.yandexcloud.net"),
+		S3Region:       getenv("MEDIA_S3_REGION", "ru-central1"),
+		S3Bucket:       getenv("MEDIA_S3_BUCKET", ""),
+		S3AccessKey:    getenv("MEDIA_S3_ACCESS_KEY", ""),
+		S3SecretKey:    getenv("MEDIA_S3_SECRET_KEY", ""),
+		MaxUploadBytes: int64(getenvInt("MEDIA_MAX_UPLOAD_BYTES", 5*1024*1024)),
+		ThumbnailSize:  getenvInt("MEDIA_THUMBNAIL_SIZE", 256),
+	}
+
 	if cfg.Feature.CohortPercent < 0 || cfg.Feature.CohortPercent > 100 {
 		return cfg, fmt.Errorf("FEATURE_COHORT_PERCENT must be between 0 and 100")
 	}
+	if cfg.Sync.ShardCount <= 0 {
+		return cfg, fmt.Errorf("SYNC_SHARD_COUNT must be positive")
+	}
+	if cfg.Sync.ShardIndex < 0 || cfg.Sync.ShardIndex >= cfg.Sync.ShardCount {
+		return cfg, fmt.Errorf("SYNC_SHARD_INDEX must be between 0 and SYNC_SHARD_COUNT-1")
+	}
 
 	return cfg, nil
 }
@@ -205,6 +264,14 @@ func httpAddr() string {
 	return ":8080"
 }
 
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "sync-worker"
+	}
+	return name
+}
+
 func getenv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -236,6 +303,16 @@ func getenvBool(key string, fallback bool) bool {
 	return parsed
 }
 
+func getenvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getenvDuration(key string, fallback time.Duration) time.Duration {
 	val := os.Getenv(key)
 	if val == "" {
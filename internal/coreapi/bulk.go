@@ -0,0 +1,44 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func (api *API) bulkStatements(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Op         string   `json:"op"`
+		IDs        []string `json:"ids"`
+		CategoryID string   `json:"categoryId"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	result, err := api.svc.BulkStatements(r.Context(), user.UID, service.BulkStatementRequest{
+		Op:         req.Op,
+		IDs:        req.IDs,
+		CategoryID: req.CategoryID,
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidBulk):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "op must be move, copy or delete with 1-500 ids; move and copy need categoryId")
+	case errors.Is(err, service.ErrInvalidCategory):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_category", err.Error())
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "statement not found")
+	case err != nil:
+		httpapi.WriteError(w, http.StatusInternalServerError, "bulk_failed", err.Error())
+	default:
+		httpapi.WriteJSON(w, http.StatusOK, result)
+	}
+}
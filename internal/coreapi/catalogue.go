@@ -0,0 +1,50 @@
+package coreapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+// maxCatalogueBytes bounds an uploaded catalogue bundle.
+const maxCatalogueBytes = 10 * 1024 * 1024
+
+func (api *API) adminExportCatalogue(w http.ResponseWriter, r *http.Request) {
+	bundle, err := api.svc.ExportCatalogue(r.Context())
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "export_catalogue_failed", err.Error())
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="catalogue-%s.json"`, time.UnixMilli(bundle.ExportedAt).UTC().Format("20060102-150405")))
+	httpapi.WriteJSON(w, http.StatusOK, bundle)
+}
+
+func (api *API) adminImportCatalogue(w http.ResponseWriter, r *http.Request) {
+	var bundle service.CatalogueBundle
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCatalogueBytes)).Decode(&bundle); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpapi.WriteError(w, http.StatusRequestEntityTooLarge, "catalogue_too_large", "bundle is too large")
+			return
+		}
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	diff, err := api.svc.ImportCatalogue(r.Context(), bundle, query.Get("mode"), query.Get("dryRun") == "true")
+	switch {
+	case errors.Is(err, service.ErrInvalidCatalogue):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_catalogue", err.Error())
+		return
+	case err != nil:
+		httpapi.WriteError(w, http.StatusInternalServerError, "import_catalogue_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, diff)
+}
@@ -52,6 +52,8 @@ func New(svc *service.Service, verifier auth.Verifier, fbAuth *fbauth.Client, jw
 	r := chi.NewRouter()
 	r.Use(corsMiddleware)
 	r.Use(httpmiddleware.RequestID)
+	r.Use(httpmiddleware.Device)
+	r.Use(httpmiddleware.Locale)
 
 	r.Get("/", serveWebFile("index.html", "text/html; charset=utf-8"))
 	r.Get("/client.md", serveWebFile("client.md", "text/markdown; charset=utf-8"))
@@ -80,30 +82,93 @@ func New(svc *service.Service, verifier auth.Verifier, fbAuth *fbauth.Client, jw
 		}
 
 		// Protected endpoints with general rate limiting (100 req/min)
+		// Board routes, which delegates may call for an owner via X-Act-As.
 		r.Group(func(r chi.Router) {
 			r.Use(APIRateLimiter.Middleware)
-			r.Use(httpmiddleware.Auth(verifier))
+			r.Use(httpmiddleware.Auth(verifier, svc.Store))
 			r.Get("/categories", api.listCategories)
 			r.Post("/categories", api.createCategory)
+			r.Post("/categories/reorder", api.reorderCategories)
 			r.Patch("/categories/{id}", api.patchCategory)
 			r.Delete("/categories/{id}", api.deleteCategory)
 
 			r.Get("/categories/{id}/statements", api.listStatements)
+			r.Post("/categories/{id}/statements/reorder", api.reorderStatements)
 			r.Post("/statements", api.createStatement)
+			r.Post("/statements/spoken", api.recordSpokenBatch)
+			r.Post("/statements/bulk", api.bulkStatements)
+			r.Get("/statements/frequent", api.listFrequentStatements)
+			r.Get("/statements/recent", api.listRecentStatements)
+			r.Post("/statements/{id}/spoken", api.recordStatementSpoken)
 			r.Patch("/statements/{id}", api.patchStatement)
 			r.Delete("/statements/{id}", api.deleteStatement)
+			r.Get("/statements/{id}/history", api.statementHistory)
+			r.Post("/statements/{id}/revert", api.revertStatement)
+
+			r.Get("/quickes", api.getQuickes)
+			r.Put("/quickes", api.putQuickes)
+			r.Get("/quickes/usage", api.listQuickeUsage)
+			r.Post("/quickes/{slot}/spoken", api.recordQuickeSpoken)
+		})
+
+		// Protected endpoints with general rate limiting (100 req/min)
+		r.Group(func(r chi.Router) {
+			r.Use(APIRateLimiter.Middleware)
+			r.Use(httpmiddleware.Auth(verifier, nil))
+			r.Post("/categories/{id}/share", api.createShare)
+			r.Get("/shares/{token}", api.previewShare)
+			r.Post("/shares/{token}/accept", api.acceptShare)
+			r.Delete("/shares/{token}", api.revokeShare)
+			r.Delete("/shares/{token}/subscription", api.unsubscribeShare)
+			r.Get("/search", api.search)
+			r.Post("/undo", api.undo)
+
+			r.Get("/grants", api.listGrants)
+			r.Put("/grants/{delegateId}", api.putGrant)
+			r.Delete("/grants/{delegateId}", api.deleteGrant)
+			r.Get("/orgs", api.listOrgs)
+			r.Post("/orgs", api.createOrg)
+			r.Get("/orgs/{orgId}/members", api.listOrgMembers)
+			r.Put("/orgs/{orgId}/members/{userId}", api.putOrgMember)
+			r.Delete("/orgs/{orgId}/members/{userId}", api.deleteOrgMember)
+			r.Post("/orgs/{orgId}/accept", api.acceptOrgInvite)
+			r.Get("/orgs/{orgId}/library", api.getOrgLibrary)
+			r.Post("/orgs/{orgId}/library/categories", api.saveOrgCategory)
+			r.Put("/orgs/{orgId}/library/categories/{categoryId}", api.saveOrgCategory)
+			r.Delete("/orgs/{orgId}/library/categories/{categoryId}", api.deleteOrgCategory)
+			r.Post("/orgs/{orgId}/library/statements", api.saveOrgStatement)
+			r.Put("/orgs/{orgId}/library/statements/{statementId}", api.saveOrgStatement)
+			r.Delete("/orgs/{orgId}/library/statements/{statementId}", api.deleteOrgStatement)
+			r.Post("/orgs/{orgId}/assign", api.assignOrgCategories)
+			r.Get("/orgs/{orgId}/stats", api.orgStats)
+
+			r.Get("/trash", api.listTrash)
+			r.Post("/trash/restore", api.restoreTrash)
+			r.Delete("/trash", api.purgeTrash)
+
+			r.Get("/media", api.listMedia)
+			r.Post("/media", api.uploadMedia)
+			r.Get("/media/{id}", api.getMedia)
+			r.Get("/media/{id}/thumbnail", api.getMediaThumbnail)
+			r.Delete("/media/{id}", api.deleteMedia)
 
 			r.Get("/user/state", api.getUserState)
 			r.Put("/user/state", api.putUserState)
 			r.Post("/user/bootstrap", api.bootstrapUser)
-			r.Get("/quickes", api.getQuickes)
-			r.Put("/quickes", api.putQuickes)
+			r.Get("/user/export", api.exportUser)
+			r.Get("/user/export/jobs/{id}", api.getExportJob)
+			r.Get("/user/export/jobs/{id}/download", api.downloadExport)
+			r.Post("/import", api.importBoard)
 
 			r.Get("/global/categories", api.listGlobalCategories)
 			r.Get("/global/categories/{id}/statements", api.listGlobalStatements)
 			r.Post("/global/import", api.importGlobal)
+			r.Get("/global/updates", api.listGlobalUpdates)
+			r.Post("/global/updates/apply", api.applyGlobalUpdates)
 
 			r.Get("/factory/questions", api.listFactoryQuestions)
+			r.Get("/onboarding", api.getOnboarding)
+			r.Put("/onboarding/answers", api.saveOnboardingAnswers)
 			r.Post("/onboarding/phrases", api.onboardingPhrases)
 
 			r.Post("/user/delete", api.deleteUser)
@@ -128,7 +193,7 @@ func New(svc *service.Service, verifier auth.Verifier, fbAuth *fbauth.Client, jw
 		})
 
 		r.Route("/admin", func(r chi.Router) {
-			r.Use(httpmiddleware.Auth(verifier))
+			r.Use(httpmiddleware.Auth(verifier, nil))
 			r.Use(httpmiddleware.Admin(svc))
 			r.Get("/stats", api.adminStats)
 			r.Get("/admins", api.adminListAdmins)
@@ -141,10 +206,28 @@ func New(svc *service.Service, verifier auth.Verifier, fbAuth *fbauth.Client, jw
 			r.Post("/global/categories", api.adminCreateGlobalCategory)
 			r.Patch("/global/categories/{id}", api.adminUpdateGlobalCategory)
 			r.Delete("/global/categories/{id}", api.adminDeleteGlobalCategory)
+			r.Post("/global/categories/{id}/statements", api.adminCreateGlobalStatement)
+			r.Post("/global/categories/{id}/statements/reorder", api.adminReorderGlobalStatements)
+			r.Post("/global/categories/{id}/statements/bulk", api.adminPasteGlobalStatements)
+			r.Patch("/global/categories/{id}/statements/{sid}", api.adminUpdateGlobalStatement)
+			r.Delete("/global/categories/{id}/statements/{sid}", api.adminDeleteGlobalStatement)
+			r.Get("/translations", api.adminListTranslations)
+			r.Put("/translations", api.adminSaveTranslation)
+			r.Delete("/translations", api.adminDeleteTranslation)
+			r.Get("/translations/missing", api.adminMissingTranslations)
+			r.Get("/catalogue/export", api.adminExportCatalogue)
+			r.Post("/catalogue/import", api.adminImportCatalogue)
 			r.Get("/factory/questions", api.adminListFactoryQuestions)
 			r.Post("/factory/questions", api.adminCreateFactoryQuestion)
 			r.Patch("/factory/questions/{id}", api.adminUpdateFactoryQuestion)
 			r.Delete("/factory/questions/{id}", api.adminDeleteFactoryQuestion)
+			r.Get("/migration", api.adminListMigrations)
+			r.Post("/migration/cohort", api.adminMoveMigrationCohort)
+			r.Get("/migration/users/{user_id}", api.adminGetMigration)
+			r.Put("/migration/users/{user_id}", api.adminSetMigration)
+			r.Post("/migration/users/{user_id}/verify", api.adminVerifyMigration)
+
+			r.Post("/search/reindex", api.adminReindexSearch)
 		})
 	})
 
@@ -156,6 +239,15 @@ func (api *API) listCategories(w http.ResponseWriter, r *http.Request) {
 	if user.UID == "" {
 		return
 	}
+	if r.URL.Query().Get("tree") == "true" {
+		tree, err := api.svc.ListCategoryTree(r.Context(), user.UID)
+		if err != nil {
+			httpapi.WriteError(w, http.StatusInternalServerError, "categories_failed", err.Error())
+			return
+		}
+		writeTaggedJSON(w, r, tree)
+		return
+	}
 	categories, err := api.svc.ListCategories(r.Context(), user.UID)
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "categories_failed", err.Error())
@@ -164,7 +256,7 @@ func (api *API) listCategories(w http.ResponseWriter, r *http.Request) {
 	if categories == nil {
 		categories = []models.Category{}
 	}
-	httpapi.WriteJSON(w, http.StatusOK, categories)
+	writeTaggedJSON(w, r, categories)
 }
 
 func (api *API) createCategory(w http.ResponseWriter, r *http.Request) {
@@ -173,11 +265,15 @@ func (api *API) createCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req struct {
-		ID      string `json:"id"`
-		Label   string `json:"label"`
-		Created int64  `json:"created"`
-		Default *bool  `json:"default"`
-		AIUse   *bool  `json:"aiUse"`
+		ID       string `json:"id"`
+		Label    string `json:"label"`
+		Created  int64  `json:"created"`
+		Default  *bool  `json:"default"`
+		AIUse    *bool  `json:"aiUse"`
+		ParentID string `json:"parentId"`
+		Color    string `json:"color"`
+		Icon     string `json:"icon"`
+		ImageID  string `json:"imageId"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
@@ -189,17 +285,28 @@ func (api *API) createCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	category, err := api.svc.CreateCategory(r.Context(), user.UID, service.CategoryInput{
-		ID:      req.ID,
-		Label:   req.Label,
-		Created: req.Created,
-		Default: req.Default,
-		AIUse:   req.AIUse != nil && *req.AIUse,
+		ID:       req.ID,
+		Label:    req.Label,
+		Created:  req.Created,
+		Default:  req.Default,
+		AIUse:    req.AIUse != nil && *req.AIUse,
+		ParentID: req.ParentID,
+		Color:    req.Color,
+		Icon:     req.Icon,
+		ImageID:  req.ImageID,
 	})
+	if errors.Is(err, service.ErrInvalidParent) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_parent", err.Error())
+		return
+	}
+	if writeVisualError(w, err) {
+		return
+	}
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "create_category_failed", err.Error())
 		return
 	}
-	httpapi.WriteJSON(w, http.StatusOK, category)
+	writeVersionedJSON(w, category.UpdatedAt, category)
 }
 
 func (api *API) patchCategory(w http.ResponseWriter, r *http.Request) {
@@ -214,29 +321,50 @@ func (api *API) patchCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Label   *string `json:"label"`
-		Default *bool   `json:"default"`
-		AIUse   *bool   `json:"aiUse"`
+		Label    *string `json:"label"`
+		Default  *bool   `json:"default"`
+		AIUse    *bool   `json:"aiUse"`
+		ParentID *string `json:"parentId"`
+		Color    *string `json:"color"`
+		Icon     *string `json:"icon"`
+		ImageID  *string `json:"imageId"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
 		return
 	}
-	if req.Label == nil && req.Default == nil && req.AIUse == nil {
-		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "label, default, or aiUse is required")
+	if req.Label == nil && req.Default == nil && req.AIUse == nil && req.ParentID == nil &&
+		req.Color == nil && req.Icon == nil && req.ImageID == nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "label, default, aiUse, parentId, color, icon, or imageId is required")
 		return
 	}
 
 	category, err := api.svc.UpdateCategory(r.Context(), user.UID, categoryID, service.CategoryPatch{
-		Label:   req.Label,
-		Default: req.Default,
-		AIUse:   req.AIUse,
+		Label:    req.Label,
+		Default:  req.Default,
+		AIUse:    req.AIUse,
+		ParentID: req.ParentID,
+		Color:    req.Color,
+		Icon:     req.Icon,
+		ImageID:  req.ImageID,
+		IfMatch:  parseIfMatch(r.Header.Get("If-Match")),
 	})
+	if errors.Is(err, service.ErrPreconditionFailed) {
+		api.writeCategoryConflict(w, r, user.UID, categoryID)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidParent) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_parent", err.Error())
+		return
+	}
+	if writeVisualError(w, err) {
+		return
+	}
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "update_category_failed", err.Error())
 		return
 	}
-	httpapi.WriteJSON(w, http.StatusOK, category)
+	writeVersionedJSON(w, category.UpdatedAt, category)
 }
 
 func (api *API) deleteCategory(w http.ResponseWriter, r *http.Request) {
@@ -250,7 +378,22 @@ func (api *API) deleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := api.svc.DeleteCategory(r.Context(), user.UID, categoryID); err != nil {
+	var cascade bool
+	switch r.URL.Query().Get("mode") {
+	case "", "reparent":
+	case "cascade":
+		cascade = true
+	default:
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_mode", "mode must be reparent or cascade")
+		return
+	}
+
+	err := api.svc.DeleteCategory(r.Context(), user.UID, categoryID, cascade, parseIfMatch(r.Header.Get("If-Match")))
+	if errors.Is(err, service.ErrPreconditionFailed) {
+		api.writeCategoryConflict(w, r, user.UID, categoryID)
+		return
+	}
+	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "delete_category_failed", err.Error())
 		return
 	}
@@ -276,7 +419,7 @@ func (api *API) listStatements(w http.ResponseWriter, r *http.Request) {
 	if statements == nil {
 		statements = []models.Statement{}
 	}
-	httpapi.WriteJSON(w, http.StatusOK, statements)
+	writeTaggedJSON(w, r, statements)
 }
 
 func (api *API) createStatement(w http.ResponseWriter, r *http.Request) {
@@ -289,6 +432,9 @@ func (api *API) createStatement(w http.ResponseWriter, r *http.Request) {
 		CategoryID string                  `json:"categoryId"`
 		Text       string                  `json:"text"`
 		Created    int64                   `json:"created"`
+		Color      string                  `json:"color"`
+		Icon       string                  `json:"icon"`
+		ImageID    string                  `json:"imageId"`
 		Questions  []service.QuestionInput `json:"questions"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
@@ -297,8 +443,8 @@ func (api *API) createStatement(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(req.Questions) > 0 {
-		if _, err := api.svc.OnboardingPhrases(r.Context(), user.UID, req.Questions); err != nil {
-			httpapi.WriteError(w, http.StatusInternalServerError, "onboarding_failed", err.Error())
+		if _, err := api.svc.OnboardingPhrases(r.Context(), user.UID, req.Questions, false, api.svc.UserLocale(r.Context(), user.UID)); err != nil {
+			writeOnboardingError(w, err)
 			return
 		}
 		writeStatusOK(w)
@@ -315,13 +461,19 @@ func (api *API) createStatement(w http.ResponseWriter, r *http.Request) {
 		CategoryID: req.CategoryID,
 		Text:       req.Text,
 		Created:    req.Created,
+		Color:      req.Color,
+		Icon:       req.Icon,
+		ImageID:    req.ImageID,
 	})
+	if writeVisualError(w, err) {
+		return
+	}
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "create_statement_failed", err.Error())
 		return
 	}
 
-	httpapi.WriteJSON(w, http.StatusOK, statement)
+	writeVersionedJSON(w, statement.UpdatedAt, statement)
 }
 
 func (api *API) patchStatement(w http.ResponseWriter, r *http.Request) {
@@ -336,23 +488,49 @@ func (api *API) patchStatement(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Text *string `json:"text"`
+		Text       *string `json:"text"`
+		CategoryID *string `json:"categoryId"`
+		Color      *string `json:"color"`
+		Icon       *string `json:"icon"`
+		ImageID    *string `json:"imageId"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
 		return
 	}
-	if req.Text == nil {
-		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "text is required")
+	if req.Text == nil && req.CategoryID == nil && req.Color == nil && req.Icon == nil && req.ImageID == nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "text, categoryId, color, icon, or imageId is required")
+		return
+	}
+	if req.CategoryID != nil && strings.TrimSpace(*req.CategoryID) == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_category", "categoryId must not be empty")
 		return
 	}
 
-	statement, err := api.svc.UpdateStatement(r.Context(), user.UID, statementID, service.StatementPatch{Text: req.Text})
+	statement, err := api.svc.UpdateStatement(r.Context(), user.UID, statementID, service.StatementPatch{
+		Text:       req.Text,
+		CategoryID: req.CategoryID,
+		Color:      req.Color,
+		Icon:       req.Icon,
+		ImageID:    req.ImageID,
+		IfMatch:    parseIfMatch(r.Header.Get("If-Match")),
+	})
+	if errors.Is(err, service.ErrPreconditionFailed) {
+		api.writeStatementConflict(w, r, user.UID, statementID)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidCategory) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_category", err.Error())
+		return
+	}
+	if writeVisualError(w, err) {
+		return
+	}
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "update_statement_failed", err.Error())
 		return
 	}
-	httpapi.WriteJSON(w, http.StatusOK, statement)
+	writeVersionedJSON(w, statement.UpdatedAt, statement)
 }
 
 func (api *API) deleteStatement(w http.ResponseWriter, r *http.Request) {
@@ -366,7 +544,12 @@ func (api *API) deleteStatement(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := api.svc.DeleteStatement(r.Context(), user.UID, statementID); err != nil {
+	err := api.svc.DeleteStatement(r.Context(), user.UID, statementID, parseIfMatch(r.Header.Get("If-Match")))
+	if errors.Is(err, service.ErrPreconditionFailed) {
+		api.writeStatementConflict(w, r, user.UID, statementID)
+		return
+	}
+	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "delete_statement_failed", err.Error())
 		return
 	}
@@ -383,13 +566,7 @@ func (api *API) getUserState(w http.ResponseWriter, r *http.Request) {
 		httpapi.WriteError(w, http.StatusInternalServerError, "state_failed", err.Error())
 		return
 	}
-	if state.Quickes == nil {
-		state.Quickes = []string{}
-	}
-	if state.Preferences == nil {
-		state.Preferences = map[string]any{}
-	}
-	httpapi.WriteJSON(w, http.StatusOK, state)
+	writeTaggedJSON(w, r, servedUserState(state))
 }
 
 func (api *API) putUserState(w http.ResponseWriter, r *http.Request) {
@@ -427,15 +604,40 @@ func (api *API) putUserState(w http.ResponseWriter, r *http.Request) {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "inited, quickes, or preferences required")
 		return
 	}
+	if match := contentMatch(r); match != nil {
+		patch.IfMatch = func(current models.UserState) bool {
+			return match(servedUserState(current))
+		}
+	}
 
 	state, err := api.svc.UpdateUserState(r.Context(), user.UID, patch)
 	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			api.writeUserStateConflict(w, r, user.UID, func(state models.UserState) any { return state })
+			return
+		}
 		httpapi.WriteError(w, http.StatusInternalServerError, "state_update_failed", err.Error())
 		return
 	}
-	httpapi.WriteJSON(w, http.StatusOK, state)
+	writeTaggedJSON(w, r, servedUserState(state))
 }
 
+// servedUserState fills empty fields the way GET /v1/user/state serves them,
+// so its ETag is the same wherever the state is returned.
+func servedUserState(state models.UserState) models.UserState {
+	if state.Quickes == nil {
+		state.Quickes = []string{}
+	}
+	if state.Preferences == nil {
+		state.Preferences = map[string]any{}
+	}
+	return state
+}
+
+// maxBootstrapBytes bounds an uploaded offline snapshot; a whole account
+// does not fit the default JSON body limit.
+const maxBootstrapBytes = 10 * 1024 * 1024
+
 func (api *API) bootstrapUser(w http.ResponseWriter, r *http.Request) {
 	user := mustUser(w, r)
 	if user.UID == "" {
@@ -445,6 +647,7 @@ func (api *API) bootstrapUser(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		MergeStrategy    string `json:"mergeStrategy"`
 		ClientSnapshotID string `json:"clientSnapshotId"`
+		Preview          bool   `json:"preview"`
 		Snapshot         struct {
 			Categories  []models.Category  `json:"categories"`
 			Statements  []models.Statement `json:"statements"`
@@ -454,7 +657,12 @@ func (api *API) bootstrapUser(w http.ResponseWriter, r *http.Request) {
 		} `json:"snapshot"`
 	}
 
-	if err := decodeJSON(w, r, &req); err != nil {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBootstrapBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpapi.WriteError(w, http.StatusRequestEntityTooLarge, "snapshot_too_large", "snapshot is too large")
+			return
+		}
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
 		return
 	}
@@ -465,10 +673,10 @@ func (api *API) bootstrapUser(w http.ResponseWriter, r *http.Request) {
 		Quickes:     req.Snapshot.Quickes,
 		Inited:      req.Snapshot.Inited,
 		Preferences: req.Snapshot.Preferences,
-	}, req.MergeStrategy)
+	}, req.MergeStrategy, req.Preview)
 	if err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "merge strategy") {
-			httpapi.WriteError(w, http.StatusBadRequest, "invalid_merge_strategy", err.Error())
+		if errors.Is(err, service.ErrInvalidMergeStrategy) {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_merge_strategy", "mergeStrategy must be local_wins, remote_wins, merge_newest or keep_both")
 			return
 		}
 		httpapi.WriteError(w, http.StatusInternalServerError, "bootstrap_failed", err.Error())
@@ -476,10 +684,12 @@ func (api *API) bootstrapUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	httpapi.WriteJSON(w, http.StatusOK, map[string]any{
-		"status":    "ok",
-		"imported":  result.Imported,
-		"conflicts": result.Conflicts,
-		"idMap":     result.IDMap,
+		"status":        "ok",
+		"preview":       req.Preview,
+		"imported":      result.Imported,
+		"conflicts":     result.Conflicts,
+		"conflictsList": result.ConflictsList,
+		"idMap":         result.IDMap,
 	})
 }
 
@@ -493,10 +703,7 @@ func (api *API) getQuickes(w http.ResponseWriter, r *http.Request) {
 		httpapi.WriteError(w, http.StatusInternalServerError, "quickes_failed", err.Error())
 		return
 	}
-	if state.Quickes == nil {
-		state.Quickes = []string{}
-	}
-	httpapi.WriteJSON(w, http.StatusOK, state.Quickes)
+	writeTaggedJSON(w, r, servedUserState(state).Quickes)
 }
 
 func (api *API) putQuickes(w http.ResponseWriter, r *http.Request) {
@@ -515,13 +722,23 @@ func (api *API) putQuickes(w http.ResponseWriter, r *http.Request) {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "quickes are required")
 		return
 	}
+	var ifMatch func([]string) bool
+	if match := contentMatch(r); match != nil {
+		ifMatch = func(current []string) bool {
+			return match(servedUserState(models.UserState{Quickes: current}).Quickes)
+		}
+	}
 
-	quickes, err := api.svc.SetQuickes(r.Context(), user.UID, req.Quickes)
+	quickes, err := api.svc.SetQuickes(r.Context(), user.UID, req.Quickes, ifMatch)
 	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			api.writeUserStateConflict(w, r, user.UID, func(state models.UserState) any { return state.Quickes })
+			return
+		}
 		httpapi.WriteError(w, http.StatusInternalServerError, "quickes_update_failed", err.Error())
 		return
 	}
-	httpapi.WriteJSON(w, http.StatusOK, quickes)
+	writeTaggedJSON(w, r, quickes)
 }
 
 func (api *API) listGlobalCategories(w http.ResponseWriter, r *http.Request) {
@@ -530,7 +747,18 @@ func (api *API) listGlobalCategories(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	includeStatements := r.URL.Query().Get("include_statements") == "true"
-	categories, err := api.svc.ListGlobalCategories(r.Context(), includeStatements)
+	loc := api.svc.UserLocale(r.Context(), user.UID)
+	w.Header().Add("Vary", "Accept-Language")
+	if r.URL.Query().Get("tree") == "true" {
+		tree, err := api.svc.ListGlobalCategoryTree(r.Context(), includeStatements, loc)
+		if err != nil {
+			httpapi.WriteError(w, http.StatusInternalServerError, "global_categories_failed", err.Error())
+			return
+		}
+		writeTaggedJSON(w, r, tree)
+		return
+	}
+	categories, err := api.svc.ListGlobalCategories(r.Context(), includeStatements, loc)
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "global_categories_failed", err.Error())
 		return
@@ -538,7 +766,7 @@ func (api *API) listGlobalCategories(w http.ResponseWriter, r *http.Request) {
 	if categories == nil {
 		categories = []models.GlobalCategory{}
 	}
-	httpapi.WriteJSON(w, http.StatusOK, categories)
+	writeTaggedJSON(w, r, categories)
 }
 
 func (api *API) listGlobalStatements(w http.ResponseWriter, r *http.Request) {
@@ -551,7 +779,8 @@ func (api *API) listGlobalStatements(w http.ResponseWriter, r *http.Request) {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_id", "category id is required")
 		return
 	}
-	statements, err := api.svc.ListGlobalStatements(r.Context(), categoryID)
+	w.Header().Add("Vary", "Accept-Language")
+	statements, err := api.svc.ListGlobalStatements(r.Context(), categoryID, api.svc.UserLocale(r.Context(), user.UID))
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "global_statements_failed", err.Error())
 		return
@@ -559,7 +788,7 @@ func (api *API) listGlobalStatements(w http.ResponseWriter, r *http.Request) {
 	if statements == nil {
 		statements = []models.Statement{}
 	}
-	httpapi.WriteJSON(w, http.StatusOK, statements)
+	writeTaggedJSON(w, r, statements)
 }
 
 func (api *API) importGlobal(w http.ResponseWriter, r *http.Request) {
@@ -580,7 +809,7 @@ func (api *API) importGlobal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	status, err := api.svc.ImportGlobalCategory(r.Context(), user.UID, req.CategoryID, req.Force)
+	status, err := api.svc.ImportGlobalCategory(r.Context(), user.UID, req.CategoryID, req.Force, api.svc.UserLocale(r.Context(), user.UID))
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "import_failed", err.Error())
 		return
@@ -588,12 +817,46 @@ func (api *API) importGlobal(w http.ResponseWriter, r *http.Request) {
 	httpapi.WriteJSON(w, http.StatusOK, map[string]any{"status": status})
 }
 
+func (api *API) listGlobalUpdates(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	updates, err := api.svc.ListGlobalUpdates(r.Context(), user.UID, api.svc.UserLocale(r.Context(), user.UID))
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "global_updates_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, updates)
+}
+
+func (api *API) applyGlobalUpdates(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		CategoryIDs []string `json:"category_ids"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	applied, err := api.svc.ApplyGlobalUpdates(r.Context(), user.UID, req.CategoryIDs, api.svc.UserLocale(r.Context(), user.UID))
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "global_updates_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, map[string]any{"applied": applied})
+}
+
 func (api *API) listFactoryQuestions(w http.ResponseWriter, r *http.Request) {
 	user := mustUser(w, r)
 	if user.UID == "" {
 		return
 	}
-	questions, err := api.svc.ListFactoryQuestions(r.Context())
+	questions, err := api.svc.ListFactoryQuestions(r.Context(), api.svc.UserLocale(r.Context(), user.UID))
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "questions_failed", err.Error())
 		return
@@ -610,22 +873,20 @@ func (api *API) onboardingPhrases(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req struct {
-		Questions []service.QuestionInput `json:"questions"`
+		Questions  []service.QuestionInput `json:"questions"`
+		Regenerate bool                    `json:"regenerate"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
 		return
 	}
-	if len(req.Questions) == 0 {
-		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "questions are required")
-		return
-	}
 
-	if _, err := api.svc.OnboardingPhrases(r.Context(), user.UID, req.Questions); err != nil {
-		httpapi.WriteError(w, http.StatusInternalServerError, "onboarding_failed", err.Error())
+	created, err := api.svc.OnboardingPhrases(r.Context(), user.UID, req.Questions, req.Regenerate, api.svc.UserLocale(r.Context(), user.UID))
+	if err != nil {
+		writeOnboardingError(w, err)
 		return
 	}
-	writeStatusOK(w)
+	httpapi.WriteJSON(w, http.StatusOK, map[string]any{"status": "ok", "created": created})
 }
 
 func (api *API) deleteUser(w http.ResponseWriter, r *http.Request) {
@@ -1175,7 +1436,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 		}
-		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Request-Id, X-Auth-Token")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Request-Id, X-Auth-Token, X-Device-Id, X-Act-As, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 		if r.Method == http.MethodOptions {
@@ -1244,17 +1506,7 @@ func firebaseAuthError(message string) (int, string, string) {
 }
 
 func (api *API) adminStats(w http.ResponseWriter, r *http.Request) {
-	window := r.URL.Query().Get("window")
-	if window == "" {
-		window = "24h"
-	}
-	duration, err := time.ParseDuration(window)
-	if err != nil {
-		duration = 24 * time.Hour
-	}
-	since := time.Now().Add(-duration)
-
-	stats, err := api.svc.AdminStats(r.Context(), since)
+	stats, err := api.svc.AdminStats(r.Context(), statsSince(r))
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "stats_failed", err.Error())
 		return
@@ -1262,6 +1514,15 @@ func (api *API) adminStats(w http.ResponseWriter, r *http.Request) {
 	httpapi.WriteJSON(w, http.StatusOK, stats)
 }
 
+// statsSince reads the ?window= duration of a stats request, 24h by default.
+func statsSince(r *http.Request) time.Time {
+	duration, err := time.ParseDuration(r.URL.Query().Get("window"))
+	if err != nil {
+		duration = 24 * time.Hour
+	}
+	return time.Now().Add(-duration)
+}
+
 func (api *API) adminListAdmins(w http.ResponseWriter, r *http.Request) {
 	admins, err := api.svc.ListAdmins(r.Context())
 	if err != nil {
@@ -1374,7 +1635,7 @@ func (api *API) adminRevokeClientKey(w http.ResponseWriter, r *http.Request) {
 }
 
 func (api *API) adminListGlobalCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := api.svc.ListGlobalCategories(r.Context(), true)
+	categories, err := api.svc.ListGlobalCategories(r.Context(), true, "")
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "global_categories_failed", err.Error())
 		return
@@ -1384,9 +1645,10 @@ func (api *API) adminListGlobalCategories(w http.ResponseWriter, r *http.Request
 
 func (api *API) adminCreateGlobalCategory(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		ID      string `json:"id"`
-		Label   string `json:"label"`
-		Default *bool  `json:"default"`
+		ID       string `json:"id"`
+		Label    string `json:"label"`
+		Default  *bool  `json:"default"`
+		ParentID string `json:"parent_id"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
@@ -1398,10 +1660,15 @@ func (api *API) adminCreateGlobalCategory(w http.ResponseWriter, r *http.Request
 	}
 
 	category, err := api.svc.CreateGlobalCategory(r.Context(), service.GlobalCategoryInput{
-		ID:      req.ID,
-		Label:   req.Label,
-		Default: req.Default,
+		ID:       req.ID,
+		Label:    req.Label,
+		Default:  req.Default,
+		ParentID: req.ParentID,
 	})
+	if errors.Is(err, service.ErrInvalidParent) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_parent", err.Error())
+		return
+	}
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "create_global_category_failed", err.Error())
 		return
@@ -1417,8 +1684,9 @@ func (api *API) adminUpdateGlobalCategory(w http.ResponseWriter, r *http.Request
 	}
 
 	var req struct {
-		Label   *string `json:"label"`
-		Default *bool   `json:"default"`
+		Label    *string `json:"label"`
+		Default  *bool   `json:"default"`
+		ParentID *string `json:"parent_id"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
@@ -1426,9 +1694,14 @@ func (api *API) adminUpdateGlobalCategory(w http.ResponseWriter, r *http.Request
 	}
 
 	category, err := api.svc.UpdateGlobalCategory(r.Context(), categoryID, service.GlobalCategoryPatch{
-		Label:   req.Label,
-		Default: req.Default,
+		Label:    req.Label,
+		Default:  req.Default,
+		ParentID: req.ParentID,
 	})
+	if errors.Is(err, service.ErrInvalidParent) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_parent", err.Error())
+		return
+	}
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "update_global_category_failed", err.Error())
 		return
@@ -1451,7 +1724,7 @@ func (api *API) adminDeleteGlobalCategory(w http.ResponseWriter, r *http.Request
 }
 
 func (api *API) adminListFactoryQuestions(w http.ResponseWriter, r *http.Request) {
-	questions, err := api.svc.ListFactoryQuestions(r.Context())
+	questions, err := api.svc.ListFactoryQuestions(r.Context(), "")
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "questions_failed", err.Error())
 		return
@@ -1461,12 +1734,15 @@ func (api *API) adminListFactoryQuestions(w http.ResponseWriter, r *http.Request
 
 func (api *API) adminCreateFactoryQuestion(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		ID         string   `json:"id"`
-		Label      string   `json:"label"`
-		Phrases    []string `json:"phrases"`
-		Category   string   `json:"category"`
-		Type       string   `json:"type"`
-		OrderIndex int      `json:"order_index"`
+		ID          string                    `json:"id"`
+		Label       string                    `json:"label"`
+		Phrases     []string                  `json:"phrases"`
+		Category    string                    `json:"category"`
+		Type        string                    `json:"type"`
+		OrderIndex  int                       `json:"order_index"`
+		Options     []models.QuestionOption   `json:"options"`
+		Placeholder string                    `json:"placeholder"`
+		ShowIf      *models.QuestionCondition `json:"show_if"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
@@ -1478,13 +1754,20 @@ func (api *API) adminCreateFactoryQuestion(w http.ResponseWriter, r *http.Reques
 	}
 
 	question, err := api.svc.CreateFactoryQuestion(r.Context(), service.FactoryQuestionInput{
-		ID:         req.ID,
-		Label:      req.Label,
-		Phrases:    req.Phrases,
-		Category:   req.Category,
-		Type:       req.Type,
-		OrderIndex: req.OrderIndex,
+		ID:          req.ID,
+		Label:       req.Label,
+		Phrases:     req.Phrases,
+		Category:    req.Category,
+		Type:        req.Type,
+		OrderIndex:  req.OrderIndex,
+		Options:     req.Options,
+		Placeholder: req.Placeholder,
+		ShowIf:      req.ShowIf,
 	})
+	if errors.Is(err, service.ErrInvalidQuestion) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_question", err.Error())
+		return
+	}
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "create_question_failed", err.Error())
 		return
@@ -1500,24 +1783,41 @@ func (api *API) adminUpdateFactoryQuestion(w http.ResponseWriter, r *http.Reques
 	}
 
 	var req struct {
-		Label      *string  `json:"label"`
-		Phrases    []string `json:"phrases"`
-		Category   *string  `json:"category"`
-		Type       *string  `json:"type"`
-		OrderIndex *int     `json:"order_index"`
+		Label       *string                 `json:"label"`
+		Phrases     []string                `json:"phrases"`
+		Category    *string                 `json:"category"`
+		Type        *string                 `json:"type"`
+		OrderIndex  *int                    `json:"order_index"`
+		Options     []models.QuestionOption `json:"options"`
+		Placeholder *string                 `json:"placeholder"`
+		ShowIf      json.RawMessage         `json:"show_if"`
 	}
 	if err := decodeJSON(w, r, &req); err != nil {
 		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
 		return
 	}
+	patch := service.FactoryQuestionPatch{
+		Label:       req.Label,
+		Phrases:     req.Phrases,
+		Category:    req.Category,
+		Type:        req.Type,
+		OrderIndex:  req.OrderIndex,
+		Options:     req.Options,
+		Placeholder: req.Placeholder,
+	}
+	if len(req.ShowIf) > 0 {
+		patch.ShowIfSet = true
+		if err := json.Unmarshal(req.ShowIf, &patch.ShowIf); err != nil {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+			return
+		}
+	}
 
-	question, err := api.svc.UpdateFactoryQuestion(r.Context(), questionID, service.FactoryQuestionPatch{
-		Label:      req.Label,
-		Phrases:    req.Phrases,
-		Category:   req.Category,
-		Type:       req.Type,
-		OrderIndex: req.OrderIndex,
-	})
+	question, err := api.svc.UpdateFactoryQuestion(r.Context(), questionID, patch)
+	if errors.Is(err, service.ErrInvalidQuestion) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_question", err.Error())
+		return
+	}
 	if err != nil {
 		httpapi.WriteError(w, http.StatusInternalServerError, "update_question_failed", err.Error())
 		return
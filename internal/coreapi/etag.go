@@ -0,0 +1,163 @@
+package coreapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+// versionTag is the ETag of a category or statement: its updated_at, which
+// clients also see in list responses.
+func versionTag(updatedAt int64) string {
+	return `"` + strconv.FormatInt(updatedAt, 10) + `"`
+}
+
+// contentTag is the ETag of a response body.
+func contentTag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// encodeJSON encodes payload exactly as httpapi.WriteJSON does.
+func encodeJSON(payload any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// matchesTag reports whether an If-Match or If-None-Match value names tag.
+// Weak tags are compared by their opaque part, since proxies that compress
+// responses weaken the tags they pass through.
+func matchesTag(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTaggedJSON writes payload with a content ETag, or 304 Not Modified
+// when a GET's If-None-Match already names it.
+func writeTaggedJSON(w http.ResponseWriter, r *http.Request, payload any) {
+	body, err := encodeJSON(payload)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "encode_failed", err.Error())
+		return
+	}
+	tag := contentTag(body)
+	w.Header().Set("ETag", tag)
+	if header := r.Header.Get("If-None-Match"); r.Method == http.MethodGet && header != "" && matchesTag(header, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// writeVersionedJSON writes a category or statement with its version tag.
+func writeVersionedJSON(w http.ResponseWriter, updatedAt int64, payload any) {
+	w.Header().Set("ETag", versionTag(updatedAt))
+	httpapi.WriteJSON(w, http.StatusOK, payload)
+}
+
+// writePreconditionFailed answers a failed If-Match with 412 and the current
+// entity, so the client can merge and retry.
+func writePreconditionFailed(w http.ResponseWriter, tag string, current any) {
+	w.Header().Set("ETag", tag)
+	httpapi.WriteJSON(w, http.StatusPreconditionFailed, map[string]any{
+		"error": map[string]string{
+			"code":    "precondition_failed",
+			"message": "entity was modified",
+		},
+		"current": current,
+	})
+}
+
+// parseIfMatch reads If-Match as a category or statement precondition. The
+// service checks it in the same transaction as the write. Tags that are not
+// versions are dropped, so a header naming only those never matches.
+func parseIfMatch(header string) service.IfMatch {
+	if header == "" {
+		return service.IfMatch{}
+	}
+	versions := []int64{}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" {
+			return service.IfMatch{Any: true}
+		}
+		if version, err := strconv.ParseInt(strings.Trim(candidate, `"`), 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return service.IfMatch{Versions: versions}
+}
+
+// writeCategoryConflict answers a failed category precondition with the
+// current category, or a bare 412 when it is gone.
+func (api *API) writeCategoryConflict(w http.ResponseWriter, r *http.Request, userID, categoryID string) {
+	current, err := api.svc.GetCategory(r.Context(), userID, categoryID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusPreconditionFailed, "precondition_failed", "category not found")
+		return
+	}
+	writePreconditionFailed(w, versionTag(current.UpdatedAt), current)
+}
+
+// writeStatementConflict answers a failed statement precondition with the
+// current statement, or a bare 412 when it is gone.
+func (api *API) writeStatementConflict(w http.ResponseWriter, r *http.Request, userID, statementID string) {
+	current, err := api.svc.GetStatement(r.Context(), userID, statementID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusPreconditionFailed, "precondition_failed", "statement not found")
+		return
+	}
+	writePreconditionFailed(w, versionTag(current.UpdatedAt), current)
+}
+
+// contentMatch returns the If-Match precondition of a content-tagged
+// entity: it accepts a current value whose GET tag the header names. The
+// service runs it in the same transaction as the write. Without If-Match it
+// returns nil.
+func contentMatch(r *http.Request) func(current any) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+	return func(current any) bool {
+		body, err := encodeJSON(current)
+		return err == nil && matchesTag(header, contentTag(body))
+	}
+}
+
+// writeUserStateConflict answers a failed user state or quickes precondition
+// with the current value picked by served.
+func (api *API) writeUserStateConflict(w http.ResponseWriter, r *http.Request, userID string, served func(models.UserState) any) {
+	state, err := api.svc.GetUserState(r.Context(), userID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "state_failed", err.Error())
+		return
+	}
+	current := served(servedUserState(state))
+	body, err := encodeJSON(current)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "encode_failed", err.Error())
+		return
+	}
+	writePreconditionFailed(w, contentTag(body), current)
+}
@@ -0,0 +1,97 @@
+package coreapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	if m := parseIfMatch(""); m.Any || m.Versions != nil {
+		t.Fatalf("expected no precondition, got %+v", m)
+	}
+	if m := parseIfMatch(`"1", *`); !m.Any {
+		t.Fatalf("expected * to accept any version, got %+v", m)
+	}
+	m := parseIfMatch(`"10", W/"20", "abc"`)
+	if m.Any || len(m.Versions) != 2 || m.Versions[0] != 10 || m.Versions[1] != 20 {
+		t.Fatalf("expected versions [10 20], got %+v", m)
+	}
+	if m := parseIfMatch(`"abc"`); m.Versions == nil || len(m.Versions) != 0 {
+		t.Fatalf("expected an empty precondition that never matches, got %+v", m)
+	}
+}
+
+func TestWriteTaggedJSONNotModified(t *testing.T) {
+	payload := []models.Statement{{ID: "s1", Text: "hello"}}
+
+	rec := httptest.NewRecorder()
+	writeTaggedJSON(rec, httptest.NewRequest(http.MethodGet, "/v1/categories/c1/statements", nil), payload)
+	tag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || tag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", rec.Code, tag)
+	}
+
+	for _, header := range []string{tag, "W/" + tag, `"other", ` + tag} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/categories/c1/statements", nil)
+		req.Header.Set("If-None-Match", header)
+		rec = httptest.NewRecorder()
+		writeTaggedJSON(rec, req, payload)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Fatalf("If-None-Match %s: expected empty 304, got %d", header, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/categories/c1/statements", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	writeTaggedJSON(rec, req, payload)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a stale tag, got %d", rec.Code)
+	}
+}
+
+func TestWritePreconditionFailed(t *testing.T) {
+	current := models.Category{ID: "c1", Label: "Food", UpdatedAt: 20}
+	tag := versionTag(current.UpdatedAt)
+
+	rec := httptest.NewRecorder()
+	writePreconditionFailed(rec, tag, current)
+	if rec.Code != http.StatusPreconditionFailed || rec.Header().Get("ETag") != tag {
+		t.Fatalf("expected 412 with the current ETag, got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	var body struct {
+		Error   map[string]string `json:"error"`
+		Current models.Category   `json:"current"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Error["code"] != "precondition_failed" || body.Current.Label != "Food" {
+		t.Fatalf("expected precondition_failed with the current entity, got %+v", body)
+	}
+}
+
+func TestContentMatch(t *testing.T) {
+	current := []string{"Привет", "Да"}
+	body, err := encodeJSON(current)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/quickes", nil)
+	if contentMatch(req) != nil {
+		t.Fatalf("expected no precondition without If-Match")
+	}
+	req.Header.Set("If-Match", contentTag(body))
+	match := contentMatch(req)
+	if !match(current) {
+		t.Fatalf("expected the served tag to match")
+	}
+	if match([]string{"Привет", "Нет"}) {
+		t.Fatalf("expected changed content not to match")
+	}
+}
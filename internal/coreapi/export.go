@@ -0,0 +1,115 @@
+package coreapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func (api *API) exportUser(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.ExportFormatJSON
+	}
+	async := r.URL.Query().Get("async") == "true"
+
+	result, err := api.svc.Export(r.Context(), user.UID, format, async)
+	if errors.Is(err, service.ErrInvalidExportFormat) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_format", err.Error())
+		return
+	}
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "export_failed", err.Error())
+		return
+	}
+	if result.Job != nil {
+		httpapi.WriteJSON(w, http.StatusAccepted, exportJobResponse(*result.Job))
+		return
+	}
+
+	w.Header().Set("Content-Type", result.ContentType)
+	w.Header().Set("Content-Disposition", exportDisposition(format, time.Now()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(result.Data)
+}
+
+func (api *API) getExportJob(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	job, err := api.svc.GetExportJob(r.Context(), user.UID, chi.URLParam(r, "id"))
+	if errors.Is(err, store.ErrNotFound) {
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "export not found")
+		return
+	}
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "export_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, exportJobResponse(job))
+}
+
+func (api *API) downloadExport(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	job, body, err := api.svc.OpenExport(r.Context(), user.UID, chi.URLParam(r, "id"))
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "export not found")
+		return
+	case errors.Is(err, service.ErrExportNotReady):
+		httpapi.WriteError(w, http.StatusConflict, "export_not_ready", err.Error())
+		return
+	case err != nil:
+		httpapi.WriteError(w, http.StatusInternalServerError, "export_failed", err.Error())
+		return
+	}
+	defer body.Close()
+
+	contentType := "application/json"
+	if job.Format == service.ExportFormatZIP {
+		contentType = "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", exportDisposition(job.Format, time.UnixMilli(job.Created)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, body)
+}
+
+func exportJobResponse(job models.ExportJob) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":         job.ID,
+		"format":     job.Format,
+		"status":     job.Status,
+		"created":    job.Created,
+		"updated_at": job.UpdatedAt,
+	}
+	if job.Error != "" {
+		out["error"] = job.Error
+	}
+	if job.Size > 0 {
+		out["size"] = job.Size
+		out["downloadUrl"] = fmt.Sprintf("/v1/user/export/jobs/%s/download", job.ID)
+	}
+	return out
+}
+
+func exportDisposition(format string, at time.Time) string {
+	return fmt.Sprintf(`attachment; filename="linka-export-%s.%s"`, at.UTC().Format("20060102"), format)
+}
@@ -0,0 +1,105 @@
+package coreapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func (api *API) adminCreateGlobalStatement(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	statement, err := api.svc.CreateGlobalStatement(r.Context(), chi.URLParam(r, "id"), req.ID, req.Text)
+	if err != nil {
+		writeGlobalStatementError(w, "create_global_statement_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, statement)
+}
+
+func (api *API) adminUpdateGlobalStatement(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	statement, err := api.svc.UpdateGlobalStatement(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "sid"), req.Text)
+	if err != nil {
+		writeGlobalStatementError(w, "update_global_statement_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, statement)
+}
+
+func (api *API) adminDeleteGlobalStatement(w http.ResponseWriter, r *http.Request) {
+	if err := api.svc.DeleteGlobalStatement(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "sid")); err != nil {
+		writeGlobalStatementError(w, "delete_global_statement_failed", err)
+		return
+	}
+	writeStatusOK(w)
+}
+
+func (api *API) adminReorderGlobalStatements(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeReorder(w, r)
+	if !ok {
+		return
+	}
+
+	statements, err := api.svc.ReorderGlobalStatements(r.Context(), chi.URLParam(r, "id"), req.input())
+	if err != nil {
+		writeReorderError(w, "reorder_global_statements_failed", err)
+		return
+	}
+	if statements == nil {
+		statements = []models.Statement{}
+	}
+	httpapi.WriteJSON(w, http.StatusOK, statements)
+}
+
+func (api *API) adminPasteGlobalStatements(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	result, err := api.svc.PasteGlobalStatements(r.Context(), chi.URLParam(r, "id"), req.Text)
+	if err != nil {
+		writeGlobalStatementError(w, "paste_global_statements_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, result)
+}
+
+func writeGlobalStatementError(w http.ResponseWriter, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidStatementText):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_text", err.Error())
+	case errors.Is(err, service.ErrStatementExists):
+		httpapi.WriteError(w, http.StatusConflict, "statement_exists", err.Error())
+	case errors.Is(err, service.ErrInvalidBulk):
+		httpapi.WriteError(w, http.StatusBadRequest, "too_many_statements", fmt.Sprintf("at most %d statements per paste", service.MaxBulkStatements))
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "global category or statement not found")
+	default:
+		httpapi.WriteError(w, http.StatusInternalServerError, code, err.Error())
+	}
+}
@@ -0,0 +1,60 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+func (api *API) listGrants(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	grants, err := api.svc.ListGrants(r.Context(), user.UID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "grants_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, grants)
+}
+
+func (api *API) putGrant(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Access string `json:"access"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	grant, err := api.svc.SetGrant(r.Context(), user.UID, chi.URLParam(r, "delegateId"), req.Access)
+	switch {
+	case errors.Is(err, service.ErrInvalidGrant):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", err.Error())
+	case err != nil:
+		httpapi.WriteError(w, http.StatusInternalServerError, "grant_failed", err.Error())
+	default:
+		httpapi.WriteJSON(w, http.StatusOK, grant)
+	}
+}
+
+func (api *API) deleteGrant(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	if err := api.svc.RevokeGrant(r.Context(), user.UID, chi.URLParam(r, "delegateId")); err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "grant_failed", err.Error())
+		return
+	}
+	writeStatusOK(w)
+}
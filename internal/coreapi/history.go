@@ -0,0 +1,103 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func (api *API) statementHistory(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	statementID := chi.URLParam(r, "id")
+	limit := 50
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := parseIntInRange(raw, 1, 200)
+		if err != nil {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_limit", err.Error())
+			return
+		}
+		limit = parsed
+	}
+
+	history, err := api.svc.StatementHistory(r.Context(), user.UID, statementID, limit)
+	if errors.Is(err, store.ErrNotFound) {
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "statement not found")
+		return
+	}
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "history_failed", err.Error())
+		return
+	}
+	writeTaggedJSON(w, r, history)
+}
+
+func (api *API) revertStatement(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	statementID := chi.URLParam(r, "id")
+	var req struct {
+		RevisionID string `json:"revisionId"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	if strings.TrimSpace(req.RevisionID) == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "revisionId is required")
+		return
+	}
+
+	statement, err := api.svc.RevertStatement(r.Context(), user.UID, statementID, req.RevisionID)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "statement or revision not found")
+	case errors.Is(err, service.ErrInvalidRevision):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_revision", err.Error())
+	case errors.Is(err, service.ErrInvalidCategory):
+		httpapi.WriteError(w, http.StatusConflict, "category_missing", err.Error())
+	case err != nil:
+		httpapi.WriteError(w, http.StatusInternalServerError, "revert_failed", err.Error())
+	default:
+		writeVersionedJSON(w, statement.UpdatedAt, statement)
+	}
+}
+
+func (api *API) undo(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Count *int `json:"count"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	count := 1
+	if req.Count != nil {
+		if *req.Count < 1 || *req.Count > service.MaxUndo {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_count", "count must be between 1 and 20")
+			return
+		}
+		count = *req.Count
+	}
+
+	result, err := api.svc.Undo(r.Context(), user.UID, count)
+	if err != nil {
+		writeTrashError(w, "undo_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,67 @@
+package coreapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/linkasu/linka.type-backend/internal/boardfile"
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+// maxImportBytes bounds an uploaded board file.
+const maxImportBytes = 10 * 1024 * 1024
+
+func (api *API) importBoard(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes+64*1024)
+	if err := r.ParseMultipartForm(maxImportBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpapi.WriteError(w, http.StatusRequestEntityTooLarge, "import_too_large", "file is too large")
+			return
+		}
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", err.Error())
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "file is required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", err.Error())
+		return
+	}
+
+	format := r.FormValue("format")
+	if format == "" {
+		format = boardfile.FormatFromName(header.Filename)
+	}
+	board, err := boardfile.Parse(format, data)
+	switch {
+	case errors.Is(err, boardfile.ErrUnsupportedFormat):
+		httpapi.WriteError(w, http.StatusBadRequest, "unsupported_format", "format must be csv, text, obf or obz")
+		return
+	case err != nil:
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_file", err.Error())
+		return
+	}
+
+	dryRun := r.FormValue("dryRun") == "true"
+	result, err := api.svc.ImportBoard(r.Context(), user.UID, board, dryRun)
+	switch {
+	case errors.Is(err, service.ErrImportTooLarge):
+		httpapi.WriteError(w, http.StatusRequestEntityTooLarge, "import_too_large", err.Error())
+	case err != nil:
+		httpapi.WriteError(w, http.StatusInternalServerError, "import_failed", err.Error())
+	default:
+		httpapi.WriteJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,157 @@
+package coreapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+type mediaResponse struct {
+	models.Media
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+}
+
+func newMediaResponse(media models.Media) mediaResponse {
+	return mediaResponse{
+		Media:        media,
+		URL:          "/v1/media/" + media.ID,
+		ThumbnailURL: "/v1/media/" + media.ID + "/thumbnail",
+	}
+}
+
+func (api *API) listMedia(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	items, err := api.svc.ListMedia(r.Context(), user.UID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "media_failed", err.Error())
+		return
+	}
+	out := make([]mediaResponse, len(items))
+	for i, media := range items {
+		out[i] = newMediaResponse(media)
+	}
+	writeTaggedJSON(w, r, out)
+}
+
+func (api *API) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	maxBytes := api.config.Media.MaxUploadBytes
+	if maxBytes <= 0 {
+		maxBytes = 5 * 1024 * 1024
+	}
+	// Leave room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64*1024)
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpapi.WriteError(w, http.StatusRequestEntityTooLarge, "media_too_large", service.ErrMediaTooLarge.Error())
+			return
+		}
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", err.Error())
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "file is required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", err.Error())
+		return
+	}
+
+	media, err := api.svc.UploadMedia(r.Context(), user.UID, data)
+	if err != nil {
+		writeMediaError(w, "upload_media_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, newMediaResponse(media))
+}
+
+func (api *API) getMedia(w http.ResponseWriter, r *http.Request) {
+	api.serveMedia(w, r, false)
+}
+
+func (api *API) getMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	api.serveMedia(w, r, true)
+}
+
+func (api *API) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	mediaID := chi.URLParam(r, "id")
+	body, contentType, err := api.svc.OpenMedia(r.Context(), user.UID, mediaID, thumbnail)
+	if err != nil {
+		writeMediaError(w, "media_failed", err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	// Media ids are never reused, so the bytes behind a URL never change.
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, body)
+}
+
+func (api *API) deleteMedia(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	mediaID := chi.URLParam(r, "id")
+	if err := api.svc.DeleteMedia(r.Context(), user.UID, mediaID); err != nil {
+		writeMediaError(w, "delete_media_failed", err)
+		return
+	}
+	writeStatusOK(w)
+}
+
+func writeMediaError(w http.ResponseWriter, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrMediaDisabled):
+		httpapi.WriteError(w, http.StatusServiceUnavailable, "media_disabled", err.Error())
+	case errors.Is(err, service.ErrMediaTooLarge):
+		httpapi.WriteError(w, http.StatusRequestEntityTooLarge, "media_too_large", err.Error())
+	case errors.Is(err, service.ErrInvalidMedia):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_media", err.Error())
+	case errors.Is(err, service.ErrMediaInUse):
+		httpapi.WriteError(w, http.StatusConflict, "media_in_use", err.Error())
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "media not found")
+	default:
+		httpapi.WriteError(w, http.StatusInternalServerError, code, err.Error())
+	}
+}
+
+// writeVisualError reports invalid color, icon or image references. It
+// returns false for other errors, which the caller handles.
+func writeVisualError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrInvalidVisual):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_visual", "color must be #RRGGBB and icon at most 32 characters")
+	case errors.Is(err, service.ErrInvalidMedia):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_image", "imageId does not reference an uploaded image")
+	default:
+		return false
+	}
+	return true
+}
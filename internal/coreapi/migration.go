@@ -0,0 +1,155 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+type migrationPayload struct {
+	State string `json:"state"`
+	Note  string `json:"note"`
+	Force bool   `json:"force"`
+}
+
+func (api *API) adminListMigrations(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := parseIntInRange(raw, 1, 1000)
+		if err != nil {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_limit", err.Error())
+			return
+		}
+		limit = parsed
+	}
+
+	items, counts, err := api.svc.ListUserMigrations(r.Context(), r.URL.Query().Get("state"), limit)
+	if err != nil {
+		writeMigrationError(w, "migrations_failed", err)
+		return
+	}
+	if items == nil {
+		items = []models.UserMigration{}
+	}
+	httpapi.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"items":  items,
+		"counts": counts,
+	})
+}
+
+func (api *API) adminGetMigration(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "user_id")
+	if userID == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_id", "user_id is required")
+		return
+	}
+
+	migration, err := api.svc.GetUserMigration(r.Context(), userID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "migration_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, migration)
+}
+
+func (api *API) adminSetMigration(w http.ResponseWriter, r *http.Request) {
+	actor := mustUser(w, r)
+	if actor.UID == "" {
+		return
+	}
+	userID := chi.URLParam(r, "user_id")
+	if userID == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_id", "user_id is required")
+		return
+	}
+
+	var req migrationPayload
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	migration, err := api.svc.SetUserMigration(r.Context(), actor.UID, userID, service.MigrationInput{
+		State: strings.TrimSpace(req.State),
+		Note:  req.Note,
+		Force: req.Force,
+	})
+	if err != nil {
+		writeMigrationError(w, "set_migration_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, migration)
+}
+
+func (api *API) adminVerifyMigration(w http.ResponseWriter, r *http.Request) {
+	actor := mustUser(w, r)
+	if actor.UID == "" {
+		return
+	}
+	userID := chi.URLParam(r, "user_id")
+	if userID == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_id", "user_id is required")
+		return
+	}
+
+	result, err := api.svc.VerifyUserMigration(r.Context(), actor.UID, userID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "verify_migration_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, result)
+}
+
+func (api *API) adminMoveMigrationCohort(w http.ResponseWriter, r *http.Request) {
+	actor := mustUser(w, r)
+	if actor.UID == "" {
+		return
+	}
+
+	var req struct {
+		migrationPayload
+		UserIDs   []string `json:"user_ids"`
+		FromState string   `json:"from_state"`
+		Limit     int      `json:"limit"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	if len(req.UserIDs) == 0 && strings.TrimSpace(req.FromState) == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "user_ids or from_state is required")
+		return
+	}
+
+	result, err := api.svc.MoveMigrationCohort(r.Context(), actor.UID, service.MigrationCohortInput{
+		UserIDs:   req.UserIDs,
+		FromState: strings.TrimSpace(req.FromState),
+		Limit:     req.Limit,
+		MigrationInput: service.MigrationInput{
+			State: strings.TrimSpace(req.State),
+			Note:  req.Note,
+			Force: req.Force,
+		},
+	})
+	if err != nil {
+		writeMigrationError(w, "migration_cohort_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, result)
+}
+
+func writeMigrationError(w http.ResponseWriter, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMigrationState):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_state", err.Error())
+	case errors.Is(err, service.ErrMigrationTransitionRefused), errors.Is(err, service.ErrMigrationNotVerified):
+		httpapi.WriteError(w, http.StatusConflict, "transition_refused", err.Error())
+	default:
+		httpapi.WriteError(w, http.StatusInternalServerError, code, err.Error())
+	}
+}
@@ -0,0 +1,51 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+func (api *API) getOnboarding(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	onboarding, err := api.svc.GetOnboarding(r.Context(), user.UID, api.svc.UserLocale(r.Context(), user.UID))
+	if err != nil {
+		writeOnboardingError(w, err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, onboarding)
+}
+
+func (api *API) saveOnboardingAnswers(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Answers []service.AnswerInput `json:"answers"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	onboarding, err := api.svc.SaveOnboardingAnswers(r.Context(), user.UID, req.Answers, api.svc.UserLocale(r.Context(), user.UID))
+	if err != nil {
+		writeOnboardingError(w, err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, onboarding)
+}
+
+func writeOnboardingError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrInvalidAnswer) {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_answer", err.Error())
+		return
+	}
+	httpapi.WriteError(w, http.StatusInternalServerError, "onboarding_failed", err.Error())
+}
@@ -0,0 +1,93 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+type reorderPayload struct {
+	IDs     []string `json:"ids"`
+	ID      string   `json:"id"`
+	AfterID string   `json:"afterId"`
+}
+
+func (p reorderPayload) input() service.ReorderInput {
+	return service.ReorderInput{IDs: p.IDs, ID: p.ID, AfterID: p.AfterID}
+}
+
+func decodeReorder(w http.ResponseWriter, r *http.Request) (reorderPayload, bool) {
+	var req reorderPayload
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return req, false
+	}
+	if len(req.IDs) == 0 && req.ID == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "ids or id is required")
+		return req, false
+	}
+	return req, true
+}
+
+func (api *API) reorderCategories(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	req, ok := decodeReorder(w, r)
+	if !ok {
+		return
+	}
+
+	categories, err := api.svc.ReorderCategories(r.Context(), user.UID, req.input())
+	if err != nil {
+		writeReorderError(w, "reorder_categories_failed", err)
+		return
+	}
+	if categories == nil {
+		categories = []models.Category{}
+	}
+	httpapi.WriteJSON(w, http.StatusOK, categories)
+}
+
+func (api *API) reorderStatements(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	categoryID := chi.URLParam(r, "id")
+	if categoryID == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_id", "category id is required")
+		return
+	}
+	req, ok := decodeReorder(w, r)
+	if !ok {
+		return
+	}
+
+	statements, err := api.svc.ReorderStatements(r.Context(), user.UID, categoryID, req.input())
+	if err != nil {
+		writeReorderError(w, "reorder_statements_failed", err)
+		return
+	}
+	if statements == nil {
+		statements = []models.Statement{}
+	}
+	httpapi.WriteJSON(w, http.StatusOK, statements)
+}
+
+func writeReorderError(w http.ResponseWriter, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOrder):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_order", err.Error())
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "category not found")
+	default:
+		httpapi.WriteError(w, http.StatusInternalServerError, code, err.Error())
+	}
+}
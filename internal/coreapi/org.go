@@ -0,0 +1,230 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func (api *API) listOrgs(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	orgs, err := api.svc.ListOrganizations(r.Context(), user.UID)
+	if err != nil {
+		writeOrgError(w, "orgs_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, orgs)
+}
+
+func (api *API) createOrg(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	org, err := api.svc.CreateOrganization(r.Context(), user.UID, req.Name)
+	if err != nil {
+		writeOrgError(w, "org_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, org)
+}
+
+func (api *API) listOrgMembers(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	members, err := api.svc.ListOrgMembers(r.Context(), user.UID, chi.URLParam(r, "orgId"))
+	if err != nil {
+		writeOrgError(w, "members_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, members)
+}
+
+func (api *API) putOrgMember(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	member, err := api.svc.SetOrgMember(r.Context(), user.UID, chi.URLParam(r, "orgId"), chi.URLParam(r, "userId"), req.Role)
+	if err != nil {
+		writeOrgError(w, "member_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, member)
+}
+
+func (api *API) deleteOrgMember(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	if err := api.svc.RemoveOrgMember(r.Context(), user.UID, chi.URLParam(r, "orgId"), chi.URLParam(r, "userId")); err != nil {
+		writeOrgError(w, "member_failed", err)
+		return
+	}
+	writeStatusOK(w)
+}
+
+func (api *API) acceptOrgInvite(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	member, err := api.svc.AcceptOrgInvite(r.Context(), user.UID, chi.URLParam(r, "orgId"))
+	if err != nil {
+		writeOrgError(w, "member_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, member)
+}
+
+func (api *API) getOrgLibrary(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	library, err := api.svc.GetOrgLibrary(r.Context(), user.UID, chi.URLParam(r, "orgId"))
+	if err != nil {
+		writeOrgError(w, "library_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, library)
+}
+
+func (api *API) saveOrgCategory(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req service.OrgCategoryInput
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	category, err := api.svc.SaveOrgCategory(r.Context(), user.UID, chi.URLParam(r, "orgId"), chi.URLParam(r, "categoryId"), req)
+	if err != nil {
+		writeOrgError(w, "library_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, category)
+}
+
+func (api *API) deleteOrgCategory(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	if err := api.svc.DeleteOrgCategory(r.Context(), user.UID, chi.URLParam(r, "orgId"), chi.URLParam(r, "categoryId")); err != nil {
+		writeOrgError(w, "library_failed", err)
+		return
+	}
+	writeStatusOK(w)
+}
+
+func (api *API) saveOrgStatement(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req service.OrgStatementInput
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	statement, err := api.svc.SaveOrgStatement(r.Context(), user.UID, chi.URLParam(r, "orgId"), chi.URLParam(r, "statementId"), req)
+	if err != nil {
+		writeOrgError(w, "library_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, statement)
+}
+
+func (api *API) deleteOrgStatement(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	if err := api.svc.DeleteOrgStatement(r.Context(), user.UID, chi.URLParam(r, "orgId"), chi.URLParam(r, "statementId")); err != nil {
+		writeOrgError(w, "library_failed", err)
+		return
+	}
+	writeStatusOK(w)
+}
+
+func (api *API) assignOrgCategories(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		CategoryIDs []string `json:"categoryIds"`
+		UserIDs     []string `json:"userIds"`
+		Force       bool     `json:"force"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	results, err := api.svc.AssignOrgCategories(r.Context(), user.UID, chi.URLParam(r, "orgId"), req.CategoryIDs, req.UserIDs, req.Force)
+	if err != nil {
+		writeOrgError(w, "assign_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+func (api *API) orgStats(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	stats, err := api.svc.OrgStats(r.Context(), user.UID, chi.URLParam(r, "orgId"), statsSince(r))
+	if err != nil {
+		writeOrgError(w, "stats_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, stats)
+}
+
+func writeOrgError(w http.ResponseWriter, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOrg):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", err.Error())
+	case errors.Is(err, service.ErrInvalidParent):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_parent", err.Error())
+	case errors.Is(err, service.ErrInvalidVisual):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_visual", err.Error())
+	case errors.Is(err, service.ErrLastOwner):
+		httpapi.WriteError(w, http.StatusConflict, "last_owner", err.Error())
+	case errors.Is(err, service.ErrOrgForbidden):
+		httpapi.WriteError(w, http.StatusForbidden, "forbidden", err.Error())
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "organization, member or library entry not found")
+	default:
+		httpapi.WriteError(w, http.StatusInternalServerError, code, err.Error())
+	}
+}
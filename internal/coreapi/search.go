@@ -0,0 +1,58 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+func (api *API) search(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := parseIntInRange(raw, 1, 100)
+		if err != nil {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_limit", err.Error())
+			return
+		}
+		limit = parsed
+	}
+
+	result, err := api.svc.Search(r.Context(), user.UID, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrEmptyQuery) {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_query", "q is required")
+			return
+		}
+		httpapi.WriteError(w, http.StatusInternalServerError, "search_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, result)
+}
+
+func (api *API) adminReindexSearch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	userID := strings.TrimSpace(req.UserID)
+	if userID == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_id", "user_id is required")
+		return
+	}
+
+	if err := api.svc.ReindexUserSearch(r.Context(), userID); err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "reindex_failed", err.Error())
+		return
+	}
+	writeStatusOK(w)
+}
@@ -0,0 +1,108 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func (api *API) createShare(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Mode      string `json:"mode"`
+		ExpiresIn int64  `json:"expiresIn"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	share, err := api.svc.CreateShare(r.Context(), user.UID, chi.URLParam(r, "id"), req.Mode, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		writeShareError(w, "share_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, share)
+}
+
+func (api *API) previewShare(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	preview, err := api.svc.PreviewShare(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writeShareError(w, "share_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, preview)
+}
+
+func (api *API) acceptShare(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Force bool `json:"force"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	status, err := api.svc.AcceptShare(r.Context(), user.UID, chi.URLParam(r, "token"), req.Force)
+	if err != nil {
+		writeShareError(w, "accept_share_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, map[string]any{"status": status})
+}
+
+func (api *API) revokeShare(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	if err := api.svc.RevokeShare(r.Context(), user.UID, chi.URLParam(r, "token")); err != nil {
+		writeShareError(w, "revoke_share_failed", err)
+		return
+	}
+	writeStatusOK(w)
+}
+
+func (api *API) unsubscribeShare(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	if err := api.svc.UnsubscribeShare(r.Context(), user.UID, chi.URLParam(r, "token")); err != nil {
+		writeShareError(w, "unsubscribe_share_failed", err)
+		return
+	}
+	writeStatusOK(w)
+}
+
+func writeShareError(w http.ResponseWriter, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidShare):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", err.Error())
+	case errors.Is(err, service.ErrOwnShare):
+		httpapi.WriteError(w, http.StatusBadRequest, "own_share", err.Error())
+	case errors.Is(err, service.ErrShareExpired):
+		httpapi.WriteError(w, http.StatusGone, "share_expired", err.Error())
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "share or category not found")
+	default:
+		httpapi.WriteError(w, http.StatusInternalServerError, code, err.Error())
+	}
+}
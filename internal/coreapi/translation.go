@@ -0,0 +1,67 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func (api *API) adminListTranslations(w http.ResponseWriter, r *http.Request) {
+	translations, err := api.svc.ListGlobalTranslations(r.Context(), r.URL.Query().Get("locale"))
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "translations_failed", err.Error())
+		return
+	}
+	if translations == nil {
+		translations = []models.GlobalTranslation{}
+	}
+	httpapi.WriteJSON(w, http.StatusOK, translations)
+}
+
+func (api *API) adminSaveTranslation(w http.ResponseWriter, r *http.Request) {
+	var req models.GlobalTranslation
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	translation, err := api.svc.SaveGlobalTranslation(r.Context(), req)
+	if err != nil {
+		writeTranslationError(w, "save_translation_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, translation)
+}
+
+func (api *API) adminDeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if err := api.svc.DeleteGlobalTranslation(r.Context(), query.Get("kind"), query.Get("entity_id"), query.Get("locale")); err != nil {
+		writeTranslationError(w, "delete_translation_failed", err)
+		return
+	}
+	writeStatusOK(w)
+}
+
+func (api *API) adminMissingTranslations(w http.ResponseWriter, r *http.Request) {
+	report, err := api.svc.MissingTranslations(r.Context(), r.URL.Query().Get("locale"))
+	if err != nil {
+		writeTranslationError(w, "missing_translations_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, report)
+}
+
+func writeTranslationError(w http.ResponseWriter, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTranslation):
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_translation", "kind, entity_id, a supported non-default locale and text are required")
+	case errors.Is(err, store.ErrNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, "not_found", "catalogue entry not found")
+	default:
+		httpapi.WriteError(w, http.StatusInternalServerError, code, err.Error())
+	}
+}
@@ -0,0 +1,86 @@
+package coreapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+type trashSelectionPayload struct {
+	CategoryIDs  []string `json:"categoryIds"`
+	StatementIDs []string `json:"statementIds"`
+}
+
+func (api *API) listTrash(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	trash, err := api.svc.ListTrash(r.Context(), user.UID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "trash_failed", err.Error())
+		return
+	}
+	writeTaggedJSON(w, r, trash)
+}
+
+func (api *API) restoreTrash(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req trashSelectionPayload
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	if len(req.CategoryIDs) == 0 && len(req.StatementIDs) == 0 {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "categoryIds or statementIds is required")
+		return
+	}
+
+	result, err := api.svc.RestoreTrash(r.Context(), user.UID, service.TrashSelection{
+		CategoryIDs:  req.CategoryIDs,
+		StatementIDs: req.StatementIDs,
+	})
+	if err != nil {
+		writeTrashError(w, "restore_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, result)
+}
+
+func (api *API) purgeTrash(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req trashSelectionPayload
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	purged, err := api.svc.PurgeTrash(r.Context(), user.UID, service.TrashSelection{
+		CategoryIDs:  req.CategoryIDs,
+		StatementIDs: req.StatementIDs,
+	})
+	if err != nil {
+		writeTrashError(w, "purge_failed", err)
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "purged": purged})
+}
+
+func writeTrashError(w http.ResponseWriter, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotInTrash):
+		httpapi.WriteError(w, http.StatusNotFound, "not_in_trash", err.Error())
+	case errors.Is(err, service.ErrCategoryInTrash):
+		httpapi.WriteError(w, http.StatusConflict, "category_in_trash", "restore the statement's category first")
+	default:
+		httpapi.WriteError(w, http.StatusInternalServerError, code, err.Error())
+	}
+}
@@ -0,0 +1,147 @@
+package coreapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+)
+
+type spokenPayload struct {
+	EventID     string `json:"eventId"`
+	StatementID string `json:"statementId"`
+	QuickeSlot  *int   `json:"quickeSlot"`
+	At          int64  `json:"at"`
+}
+
+func (api *API) recordStatementSpoken(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	statementID := chi.URLParam(r, "id")
+	if statementID == "" {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_id", "statement id is required")
+		return
+	}
+	var req spokenPayload
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	api.recordSpoken(w, r, user.UID, []service.SpokenEvent{{EventID: req.EventID, StatementID: statementID, At: req.At}})
+}
+
+func (api *API) recordQuickeSpoken(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	slot, err := strconv.Atoi(chi.URLParam(r, "slot"))
+	if err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_slot", "slot must be a number")
+		return
+	}
+	var req spokenPayload
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+
+	api.recordSpoken(w, r, user.UID, []service.SpokenEvent{{EventID: req.EventID, QuickeSlot: &slot, At: req.At}})
+}
+
+func (api *API) recordSpokenBatch(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	var req struct {
+		Events []spokenPayload `json:"events"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return
+	}
+	if len(req.Events) == 0 {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid_payload", "events are required")
+		return
+	}
+
+	events := make([]service.SpokenEvent, 0, len(req.Events))
+	for _, event := range req.Events {
+		events = append(events, service.SpokenEvent{
+			EventID:     event.EventID,
+			StatementID: event.StatementID,
+			QuickeSlot:  event.QuickeSlot,
+			At:          event.At,
+		})
+	}
+	api.recordSpoken(w, r, user.UID, events)
+}
+
+func (api *API) recordSpoken(w http.ResponseWriter, r *http.Request, userID string, events []service.SpokenEvent) {
+	result, err := api.svc.RecordSpoken(r.Context(), userID, events)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSpokenEvent) {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_event", err.Error())
+			return
+		}
+		httpapi.WriteError(w, http.StatusInternalServerError, "record_spoken_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "recorded": result.Recorded, "skipped": result.Skipped})
+}
+
+func (api *API) listFrequentStatements(w http.ResponseWriter, r *http.Request) {
+	api.listStatementUsage(w, r, api.svc.ListFrequentStatements)
+}
+
+func (api *API) listRecentStatements(w http.ResponseWriter, r *http.Request) {
+	api.listStatementUsage(w, r, api.svc.ListRecentStatements)
+}
+
+func (api *API) listStatementUsage(w http.ResponseWriter, r *http.Request, list func(ctx context.Context, userID string, limit int) ([]models.StatementUsage, error)) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := parseIntInRange(raw, 1, 100)
+		if err != nil {
+			httpapi.WriteError(w, http.StatusBadRequest, "invalid_limit", err.Error())
+			return
+		}
+		limit = parsed
+	}
+
+	items, err := list(r.Context(), user.UID, limit)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "statement_usage_failed", err.Error())
+		return
+	}
+	if items == nil {
+		items = []models.StatementUsage{}
+	}
+	httpapi.WriteJSON(w, http.StatusOK, items)
+}
+
+func (api *API) listQuickeUsage(w http.ResponseWriter, r *http.Request) {
+	user := mustUser(w, r)
+	if user.UID == "" {
+		return
+	}
+	items, err := api.svc.ListQuickeUsage(r.Context(), user.UID)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, "quicke_usage_failed", err.Error())
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, items)
+}
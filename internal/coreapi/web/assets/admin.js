@@ -19,6 +19,16 @@
   const refreshGlobalCategoriesBtn = document.getElementById("refresh-global-categories");
   const createGlobalCategoryBtn = document.getElementById("create-global-category");
 
+  const globalStatementsPanel = document.getElementById("global-statements-panel");
+  const globalStatementsTitle = document.getElementById("global-statements-title");
+  const globalStatementsTable = document.getElementById("global-statements-table");
+  const closeGlobalStatementsBtn = document.getElementById("close-global-statements");
+  const newGlobalStatement = document.getElementById("new-global-statement");
+  const addGlobalStatementBtn = document.getElementById("add-global-statement");
+  const pasteGlobalStatements = document.getElementById("paste-global-statements");
+  const pasteGlobalStatementsBtn = document.getElementById("paste-global-statements-btn");
+  let editedCategoryId = "";
+
   const questionsTable = document.getElementById("questions-table");
   const refreshQuestionsBtn = document.getElementById("refresh-questions");
   const createQuestionBtn = document.getElementById("create-question");
@@ -116,12 +126,19 @@
         <td>${item.label || item.Label || "—"}</td>
         <td>${item.default || item.Default ? "Да" : "Нет"}</td>
         <td>
+          <button class="btn btn-ghost btn-sm" data-statements-category="${item.id || item.ID}" data-label="${item.label || item.Label || ""}">Фразы</button>
           <button class="btn btn-ghost btn-sm" data-edit-category="${item.id || item.ID}">Редактировать</button>
           <button class="btn btn-ghost btn-sm" data-delete-category="${item.id || item.ID}">Удалить</button>
         </td>
       `;
       globalCategoriesTable.appendChild(row);
     });
+    globalCategoriesTable.querySelectorAll("button[data-statements-category]").forEach((btn) => {
+      btn.addEventListener("click", () => {
+        openGlobalStatements(btn.getAttribute("data-statements-category"), btn.getAttribute("data-label"))
+          .catch(err => setMessage(adminMessage, err.message, "error"));
+      });
+    });
     globalCategoriesTable.querySelectorAll("button[data-delete-category]").forEach((btn) => {
       btn.addEventListener("click", async () => {
         const id = btn.getAttribute("data-delete-category");
@@ -136,6 +153,105 @@
     });
   }
 
+  async function openGlobalStatements(categoryId, label) {
+    editedCategoryId = categoryId;
+    globalStatementsTitle.textContent = label || categoryId;
+    globalStatementsPanel.classList.remove("d-none");
+    await loadGlobalStatements();
+  }
+
+  function closeGlobalStatements() {
+    editedCategoryId = "";
+    globalStatementsPanel.classList.add("d-none");
+  }
+
+  function globalStatementsPath(suffix = "") {
+    return `/v1/admin/global/categories/${encodeURIComponent(editedCategoryId)}/statements${suffix}`;
+  }
+
+  async function loadGlobalStatements() {
+    if (!editedCategoryId) return;
+    const data = await apiFetch(`/v1/global/categories/${encodeURIComponent(editedCategoryId)}/statements`);
+    renderGlobalStatements(data || []);
+  }
+
+  function renderGlobalStatements(items) {
+    globalStatementsTable.innerHTML = "";
+    if (items.length === 0) {
+      globalStatementsTable.innerHTML = "<tr><td colspan=\"2\" class=\"text-muted\">Нет фраз</td></tr>";
+      return;
+    }
+    items.forEach((item, index) => {
+      const row = document.createElement("tr");
+      row.innerHTML = `
+        <td><input class="form-control form-control-sm" type="text"></td>
+        <td class="text-nowrap">
+          <button class="btn btn-ghost btn-sm" data-move="up" ${index === 0 ? "disabled" : ""}>↑</button>
+          <button class="btn btn-ghost btn-sm" data-move="down" ${index === items.length - 1 ? "disabled" : ""}>↓</button>
+          <button class="btn btn-ghost btn-sm" data-delete-statement>Удалить</button>
+        </td>
+      `;
+      const input = row.querySelector("input");
+      input.value = item.text;
+      input.addEventListener("change", () => {
+        apiFetch(globalStatementsPath(`/${encodeURIComponent(item.id)}`), {
+          method: "PATCH",
+          body: JSON.stringify({ text: input.value })
+        }).then(() => setMessage(adminMessage, "Фраза сохранена", "success"))
+          .catch(err => setMessage(adminMessage, err.message, "error"));
+      });
+      row.querySelectorAll("button[data-move]").forEach((btn) => {
+        btn.addEventListener("click", () => {
+          const ids = items.map(stmt => stmt.id);
+          const target = btn.getAttribute("data-move") === "up" ? index - 1 : index + 1;
+          [ids[index], ids[target]] = [ids[target], ids[index]];
+          apiFetch(globalStatementsPath("/reorder"), {
+            method: "POST",
+            body: JSON.stringify({ ids })
+          }).then(renderGlobalStatements).catch(err => setMessage(adminMessage, err.message, "error"));
+        });
+      });
+      row.querySelector("button[data-delete-statement]").addEventListener("click", () => {
+        if (!confirm("Удалить фразу?")) return;
+        apiFetch(globalStatementsPath(`/${encodeURIComponent(item.id)}`), { method: "DELETE" })
+          .then(loadGlobalStatements)
+          .catch(err => setMessage(adminMessage, err.message, "error"));
+      });
+      globalStatementsTable.appendChild(row);
+    });
+  }
+
+  async function addGlobalStatement() {
+    const text = newGlobalStatement.value.trim();
+    if (!text) return;
+    try {
+      await apiFetch(globalStatementsPath(), {
+        method: "POST",
+        body: JSON.stringify({ text })
+      });
+      newGlobalStatement.value = "";
+      await loadGlobalStatements();
+    } catch (err) {
+      setMessage(adminMessage, err.message, "error");
+    }
+  }
+
+  async function pasteStatements() {
+    const text = pasteGlobalStatements.value;
+    if (!text.trim()) return;
+    try {
+      const data = await apiFetch(globalStatementsPath("/bulk"), {
+        method: "POST",
+        body: JSON.stringify({ text })
+      });
+      pasteGlobalStatements.value = "";
+      setMessage(adminMessage, `Добавлено фраз: ${data.statements.length}, пропущено: ${data.skipped}`, "success");
+      await loadGlobalStatements();
+    } catch (err) {
+      setMessage(adminMessage, err.message, "error");
+    }
+  }
+
   async function loadQuestions() {
     const data = await apiFetch("/v1/admin/factory/questions");
     questionsTable.innerHTML = "";
@@ -308,6 +424,9 @@
   createKeyBtn.addEventListener("click", createKey);
   addAdminBtn.addEventListener("click", addAdmin);
   statWindowSelect.addEventListener("change", loadStats);
+  closeGlobalStatementsBtn.addEventListener("click", closeGlobalStatements);
+  addGlobalStatementBtn.addEventListener("click", addGlobalStatement);
+  pasteGlobalStatementsBtn.addEventListener("click", pasteStatements);
 
   createGlobalCategoryBtn.addEventListener("click", () => {
     const label = prompt("Название категории:");
@@ -9,3 +9,47 @@ var DefaultQuickes = []string{
 	"Подождите",
 	"Я пишу ответ",
 }
+
+// localQuickes holds the default quick phrases for non-default locales.
+var localQuickes = map[string][]string{
+	"kk": {
+		"Сәлем",
+		"Қалайсыз?",
+		"Иә",
+		"Жоқ",
+		"Күте тұрыңыз",
+		"Мен жауап жазып жатырмын",
+	},
+	"en": {
+		"Hello",
+		"How are you?",
+		"Yes",
+		"No",
+		"Please wait",
+		"I'm typing a reply",
+	},
+}
+
+// Quickes returns the default quick phrases for locale, falling back to
+// DefaultQuickes.
+func Quickes(locale string) []string {
+	if quickes, ok := localQuickes[locale]; ok {
+		return append([]string(nil), quickes...)
+	}
+	return append([]string(nil), DefaultQuickes...)
+}
+
+// IsDefaultQuicke reports whether phrase is the default for slot in any
+// locale, so a slot left at a default in another language is not taken for
+// a custom phrase.
+func IsDefaultQuicke(slot int, phrase string) bool {
+	if slot < len(DefaultQuickes) && DefaultQuickes[slot] == phrase {
+		return true
+	}
+	for _, quickes := range localQuickes {
+		if slot < len(quickes) && quickes[slot] == phrase {
+			return true
+		}
+	}
+	return false
+}
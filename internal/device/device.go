@@ -0,0 +1,37 @@
+package device
+
+import (
+	"context"
+	"strings"
+)
+
+// Header is the HTTP header clients use to identify the device a request
+// comes from.
+const Header = "X-Device-Id"
+
+// maxLength bounds the stored device id.
+const maxLength = 64
+
+type ctxKey struct{}
+
+// Normalize trims a client-supplied device id and drops oversized values.
+func Normalize(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > maxLength {
+		return ""
+	}
+	return raw
+}
+
+// WithContext stores the device id in context.
+func WithContext(ctx context.Context, deviceID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, deviceID)
+}
+
+// FromContext reads the device id from context.
+func FromContext(ctx context.Context) string {
+	if val, ok := ctx.Value(ctxKey{}).(string); ok {
+		return val
+	}
+	return ""
+}
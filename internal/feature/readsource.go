@@ -32,3 +32,61 @@ func hashPercent(value string) int {
 	_, _ = h.Write([]byte(value))
 	return int(h.Sum32() % 100)
 }
+
+// Per-user migration states, in rollout order.
+const (
+	MigrationFirebase               = "firebase"
+	MigrationSeeding                = "seeding"
+	MigrationDual                   = "dual"
+	MigrationYDBPrimary             = "ydb_primary"
+	MigrationFirebaseDecommissioned = "firebase_decommissioned"
+)
+
+var migrationOrder = map[string]int{
+	MigrationFirebase:               0,
+	MigrationSeeding:                1,
+	MigrationDual:                   2,
+	MigrationYDBPrimary:             3,
+	MigrationFirebaseDecommissioned: 4,
+}
+
+// ValidMigrationState reports whether state is a known migration state.
+func ValidMigrationState(state string) bool {
+	_, ok := migrationOrder[state]
+	return ok
+}
+
+// DefaultMigrationState maps the read-source flag onto a state for users
+// without an explicit migration row.
+func DefaultMigrationState(userID string, cfg config.FeatureConfig) string {
+	if UseYDB(userID, cfg) {
+		return MigrationDual
+	}
+	return MigrationFirebase
+}
+
+// MigrationReadsYDB returns true when the state reads from YDB.
+func MigrationReadsYDB(state string) bool {
+	return migrationOrder[state] >= migrationOrder[MigrationDual]
+}
+
+// MigrationUsesFirebase returns true while Firebase is still mirrored and
+// may be used as a read-through fallback.
+func MigrationUsesFirebase(state string) bool {
+	return state != MigrationFirebaseDecommissioned
+}
+
+// MigrationTransitionAllowed allows one step forward or any step back.
+// Leaving firebase_decommissioned is refused: every other state mirrors
+// writes to, or reads from, RTDB data that is no longer maintained.
+func MigrationTransitionAllowed(from, to string) bool {
+	fromIdx, okFrom := migrationOrder[from]
+	toIdx, okTo := migrationOrder[to]
+	if !okFrom || !okTo {
+		return false
+	}
+	if from == MigrationFirebaseDecommissioned {
+		return to == from
+	}
+	return toIdx <= fromIdx+1
+}
@@ -27,3 +27,23 @@ func TestUseYDB(t *testing.T) {
 		t.Fatalf("expected cohort=100 to be true")
 	}
 }
+
+func TestMigrationTransitionAllowed(t *testing.T) {
+	if !MigrationTransitionAllowed(MigrationFirebase, MigrationSeeding) {
+		t.Fatalf("expected firebase -> seeding to be allowed")
+	}
+	if MigrationTransitionAllowed(MigrationFirebase, MigrationYDBPrimary) {
+		t.Fatalf("expected skipping states to be refused")
+	}
+	if !MigrationTransitionAllowed(MigrationYDBPrimary, MigrationFirebase) {
+		t.Fatalf("expected rollback to firebase to be allowed")
+	}
+	for _, to := range []string{MigrationFirebase, MigrationSeeding, MigrationDual, MigrationYDBPrimary} {
+		if MigrationTransitionAllowed(MigrationFirebaseDecommissioned, to) {
+			t.Fatalf("expected leaving decommissioned for %s to be refused", to)
+		}
+	}
+	if MigrationTransitionAllowed(MigrationFirebase, "unknown") {
+		t.Fatalf("expected unknown state to be refused")
+	}
+}
@@ -1,16 +1,33 @@
 package httpmiddleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/linkasu/linka.type-backend/internal/auth"
 	"github.com/linkasu/linka.type-backend/internal/httpapi"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/service"
+	"github.com/linkasu/linka.type-backend/internal/store"
 	"github.com/linkasu/linka.type-backend/internal/userctx"
 )
 
+// ActAsHeader names the account a delegate is acting for.
+const ActAsHeader = "X-Act-As"
+
+// GrantLookup finds the access an owner granted a delegate.
+type GrantLookup interface {
+	GetGrant(ctx context.Context, ownerID, delegateID string) (models.Grant, error)
+}
+
 // Auth verifies a Firebase bearer token and injects the user into context.
-func Auth(verifier auth.Verifier) func(http.Handler) http.Handler {
+// With grants set, a delegate may send X-Act-As to act for an owner who
+// granted them access: the owner becomes the context user and the delegate
+// the actor. Read grants only allow GET and HEAD. With nil grants the header
+// is refused.
+func Auth(verifier auth.Verifier, grants GrantLookup) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			token := bearerToken(r.Header.Get("Authorization"))
@@ -25,7 +42,31 @@ func Auth(verifier auth.Verifier) func(http.Handler) http.Handler {
 				return
 			}
 
-			ctx := userctx.With(r.Context(), user)
+			ctx := r.Context()
+			ownerID := strings.TrimSpace(r.Header.Get(ActAsHeader))
+			if ownerID != "" && ownerID != user.UID {
+				if grants == nil {
+					httpapi.WriteError(w, http.StatusForbidden, "act_as_not_allowed", "this endpoint cannot be used on behalf of another user")
+					return
+				}
+				grant, err := grants.GetGrant(ctx, ownerID, user.UID)
+				if errors.Is(err, store.ErrNotFound) {
+					httpapi.WriteError(w, http.StatusForbidden, "forbidden", "no access to this user")
+					return
+				}
+				if err != nil {
+					httpapi.WriteError(w, http.StatusInternalServerError, "grant_check_failed", "failed to check access")
+					return
+				}
+				if grant.Access != service.GrantEdit && r.Method != http.MethodGet && r.Method != http.MethodHead {
+					httpapi.WriteError(w, http.StatusForbidden, "forbidden", "read-only access to this user")
+					return
+				}
+				ctx = userctx.WithActor(ctx, user.UID)
+				user = auth.User{UID: ownerID}
+			}
+
+			ctx = userctx.With(ctx, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,73 @@
+package httpmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/auth"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/linkasu/linka.type-backend/internal/userctx"
+)
+
+type tokenVerifier struct{}
+
+func (tokenVerifier) Verify(_ context.Context, token string) (auth.User, error) {
+	if token == "" {
+		return auth.User{}, errors.New("empty token")
+	}
+	return auth.User{UID: token}, nil
+}
+
+type grantMap map[string]string
+
+func (g grantMap) GetGrant(_ context.Context, ownerID, delegateID string) (models.Grant, error) {
+	access, ok := g[ownerID+"/"+delegateID]
+	if !ok {
+		return models.Grant{}, store.ErrNotFound
+	}
+	return models.Grant{OwnerID: ownerID, DelegateID: delegateID, Access: access}, nil
+}
+
+func TestAuthActAs(t *testing.T) {
+	grants := grantMap{"owner/reader": "read", "owner/editor": "edit"}
+	cases := []struct {
+		name   string
+		grants GrantLookup
+		caller string
+		method string
+		status int
+		user   string
+		actor  string
+	}{
+		{name: "self", grants: grants, caller: "owner", method: http.MethodPost, status: http.StatusOK, user: "owner"},
+		{name: "read get", grants: grants, caller: "reader", method: http.MethodGet, status: http.StatusOK, user: "owner", actor: "reader"},
+		{name: "read post", grants: grants, caller: "reader", method: http.MethodPost, status: http.StatusForbidden},
+		{name: "edit post", grants: grants, caller: "editor", method: http.MethodPost, status: http.StatusOK, user: "owner", actor: "editor"},
+		{name: "no grant", grants: grants, caller: "stranger", method: http.MethodGet, status: http.StatusForbidden},
+		{name: "not allowed", grants: nil, caller: "editor", method: http.MethodGet, status: http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var user, actor string
+			handler := Auth(tokenVerifier{}, tc.grants)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				u, _ := userctx.From(r.Context())
+				user, actor = u.UID, userctx.Actor(r.Context())
+			}))
+			req := httptest.NewRequest(tc.method, "/v1/categories", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.caller)
+			req.Header.Set(ActAsHeader, "owner")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+			if user != tc.user || actor != tc.actor {
+				t.Fatalf("user/actor = %q/%q, want %q/%q", user, actor, tc.user, tc.actor)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package httpmiddleware
+
+import (
+	"net/http"
+
+	"github.com/linkasu/linka.type-backend/internal/device"
+)
+
+// Device injects the client's device id, when sent, into context.
+func Device(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deviceID := device.Normalize(r.Header.Get(device.Header))
+		if deviceID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(device.WithContext(r.Context(), deviceID)))
+	})
+}
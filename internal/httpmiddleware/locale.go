@@ -0,0 +1,20 @@
+package httpmiddleware
+
+import (
+	"net/http"
+
+	"github.com/linkasu/linka.type-backend/internal/locale"
+)
+
+// Locale injects the supported locale requested via Accept-Language, when
+// any, into context.
+func Locale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loc := locale.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+		if loc == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(locale.WithContext(r.Context(), loc)))
+	})
+}
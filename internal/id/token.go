@@ -0,0 +1,15 @@
+package id
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// NewToken returns an unguessable URL-safe token for links that grant access.
+func NewToken() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,106 @@
+// Package imaging validates uploaded pictures and renders thumbnails using
+// only the standard library decoders (PNG, JPEG, GIF).
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+)
+
+// MaxPixels rejects images whose decoded size would exhaust memory.
+const MaxPixels = 40_000_000
+
+// ErrUnsupported is returned for data that is not a supported image.
+var ErrUnsupported = errors.New("unsupported image")
+
+// Info describes a decoded image.
+type Info struct {
+	Format      string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+var contentTypes = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+	"gif":  "image/gif",
+}
+
+// Inspect reads the image header without decoding pixels.
+func Inspect(data []byte) (Info, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, ErrUnsupported
+	}
+	contentType, ok := contentTypes[format]
+	if !ok || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return Info{}, ErrUnsupported
+	}
+	return Info{Format: format, ContentType: contentType, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// Thumbnail scales the image to fit a size x size box, keeping the aspect
+// ratio, and encodes it as PNG. Smaller images are re-encoded unscaled.
+func Thumbnail(data []byte, size int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	bounds := src.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), size)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+			dst.SetRGBA(x, y, average(src, x0, y0, x1, y1))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fit returns dimensions no larger than size on either side.
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// average box-filters the source rectangle [x0,x1) x [y0,y1).
+func average(src image.Image, x0, y0, x1, y1 int) color.RGBA {
+	var r, g, b, a, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			cr, cg, cb, ca := src.At(x, y).RGBA()
+			r += uint64(cr)
+			g += uint64(cg)
+			b += uint64(cb)
+			a += uint64(ca)
+			n++
+		}
+	}
+	return color.RGBA{
+		R: uint8(r / n >> 8),
+		G: uint8(g / n >> 8),
+		B: uint8(b / n >> 8),
+		A: uint8(a / n >> 8),
+	}
+}
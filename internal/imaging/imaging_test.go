@@ -0,0 +1,70 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int, fill color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspect(t *testing.T) {
+	info, err := Inspect(encodePNG(t, 40, 20, color.White))
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	if info.ContentType != "image/png" || info.Width != 40 || info.Height != 20 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if _, err := Inspect([]byte("not an image")); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestThumbnailFitsBoxAndKeepsColor(t *testing.T) {
+	data, err := Thumbnail(encodePNG(t, 300, 100, color.NRGBA{R: 200, G: 10, B: 10, A: 255}), 60)
+	if err != nil {
+		t.Fatalf("thumbnail: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := img.Bounds().Size(); got.X != 60 || got.Y != 20 {
+		t.Fatalf("expected 60x20, got %v", got)
+	}
+	r, g, _, a := img.At(30, 10).RGBA()
+	if r>>8 != 200 || g>>8 != 10 || a>>8 != 255 {
+		t.Fatalf("unexpected pixel %d %d %d", r>>8, g>>8, a>>8)
+	}
+}
+
+func TestThumbnailKeepsSmallImages(t *testing.T) {
+	data, err := Thumbnail(encodePNG(t, 10, 30, color.Black), 64)
+	if err != nil {
+		t.Fatalf("thumbnail: %v", err)
+	}
+	info, err := Inspect(data)
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	if info.Width != 10 || info.Height != 30 {
+		t.Fatalf("expected 10x30, got %dx%d", info.Width, info.Height)
+	}
+}
@@ -0,0 +1,90 @@
+package locale
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the locale of the canonical catalogue text.
+const Default = "ru"
+
+// PreferenceKey is the user preference that overrides Accept-Language.
+const PreferenceKey = "locale"
+
+// Supported lists the locales clients can request.
+var Supported = []string{"ru", "kk", "en"}
+
+type ctxKey struct{}
+
+// Normalize returns the supported locale for a language tag such as
+// "kk-KZ", or "" when the language is not supported.
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	for _, loc := range Supported {
+		if tag == loc {
+			return loc
+		}
+	}
+	return ""
+}
+
+// FromAcceptLanguage picks the supported locale with the highest weight
+// in an Accept-Language header, or "" when none matches.
+func FromAcceptLanguage(header string) string {
+	type option struct {
+		locale string
+		weight float64
+	}
+	var options []option
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		if loc := Normalize(tag); loc != "" && weight > 0 {
+			options = append(options, option{locale: loc, weight: weight})
+		}
+	}
+	if len(options) == 0 {
+		return ""
+	}
+	sort.SliceStable(options, func(i, j int) bool { return options[i].weight > options[j].weight })
+	return options[0].locale
+}
+
+// WithContext stores the requested locale in context.
+func WithContext(ctx context.Context, loc string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, loc)
+}
+
+// FromContext reads the requested locale from context.
+func FromContext(ctx context.Context) string {
+	if val, ok := ctx.Value(ctxKey{}).(string); ok {
+		return val
+	}
+	return ""
+}
+
+// Resolve returns the locale for a user: the preference when set and
+// supported, then the requested locale, then Default.
+func Resolve(preferences map[string]any, requested string) string {
+	if pref, ok := preferences[PreferenceKey].(string); ok {
+		if loc := Normalize(pref); loc != "" {
+			return loc
+		}
+	}
+	if loc := Normalize(requested); loc != "" {
+		return loc
+	}
+	return Default
+}
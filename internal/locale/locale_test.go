@@ -0,0 +1,32 @@
+package locale
+
+import "testing"
+
+func TestFromAcceptLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                          "",
+		"kk-KZ":                     "kk",
+		"de-DE, en;q=0.5, ru;q=0.8": "ru",
+		"en-US,en;q=0.9":            "en",
+		"fr, de":                    "",
+		"ru;q=0, kk;q=0.1":          "kk",
+		"en;q=bad, kk":              "kk",
+	}
+	for header, want := range cases {
+		if got := FromAcceptLanguage(header); got != want {
+			t.Errorf("FromAcceptLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if got := Resolve(map[string]any{PreferenceKey: "en"}, "kk"); got != "en" {
+		t.Fatalf("preference should win, got %q", got)
+	}
+	if got := Resolve(map[string]any{PreferenceKey: "fr"}, "kk"); got != "kk" {
+		t.Fatalf("unsupported preference should fall back to request, got %q", got)
+	}
+	if got := Resolve(nil, ""); got != Default {
+		t.Fatalf("expected default, got %q", got)
+	}
+}
@@ -9,15 +9,30 @@ type Category struct {
 	Created   int64  `json:"created"`
 	Default   *bool  `json:"default,omitempty"`
 	AIUse     bool   `json:"aiUse,omitempty"`
+	ParentID  string `json:"parentId,omitempty"`
+	Position  string `json:"position,omitempty"`
+	Color     string `json:"color,omitempty"`
+	Icon      string `json:"icon,omitempty"`
+	ImageID   string `json:"imageId,omitempty"`
 	UpdatedAt int64  `json:"updated_at,omitempty"`
 }
 
+// CategoryNode is a category with its sub-categories.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
 // Statement matches client-visible fields.
 type Statement struct {
 	ID         string `json:"id"`
 	CategoryID string `json:"categoryId"`
 	Text       string `json:"text"`
 	Created    int64  `json:"created"`
+	Position   string `json:"position,omitempty"`
+	Color      string `json:"color,omitempty"`
+	Icon       string `json:"icon,omitempty"`
+	ImageID    string `json:"imageId,omitempty"`
 	UpdatedAt  int64  `json:"updated_at,omitempty"`
 }
 
@@ -80,18 +95,67 @@ type GlobalCategory struct {
 	Label      string      `json:"label"`
 	Created    int64       `json:"created"`
 	Default    *bool       `json:"default,omitempty"`
+	ParentID   string      `json:"parentId,omitempty"`
 	UpdatedAt  int64       `json:"updated_at,omitempty"`
 	Statements []Statement `json:"statements,omitempty"`
 }
 
-// FactoryQuestion defines onboarding templates.
+// GlobalCategoryNode is a global category with its sub-categories.
+type GlobalCategoryNode struct {
+	GlobalCategory
+	Children []GlobalCategoryNode `json:"children"`
+}
+
+// FactoryQuestion defines onboarding templates. Phrases may use %% for the
+// answer and {placeholder} for answers to other questions. ShowIf makes the
+// question a follow-up to an earlier one.
 type FactoryQuestion struct {
-	ID         string   `json:"id"`
-	Label      string   `json:"label"`
-	Phrases    []string `json:"phrases"`
-	Category   string   `json:"category"`
-	Type       string   `json:"type"`
-	OrderIndex int      `json:"order_index"`
+	ID          string             `json:"id"`
+	Label       string             `json:"label"`
+	Phrases     []string           `json:"phrases"`
+	Category    string             `json:"category"`
+	Type        string             `json:"type"`
+	OrderIndex  int                `json:"order_index"`
+	Options     []QuestionOption   `json:"options,omitempty"`
+	Placeholder string             `json:"placeholder,omitempty"`
+	ShowIf      *QuestionCondition `json:"show_if,omitempty"`
+}
+
+// QuestionOption is one choice of a single or multi choice question. Value
+// is stable across locales; Label is shown and used in phrases.
+type QuestionOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// QuestionCondition shows a question when QuestionID was answered with any
+// of Values, or answered at all when Values is empty.
+type QuestionCondition struct {
+	QuestionID string   `json:"question_id"`
+	Values     []string `json:"values,omitempty"`
+}
+
+// OnboardingAnswer is a user's answer to a factory question. Choice answers
+// hold option values; other types hold a single value.
+type OnboardingAnswer struct {
+	QuestionID string   `json:"question_id"`
+	Values     []string `json:"values"`
+	UpdatedAt  int64    `json:"updated_at"`
+}
+
+// GlobalTranslation is a per-locale variant of a global category label
+// (kind "category"), global statement text (kind "statement", entity id
+// "categoryId/statementId") or factory question (kind "question"). Options
+// translate the labels of a choice question's options by value.
+type GlobalTranslation struct {
+	Kind      string           `json:"kind"`
+	EntityID  string           `json:"entity_id"`
+	Locale    string           `json:"locale"`
+	Text      string           `json:"text"`
+	Phrases   []string         `json:"phrases,omitempty"`
+	Category  string           `json:"category,omitempty"`
+	Options   []QuestionOption `json:"options,omitempty"`
+	UpdatedAt int64            `json:"updated_at"`
 }
 
 // ChangeEvent is emitted to realtime consumers.
@@ -102,6 +166,42 @@ type ChangeEvent struct {
 	Payload    json.RawMessage `json:"payload"`
 	UpdatedAt  int64           `json:"updated_at"`
 	Cursor     string          `json:"cursor,omitempty"`
+	Origin     string          `json:"origin,omitempty"`
+	Actor      string          `json:"actor,omitempty"`
+}
+
+// OrderChange is the payload of category_order and statement_order changes.
+type OrderChange struct {
+	CategoryID string            `json:"categoryId,omitempty"`
+	Positions  map[string]string `json:"positions"`
+}
+
+// StatementUsage is a statement with its spoken counters.
+type StatementUsage struct {
+	Statement
+	SpokenCount  int64 `json:"spokenCount"`
+	LastSpokenAt int64 `json:"lastSpokenAt"`
+}
+
+// QuickeUsage holds spoken counters for a quick phrase slot.
+type QuickeUsage struct {
+	Slot         int    `json:"slot"`
+	Text         string `json:"text"`
+	SpokenCount  int64  `json:"spokenCount"`
+	LastSpokenAt int64  `json:"lastSpokenAt"`
+}
+
+// StatementHit is a statement search result.
+type StatementHit struct {
+	Statement
+	Score       float64 `json:"score"`
+	SpokenCount int64   `json:"spokenCount,omitempty"`
+}
+
+// CategoryHit is a category search result.
+type CategoryHit struct {
+	Category
+	Score float64 `json:"score"`
 }
 
 // UsageLimit tracks monthly inference usage per user.
@@ -112,3 +212,161 @@ type UsageLimit struct {
 	Limit          int64  `json:"limit"`
 	UpdatedAt      int64  `json:"updated_at,omitempty"`
 }
+
+// UserMigration records where a user's data is read from and mirrored to.
+type UserMigration struct {
+	UserID     string `json:"user_id"`
+	State      string `json:"state"`
+	Note       string `json:"note,omitempty"`
+	VerifiedAt int64  `json:"verified_at,omitempty"`
+	UpdatedAt  int64  `json:"updated_at,omitempty"`
+	UpdatedBy  string `json:"updated_by,omitempty"`
+	// Explicit is false when no row exists and the state is derived from
+	// the read-source feature flag.
+	Explicit bool `json:"explicit"`
+}
+
+// Media is an uploaded image owned by a user.
+type Media struct {
+	ID           string `json:"id"`
+	ContentType  string `json:"contentType"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	BlobKey      string `json:"-"`
+	ThumbnailKey string `json:"-"`
+	Created      int64  `json:"created"`
+}
+
+// TrashedCategory is a soft-deleted category with the statements and
+// sub-categories that were deleted together with it.
+type TrashedCategory struct {
+	Category
+	DeletedAt     int64             `json:"deletedAt"`
+	Statements    []Statement       `json:"statements"`
+	Subcategories []TrashedCategory `json:"subcategories,omitempty"`
+}
+
+// TrashedStatement is a statement deleted on its own.
+type TrashedStatement struct {
+	Statement
+	DeletedAt int64 `json:"deletedAt"`
+}
+
+// Trash lists what a user can restore.
+type Trash struct {
+	Categories []TrashedCategory  `json:"categories"`
+	Statements []TrashedStatement `json:"statements"`
+}
+
+// Revision is a snapshot of a category or statement taken after a mutation.
+type Revision struct {
+	ID         string          `json:"id"`
+	EntityType string          `json:"entityType"`
+	EntityID   string          `json:"entityId"`
+	Op         string          `json:"op"`
+	Snapshot   json.RawMessage `json:"snapshot"`
+	Device     string          `json:"device,omitempty"`
+	At         int64           `json:"at"`
+}
+
+// StatementRevision is one entry of a statement's edit history.
+type StatementRevision struct {
+	ID         string `json:"id"`
+	Op         string `json:"op"`
+	Text       string `json:"text"`
+	CategoryID string `json:"categoryId"`
+	Device     string `json:"device,omitempty"`
+	At         int64  `json:"at"`
+}
+
+// UserExport is a versioned copy of a user's data. Sent as the snapshot
+// field of a bootstrap request ({"snapshot": export}), its categories,
+// statements, quickes, inited and preferences are imported and the rest is
+// ignored.
+type UserExport struct {
+	Version     int            `json:"version"`
+	ExportedAt  int64          `json:"exportedAt"`
+	UserID      string         `json:"userId"`
+	Categories  []Category     `json:"categories"`
+	Statements  []Statement    `json:"statements"`
+	Quickes     []string       `json:"quickes"`
+	Inited      bool           `json:"inited"`
+	Preferences map[string]any `json:"preferences"`
+	Dialog      DialogExport   `json:"dialog"`
+	UsageLimits []UsageLimit   `json:"usageLimits"`
+}
+
+// DialogExport holds a user's dialog helper history.
+type DialogExport struct {
+	Chats       []DialogChat       `json:"chats"`
+	Messages    []DialogMessage    `json:"messages"`
+	Suggestions []DialogSuggestion `json:"suggestions"`
+}
+
+// ExportJob tracks an export built in the background.
+type ExportJob struct {
+	ID        string `json:"id"`
+	Format    string `json:"format"`
+	Status    string `json:"status"`
+	Size      int64  `json:"size,omitempty"`
+	Error     string `json:"error,omitempty"`
+	BlobKey   string `json:"-"`
+	Created   int64  `json:"created"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// Share is an invite link to a copy of a category and its sub-categories.
+// Live copies keep following the owner's edits after they are accepted.
+type Share struct {
+	Token      string `json:"token"`
+	OwnerID    string `json:"-"`
+	CategoryID string `json:"categoryId"`
+	Mode       string `json:"mode"`
+	ExpiresAt  int64  `json:"expiresAt"`
+	Created    int64  `json:"created"`
+}
+
+// ShareSubscription links a recipient's copy of a category to the owner's
+// original for a live share.
+type ShareSubscription struct {
+	OwnerID     string
+	RecipientID string
+	CategoryID  string
+	Token       string
+	Created     int64
+}
+
+// Grant lets a delegate read or edit the owner's board.
+type Grant struct {
+	OwnerID    string `json:"ownerId"`
+	DelegateID string `json:"delegateId"`
+	Access     string `json:"access"`
+	Created    int64  `json:"created"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+// Organization groups therapists and their clients around a shared library.
+type Organization struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Created   int64  `json:"created"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// OrgMember is a user's role in an organization. Invited members become
+// active once they accept.
+type OrgMember struct {
+	OrgID   string `json:"orgId"`
+	UserID  string `json:"userId"`
+	Role    string `json:"role"`
+	Status  string `json:"status"`
+	Created int64  `json:"created"`
+}
+
+// GlobalImport records which version of a global category a user imported.
+type GlobalImport struct {
+	CategoryID string `json:"categoryId"`
+	Version    int64  `json:"version"`
+	ImportedAt int64  `json:"importedAt"`
+}
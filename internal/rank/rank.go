@@ -0,0 +1,111 @@
+// Package rank generates lexicographically ordered position keys. A new key
+// can always be placed between two existing ones, so moving an item only
+// rewrites that item's key.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// ErrInvalidRange is returned when the lower key does not sort before the upper key.
+var ErrInvalidRange = errors.New("rank: lower key must sort before upper key")
+
+// Valid reports whether key uses only rank digits and does not end in the
+// smallest digit (such keys leave no room directly below them).
+func Valid(key string) bool {
+	if key == "" || key[len(key)-1] == digits[0] {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Between returns a key strictly between lo and hi. An empty lo means the
+// start of the list and an empty hi means the end.
+func Between(lo, hi string) (string, error) {
+	if (lo != "" && !Valid(lo)) || (hi != "" && !Valid(hi)) {
+		return "", ErrInvalidRange
+	}
+	if lo != "" && hi != "" && lo >= hi {
+		return "", ErrInvalidRange
+	}
+
+	var out []byte
+	upperOpen := hi == ""
+	for i := 0; ; i++ {
+		dlo := 0
+		if i < len(lo) {
+			dlo = digit(lo[i])
+		}
+		dhi := base
+		if !upperOpen && i < len(hi) {
+			dhi = digit(hi[i])
+		}
+
+		if dhi-dlo > 1 {
+			return string(append(out, digits[(dlo+dhi)/2])), nil
+		}
+		out = append(out, digits[dlo])
+		if dhi-dlo == 1 {
+			// The prefix is already below hi; only lo constrains the rest.
+			upperOpen = true
+		}
+	}
+}
+
+// After returns a key that sorts after key.
+func After(key string) (string, error) {
+	return Between(key, "")
+}
+
+// Sequence returns n evenly spaced ascending keys.
+func Sequence(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	width := 1
+	for span := base; span <= n; span *= base {
+		width++
+	}
+	span := 1
+	for i := 0; i < width; i++ {
+		span *= base
+	}
+
+	keys := make([]string, n)
+	buf := make([]byte, width)
+	for i := range keys {
+		value := (i + 1) * span / (n + 1)
+		for j := width - 1; j >= 0; j-- {
+			buf[j] = digits[value%base]
+			value /= base
+		}
+		keys[i] = strings.TrimRight(string(buf), digits[:1])
+	}
+	return keys
+}
+
+// Less orders positioned keys first, then unpositioned ones.
+func Less(a, b string) bool {
+	switch {
+	case a == "":
+		return false
+	case b == "":
+		return true
+	default:
+		return a < b
+	}
+}
+
+func digit(c byte) int {
+	return strings.IndexByte(digits, c)
+}
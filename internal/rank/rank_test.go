@@ -0,0 +1,90 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	cases := []struct{ lo, hi string }{
+		{"", ""},
+		{"", "1"},
+		{"", "0001"},
+		{"i", ""},
+		{"z", ""},
+		{"a", "b"},
+		{"a", "a1"},
+		{"az", "b"},
+		{"a1", "a2"},
+	}
+	for _, tc := range cases {
+		got, err := Between(tc.lo, tc.hi)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", tc.lo, tc.hi, err)
+		}
+		if !Valid(got) {
+			t.Fatalf("Between(%q, %q) = %q is not a valid key", tc.lo, tc.hi, got)
+		}
+		if tc.lo != "" && got <= tc.lo {
+			t.Fatalf("Between(%q, %q) = %q, want > lo", tc.lo, tc.hi, got)
+		}
+		if tc.hi != "" && got >= tc.hi {
+			t.Fatalf("Between(%q, %q) = %q, want < hi", tc.lo, tc.hi, got)
+		}
+	}
+}
+
+func TestBetweenRejectsInvalidRange(t *testing.T) {
+	for _, tc := range []struct{ lo, hi string }{{"b", "a"}, {"a", "a"}, {"a0", ""}, {"A", ""}} {
+		if _, err := Between(tc.lo, tc.hi); err == nil {
+			t.Fatalf("Between(%q, %q) expected error", tc.lo, tc.hi)
+		}
+	}
+}
+
+func TestRepeatedInsertsStayOrdered(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	keys := Sequence(3)
+	for i := 0; i < 500; i++ {
+		at := rng.Intn(len(keys) + 1)
+		lo, hi := "", ""
+		if at > 0 {
+			lo = keys[at-1]
+		}
+		if at < len(keys) {
+			hi = keys[at]
+		}
+		key, err := Between(lo, hi)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", lo, hi, err)
+		}
+		keys = append(keys[:at], append([]string{key}, keys[at:]...)...)
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Fatalf("keys are not sorted")
+	}
+}
+
+func TestSequence(t *testing.T) {
+	for _, n := range []int{1, 2, 35, 36, 37, 1000} {
+		keys := Sequence(n)
+		if len(keys) != n {
+			t.Fatalf("Sequence(%d) returned %d keys", n, len(keys))
+		}
+		for i, key := range keys {
+			if !Valid(key) {
+				t.Fatalf("Sequence(%d)[%d] = %q is not valid", n, i, key)
+			}
+			if i > 0 && keys[i-1] >= key {
+				t.Fatalf("Sequence(%d) not ascending at %d: %q >= %q", n, i, keys[i-1], key)
+			}
+		}
+	}
+}
+
+func TestLess(t *testing.T) {
+	if !Less("a", "") || Less("", "a") || Less("", "") || !Less("a", "b") {
+		t.Fatalf("unexpected ordering")
+	}
+}
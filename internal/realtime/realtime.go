@@ -28,7 +28,7 @@ func New(store store.Store, verifier auth.Verifier) http.Handler {
 
 	r := chi.NewRouter()
 	r.Use(httpmiddleware.RequestID)
-	r.Use(httpmiddleware.Auth(verifier))
+	r.Use(httpmiddleware.Auth(verifier, store))
 
 	r.Get("/v1/changes", s.longPoll)
 	r.Get("/v1/stream", s.stream)
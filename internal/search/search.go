@@ -0,0 +1,151 @@
+// Package search turns phrase text into index terms. The same terms are
+// produced for documents and queries: whole-word stems match exactly, while
+// trigrams of the stems tolerate typos and incomplete words.
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Term weights. A query's best possible score is the sum of its term weights.
+const (
+	WordWeight    = 3
+	TrigramWeight = 1
+)
+
+const minStemRunes = 3
+
+// Term is an index key with its weight.
+type Term struct {
+	Key    string
+	Weight int64
+}
+
+// Normalize lowercases text, folds ё into е and replaces everything but
+// letters and digits with single spaces.
+func Normalize(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	space := true
+	for _, r := range strings.ToLower(text) {
+		if r == 'ё' {
+			r = 'е'
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Words returns the normalized words of text.
+func Words(text string) []string {
+	return strings.Fields(Normalize(text))
+}
+
+// Terms returns the deduplicated index terms for text.
+func Terms(text string) []Term {
+	seen := make(map[string]bool)
+	var terms []Term
+	add := func(key string, weight int64) {
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		terms = append(terms, Term{Key: key, Weight: weight})
+	}
+	for _, word := range Words(text) {
+		stem := Stem(word)
+		add("w:"+stem, WordWeight)
+		for _, gram := range trigrams(stem) {
+			add("t:"+gram, TrigramWeight)
+		}
+	}
+	return terms
+}
+
+// Keys returns only the term keys.
+func Keys(terms []Term) []string {
+	keys := make([]string, len(terms))
+	for i, term := range terms {
+		keys[i] = term.Key
+	}
+	return keys
+}
+
+// MaxScore is the score of a document matching every query term.
+func MaxScore(terms []Term) int64 {
+	var total int64
+	for _, term := range terms {
+		total += term.Weight
+	}
+	return total
+}
+
+// Stem strips common Russian or English inflections from a normalized word.
+func Stem(word string) string {
+	if isCyrillic(word) {
+		return stripSuffix(word, russianSuffixes)
+	}
+	if strings.HasSuffix(word, "ies") && utf8.RuneCountInString(word) > minStemRunes+2 {
+		return strings.TrimSuffix(word, "ies") + "y"
+	}
+	return stripSuffix(word, englishSuffixes)
+}
+
+// Longest suffixes come first so the longest match wins.
+var russianSuffixes = []string{
+	"иями", "ться",
+	"ями", "ами", "ого", "его", "ому", "ему", "ыми", "ими", "ешь", "ете", "ишь", "ите",
+	"ать", "ять", "ить", "еть", "уть", "тся",
+	"ая", "яя", "ое", "ее", "ые", "ие", "ый", "ий", "ой", "ей", "ую", "юю", "ам", "ям",
+	"ах", "ях", "ом", "ем", "ов", "ев", "ию", "ия", "ть", "ут", "ют", "ат", "ят", "ит", "ет",
+	"а", "я", "о", "е", "ы", "и", "у", "ю", "ь", "й",
+}
+
+var englishSuffixes = []string{"ing", "ed", "es", "s"}
+
+func stripSuffix(word string, suffixes []string) string {
+	runes := utf8.RuneCountInString(word)
+	for _, suffix := range suffixes {
+		if !strings.HasSuffix(word, suffix) {
+			continue
+		}
+		if runes-utf8.RuneCountInString(suffix) < minStemRunes {
+			continue
+		}
+		return strings.TrimSuffix(word, suffix)
+	}
+	return word
+}
+
+// trigrams returns rune trigrams of word with a leading boundary marker, so
+// prefixes of a word share the most grams with it.
+func trigrams(word string) []string {
+	runes := append([]rune{'_'}, []rune(word)...)
+	if len(runes) < 3 {
+		return []string{string(runes)}
+	}
+	grams := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		grams = append(grams, string(runes[i:i+3]))
+	}
+	return grams
+}
+
+func isCyrillic(word string) bool {
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package search
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	got := Normalize("  Ёлка, ЁЖИК!  Hello-World ")
+	if got != "елка ежик hello world" {
+		t.Fatalf("unexpected normalization: %q", got)
+	}
+}
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"воды":    "вод",
+		"воду":    "вод",
+		"водой":   "вод",
+		"хочу":    "хоч",
+		"пить":    "пит",
+		"дай":     "дай",
+		"phrases": "phras",
+		"stories": "story",
+		"walking": "walk",
+		"go":      "go",
+	}
+	for word, want := range cases {
+		if got := Stem(word); got != want {
+			t.Fatalf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestTermsMatchAcrossInflections(t *testing.T) {
+	doc := make(map[string]bool)
+	for _, term := range Terms("Я хочу пить воды") {
+		doc[term.Key] = true
+	}
+	for _, query := range []string{"вода", "ВОДУ", "пить"} {
+		if !doc["w:"+Stem(Words(query)[0])] {
+			t.Fatalf("expected %q to match the document by stem", query)
+		}
+	}
+}
+
+func TestTermsDeduplicate(t *testing.T) {
+	terms := Terms("мама мама")
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if seen[term.Key] {
+			t.Fatalf("duplicate term %q", term.Key)
+		}
+		seen[term.Key] = true
+	}
+	if MaxScore(terms) == 0 {
+		t.Fatalf("expected a positive max score")
+	}
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/linkasu/linka.type-backend/internal/defaults"
+	"github.com/linkasu/linka.type-backend/internal/locale"
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+// Merge strategies for BootstrapUserData.
+const (
+	MergeLocalWins  = "local_wins"
+	MergeRemoteWins = "remote_wins"
+	MergeNewest     = "merge_newest"
+	MergeKeepBoth   = "keep_both"
+)
+
+// Conflict resolutions.
+const (
+	resolveLocal  = "local"
+	resolveRemote = "remote"
+	resolveBoth   = "both"
+)
+
+var ErrInvalidMergeStrategy = errors.New("unsupported merge strategy")
+
+// BootstrapConflict is a snapshot entity that differs from the account's
+// version of it. Key is the quicke slot or preference name. Resolution is
+// the side that was kept: local, remote, or both for a category copied
+// under a new label.
+type BootstrapConflict struct {
+	Kind       string `json:"kind"`
+	LocalID    string `json:"localId,omitempty"`
+	RemoteID   string `json:"remoteId,omitempty"`
+	Key        string `json:"key,omitempty"`
+	Label      string `json:"label,omitempty"`
+	Resolution string `json:"resolution"`
+}
+
+// BootstrapUserData imports a local snapshot into the user's cloud account.
+// Categories match by label and statements by text within their category;
+// the strategy decides which side wins when a matched pair differs. Phrases
+// missing from a matched category are added under every strategy.
+func (s *Service) BootstrapUserData(
+	ctx context.Context,
+	userID string,
+	snapshot UserBootstrapSnapshot,
+	mergeStrategy string,
+	preview bool,
+) (UserBootstrapResult, error) {
+	if mergeStrategy == "" {
+		mergeStrategy = MergeLocalWins
+	}
+	switch mergeStrategy {
+	case MergeLocalWins, MergeRemoteWins, MergeNewest, MergeKeepBoth:
+	default:
+		return UserBootstrapResult{}, ErrInvalidMergeStrategy
+	}
+
+	result := UserBootstrapResult{ConflictsList: []BootstrapConflict{}}
+	result.IDMap.Categories = make(map[string]string)
+	result.IDMap.Statements = make(map[string]string)
+	addConflict := func(conflict BootstrapConflict) {
+		result.Conflicts++
+		result.ConflictsList = append(result.ConflictsList, conflict)
+	}
+
+	existingCategories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	existingStatements, err := s.Store.ListAllStatements(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+
+	remoteIDs := make(map[string]bool, len(existingCategories)+len(existingStatements))
+	categoryByLabel := make(map[string]models.Category, len(existingCategories))
+	for _, category := range existingCategories {
+		categoryByLabel[normalizeLabel(category.Label)] = category
+		remoteIDs[category.ID] = true
+	}
+	statementByKey := make(map[string]models.Statement, len(existingStatements))
+	for _, statement := range existingStatements {
+		statementByKey[statementDedupKey(statement.CategoryID, statement.Text)] = statement
+		remoteIDs[statement.ID] = true
+	}
+	localStatements := make(map[string][]models.Statement)
+	for _, statement := range snapshot.Statements {
+		localStatements[statement.CategoryID] = append(localStatements[statement.CategoryID], statement)
+	}
+
+	snapshotCategoryLabelByID := make(map[string]string, len(snapshot.Categories))
+	// resolvedCategories maps snapshot category IDs to account ones; in a
+	// preview, categories that would be created get a placeholder.
+	resolvedCategories := make(map[string]string)
+	createdCategoryIDs := make(map[string]string)
+	for _, category := range snapshot.Categories {
+		label := strings.TrimSpace(category.Label)
+		if label == "" {
+			continue
+		}
+		snapshotCategoryLabelByID[category.ID] = label
+		normalizedLabel := normalizeLabel(label)
+		imageID := s.knownMediaID(ctx, userID, category.ImageID)
+
+		if existing, ok := categoryByLabel[normalizedLabel]; ok {
+			resolution := ""
+			if remoteIDs[existing.ID] && categoryDiffers(category, imageID, existing, localStatements[category.ID], statementByKey) {
+				resolution = resolveConflict(mergeStrategy,
+					entityTime(category.UpdatedAt, category.Created), entityTime(existing.UpdatedAt, existing.Created))
+				addConflict(BootstrapConflict{Kind: "category", LocalID: category.ID, RemoteID: existing.ID, Label: label, Resolution: resolution})
+			}
+			if resolution == resolveLocal && !preview {
+				_, err := s.UpdateCategory(ctx, userID, existing.ID, CategoryPatch{
+					AIUse:   &category.AIUse,
+					Color:   &category.Color,
+					Icon:    &category.Icon,
+					ImageID: &imageID,
+				})
+				if err != nil {
+					return result, err
+				}
+			}
+			if resolution != resolveBoth {
+				if category.ID != "" {
+					resolvedCategories[category.ID] = existing.ID
+					if remoteIDs[existing.ID] || !preview {
+						result.IDMap.Categories[category.ID] = existing.ID
+					}
+				}
+				continue
+			}
+			label = uniqueLabel(label, categoryByLabel)
+			normalizedLabel = normalizeLabel(label)
+		}
+
+		created := models.Category{ID: "new:" + normalizedLabel, Label: label}
+		if !preview {
+			created, err = s.CreateCategory(ctx, userID, CategoryInput{
+				Label:   label,
+				Created: category.Created,
+				Default: category.Default,
+				AIUse:   category.AIUse,
+				Color:   category.Color,
+				Icon:    category.Icon,
+				ImageID: imageID,
+			})
+			if err != nil {
+				return result, err
+			}
+		}
+
+		categoryByLabel[normalizedLabel] = created
+		result.Imported.Categories++
+		if category.ID != "" {
+			resolvedCategories[category.ID] = created.ID
+			if !preview {
+				result.IDMap.Categories[category.ID] = created.ID
+				createdCategoryIDs[category.ID] = created.ID
+			}
+		}
+	}
+
+	// Parents are attached once every snapshot category has an ID.
+	for _, category := range snapshot.Categories {
+		createdID, ok := createdCategoryIDs[category.ID]
+		if !ok || category.ParentID == "" {
+			continue
+		}
+		parentID, ok := result.IDMap.Categories[category.ParentID]
+		if !ok {
+			continue
+		}
+		_, err := s.UpdateCategory(ctx, userID, createdID, CategoryPatch{ParentID: &parentID})
+		if err != nil && !errors.Is(err, ErrInvalidParent) {
+			return result, err
+		}
+	}
+
+	for _, statement := range snapshot.Statements {
+		text := strings.TrimSpace(statement.Text)
+		if text == "" {
+			continue
+		}
+
+		resolvedCategoryID := statement.CategoryID
+		if mapped, ok := resolvedCategories[statement.CategoryID]; ok {
+			resolvedCategoryID = mapped
+		} else if label, ok := snapshotCategoryLabelByID[statement.CategoryID]; ok {
+			if resolved, exists := categoryByLabel[normalizeLabel(label)]; exists {
+				resolvedCategoryID = resolved.ID
+			}
+		}
+
+		if strings.TrimSpace(resolvedCategoryID) == "" {
+			continue
+		}
+
+		imageID := s.knownMediaID(ctx, userID, statement.ImageID)
+		key := statementDedupKey(resolvedCategoryID, text)
+		if existing, exists := statementByKey[key]; exists {
+			if remoteIDs[existing.ID] && statementDiffers(statement, text, imageID, existing) {
+				resolution := resolveConflict(mergeStrategy,
+					entityTime(statement.UpdatedAt, statement.Created), entityTime(existing.UpdatedAt, existing.Created))
+				// Both sides hold the same phrase, so it is never duplicated.
+				if resolution == resolveBoth {
+					resolution = resolveRemote
+				}
+				addConflict(BootstrapConflict{Kind: "statement", LocalID: statement.ID, RemoteID: existing.ID, Label: text, Resolution: resolution})
+				if resolution == resolveLocal && !preview {
+					_, err := s.UpdateStatement(ctx, userID, existing.ID, StatementPatch{
+						Text:    &text,
+						Color:   &statement.Color,
+						Icon:    &statement.Icon,
+						ImageID: &imageID,
+					})
+					if err != nil {
+						return result, err
+					}
+				}
+			}
+			if statement.ID != "" && existing.ID != "" {
+				result.IDMap.Statements[statement.ID] = existing.ID
+			}
+			continue
+		}
+
+		created := models.Statement{CategoryID: resolvedCategoryID, Text: text}
+		if !preview {
+			created, err = s.CreateStatement(ctx, userID, StatementInput{
+				CategoryID: resolvedCategoryID,
+				Text:       text,
+				Created:    statement.Created,
+				Color:      statement.Color,
+				Icon:       statement.Icon,
+				ImageID:    imageID,
+			})
+			if err != nil {
+				return result, err
+			}
+			if statement.ID != "" {
+				result.IDMap.Statements[statement.ID] = created.ID
+			}
+		}
+		statementByKey[key] = created
+		result.Imported.Statements++
+	}
+
+	remote, err := s.GetUserState(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	localWins := mergeStrategy == MergeLocalWins
+	// User state has no per-field timestamps, so merge_newest keeps the
+	// account's values like remote_wins.
+	stateResolution := resolveRemote
+	if localWins {
+		stateResolution = resolveLocal
+	}
+
+	patch := UserStatePatch{}
+	if localWins || (snapshot.Inited && !remote.Inited) {
+		patch.Inited = &snapshot.Inited
+		result.Imported.Inited = true
+	}
+	if len(snapshot.Quickes) > 0 {
+		loc := locale.Resolve(remote.Preferences, locale.FromContext(ctx))
+		quickes, conflicts := mergeQuickes(remote.Quickes, snapshot.Quickes, loc, localWins)
+		local := normalizeQuickes(snapshot.Quickes, loc)
+		for _, slot := range conflicts {
+			addConflict(BootstrapConflict{Kind: "quicke", Key: strconv.Itoa(slot), Label: local[slot], Resolution: stateResolution})
+		}
+		if !slices.Equal(quickes, normalizeQuickes(remote.Quickes, loc)) {
+			patch.QuickesSet = true
+			patch.Quickes = quickes
+			result.Imported.Quickes = true
+		}
+	}
+	if len(snapshot.Preferences) > 0 {
+		preferences, conflicts := mergePreferences(remote.Preferences, snapshot.Preferences, localWins)
+		for _, key := range conflicts {
+			addConflict(BootstrapConflict{Kind: "preference", Key: key, Resolution: stateResolution})
+		}
+		if len(preferences) > 0 {
+			patch.PreferencesSet = true
+			patch.Preferences = preferences
+			result.Imported.Preferences = true
+		}
+	}
+
+	if preview || (patch.Inited == nil && !patch.QuickesSet && !patch.PreferencesSet) {
+		return result, nil
+	}
+	if _, err := s.UpdateUserState(ctx, userID, patch); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// resolveConflict picks the side that wins a conflict. local and remote are
+// the entities' last update times.
+func resolveConflict(strategy string, local, remote int64) string {
+	switch strategy {
+	case MergeLocalWins:
+		return resolveLocal
+	case MergeKeepBoth:
+		return resolveBoth
+	case MergeNewest:
+		if local > remote {
+			return resolveLocal
+		}
+	}
+	return resolveRemote
+}
+
+func entityTime(updatedAt, created int64) int64 {
+	if updatedAt > 0 {
+		return updatedAt
+	}
+	return created
+}
+
+// categoryDiffers reports whether a snapshot category differs from the
+// account category with its label, in looks or by holding phrases the
+// account category lacks.
+func categoryDiffers(local models.Category, imageID string, remote models.Category, statements []models.Statement, existing map[string]models.Statement) bool {
+	if local.AIUse != remote.AIUse || local.Color != remote.Color || local.Icon != remote.Icon || imageID != remote.ImageID {
+		return true
+	}
+	for _, statement := range statements {
+		if strings.TrimSpace(statement.Text) == "" {
+			continue
+		}
+		if _, ok := existing[statementDedupKey(remote.ID, statement.Text)]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func statementDiffers(local models.Statement, text, imageID string, remote models.Statement) bool {
+	return text != remote.Text || local.Color != remote.Color || local.Icon != remote.Icon || imageID != remote.ImageID
+}
+
+// uniqueLabel suffixes label with the first free number: "Food (2)".
+func uniqueLabel(label string, taken map[string]models.Category) string {
+	for n := 2; ; n++ {
+		candidate := label + " (" + strconv.Itoa(n) + ")"
+		if _, ok := taken[normalizeLabel(candidate)]; !ok {
+			return candidate
+		}
+	}
+}
+
+// mergeQuickes fills remote slots still holding a default phrase with the
+// local ones; blank slots take the defaults for loc. A default in any
+// locale counts as unset, so a guest's English defaults do not replace a
+// Russian account's. Slots customised on both sides are returned as
+// conflicts and take the local phrase only when localWins is set.
+func mergeQuickes(remote, local []string, loc string, localWins bool) ([]string, []int) {
+	merged := normalizeQuickes(remote, loc)
+	local = normalizeQuickes(local, loc)
+	var conflicts []int
+	for slot := range merged {
+		if defaults.IsDefaultQuicke(slot, local[slot]) || local[slot] == merged[slot] {
+			continue
+		}
+		if !defaults.IsDefaultQuicke(slot, merged[slot]) {
+			conflicts = append(conflicts, slot)
+			if !localWins {
+				continue
+			}
+		}
+		merged[slot] = local[slot]
+	}
+	return merged, conflicts
+}
+
+// mergePreferences returns the local preferences to write: those missing
+// from the account, plus differing ones when localWins is set. The keys set
+// on both sides to different values are returned sorted.
+func mergePreferences(remote, local map[string]any, localWins bool) (map[string]any, []string) {
+	out := make(map[string]any)
+	var conflicts []string
+	for key, value := range local {
+		current, ok := remote[key]
+		if !ok {
+			out[key] = value
+			continue
+		}
+		if reflect.DeepEqual(current, value) {
+			continue
+		}
+		conflicts = append(conflicts, key)
+		if localWins {
+			out[key] = value
+		}
+	}
+	slices.Sort(conflicts)
+	return out, conflicts
+}
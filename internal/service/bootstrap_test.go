@@ -0,0 +1,71 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/defaults"
+)
+
+func TestResolveConflict(t *testing.T) {
+	cases := []struct {
+		strategy      string
+		local, remote int64
+		want          string
+	}{
+		{MergeLocalWins, 1, 2, resolveLocal},
+		{MergeRemoteWins, 2, 1, resolveRemote},
+		{MergeNewest, 2, 1, resolveLocal},
+		{MergeNewest, 1, 2, resolveRemote},
+		{MergeNewest, 0, 0, resolveRemote},
+		{MergeKeepBoth, 1, 2, resolveBoth},
+	}
+	for _, tc := range cases {
+		if got := resolveConflict(tc.strategy, tc.local, tc.remote); got != tc.want {
+			t.Fatalf("%s %d vs %d: expected %s, got %s", tc.strategy, tc.local, tc.remote, tc.want, got)
+		}
+	}
+}
+
+func TestMergeQuickesFillsDefaultSlots(t *testing.T) {
+	remote := normalizeQuickes(nil, "ru")
+	remote[0] = "Hey there"
+	local := []string{"Hi", "", "Yes please"}
+
+	merged, conflicts := mergeQuickes(remote, local, "ru", false)
+	if merged[0] != "Hey there" || merged[2] != "Yes please" {
+		t.Fatalf("unexpected merge %v", merged)
+	}
+	if !slices.Equal(conflicts, []int{0}) {
+		t.Fatalf("expected conflict in slot 0, got %v", conflicts)
+	}
+
+	merged, _ = mergeQuickes(remote, local, "ru", true)
+	if merged[0] != "Hi" || merged[1] != normalizeQuickes(nil, "ru")[1] {
+		t.Fatalf("unexpected local merge %v", merged)
+	}
+
+	// A guest's English defaults do not count as custom phrases.
+	merged, conflicts = mergeQuickes(remote, defaults.Quickes("en"), "ru", false)
+	if !slices.Equal(merged, remote) || len(conflicts) != 0 {
+		t.Fatalf("expected en defaults to leave remote alone, got %v %v", merged, conflicts)
+	}
+}
+
+func TestMergePreferences(t *testing.T) {
+	remote := map[string]any{"voice": "alena", "rate": 1.0}
+	local := map[string]any{"voice": "filipp", "rate": 1.0, "theme": "dark"}
+
+	out, conflicts := mergePreferences(remote, local, false)
+	if len(out) != 1 || out["theme"] != "dark" {
+		t.Fatalf("unexpected preferences %v", out)
+	}
+	if !slices.Equal(conflicts, []string{"voice"}) {
+		t.Fatalf("expected voice conflict, got %v", conflicts)
+	}
+
+	out, _ = mergePreferences(remote, local, true)
+	if out["voice"] != "filipp" || len(out) != 2 {
+		t.Fatalf("unexpected local preferences %v", out)
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// Bulk statement operations.
+const (
+	BulkMove   = "move"
+	BulkCopy   = "copy"
+	BulkDelete = "delete"
+)
+
+// MaxBulkStatements caps the number of statements in one bulk call.
+const MaxBulkStatements = 500
+
+// ErrInvalidBulk is returned for an unknown op, an empty or oversized id
+// list, or a move or copy without a target category.
+var ErrInvalidBulk = errors.New("invalid bulk operation")
+
+// BulkStatementRequest applies Op to the statements in IDs. Move and copy
+// put them into CategoryID.
+type BulkStatementRequest struct {
+	Op         string
+	IDs        []string
+	CategoryID string
+}
+
+// BulkStatementResult lists moved or created statements and deleted ids.
+type BulkStatementResult struct {
+	Statements []models.Statement `json:"statements"`
+	Deleted    []string           `json:"deleted"`
+}
+
+// BulkStatements moves, copies or deletes many statements at once. Every id
+// must name a live statement; the rows are written in one transaction and
+// share one timestamp, so a single undo reverts the whole call. Moves and
+// copies keep text and look, and land at the end of the target category.
+func (s *Service) BulkStatements(ctx context.Context, userID string, req BulkStatementRequest) (BulkStatementResult, error) {
+	result := BulkStatementResult{Statements: []models.Statement{}, Deleted: []string{}}
+	if len(req.IDs) == 0 || len(req.IDs) > MaxBulkStatements {
+		return result, ErrInvalidBulk
+	}
+	switch req.Op {
+	case BulkMove, BulkCopy:
+		if req.CategoryID == "" {
+			return result, ErrInvalidBulk
+		}
+		if _, err := s.findCategory(ctx, userID, req.CategoryID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return result, ErrInvalidCategory
+			}
+			return result, err
+		}
+	case BulkDelete:
+	default:
+		return result, ErrInvalidBulk
+	}
+
+	all, err := s.Store.ListAllStatements(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	byID := make(map[string]models.Statement, len(all))
+	for _, stmt := range all {
+		byID[stmt.ID] = stmt
+	}
+
+	updatedAt := time.Now().UnixMilli()
+	batch, err := planStatementBatch(req, byID, updatedAt)
+	if err != nil {
+		return result, err
+	}
+	if len(batch.Upserts) == 0 && len(batch.Deleted) == 0 {
+		return result, nil
+	}
+
+	if err := s.Store.ApplyStatementBatch(ctx, userID, batch); err != nil {
+		return result, err
+	}
+
+	if err := s.mirrorStatementBatch(ctx, userID, batch); err != nil {
+		return result, err
+	}
+
+	op := revisionMove
+	if req.Op == BulkCopy {
+		op = revisionCreate
+	}
+	for _, stmt := range batch.Upserts {
+		_ = s.appendChange(ctx, userID, "statement", stmt.ID, "upsert", stmt, updatedAt)
+		_ = s.recordRevision(ctx, userID, "statement", stmt.ID, op, stmt, updatedAt)
+		result.Statements = append(result.Statements, stmt)
+	}
+	for _, stmt := range batch.Deleted {
+		_ = s.appendChange(ctx, userID, "statement", stmt.ID, "delete", map[string]string{"id": stmt.ID, "categoryId": stmt.CategoryID}, updatedAt)
+		_ = s.recordRevision(ctx, userID, "statement", stmt.ID, revisionDelete, stmt, updatedAt)
+		result.Deleted = append(result.Deleted, stmt.ID)
+	}
+	return result, nil
+}
+
+// planStatementBatch turns a bulk request into rows to write. Repeated ids
+// are applied once, an unknown id fails the whole request, and statements
+// already in the target category are left out of a move.
+func planStatementBatch(req BulkStatementRequest, byID map[string]models.Statement, updatedAt int64) (store.StatementBatch, error) {
+	batch := store.StatementBatch{UpdatedAt: updatedAt}
+	seen := make(map[string]bool, len(req.IDs))
+	for _, statementID := range req.IDs {
+		if seen[statementID] {
+			continue
+		}
+		seen[statementID] = true
+		stmt, ok := byID[statementID]
+		if !ok {
+			return store.StatementBatch{}, store.ErrNotFound
+		}
+		switch req.Op {
+		case BulkMove:
+			if stmt.CategoryID == req.CategoryID {
+				continue
+			}
+			batch.Removed = append(batch.Removed, stmt)
+			stmt.CategoryID = req.CategoryID
+			stmt.Position = ""
+			stmt.UpdatedAt = updatedAt
+			batch.Upserts = append(batch.Upserts, stmt)
+		case BulkCopy:
+			stmt.ID = id.NewShort()
+			stmt.CategoryID = req.CategoryID
+			stmt.Created = updatedAt
+			stmt.Position = ""
+			stmt.UpdatedAt = updatedAt
+			batch.Upserts = append(batch.Upserts, stmt)
+		case BulkDelete:
+			batch.Deleted = append(batch.Deleted, stmt)
+		}
+	}
+	return batch, nil
+}
+
+// mirrorStatementBatch repeats an applied batch in the user's Firebase copy.
+func (s *Service) mirrorStatementBatch(ctx context.Context, userID string, batch store.StatementBatch) error {
+	mirror := s.legacyWriter(ctx, userID)
+	if mirror == nil {
+		return nil
+	}
+	for _, stmt := range batch.Removed {
+		if err := mirror.DeleteStatement(ctx, userID, stmt.CategoryID, stmt.ID); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range batch.Deleted {
+		if err := mirror.DeleteStatement(ctx, userID, stmt.CategoryID, stmt.ID); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range batch.Upserts {
+		if err := mirror.UpsertStatement(ctx, userID, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func bulkStatements() map[string]models.Statement {
+	return map[string]models.Statement{
+		"s1": {ID: "s1", CategoryID: "a", Text: "one", Created: 1, Position: "a0", Color: "red"},
+		"s2": {ID: "s2", CategoryID: "b", Text: "two", Created: 2, Position: "a1"},
+	}
+}
+
+func TestPlanStatementBatchMove(t *testing.T) {
+	req := BulkStatementRequest{Op: BulkMove, IDs: []string{"s1", "s2", "s1"}, CategoryID: "b"}
+	batch, err := planStatementBatch(req, bulkStatements(), 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// s2 is already in b; the repeated s1 is moved once.
+	if len(batch.Removed) != 1 || batch.Removed[0].CategoryID != "a" {
+		t.Fatalf("expected the old s1 row to be removed, got %+v", batch.Removed)
+	}
+	if len(batch.Upserts) != 1 {
+		t.Fatalf("expected one upsert, got %+v", batch.Upserts)
+	}
+	moved := batch.Upserts[0]
+	if moved.ID != "s1" || moved.CategoryID != "b" || moved.Created != 1 || moved.Position != "" || moved.UpdatedAt != 100 || moved.Color != "red" {
+		t.Fatalf("expected s1 in b with its id, created and look kept, got %+v", moved)
+	}
+	if len(batch.Deleted) != 0 || batch.UpdatedAt != 100 {
+		t.Fatalf("unexpected batch %+v", batch)
+	}
+}
+
+func TestPlanStatementBatchCopy(t *testing.T) {
+	req := BulkStatementRequest{Op: BulkCopy, IDs: []string{"s1", "s1", "s2"}, CategoryID: "a"}
+	batch, err := planStatementBatch(req, bulkStatements(), 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batch.Upserts) != 2 || len(batch.Removed) != 0 {
+		t.Fatalf("expected two copies and no removals, got %+v", batch)
+	}
+	for i, copied := range batch.Upserts {
+		if copied.ID == "" || copied.ID == "s1" || copied.ID == "s2" {
+			t.Fatalf("copy %d: expected a new id, got %q", i, copied.ID)
+		}
+		if copied.CategoryID != "a" || copied.Created != 100 || copied.Position != "" {
+			t.Fatalf("copy %d: expected a fresh row in a, got %+v", i, copied)
+		}
+	}
+	if batch.Upserts[0].Text != "one" || batch.Upserts[1].Text != "two" {
+		t.Fatalf("expected copies in request order, got %+v", batch.Upserts)
+	}
+}
+
+func TestPlanStatementBatchDelete(t *testing.T) {
+	req := BulkStatementRequest{Op: BulkDelete, IDs: []string{"s2", "s2"}}
+	batch, err := planStatementBatch(req, bulkStatements(), 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batch.Deleted) != 1 || batch.Deleted[0].ID != "s2" || len(batch.Upserts) != 0 {
+		t.Fatalf("expected s2 deleted once, got %+v", batch)
+	}
+}
+
+func TestPlanStatementBatchRejectsUnknownIDs(t *testing.T) {
+	for _, op := range []string{BulkMove, BulkCopy, BulkDelete} {
+		req := BulkStatementRequest{Op: op, IDs: []string{"s1", "missing"}, CategoryID: "b"}
+		batch, err := planStatementBatch(req, bulkStatements(), 100)
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("%s: expected ErrNotFound, got %v", op, err)
+		}
+		if len(batch.Upserts) != 0 || len(batch.Removed) != 0 || len(batch.Deleted) != 0 {
+			t.Fatalf("%s: expected nothing to be written, got %+v", op, batch)
+		}
+	}
+}
+
+func TestPatchStatementMoveKeepsIdentity(t *testing.T) {
+	statement := models.Statement{ID: "s1", CategoryID: "a", Text: "one", Created: 1, Position: "a0", Icon: "star"}
+	target := "b"
+	text := "uno"
+
+	patched := patchStatement(statement, StatementPatch{CategoryID: &target, Text: &text})
+	if patched.ID != "s1" || patched.Created != 1 {
+		t.Fatalf("expected id and created to be kept, got %+v", patched)
+	}
+	if patched.CategoryID != "b" || patched.Position != "" || patched.Text != "uno" || patched.Icon != "star" {
+		t.Fatalf("expected a moved statement without position, got %+v", patched)
+	}
+
+	same := "a"
+	if kept := patchStatement(statement, StatementPatch{CategoryID: &same}); kept.Position != "a0" {
+		t.Fatalf("expected a patch to the same category to keep the position, got %+v", kept)
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// CatalogueVersion is the bundle format written by ExportCatalogue.
+const CatalogueVersion = 1
+
+// Catalogue import modes. Upsert adds and updates entries; replace also
+// removes entries missing from the bundle.
+const (
+	CatalogueUpsert  = "upsert"
+	CatalogueReplace = "replace"
+)
+
+var ErrInvalidCatalogue = errors.New("invalid catalogue bundle")
+
+// CatalogueBundle is the global catalogue and factory questions as one
+// document. Category statements are nested in Statements.
+type CatalogueBundle struct {
+	Version    int                      `json:"version"`
+	ExportedAt int64                    `json:"exportedAt,omitempty"`
+	Categories []models.GlobalCategory  `json:"categories"`
+	Questions  []models.FactoryQuestion `json:"questions"`
+}
+
+// CatalogueDiffSet lists the IDs an import adds, updates and removes.
+// Statement IDs are given as categoryId/statementId.
+type CatalogueDiffSet struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+}
+
+func newCatalogueDiffSet() CatalogueDiffSet {
+	return CatalogueDiffSet{Added: []string{}, Updated: []string{}, Removed: []string{}}
+}
+
+// CatalogueDiff is the outcome, or with DryRun the preview, of an import.
+type CatalogueDiff struct {
+	Mode       string           `json:"mode"`
+	DryRun     bool             `json:"dryRun"`
+	Categories CatalogueDiffSet `json:"categories"`
+	Statements CatalogueDiffSet `json:"statements"`
+	Questions  CatalogueDiffSet `json:"questions"`
+}
+
+// ExportCatalogue returns the global catalogue and factory questions.
+func (s *Service) ExportCatalogue(ctx context.Context) (CatalogueBundle, error) {
+	categories, err := s.Store.ListGlobalCategories(ctx, true)
+	if err != nil {
+		return CatalogueBundle{}, err
+	}
+	questions, err := s.Store.ListFactoryQuestions(ctx)
+	if err != nil {
+		return CatalogueBundle{}, err
+	}
+	for i := range categories {
+		sortStatements(categories[i].Statements)
+	}
+	return CatalogueBundle{
+		Version:    CatalogueVersion,
+		ExportedAt: time.Now().UnixMilli(),
+		Categories: append([]models.GlobalCategory{}, categories...),
+		Questions:  append([]models.FactoryQuestion{}, questions...),
+	}, nil
+}
+
+// ImportCatalogue compares the bundle with the stored catalogue and, unless
+// dryRun is set, writes the difference in one transaction.
+func (s *Service) ImportCatalogue(ctx context.Context, bundle CatalogueBundle, mode string, dryRun bool) (CatalogueDiff, error) {
+	if mode == "" {
+		mode = CatalogueUpsert
+	}
+	current, err := s.ExportCatalogue(ctx)
+	if err != nil {
+		return CatalogueDiff{}, err
+	}
+	change, diff, err := planCatalogueImport(current, bundle, mode, time.Now().UnixMilli())
+	if err != nil {
+		return CatalogueDiff{}, err
+	}
+	diff.DryRun = dryRun
+	if dryRun {
+		return diff, nil
+	}
+	if err := s.Store.ApplyCatalogueChange(ctx, change); err != nil {
+		return CatalogueDiff{}, err
+	}
+	return diff, nil
+}
+
+// planCatalogueImport validates the incoming bundle and works out the
+// writes that bring current in line with it.
+func planCatalogueImport(current, incoming CatalogueBundle, mode string, now int64) (store.CatalogueChange, CatalogueDiff, error) {
+	change := store.CatalogueChange{UpdatedAt: now}
+	diff := CatalogueDiff{
+		Mode:       mode,
+		Categories: newCatalogueDiffSet(),
+		Statements: newCatalogueDiffSet(),
+		Questions:  newCatalogueDiffSet(),
+	}
+	if mode != CatalogueUpsert && mode != CatalogueReplace {
+		return change, diff, fmt.Errorf("%w: mode must be upsert or replace", ErrInvalidCatalogue)
+	}
+	if incoming.Version != CatalogueVersion {
+		return change, diff, fmt.Errorf("%w: unsupported version %d", ErrInvalidCatalogue, incoming.Version)
+	}
+
+	currentCategories := make(map[string]models.GlobalCategory, len(current.Categories))
+	currentStatements := make(map[string]models.Statement)
+	for _, cat := range current.Categories {
+		currentCategories[cat.ID] = cat
+		for _, stmt := range cat.Statements {
+			currentStatements[cat.ID+"/"+stmt.ID] = stmt
+		}
+	}
+
+	parents := make(map[string]string)
+	if mode == CatalogueUpsert {
+		for _, cat := range current.Categories {
+			parents[cat.ID] = cat.ParentID
+		}
+	}
+	seenCategories := make(map[string]bool, len(incoming.Categories))
+	seenStatements := make(map[string]bool)
+	for _, cat := range incoming.Categories {
+		cat.ID, cat.Label = strings.TrimSpace(cat.ID), strings.TrimSpace(cat.Label)
+		if cat.ID == "" || cat.Label == "" || seenCategories[cat.ID] {
+			return change, diff, fmt.Errorf("%w: category %q needs a unique id and a label", ErrInvalidCatalogue, cat.ID)
+		}
+		seenCategories[cat.ID] = true
+		parents[cat.ID] = cat.ParentID
+
+		have, ok := currentCategories[cat.ID]
+		if cat.Created == 0 {
+			cat.Created = have.Created
+			if !ok {
+				cat.Created = now
+			}
+		}
+		statements := cat.Statements
+		cat.Statements = nil
+		switch {
+		case !ok:
+			change.Categories = append(change.Categories, cat)
+			diff.Categories.Added = append(diff.Categories.Added, cat.ID)
+		case !sameGlobalCategory(have, cat):
+			change.Categories = append(change.Categories, cat)
+			diff.Categories.Updated = append(diff.Categories.Updated, cat.ID)
+		}
+
+		for _, stmt := range statements {
+			stmt.ID, stmt.Text, stmt.CategoryID = strings.TrimSpace(stmt.ID), strings.TrimSpace(stmt.Text), cat.ID
+			key := cat.ID + "/" + stmt.ID
+			if stmt.ID == "" || stmt.Text == "" || seenStatements[key] {
+				return change, diff, fmt.Errorf("%w: statement %q needs a unique id and text", ErrInvalidCatalogue, key)
+			}
+			seenStatements[key] = true
+			have, ok := currentStatements[key]
+			if stmt.Created == 0 {
+				stmt.Created = have.Created
+				if !ok {
+					stmt.Created = now
+				}
+			}
+			switch {
+			case !ok:
+				change.Statements = append(change.Statements, stmt)
+				diff.Statements.Added = append(diff.Statements.Added, key)
+			case have.Text != stmt.Text || have.Position != stmt.Position:
+				change.Statements = append(change.Statements, stmt)
+				diff.Statements.Updated = append(diff.Statements.Updated, key)
+			}
+		}
+	}
+	if err := validateCatalogueParents(parents); err != nil {
+		return change, diff, err
+	}
+
+	currentQuestions := make(map[string]models.FactoryQuestion, len(current.Questions))
+	for _, question := range current.Questions {
+		currentQuestions[question.ID] = question
+	}
+	seenQuestions := make(map[string]bool, len(incoming.Questions))
+	for _, question := range incoming.Questions {
+		question.ID, question.Label = strings.TrimSpace(question.ID), strings.TrimSpace(question.Label)
+		if question.ID == "" || question.Label == "" || seenQuestions[question.ID] {
+			return change, diff, fmt.Errorf("%w: question %q needs a unique id and a label", ErrInvalidCatalogue, question.ID)
+		}
+		if err := validateQuestion(question); err != nil {
+			return change, diff, fmt.Errorf("%w: question %q: %v", ErrInvalidCatalogue, question.ID, err)
+		}
+		seenQuestions[question.ID] = true
+		have, ok := currentQuestions[question.ID]
+		switch {
+		case !ok:
+			change.Questions = append(change.Questions, question)
+			diff.Questions.Added = append(diff.Questions.Added, question.ID)
+		case !sameFactoryQuestion(have, question):
+			change.Questions = append(change.Questions, question)
+			diff.Questions.Updated = append(diff.Questions.Updated, question.ID)
+		}
+	}
+
+	if mode == CatalogueReplace {
+		for _, cat := range current.Categories {
+			if !seenCategories[cat.ID] {
+				change.RemovedCategories = append(change.RemovedCategories, cat.ID)
+				diff.Categories.Removed = append(diff.Categories.Removed, cat.ID)
+			}
+			for _, stmt := range cat.Statements {
+				key := cat.ID + "/" + stmt.ID
+				if !seenStatements[key] {
+					stmt.CategoryID = cat.ID
+					change.RemovedStatements = append(change.RemovedStatements, stmt)
+					diff.Statements.Removed = append(diff.Statements.Removed, key)
+				}
+			}
+		}
+		for _, question := range current.Questions {
+			if !seenQuestions[question.ID] {
+				change.RemovedQuestions = append(change.RemovedQuestions, question.ID)
+				diff.Questions.Removed = append(diff.Questions.Removed, question.ID)
+			}
+		}
+	}
+	return change, diff, nil
+}
+
+// validateCatalogueParents checks that every parent exists and that no
+// chain loops or nests deeper than maxCategoryDepth.
+func validateCatalogueParents(parents map[string]string) error {
+	for id := range parents {
+		depth := 1
+		for current := parents[id]; current != ""; current = parents[current] {
+			if _, ok := parents[current]; !ok {
+				return fmt.Errorf("%w: category %q has an unknown parent", ErrInvalidCatalogue, id)
+			}
+			depth++
+			if depth > maxCategoryDepth {
+				return fmt.Errorf("%w: category %q is nested too deep or in a loop", ErrInvalidCatalogue, id)
+			}
+		}
+	}
+	return nil
+}
+
+func sameGlobalCategory(a, b models.GlobalCategory) bool {
+	return a.Label == b.Label && a.ParentID == b.ParentID && a.Created == b.Created &&
+		(a.Default == nil) == (b.Default == nil) && (a.Default == nil || *a.Default == *b.Default)
+}
+
+func sameFactoryQuestion(a, b models.FactoryQuestion) bool {
+	return a.Label == b.Label && a.Category == b.Category && a.Type == b.Type &&
+		a.OrderIndex == b.OrderIndex && slices.Equal(a.Phrases, b.Phrases) &&
+		slices.Equal(a.Options, b.Options) && a.Placeholder == b.Placeholder &&
+		(a.ShowIf == nil) == (b.ShowIf == nil) &&
+		(a.ShowIf == nil || a.ShowIf.QuestionID == b.ShowIf.QuestionID && slices.Equal(a.ShowIf.Values, b.ShowIf.Values))
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestPlanCatalogueImport(t *testing.T) {
+	current := CatalogueBundle{
+		Version: CatalogueVersion,
+		Categories: []models.GlobalCategory{
+			{ID: "food", Label: "Food", Created: 1, Statements: []models.Statement{
+				{ID: "water", Text: "Water", Created: 1},
+				{ID: "milk", Text: "Milk", Created: 1},
+			}},
+			{ID: "old", Label: "Old", Created: 1},
+		},
+		Questions: []models.FactoryQuestion{{ID: "q1", Label: "Name"}},
+	}
+	incoming := CatalogueBundle{
+		Version: CatalogueVersion,
+		Categories: []models.GlobalCategory{
+			{ID: "food", Label: "Meals", Statements: []models.Statement{
+				{ID: "water", Text: "Water", CategoryID: "elsewhere"},
+				{ID: "tea", Text: "Tea"},
+			}},
+			{ID: "snacks", Label: "Snacks", ParentID: "food"},
+		},
+		Questions: []models.FactoryQuestion{{ID: "q1", Label: "Name"}, {ID: "q2", Label: "City"}},
+	}
+
+	change, diff, err := planCatalogueImport(current, incoming, CatalogueUpsert, 100)
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if len(diff.Categories.Added) != 1 || len(diff.Categories.Updated) != 1 || len(diff.Categories.Removed) != 0 {
+		t.Fatalf("unexpected category diff: %+v", diff.Categories)
+	}
+	if len(change.Categories) != 2 || change.Categories[0].Created != 1 || change.Categories[1].Created != 100 {
+		t.Fatalf("expected created kept for existing and set for new, got %+v", change.Categories)
+	}
+	if len(change.Statements) != 1 || change.Statements[0].ID != "tea" || change.Statements[0].CategoryID != "food" {
+		t.Fatalf("expected only tea written under food, got %+v", change.Statements)
+	}
+	if len(diff.Questions.Added) != 1 || len(change.RemovedCategories)+len(change.RemovedStatements)+len(change.RemovedQuestions) != 0 {
+		t.Fatalf("upsert must not remove: %+v", change)
+	}
+
+	change, diff, err = planCatalogueImport(current, incoming, CatalogueReplace, 100)
+	if err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if len(change.RemovedCategories) != 1 || change.RemovedCategories[0] != "old" {
+		t.Fatalf("expected old removed, got %v", change.RemovedCategories)
+	}
+	if len(diff.Statements.Removed) != 1 || diff.Statements.Removed[0] != "food/milk" || change.RemovedStatements[0].CategoryID != "food" {
+		t.Fatalf("expected milk removed, got %+v", diff.Statements)
+	}
+}
+
+func TestPlanCatalogueImportRejects(t *testing.T) {
+	cases := map[string]CatalogueBundle{
+		"version": {Version: 2},
+		"label":   {Version: 1, Categories: []models.GlobalCategory{{ID: "a"}}},
+		"parent":  {Version: 1, Categories: []models.GlobalCategory{{ID: "a", Label: "A", ParentID: "missing"}}},
+		"loop": {Version: 1, Categories: []models.GlobalCategory{
+			{ID: "a", Label: "A", ParentID: "b"},
+			{ID: "b", Label: "B", ParentID: "a"},
+		}},
+		"statement": {Version: 1, Categories: []models.GlobalCategory{
+			{ID: "a", Label: "A", Statements: []models.Statement{{ID: "s", Text: " "}}},
+		}},
+	}
+	for name, bundle := range cases {
+		if _, _, err := planCatalogueImport(CatalogueBundle{}, bundle, CatalogueReplace, 1); !errors.Is(err, ErrInvalidCatalogue) {
+			t.Errorf("%s: expected ErrInvalidCatalogue, got %v", name, err)
+		}
+	}
+	if _, _, err := planCatalogueImport(CatalogueBundle{}, CatalogueBundle{Version: 1}, "merge", 1); !errors.Is(err, ErrInvalidCatalogue) {
+		t.Errorf("mode: expected ErrInvalidCatalogue, got %v", err)
+	}
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+// Export formats.
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+// ExportVersion is the version of the export document.
+const ExportVersion = 1
+
+// ExportSyncLimit is the most statements and dialog messages an export may
+// hold to be returned inline; larger ones are built by a background job.
+const ExportSyncLimit = 5000
+
+// Export job statuses.
+const (
+	exportPending = "pending"
+	exportDone    = "done"
+	exportFailed  = "failed"
+)
+
+// exportPageSize is how many dialog messages or suggestions one export read
+// fetches.
+const exportPageSize = 500
+
+// exportJobTimeout bounds one background export.
+const exportJobTimeout = 10 * time.Minute
+
+// exportJobStaleAfter is how long a job may stay pending before it is
+// reported as failed. Jobs run inside the API process, so a restart leaves
+// the ones it was running pending for good.
+const exportJobStaleAfter = exportJobTimeout + time.Minute
+
+var (
+	ErrInvalidExportFormat = errors.New("format must be json or zip")
+	ErrExportNotReady      = errors.New("export is not ready")
+)
+
+// ExportResult is either an inline export or a background job.
+type ExportResult struct {
+	Data        []byte
+	ContentType string
+	Job         *models.ExportJob
+}
+
+// Export collects the user's data in the given format. Large accounts, or
+// any account when async is set, get a background job whose file is kept in
+// blob storage; without blob storage every export is built inline. An async
+// job loads the data itself, so the request returns without reading it.
+func (s *Service) Export(ctx context.Context, userID, format string, async bool) (ExportResult, error) {
+	if format != ExportFormatJSON && format != ExportFormatZIP {
+		return ExportResult{}, ErrInvalidExportFormat
+	}
+
+	var loaded *models.UserExport
+	if s.Blobs == nil || !async {
+		export, err := s.ExportUser(ctx, userID)
+		if err != nil {
+			return ExportResult{}, err
+		}
+		if s.Blobs == nil || len(export.Statements)+len(export.Dialog.Messages) <= ExportSyncLimit {
+			data, contentType, err := EncodeExport(export, format)
+			if err != nil {
+				return ExportResult{}, err
+			}
+			return ExportResult{Data: data, ContentType: contentType}, nil
+		}
+		loaded = &export
+	}
+
+	now := time.Now().UnixMilli()
+	job := models.ExportJob{
+		ID:        id.NewShort(),
+		Format:    format,
+		Status:    exportPending,
+		Created:   now,
+		UpdatedAt: now,
+	}
+	if err := s.Store.UpsertExportJob(ctx, userID, job); err != nil {
+		return ExportResult{}, err
+	}
+	go s.runExportJob(context.WithoutCancel(ctx), userID, job, loaded)
+	return ExportResult{Job: &job}, nil
+}
+
+// runExportJob builds and stores the file of job. It loads the user's data
+// unless the request already did.
+func (s *Service) runExportJob(ctx context.Context, userID string, job models.ExportJob, loaded *models.UserExport) {
+	ctx, cancel := context.WithTimeout(ctx, exportJobTimeout)
+	defer cancel()
+
+	var (
+		data        []byte
+		contentType string
+		err         error
+	)
+	export := loaded
+	if export == nil {
+		var fresh models.UserExport
+		fresh, err = s.ExportUser(ctx, userID)
+		export = &fresh
+	}
+	if err == nil {
+		data, contentType, err = EncodeExport(*export, job.Format)
+	}
+	if err == nil {
+		job.BlobKey = fmt.Sprintf("exports/%s/%s.%s", userID, job.ID, job.Format)
+		err = s.Blobs.Put(ctx, job.BlobKey, contentType, data)
+	}
+	job.Status = exportDone
+	job.Size = int64(len(data))
+	if err != nil {
+		job.Status = exportFailed
+		job.Error = err.Error()
+		job.BlobKey = ""
+		job.Size = 0
+	}
+	job.UpdatedAt = time.Now().UnixMilli()
+	// The status is written even when the run hit its timeout.
+	if err := s.Store.UpsertExportJob(context.WithoutCancel(ctx), userID, job); err != nil {
+		slog.Warn("export job update failed", "user_id", userID, "job_id", job.ID, "error", err)
+		return
+	}
+	if job.Status == exportDone {
+		s.pruneExports(ctx, userID, job.ID)
+	}
+}
+
+// pruneExports keeps only the newest finished export of a user.
+func (s *Service) pruneExports(ctx context.Context, userID, keepID string) {
+	jobs, err := s.Store.ListExportJobs(ctx, userID)
+	if err != nil {
+		slog.Warn("export cleanup failed", "user_id", userID, "error", err)
+		return
+	}
+	now := time.Now().UnixMilli()
+	for _, job := range jobs {
+		if job.ID == keepID || (job.Status == exportPending && !exportJobStale(job, now)) {
+			continue
+		}
+		if job.BlobKey != "" {
+			if err := s.Blobs.Delete(ctx, job.BlobKey); err != nil {
+				slog.Warn("export cleanup failed", "user_id", userID, "job_id", job.ID, "error", err)
+				continue
+			}
+		}
+		if err := s.Store.DeleteExportJob(ctx, userID, job.ID); err != nil {
+			slog.Warn("export cleanup failed", "user_id", userID, "job_id", job.ID, "error", err)
+		}
+	}
+}
+
+// GetExportJob returns a background export. A job left pending past its
+// timeout is recorded as failed first.
+func (s *Service) GetExportJob(ctx context.Context, userID, jobID string) (models.ExportJob, error) {
+	job, err := s.Store.GetExportJob(ctx, userID, jobID)
+	if err != nil {
+		return job, err
+	}
+	now := time.Now().UnixMilli()
+	if !exportJobStale(job, now) {
+		return job, nil
+	}
+	job.Status = exportFailed
+	job.Error = "export was interrupted"
+	job.UpdatedAt = now
+	if err := s.Store.UpsertExportJob(ctx, userID, job); err != nil {
+		return models.ExportJob{}, err
+	}
+	return job, nil
+}
+
+// exportJobStale reports whether job is pending longer than any run lasts,
+// which means the process running it is gone.
+func exportJobStale(job models.ExportJob, now int64) bool {
+	return job.Status == exportPending && now-job.UpdatedAt > exportJobStaleAfter.Milliseconds()
+}
+
+// OpenExport returns the file of a finished background export.
+func (s *Service) OpenExport(ctx context.Context, userID, jobID string) (models.ExportJob, io.ReadCloser, error) {
+	job, err := s.GetExportJob(ctx, userID, jobID)
+	if err != nil {
+		return models.ExportJob{}, nil, err
+	}
+	if job.Status != exportDone || s.Blobs == nil {
+		return job, nil, ErrExportNotReady
+	}
+	body, err := s.Blobs.Get(ctx, job.BlobKey)
+	if err != nil {
+		return job, nil, err
+	}
+	return job, body, nil
+}
+
+func (s *Service) deleteUserExports(ctx context.Context, userID string) error {
+	if s.Blobs == nil {
+		return nil
+	}
+	jobs, err := s.Store.ListExportJobs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.BlobKey == "" {
+			continue
+		}
+		if err := s.Blobs.Delete(ctx, job.BlobKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportUser collects everything stored for the user.
+func (s *Service) ExportUser(ctx context.Context, userID string) (models.UserExport, error) {
+	export := models.UserExport{
+		Version:    ExportVersion,
+		ExportedAt: time.Now().UnixMilli(),
+		UserID:     userID,
+		Dialog: models.DialogExport{
+			Chats:       []models.DialogChat{},
+			Messages:    []models.DialogMessage{},
+			Suggestions: []models.DialogSuggestion{},
+		},
+		UsageLimits: []models.UsageLimit{},
+	}
+
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return export, err
+	}
+	export.Categories = append([]models.Category{}, categories...)
+	export.Statements = []models.Statement{}
+	for _, cat := range categories {
+		statements, err := s.ListStatements(ctx, userID, cat.ID)
+		if err != nil {
+			return export, err
+		}
+		export.Statements = append(export.Statements, statements...)
+	}
+
+	state, err := s.GetUserState(ctx, userID)
+	if err != nil {
+		return export, err
+	}
+	export.Quickes = append([]string{}, state.Quickes...)
+	export.Inited = state.Inited
+	export.Preferences = state.Preferences
+	if export.Preferences == nil {
+		export.Preferences = map[string]any{}
+	}
+
+	chats, err := s.Store.ListDialogChats(ctx, userID)
+	if err != nil {
+		return export, err
+	}
+	for _, chat := range chats {
+		export.Dialog.Chats = append(export.Dialog.Chats, chat)
+		// Messages page on (created, id) so ones sharing a timestamp at a
+		// page boundary are not skipped.
+		var last models.DialogMessage
+		for {
+			page, err := s.Store.ListDialogMessagesAfter(ctx, userID, chat.ID, last.Created, last.ID, exportPageSize)
+			if err != nil {
+				return export, err
+			}
+			export.Dialog.Messages = append(export.Dialog.Messages, page...)
+			if len(page) < exportPageSize {
+				break
+			}
+			last = page[len(page)-1]
+		}
+	}
+	var afterSuggestion string
+	for {
+		page, err := s.Store.ListDialogSuggestionsAfter(ctx, userID, afterSuggestion, exportPageSize)
+		if err != nil {
+			return export, err
+		}
+		export.Dialog.Suggestions = append(export.Dialog.Suggestions, page...)
+		if len(page) < exportPageSize {
+			break
+		}
+		afterSuggestion = page[len(page)-1].ID
+	}
+
+	limits, err := s.Store.ListUsageLimits(ctx, userID)
+	if err != nil {
+		return export, err
+	}
+	export.UsageLimits = append(export.UsageLimits, limits...)
+	return export, nil
+}
+
+// EncodeExport renders an export as a JSON document, or as a ZIP holding
+// that document and CSV tables of the boards, quickes and dialog messages.
+func EncodeExport(export models.UserExport, format string) ([]byte, string, error) {
+	doc, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	if format == ExportFormatJSON {
+		return doc, "application/json", nil
+	}
+	if format != ExportFormatZIP {
+		return nil, "", ErrInvalidExportFormat
+	}
+
+	labels := make(map[string]string, len(export.Categories))
+	for _, cat := range export.Categories {
+		labels[cat.ID] = cat.Label
+	}
+	chats := make(map[string]string, len(export.Dialog.Chats))
+	for _, chat := range export.Dialog.Chats {
+		chats[chat.ID] = chat.Title
+	}
+
+	categories := [][]string{{"id", "parent_id", "label", "created", "color", "icon"}}
+	for _, cat := range export.Categories {
+		categories = append(categories, []string{cat.ID, cat.ParentID, cat.Label, formatMillis(cat.Created), cat.Color, cat.Icon})
+	}
+	statements := [][]string{{"id", "category_id", "category", "text", "created"}}
+	for _, stmt := range export.Statements {
+		statements = append(statements, []string{stmt.ID, stmt.CategoryID, labels[stmt.CategoryID], stmt.Text, formatMillis(stmt.Created)})
+	}
+	quickes := [][]string{{"slot", "text"}}
+	for slot, text := range export.Quickes {
+		quickes = append(quickes, []string{strconv.Itoa(slot), text})
+	}
+	messages := [][]string{{"chat_id", "chat", "role", "content", "created"}}
+	for _, message := range export.Dialog.Messages {
+		messages = append(messages, []string{message.ChatID, chats[message.ChatID], message.Role, message.Content, formatMillis(message.Created)})
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"categories.csv", categories},
+		{"statements.csv", statements},
+		{"quickes.csv", quickes},
+		{"dialog_messages.csv", messages},
+	}
+	file, err := archive.Create("export.json")
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := file.Write(doc); err != nil {
+		return nil, "", err
+	}
+	for _, item := range files {
+		file, err := archive.Create(item.name)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := csv.NewWriter(file).WriteAll(item.rows); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "application/zip", nil
+}
+
+// formatMillis renders epoch milliseconds as RFC 3339 for spreadsheets.
+func formatMillis(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestEncodeExportZip(t *testing.T) {
+	export := models.UserExport{
+		Version:    ExportVersion,
+		Categories: []models.Category{{ID: "c1", Label: "Food"}},
+		Statements: []models.Statement{{ID: "s1", CategoryID: "c1", Text: "I want tea, please"}},
+		Quickes:    []string{"yes", "no"},
+	}
+	data, contentType, err := EncodeExport(export, ExportFormatZIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/zip" {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	var decoded models.UserExport
+	if err := json.Unmarshal(files["export.json"], &decoded); err != nil {
+		t.Fatalf("export.json: %v", err)
+	}
+	if decoded.Version != ExportVersion || len(decoded.Statements) != 1 {
+		t.Fatalf("unexpected export.json: %+v", decoded)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(files["statements.csv"])).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1][2] != "Food" || rows[1][3] != "I want tea, please" {
+		t.Fatalf("unexpected statements.csv: %v", rows)
+	}
+	rows, err = csv.NewReader(bytes.NewReader(files["quickes.csv"])).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[2][0] != "1" || rows[2][1] != "no" {
+		t.Fatalf("unexpected quickes.csv: %v", rows)
+	}
+}
+
+func TestEncodeExportRejectsUnknownFormat(t *testing.T) {
+	if _, _, err := EncodeExport(models.UserExport{}, "xml"); err != ErrInvalidExportFormat {
+		t.Fatalf("expected ErrInvalidExportFormat, got %v", err)
+	}
+}
+
+func TestExportJobStale(t *testing.T) {
+	now := int64(100_000_000)
+	limit := exportJobStaleAfter.Milliseconds()
+	cases := []struct {
+		job   models.ExportJob
+		stale bool
+	}{
+		{models.ExportJob{Status: exportPending, UpdatedAt: now - limit - 1}, true},
+		{models.ExportJob{Status: exportPending, UpdatedAt: now - limit}, false},
+		{models.ExportJob{Status: exportPending, UpdatedAt: now}, false},
+		{models.ExportJob{Status: exportDone, UpdatedAt: 0}, false},
+		{models.ExportJob{Status: exportFailed, UpdatedAt: 0}, false},
+	}
+	for i, tc := range cases {
+		if got := exportJobStale(tc.job, now); got != tc.stale {
+			t.Fatalf("case %d: expected stale=%v, got %v", i, tc.stale, got)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/rank"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+var (
+	// ErrInvalidStatementText is returned for empty global statement text.
+	ErrInvalidStatementText = errors.New("statement text is required")
+	// ErrStatementExists is returned when a new global statement names an
+	// id the category already has.
+	ErrStatementExists = errors.New("statement already exists")
+)
+
+// PasteResult lists the statements a bulk paste created and how many lines
+// it skipped as blank or duplicate.
+type PasteResult struct {
+	Statements []models.Statement `json:"statements"`
+	Skipped    int                `json:"skipped"`
+}
+
+// globalCategoryStatements returns the statements of an existing global
+// category in display order.
+func (s *Service) globalCategoryStatements(ctx context.Context, categoryID string) ([]models.Statement, error) {
+	categories, err := s.Store.ListGlobalCategories(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, cat := range categories {
+		if cat.ID == categoryID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, store.ErrNotFound
+	}
+	statements, err := s.Store.ListGlobalStatements(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	sortStatements(statements)
+	return statements, nil
+}
+
+// CreateGlobalStatement adds a statement to the end of a global category.
+func (s *Service) CreateGlobalStatement(ctx context.Context, categoryID, statementID, text string) (models.Statement, error) {
+	statements, err := s.globalCategoryStatements(ctx, categoryID)
+	if err != nil {
+		return models.Statement{}, err
+	}
+	if statementID == "" {
+		statementID = id.NewShort()
+	}
+	rows, err := planGlobalStatement(statements, categoryID, statementID, text, time.Now().UnixMilli())
+	if err != nil {
+		return models.Statement{}, err
+	}
+	if err := s.Store.UpsertGlobalStatements(ctx, rows); err != nil {
+		return models.Statement{}, err
+	}
+	return rows[len(rows)-1], nil
+}
+
+// planGlobalStatement returns the rows that add a statement to the end of
+// statements, given in display order: the new one last, after any existing
+// ones whose position had to be spread out to make room.
+func planGlobalStatement(statements []models.Statement, categoryID, statementID, text string, now int64) ([]models.Statement, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrInvalidStatementText
+	}
+	if globalStatementIndex(statements, statementID) >= 0 {
+		return nil, ErrStatementExists
+	}
+	statement := models.Statement{ID: statementID, CategoryID: categoryID, Text: text, Created: now, UpdatedAt: now}
+	return appendGlobalStatements(statements, []models.Statement{statement}), nil
+}
+
+// appendGlobalStatements gives added positions after statements, given in
+// display order, and returns the rows to write. Each new key follows the
+// last one when the category is fully ranked; otherwise the whole list is
+// re-spread and the existing statements whose key changed come first.
+// Neither path touches updated_at of existing statements.
+func appendGlobalStatements(statements, added []models.Statement) []models.Statement {
+	items := make([]orderedItem, len(statements))
+	for i, stmt := range statements {
+		items[i] = orderedItem{ID: stmt.ID, Position: stmt.Position}
+	}
+	if ranked(items) {
+		last := ""
+		if len(items) > 0 {
+			last = items[len(items)-1].Position
+		}
+		for i := range added {
+			// last is a valid key or empty, so After cannot fail.
+			last, _ = rank.After(last)
+			added[i].Position = last
+		}
+		return added
+	}
+
+	keys := rank.Sequence(len(statements) + len(added))
+	var rows []models.Statement
+	for i, stmt := range statements {
+		if stmt.Position != keys[i] {
+			stmt.Position = keys[i]
+			rows = append(rows, stmt)
+		}
+	}
+	for i := range added {
+		added[i].Position = keys[len(statements)+i]
+	}
+	return append(rows, added...)
+}
+
+// UpdateGlobalStatement changes the text of a global statement.
+func (s *Service) UpdateGlobalStatement(ctx context.Context, categoryID, statementID, text string) (models.Statement, error) {
+	statements, err := s.globalCategoryStatements(ctx, categoryID)
+	if err != nil {
+		return models.Statement{}, err
+	}
+	statement, changed, err := planGlobalStatementText(statements, statementID, text, time.Now().UnixMilli())
+	if err != nil || !changed {
+		return statement, err
+	}
+	if err := s.Store.UpsertGlobalStatements(ctx, []models.Statement{statement}); err != nil {
+		return models.Statement{}, err
+	}
+	return statement, nil
+}
+
+// planGlobalStatementText sets the text of a statement and reports whether
+// it changed. updated_at only moves with the text, so users who imported the
+// category are not offered an update that changes nothing.
+func planGlobalStatementText(statements []models.Statement, statementID, text string, now int64) (models.Statement, bool, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return models.Statement{}, false, ErrInvalidStatementText
+	}
+	i := globalStatementIndex(statements, statementID)
+	if i < 0 {
+		return models.Statement{}, false, store.ErrNotFound
+	}
+	statement := statements[i]
+	if statement.Text == text {
+		return statement, false, nil
+	}
+	statement.Text = text
+	statement.UpdatedAt = now
+	return statement, true, nil
+}
+
+func globalStatementIndex(statements []models.Statement, statementID string) int {
+	for i, stmt := range statements {
+		if stmt.ID == statementID {
+			return i
+		}
+	}
+	return -1
+}
+
+// DeleteGlobalStatement deletes a global statement. Users' imported copies
+// are kept.
+func (s *Service) DeleteGlobalStatement(ctx context.Context, categoryID, statementID string) error {
+	statements, err := s.globalCategoryStatements(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+	if globalStatementIndex(statements, statementID) < 0 {
+		return store.ErrNotFound
+	}
+	return s.Store.DeleteGlobalStatement(ctx, categoryID, statementID, time.Now().UnixMilli())
+}
+
+// ReorderGlobalStatements changes the order of statements within a global
+// category. Positions are not content, so updated_at stays as it was and
+// users who imported the category are not offered an update.
+func (s *Service) ReorderGlobalStatements(ctx context.Context, categoryID string, input ReorderInput) ([]models.Statement, error) {
+	statements, err := s.globalCategoryStatements(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	reordered, changed, err := planGlobalReorder(statements, input)
+	if err != nil || len(changed) == 0 {
+		return reordered, err
+	}
+	if err := s.Store.SetGlobalStatementPositions(ctx, categoryID, changed); err != nil {
+		return nil, err
+	}
+	return reordered, nil
+}
+
+// planGlobalReorder returns statements in their new order and the position
+// keys that changed.
+func planGlobalReorder(statements []models.Statement, input ReorderInput) ([]models.Statement, map[string]string, error) {
+	items := make([]orderedItem, len(statements))
+	for i, stmt := range statements {
+		items[i] = orderedItem{ID: stmt.ID, Position: stmt.Position}
+	}
+	changed, err := planPositions(items, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	reordered := append([]models.Statement(nil), statements...)
+	for i := range reordered {
+		if position, ok := changed[reordered[i].ID]; ok {
+			reordered[i].Position = position
+		}
+	}
+	sortStatements(reordered)
+	return reordered, changed, nil
+}
+
+// PasteGlobalStatements adds one statement per line of text to the end of a
+// global category, in one transaction. Blank lines and lines the category
+// already has are skipped.
+func (s *Service) PasteGlobalStatements(ctx context.Context, categoryID, text string) (PasteResult, error) {
+	statements, err := s.globalCategoryStatements(ctx, categoryID)
+	if err != nil {
+		return PasteResult{Statements: []models.Statement{}}, err
+	}
+	result, rows, err := planPaste(statements, categoryID, text, time.Now().UnixMilli(), id.NewShort)
+	if err != nil {
+		return PasteResult{Statements: []models.Statement{}}, err
+	}
+	if err := s.Store.UpsertGlobalStatements(ctx, rows); err != nil {
+		return PasteResult{Statements: []models.Statement{}}, err
+	}
+	return result, nil
+}
+
+// planPaste builds the statements a paste adds to the end of statements,
+// given in display order, and the rows to write for them.
+func planPaste(statements []models.Statement, categoryID, text string, now int64, newID func() string) (PasteResult, []models.Statement, error) {
+	result := PasteResult{Statements: []models.Statement{}}
+	seen := make(map[string]bool, len(statements))
+	for _, stmt := range statements {
+		seen[statementDedupKey(categoryID, stmt.Text)] = true
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		key := statementDedupKey(categoryID, line)
+		if line == "" || seen[key] {
+			result.Skipped++
+			continue
+		}
+		seen[key] = true
+		// Distinct created times keep the pasted order.
+		created := now + int64(len(result.Statements))
+		result.Statements = append(result.Statements, models.Statement{
+			ID:         newID(),
+			CategoryID: categoryID,
+			Text:       line,
+			Created:    created,
+			UpdatedAt:  created,
+		})
+	}
+	if len(result.Statements) > MaxBulkStatements {
+		return PasteResult{Statements: []models.Statement{}}, nil, ErrInvalidBulk
+	}
+	rows := appendGlobalStatements(statements, result.Statements)
+	return result, rows, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/rank"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func statementOrder(statements []models.Statement) string {
+	out := ""
+	for _, stmt := range statements {
+		out += stmt.ID
+	}
+	return out
+}
+
+// appendedOrder applies rows to statements and returns the ids in display
+// order.
+func appendedOrder(statements, rows []models.Statement) string {
+	merged := append([]models.Statement(nil), statements...)
+	for _, row := range rows {
+		if i := globalStatementIndex(merged, row.ID); i >= 0 {
+			merged[i] = row
+		} else {
+			merged = append(merged, row)
+		}
+	}
+	sortStatements(merged)
+	return statementOrder(merged)
+}
+
+func TestPlanGlobalStatement(t *testing.T) {
+	ranked := []models.Statement{
+		{ID: "a", Text: "Да", Position: "5", Created: 1, UpdatedAt: 10},
+		{ID: "b", Text: "Нет", Position: "i", Created: 2, UpdatedAt: 20},
+	}
+	// Created times would put the new statement first if ties were left to
+	// them.
+	unranked := []models.Statement{
+		{ID: "a", Text: "Да", Created: 100, UpdatedAt: 10},
+		{ID: "b", Text: "Нет", Created: 200, UpdatedAt: 20},
+	}
+	cases := []struct {
+		name       string
+		statements []models.Statement
+		id         string
+		text       string
+		wantErr    error
+		wantRows   int
+		wantOrder  string
+	}{
+		{name: "empty category", id: "n", text: "Привет", wantRows: 1, wantOrder: "n"},
+		{name: "after last ranked", statements: ranked, id: "n", text: " Привет ", wantRows: 1, wantOrder: "abn"},
+		{name: "unranked spread", statements: unranked, id: "n", text: "Привет", wantRows: 3, wantOrder: "abn"},
+		{name: "existing id", statements: ranked, id: "b", text: "Привет", wantErr: ErrStatementExists},
+		{name: "blank text", statements: ranked, id: "n", text: "  ", wantErr: ErrInvalidStatementText},
+	}
+	for _, tc := range cases {
+		rows, err := planGlobalStatement(tc.statements, "cat", tc.id, tc.text, 50)
+		if !errors.Is(err, tc.wantErr) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+		if tc.wantErr != nil {
+			continue
+		}
+		if len(rows) != tc.wantRows {
+			t.Fatalf("%s: expected %d rows, got %+v", tc.name, tc.wantRows, rows)
+		}
+		added := rows[len(rows)-1]
+		if added.ID != tc.id || added.Text != "Привет" || added.CategoryID != "cat" || !rank.Valid(added.Position) {
+			t.Fatalf("%s: unexpected new statement %+v", tc.name, added)
+		}
+		for _, row := range rows[:len(rows)-1] {
+			if i := globalStatementIndex(tc.statements, row.ID); row.UpdatedAt != tc.statements[i].UpdatedAt {
+				t.Fatalf("%s: expected respread %s to keep updated_at, got %+v", tc.name, row.ID, row)
+			}
+		}
+		if got := appendedOrder(tc.statements, rows); got != tc.wantOrder {
+			t.Fatalf("%s: expected order %s, got %s", tc.name, tc.wantOrder, got)
+		}
+	}
+}
+
+func TestPlanGlobalStatementText(t *testing.T) {
+	statements := []models.Statement{{ID: "a", Text: "Да", Position: "i", UpdatedAt: 10}}
+	cases := []struct {
+		name        string
+		id          string
+		text        string
+		wantErr     error
+		wantChanged bool
+		wantUpdated int64
+	}{
+		{name: "new text", id: "a", text: "Ага", wantChanged: true, wantUpdated: 50},
+		{name: "same text", id: "a", text: " Да ", wantUpdated: 10},
+		{name: "unknown", id: "x", text: "Ага", wantErr: store.ErrNotFound},
+		{name: "blank", id: "a", text: "", wantErr: ErrInvalidStatementText},
+	}
+	for _, tc := range cases {
+		statement, changed, err := planGlobalStatementText(statements, tc.id, tc.text, 50)
+		if !errors.Is(err, tc.wantErr) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+		if tc.wantErr != nil {
+			continue
+		}
+		if changed != tc.wantChanged || statement.UpdatedAt != tc.wantUpdated || statement.Position != "i" {
+			t.Fatalf("%s: unexpected result %+v (changed %v)", tc.name, statement, changed)
+		}
+	}
+}
+
+func TestPlanGlobalReorder(t *testing.T) {
+	statements := []models.Statement{
+		{ID: "a", Position: "5", UpdatedAt: 10},
+		{ID: "b", Position: "i", UpdatedAt: 20},
+		{ID: "c", Position: "t", UpdatedAt: 30},
+	}
+	cases := []struct {
+		name        string
+		input       ReorderInput
+		wantErr     error
+		wantChanged int
+		wantOrder   string
+	}{
+		{name: "move", input: ReorderInput{ID: "c", AfterID: "a"}, wantChanged: 1, wantOrder: "acb"},
+		{name: "full order", input: ReorderInput{IDs: []string{"b", "c", "a"}}, wantOrder: "bca"},
+		{name: "noop", input: ReorderInput{ID: "b", AfterID: "a"}, wantOrder: "abc"},
+		{name: "unknown", input: ReorderInput{ID: "x"}, wantErr: ErrInvalidOrder},
+	}
+	for _, tc := range cases {
+		reordered, changed, err := planGlobalReorder(statements, tc.input)
+		if !errors.Is(err, tc.wantErr) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+		if tc.wantErr != nil {
+			continue
+		}
+		if tc.wantChanged > 0 && len(changed) != tc.wantChanged {
+			t.Fatalf("%s: expected %d changed keys, got %v", tc.name, tc.wantChanged, changed)
+		}
+		if got := statementOrder(reordered); got != tc.wantOrder {
+			t.Fatalf("%s: expected order %s, got %s", tc.name, tc.wantOrder, got)
+		}
+		for _, stmt := range reordered {
+			if i := globalStatementIndex(statements, stmt.ID); stmt.UpdatedAt != statements[i].UpdatedAt {
+				t.Fatalf("%s: expected %s to keep updated_at, got %+v", tc.name, stmt.ID, stmt)
+			}
+		}
+	}
+	if statementOrder(statements) != "abc" {
+		t.Fatalf("expected the input to be left alone, got %s", statementOrder(statements))
+	}
+}
+
+func TestPlanPaste(t *testing.T) {
+	existing := []models.Statement{
+		{ID: "a", CategoryID: "cat", Text: "Да", Position: "i", Created: 1},
+	}
+	cases := []struct {
+		name        string
+		statements  []models.Statement
+		text        string
+		wantErr     error
+		wantAdded   string
+		wantSkipped int
+		wantOrder   string
+	}{
+		{name: "lines in order", statements: existing, text: "Привет\nПока", wantAdded: "01", wantOrder: "a01"},
+		{name: "skips blank and known", statements: existing, text: "да\n\nПока\nпока", wantAdded: "0", wantSkipped: 3, wantOrder: "a0"},
+		{name: "unranked category", statements: []models.Statement{{ID: "a", CategoryID: "cat", Text: "Да", Created: 100}}, text: "Привет", wantAdded: "0", wantOrder: "a0"},
+		{name: "too many", text: manyLines(MaxBulkStatements + 1), wantErr: ErrInvalidBulk},
+	}
+	for _, tc := range cases {
+		next := 0
+		newID := func() string {
+			next++
+			return strconv.Itoa(next - 1)
+		}
+		result, rows, err := planPaste(tc.statements, "cat", tc.text, 50, newID)
+		if !errors.Is(err, tc.wantErr) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+		if tc.wantErr != nil {
+			continue
+		}
+		if got := statementOrder(result.Statements); got != tc.wantAdded || result.Skipped != tc.wantSkipped {
+			t.Fatalf("%s: expected %s added and %d skipped, got %s and %d", tc.name, tc.wantAdded, tc.wantSkipped, got, result.Skipped)
+		}
+		for _, stmt := range result.Statements {
+			if !rank.Valid(stmt.Position) {
+				t.Fatalf("%s: expected %s to get a position, got %+v", tc.name, stmt.ID, stmt)
+			}
+		}
+		if got := appendedOrder(tc.statements, rows); got != tc.wantOrder {
+			t.Fatalf("%s: expected order %s, got %s", tc.name, tc.wantOrder, got)
+		}
+	}
+}
+
+func manyLines(n int) string {
+	out := ""
+	for i := 0; i < n; i++ {
+		out += strconv.Itoa(i) + "\n"
+	}
+	return out
+}
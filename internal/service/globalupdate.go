@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// GlobalUpdate lists upstream changes to an imported global category.
+// Renamed, Added and Changed are what applying it writes; Kept lists
+// statements changed upstream that the user edited, moved or deleted, which
+// applying leaves alone.
+type GlobalUpdate struct {
+	CategoryID      string             `json:"categoryId"`
+	Label           string             `json:"label"`
+	Version         int64              `json:"version"`
+	ImportedVersion int64              `json:"importedVersion"`
+	Renamed         bool               `json:"renamed,omitempty"`
+	Added           []models.Statement `json:"added"`
+	Changed         []models.Statement `json:"changed"`
+	Kept            []string           `json:"kept"`
+}
+
+func (u GlobalUpdate) empty() bool {
+	return !u.Renamed && len(u.Added) == 0 && len(u.Changed) == 0 && len(u.Kept) == 0
+}
+
+// globalVersion is the newest change to a global category or its statements.
+func globalVersion(category models.GlobalCategory, statements []models.Statement) int64 {
+	version := category.UpdatedAt
+	for _, stmt := range statements {
+		version = max(version, stmt.UpdatedAt)
+	}
+	return version
+}
+
+// planGlobalUpdate compares a global category with the user's imported copy.
+// Anything the user wrote after the import counts as their edit and is kept.
+// Statements removed upstream stay in the user's board.
+func planGlobalUpdate(global models.GlobalCategory, globalStatements []models.Statement, imp models.GlobalImport, local models.Category, localStatements map[string]models.Statement) GlobalUpdate {
+	update := GlobalUpdate{
+		CategoryID:      global.ID,
+		Label:           local.Label,
+		Version:         globalVersion(global, globalStatements),
+		ImportedVersion: imp.Version,
+		Added:           []models.Statement{},
+		Changed:         []models.Statement{},
+		Kept:            []string{},
+	}
+	if global.UpdatedAt > imp.Version && global.Label != local.Label && local.UpdatedAt <= imp.ImportedAt {
+		update.Label, update.Renamed = global.Label, true
+	}
+	for _, stmt := range globalStatements {
+		if stmt.UpdatedAt <= imp.Version {
+			continue
+		}
+		have, ok := localStatements[stmt.ID]
+		switch {
+		case !ok && stmt.Created > imp.Version:
+			stmt.CategoryID = global.ID
+			update.Added = append(update.Added, stmt)
+		case !ok || have.CategoryID != global.ID || have.UpdatedAt > imp.ImportedAt:
+			update.Kept = append(update.Kept, stmt.ID)
+		case have.Text != stmt.Text:
+			have.Text = stmt.Text
+			update.Changed = append(update.Changed, have)
+		}
+	}
+	return update
+}
+
+// ListGlobalUpdates returns upstream changes, translated into loc, to the
+// global categories the user imported and still has. New translations
+// count as changes.
+func (s *Service) ListGlobalUpdates(ctx context.Context, userID, loc string) ([]GlobalUpdate, error) {
+	imports, err := s.Store.ListGlobalImports(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	updates := []GlobalUpdate{}
+	if len(imports) == 0 {
+		return updates, nil
+	}
+	globals, err := s.Store.ListGlobalCategories(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := s.translations(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	globalByID := make(map[string]models.GlobalCategory, len(globals))
+	for _, cat := range globals {
+		globalByID[cat.ID] = translations.category(cat)
+	}
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	localByID := make(map[string]models.Category, len(categories))
+	for _, cat := range categories {
+		localByID[cat.ID] = cat
+	}
+	statements, err := s.Store.ListAllStatements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	localStatements := make(map[string]models.Statement, len(statements))
+	for _, stmt := range statements {
+		localStatements[stmt.ID] = stmt
+	}
+
+	for _, imp := range imports {
+		global, ok := globalByID[imp.CategoryID]
+		if !ok {
+			continue
+		}
+		local, ok := localByID[imp.CategoryID]
+		if !ok {
+			continue
+		}
+		globalStatements, err := s.Store.ListGlobalStatements(ctx, global.ID)
+		if err != nil {
+			return nil, err
+		}
+		globalStatements = translations.statements(global.ID, globalStatements)
+		if globalVersion(global, globalStatements) <= imp.Version {
+			continue
+		}
+		if update := planGlobalUpdate(global, globalStatements, imp, local, localStatements); !update.empty() {
+			updates = append(updates, update)
+		}
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].CategoryID < updates[j].CategoryID })
+	return updates, nil
+}
+
+// ApplyGlobalUpdates writes the listed updates, or all of them when
+// categoryIDs is empty, and marks the categories as up to date.
+func (s *Service) ApplyGlobalUpdates(ctx context.Context, userID string, categoryIDs []string, loc string) ([]GlobalUpdate, error) {
+	updates, err := s.ListGlobalUpdates(ctx, userID, loc)
+	if err != nil {
+		return nil, err
+	}
+	mirror := s.legacyWriter(ctx, userID)
+	applied := []GlobalUpdate{}
+	for _, update := range updates {
+		if len(categoryIDs) > 0 && !slices.Contains(categoryIDs, update.CategoryID) {
+			continue
+		}
+		updatedAt := time.Now().UnixMilli()
+		if update.Renamed {
+			category, err := s.findCategory(ctx, userID, update.CategoryID)
+			if err != nil {
+				return nil, err
+			}
+			category.Label = update.Label
+			category.UpdatedAt = updatedAt
+			if _, err := s.Store.UpsertCategory(ctx, userID, category); err != nil {
+				return nil, err
+			}
+			if mirror != nil {
+				if err := mirror.UpsertCategory(ctx, userID, category); err != nil {
+					return nil, err
+				}
+			}
+			_ = s.appendChange(ctx, userID, "category", category.ID, "upsert", category, updatedAt)
+			_ = s.recordRevision(ctx, userID, "category", category.ID, revisionUpdate, category, updatedAt)
+		}
+
+		batch := store.StatementBatch{UpdatedAt: updatedAt}
+		for _, stmt := range append(append([]models.Statement{}, update.Added...), update.Changed...) {
+			stmt.UpdatedAt = updatedAt
+			batch.Upserts = append(batch.Upserts, stmt)
+		}
+		if len(batch.Upserts) > 0 {
+			if err := s.Store.ApplyStatementBatch(ctx, userID, batch); err != nil {
+				return nil, err
+			}
+			if err := s.mirrorStatementBatch(ctx, userID, batch); err != nil {
+				return nil, err
+			}
+			for i, stmt := range batch.Upserts {
+				op := revisionUpdate
+				if i < len(update.Added) {
+					op = revisionCreate
+				}
+				_ = s.appendChange(ctx, userID, "statement", stmt.ID, "upsert", stmt, updatedAt)
+				_ = s.recordRevision(ctx, userID, "statement", stmt.ID, op, stmt, updatedAt)
+			}
+		}
+
+		imp := models.GlobalImport{CategoryID: update.CategoryID, Version: update.Version, ImportedAt: updatedAt}
+		if err := s.Store.UpsertGlobalImport(ctx, userID, imp); err != nil {
+			return nil, err
+		}
+		applied = append(applied, update)
+	}
+	return applied, nil
+}
+
+// trackGlobalImport records the version of each category imported with
+// rootID. Without force, categories already tracked keep their record, as
+// the import kept them. Versions include the translations the import used.
+func (s *Service) trackGlobalImport(ctx context.Context, userID, rootID string, force bool, translations translationSet) error {
+	globals, err := s.Store.ListGlobalCategories(ctx, false)
+	if err != nil {
+		return err
+	}
+	imports, err := s.Store.ListGlobalImports(ctx, userID)
+	if err != nil {
+		return err
+	}
+	tracked := make(map[string]bool, len(imports))
+	for _, imp := range imports {
+		tracked[imp.CategoryID] = true
+	}
+	now := time.Now().UnixMilli()
+	for _, cat := range store.GlobalSubtree(globals, rootID) {
+		if tracked[cat.ID] && !force {
+			continue
+		}
+		statements, err := s.Store.ListGlobalStatements(ctx, cat.ID)
+		if err != nil {
+			return err
+		}
+		version := globalVersion(translations.category(cat), translations.statements(cat.ID, statements))
+		imp := models.GlobalImport{CategoryID: cat.ID, Version: version, ImportedAt: now}
+		if err := s.Store.UpsertGlobalImport(ctx, userID, imp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
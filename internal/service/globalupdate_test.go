@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestPlanGlobalUpdate(t *testing.T) {
+	imp := models.GlobalImport{CategoryID: "food", Version: 100, ImportedAt: 500}
+	global := models.GlobalCategory{ID: "food", Label: "Meals", UpdatedAt: 200}
+	globalStatements := []models.Statement{
+		{ID: "same", Text: "Water", Created: 10, UpdatedAt: 50},
+		{ID: "fixed", Text: "Apple", Created: 10, UpdatedAt: 150},
+		{ID: "edited", Text: "Bread", Created: 10, UpdatedAt: 150},
+		{ID: "deleted", Text: "Milk", Created: 10, UpdatedAt: 150},
+		{ID: "moved", Text: "Tea", Created: 10, UpdatedAt: 150},
+		{ID: "new", Text: "Soup", Created: 180, UpdatedAt: 180},
+	}
+	local := models.Category{ID: "food", Label: "Food", UpdatedAt: 400}
+	localStatements := map[string]models.Statement{
+		"same":   {ID: "same", CategoryID: "food", Text: "Water", UpdatedAt: 400},
+		"fixed":  {ID: "fixed", CategoryID: "food", Text: "Aple", Position: "a0", UpdatedAt: 400},
+		"edited": {ID: "edited", CategoryID: "food", Text: "Brown bread", UpdatedAt: 600},
+		"moved":  {ID: "moved", CategoryID: "drinks", Text: "Tea", UpdatedAt: 400},
+	}
+
+	update := planGlobalUpdate(global, globalStatements, imp, local, localStatements)
+	if update.Version != 200 || !update.Renamed || update.Label != "Meals" {
+		t.Fatalf("unexpected header: %+v", update)
+	}
+	if len(update.Added) != 1 || update.Added[0].ID != "new" || update.Added[0].CategoryID != "food" {
+		t.Fatalf("expected new statement added, got %+v", update.Added)
+	}
+	if len(update.Changed) != 1 || update.Changed[0].Text != "Apple" || update.Changed[0].Position != "a0" {
+		t.Fatalf("expected fixed statement changed in place, got %+v", update.Changed)
+	}
+	if len(update.Kept) != 3 {
+		t.Fatalf("expected edited, deleted and moved kept, got %v", update.Kept)
+	}
+
+	local.UpdatedAt = 600
+	if update := planGlobalUpdate(global, globalStatements, imp, local, localStatements); update.Renamed {
+		t.Fatalf("expected the user's label to be kept")
+	}
+}
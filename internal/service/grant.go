@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// Grant access levels: read allows the delegate to view the board, edit
+// also to change it.
+const (
+	GrantRead = "read"
+	GrantEdit = "edit"
+)
+
+var ErrInvalidGrant = errors.New("access must be read or edit for another user")
+
+// Grants lists the access a user has given and received.
+type Grants struct {
+	Given    []models.Grant `json:"given"`
+	Received []models.Grant `json:"received"`
+}
+
+// SetGrant gives the delegate access to the owner's board, replacing any
+// earlier grant to them.
+func (s *Service) SetGrant(ctx context.Context, ownerID, delegateID, access string) (models.Grant, error) {
+	delegateID = strings.TrimSpace(delegateID)
+	if delegateID == "" || delegateID == ownerID || (access != GrantRead && access != GrantEdit) {
+		return models.Grant{}, ErrInvalidGrant
+	}
+	now := time.Now().UnixMilli()
+	grant := models.Grant{OwnerID: ownerID, DelegateID: delegateID, Access: access, Created: now, UpdatedAt: now}
+	if current, err := s.Store.GetGrant(ctx, ownerID, delegateID); err == nil {
+		grant.Created = current.Created
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return models.Grant{}, err
+	}
+	if err := s.Store.UpsertGrant(ctx, grant); err != nil {
+		return models.Grant{}, err
+	}
+	return grant, nil
+}
+
+// RevokeGrant removes the delegate's access to the owner's board.
+func (s *Service) RevokeGrant(ctx context.Context, ownerID, delegateID string) error {
+	return s.Store.DeleteGrant(ctx, ownerID, delegateID)
+}
+
+// ListGrants returns the grants a user has given and received.
+func (s *Service) ListGrants(ctx context.Context, userID string) (Grants, error) {
+	given, err := s.Store.ListGrantsByOwner(ctx, userID)
+	if err != nil {
+		return Grants{}, err
+	}
+	received, err := s.Store.ListGrantsByDelegate(ctx, userID)
+	if err != nil {
+		return Grants{}, err
+	}
+	return Grants{Given: append([]models.Grant{}, given...), Received: append([]models.Grant{}, received...)}, nil
+}
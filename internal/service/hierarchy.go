@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+// maxCategoryDepth limits how deep sub-categories may nest.
+const maxCategoryDepth = 8
+
+// ErrInvalidParent is returned for unknown parents, cycles and too deep nesting.
+var ErrInvalidParent = errors.New("invalid parent category")
+
+// ListCategoryTree returns the user's categories nested under their parents.
+// Siblings keep the user's order.
+func (s *Service) ListCategoryTree(ctx context.Context, userID string) ([]models.CategoryNode, error) {
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return categoryTree(categories), nil
+}
+
+// ListGlobalCategoryTree returns global categories, translated into loc,
+// nested under their parents.
+func (s *Service) ListGlobalCategoryTree(ctx context.Context, includeStatements bool, loc string) ([]models.GlobalCategoryNode, error) {
+	categories, err := s.ListGlobalCategories(ctx, includeStatements, loc)
+	if err != nil {
+		return nil, err
+	}
+	return globalCategoryTree(categories), nil
+}
+
+func categoryTree(categories []models.Category) []models.CategoryNode {
+	parents := make([]string, len(categories))
+	ids := make([]string, len(categories))
+	for i, cat := range categories {
+		ids[i], parents[i] = cat.ID, cat.ParentID
+	}
+	roots, children := treeIndex(ids, parents)
+
+	var build func(i int) models.CategoryNode
+	build = func(i int) models.CategoryNode {
+		node := models.CategoryNode{Category: categories[i], Children: []models.CategoryNode{}}
+		for _, child := range children[i] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+	out := make([]models.CategoryNode, 0, len(roots))
+	for _, i := range roots {
+		out = append(out, build(i))
+	}
+	return out
+}
+
+func globalCategoryTree(categories []models.GlobalCategory) []models.GlobalCategoryNode {
+	parents := make([]string, len(categories))
+	ids := make([]string, len(categories))
+	for i, cat := range categories {
+		ids[i], parents[i] = cat.ID, cat.ParentID
+	}
+	roots, children := treeIndex(ids, parents)
+
+	var build func(i int) models.GlobalCategoryNode
+	build = func(i int) models.GlobalCategoryNode {
+		node := models.GlobalCategoryNode{GlobalCategory: categories[i], Children: []models.GlobalCategoryNode{}}
+		for _, child := range children[i] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+	out := make([]models.GlobalCategoryNode, 0, len(roots))
+	for _, i := range roots {
+		out = append(out, build(i))
+	}
+	return out
+}
+
+// treeIndex arranges items into roots and per-item children, preserving
+// input order. Items whose parent is missing become roots; so does the first
+// item of any cycle, which can only appear through data written elsewhere.
+func treeIndex(ids, parents []string) (roots []int, children [][]int) {
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	children = make([][]int, len(ids))
+	attached := make([]bool, len(ids))
+	for i, parent := range parents {
+		p, ok := index[parent]
+		if parent == "" || !ok || p == i {
+			continue
+		}
+		children[p] = append(children[p], i)
+		attached[i] = true
+	}
+
+	visited := make([]bool, len(ids))
+	var walk func(i int)
+	walk = func(i int) {
+		visited[i] = true
+		kept := children[i][:0]
+		for _, child := range children[i] {
+			if visited[child] {
+				continue
+			}
+			kept = append(kept, child)
+			walk(child)
+		}
+		children[i] = kept
+	}
+	for i := range ids {
+		if !attached[i] {
+			roots = append(roots, i)
+			walk(i)
+		}
+	}
+	for i := range ids {
+		if !visited[i] {
+			roots = append(roots, i)
+			walk(i)
+		}
+	}
+	return roots, children
+}
+
+// validateParent checks that categoryID, with its current sub-categories,
+// may be placed under parentID given the current parent of every category.
+func validateParent(parents map[string]string, categoryID, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	if _, ok := parents[parentID]; !ok || parentID == categoryID {
+		return ErrInvalidParent
+	}
+	depth := subtreeHeight(parents, categoryID)
+	for current := parentID; current != ""; current = parents[current] {
+		if current == categoryID {
+			return ErrInvalidParent
+		}
+		depth++
+		if depth > maxCategoryDepth {
+			return ErrInvalidParent
+		}
+	}
+	return nil
+}
+
+// subtreeHeight counts the levels of rootID and its descendants.
+func subtreeHeight(parents map[string]string, rootID string) int {
+	height := 1
+	for _, id := range descendantIDs(parents, rootID) {
+		level := 1
+		for current := id; current != rootID && current != "" && level <= maxCategoryDepth; current = parents[current] {
+			level++
+		}
+		height = max(height, level)
+	}
+	return height
+}
+
+// descendantIDs returns the IDs below rootID, children before their parents.
+func descendantIDs(parents map[string]string, rootID string) []string {
+	children := make(map[string][]string)
+	for id, parent := range parents {
+		if parent != "" {
+			children[parent] = append(children[parent], id)
+		}
+	}
+	var out []string
+	seen := map[string]bool{rootID: true}
+	var walk func(id string)
+	walk = func(id string) {
+		for _, child := range children[id] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			walk(child)
+			out = append(out, child)
+		}
+	}
+	walk(rootID)
+	return out
+}
+
+func categoryParents(categories []models.Category) map[string]string {
+	parents := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		parents[cat.ID] = cat.ParentID
+	}
+	return parents
+}
+
+func globalCategoryParents(categories []models.GlobalCategory) map[string]string {
+	parents := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		parents[cat.ID] = cat.ParentID
+	}
+	return parents
+}
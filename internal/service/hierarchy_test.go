@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestCategoryTreeNestsAndKeepsOrder(t *testing.T) {
+	tree := categoryTree([]models.Category{
+		{ID: "drinks", ParentID: "breakfast"},
+		{ID: "food"},
+		{ID: "breakfast", ParentID: "food"},
+		{ID: "lost", ParentID: "missing"},
+		{ID: "lunch", ParentID: "food"},
+	})
+	if len(tree) != 2 || tree[0].ID != "food" || tree[1].ID != "lost" {
+		t.Fatalf("unexpected roots: %+v", tree)
+	}
+	food := tree[0]
+	if len(food.Children) != 2 || food.Children[0].ID != "breakfast" || food.Children[1].ID != "lunch" {
+		t.Fatalf("unexpected children of food: %+v", food.Children)
+	}
+	if len(food.Children[0].Children) != 1 || food.Children[0].Children[0].ID != "drinks" {
+		t.Fatalf("unexpected children of breakfast: %+v", food.Children[0].Children)
+	}
+}
+
+func TestCategoryTreeBreaksStoredCycles(t *testing.T) {
+	tree := categoryTree([]models.Category{
+		{ID: "a", ParentID: "b"},
+		{ID: "b", ParentID: "a"},
+	})
+	if len(tree) != 1 || tree[0].ID != "a" || len(tree[0].Children) != 1 || tree[0].Children[0].ID != "b" {
+		t.Fatalf("expected a cycle to be cut at its first item, got %+v", tree)
+	}
+}
+
+func TestValidateParent(t *testing.T) {
+	parents := map[string]string{"food": "", "breakfast": "food", "drinks": "breakfast", "other": ""}
+
+	if err := validateParent(parents, "other", "drinks"); err != nil {
+		t.Fatalf("expected move under drinks to be allowed: %v", err)
+	}
+	if err := validateParent(parents, "new", ""); err != nil {
+		t.Fatalf("expected top level to be allowed: %v", err)
+	}
+	for _, tc := range []struct{ id, parent string }{
+		{"food", "drinks"},
+		{"food", "food"},
+		{"other", "missing"},
+	} {
+		if err := validateParent(parents, tc.id, tc.parent); !errors.Is(err, ErrInvalidParent) {
+			t.Fatalf("%s under %s: expected ErrInvalidParent, got %v", tc.id, tc.parent, err)
+		}
+	}
+}
+
+func TestValidateParentLimitsDepth(t *testing.T) {
+	parents := map[string]string{"c0": ""}
+	chain := []string{"c0"}
+	for i := 1; i < maxCategoryDepth; i++ {
+		id := "c" + string(rune('0'+i))
+		parents[id] = chain[len(chain)-1]
+		chain = append(chain, id)
+	}
+	parents["leaf"] = ""
+	if err := validateParent(parents, "leaf", chain[len(chain)-1]); !errors.Is(err, ErrInvalidParent) {
+		t.Fatalf("expected depth limit, got %v", err)
+	}
+	if err := validateParent(parents, "leaf", chain[len(chain)-2]); err != nil {
+		t.Fatalf("expected last allowed level to pass: %v", err)
+	}
+}
+
+func TestDescendantIDsListsChildrenFirst(t *testing.T) {
+	parents := map[string]string{"food": "", "breakfast": "food", "drinks": "breakfast", "other": ""}
+	got := descendantIDs(parents, "food")
+	if len(got) != 2 || got[0] != "drinks" || got[1] != "breakfast" {
+		t.Fatalf("unexpected descendants: %v", got)
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/boardfile"
+	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// MaxImportStatements caps the phrases one import may create.
+const MaxImportStatements = 5000
+
+// ErrImportTooLarge is returned when a file holds too many new phrases.
+var ErrImportTooLarge = errors.New("import has too many phrases")
+
+// ImportedCategory is one category of an import. Statements lists the
+// phrases that are new to it; Existing marks a category the user already
+// had, matched by label under the same parent.
+type ImportedCategory struct {
+	ID         string   `json:"id,omitempty"`
+	Label      string   `json:"label"`
+	Parent     string   `json:"parent,omitempty"`
+	Existing   bool     `json:"existing"`
+	Statements []string `json:"statements"`
+	Duplicates int      `json:"duplicates"`
+}
+
+// ImportResult describes an import, or what it would do on a dry run.
+type ImportResult struct {
+	DryRun     bool               `json:"dryRun"`
+	Categories []ImportedCategory `json:"categories"`
+	Created    struct {
+		Categories int `json:"categories"`
+		Statements int `json:"statements"`
+	} `json:"created"`
+	Duplicates int `json:"duplicates"`
+	Skipped    int `json:"skipped"`
+}
+
+// importPlan is an ImportedCategory with the index of its parent plan.
+type importPlan struct {
+	ImportedCategory
+	parent int
+}
+
+// importKey identifies a category by its parent and normalized label, as
+// the nesting in the board and in the account both do.
+type importKey struct {
+	parent string
+	label  string
+}
+
+// planImport merges board categories with the same label under the same
+// parent, matches them to existing categories and drops phrases the
+// category already has.
+func planImport(board boardfile.Board, categories []models.Category, statements []models.Statement) ([]importPlan, int) {
+	existing := make(map[importKey]models.Category, len(categories))
+	for _, cat := range categories {
+		existing[importKey{cat.ParentID, normalizeLabel(cat.Label)}] = cat
+	}
+	keys := make(map[string]struct{}, len(statements))
+	for _, stmt := range statements {
+		keys[statementDedupKey(stmt.CategoryID, stmt.Text)] = struct{}{}
+	}
+
+	var plans []importPlan
+	// byKey holds plans by their parent's scope and label.
+	byKey := make(map[importKey]int)
+	// planOf maps board category indexes to plan indexes.
+	planOf := make([]int, len(board.Categories))
+	duplicates := 0
+	for i, cat := range board.Categories {
+		parent := -1
+		if cat.Parent >= 0 && cat.Parent < i {
+			parent = planOf[cat.Parent]
+		}
+		key := importKey{label: normalizeLabel(cat.Label)}
+		if parent >= 0 {
+			key.parent = importScope(plans, parent)
+		}
+		index, ok := byKey[key]
+		if !ok {
+			plan := importPlan{ImportedCategory: ImportedCategory{Label: cat.Label, Statements: []string{}}, parent: parent}
+			if parent >= 0 {
+				plan.Parent = plans[parent].Label
+			}
+			// A new parent has no existing children to match.
+			if parent < 0 || plans[parent].Existing {
+				if match, found := existing[key]; found {
+					plan.ID = match.ID
+					plan.Label = match.Label
+					plan.Existing = true
+				}
+			}
+			plans = append(plans, plan)
+			index = len(plans) - 1
+			byKey[key] = index
+		}
+		planOf[i] = index
+
+		plan := &plans[index]
+		scope := importScope(plans, index)
+		for _, text := range cat.Phrases {
+			key := statementDedupKey(scope, text)
+			if _, seen := keys[key]; seen {
+				plan.Duplicates++
+				duplicates++
+				continue
+			}
+			keys[key] = struct{}{}
+			plan.Statements = append(plan.Statements, text)
+		}
+	}
+	return plans, duplicates
+}
+
+// importScope is the id of an existing category, or a stand-in for a new
+// one, which has no id yet.
+func importScope(plans []importPlan, index int) string {
+	if plans[index].Existing {
+		return plans[index].ID
+	}
+	return "new:" + strconv.Itoa(index)
+}
+
+// ImportBoard creates the categories and phrases of a parsed board file,
+// skipping phrases the target category already has. A dry run only reports
+// the plan. Categories are created one by one, then every phrase is written
+// in one transaction, so a failed import adds no phrases but may leave some
+// of its new categories behind, empty.
+func (s *Service) ImportBoard(ctx context.Context, userID string, board boardfile.Board, dryRun bool) (ImportResult, error) {
+	result := ImportResult{DryRun: dryRun, Categories: []ImportedCategory{}, Skipped: board.Skipped}
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	statements, err := s.Store.ListAllStatements(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+
+	plans, duplicates := planImport(board, categories, statements)
+	result.Duplicates = duplicates
+	total := 0
+	for _, plan := range plans {
+		total += len(plan.Statements)
+	}
+	if total > MaxImportStatements {
+		return result, ErrImportTooLarge
+	}
+
+	for i := range plans {
+		plan := &plans[i]
+		if !dryRun && !plan.Existing {
+			input := CategoryInput{Label: plan.Label}
+			if plan.parent >= 0 {
+				input.ParentID = plans[plan.parent].ID
+			}
+			created, err := s.CreateCategory(ctx, userID, input)
+			if errors.Is(err, ErrInvalidParent) {
+				// Too deep for this account: keep the phrases at the top level.
+				input.ParentID = ""
+				created, err = s.CreateCategory(ctx, userID, input)
+			}
+			if err != nil {
+				return result, err
+			}
+			plan.ID = created.ID
+		}
+		if !plan.Existing {
+			result.Created.Categories++
+		}
+		result.Created.Statements += len(plan.Statements)
+		result.Categories = append(result.Categories, plan.ImportedCategory)
+	}
+	if dryRun || total == 0 {
+		return result, nil
+	}
+
+	batch := importBatch(plans, time.Now().UnixMilli(), id.NewShort)
+	if err := s.Store.ApplyStatementBatch(ctx, userID, batch); err != nil {
+		return result, err
+	}
+	if err := s.mirrorStatementBatch(ctx, userID, batch); err != nil {
+		return result, err
+	}
+	for _, stmt := range batch.Upserts {
+		_ = s.appendChange(ctx, userID, "statement", stmt.ID, "upsert", stmt, batch.UpdatedAt)
+		_ = s.recordRevision(ctx, userID, "statement", stmt.ID, revisionCreate, stmt, batch.UpdatedAt)
+	}
+	return result, nil
+}
+
+// importBatch lists the phrases of plans as new statements. Distinct created
+// times keep the order of the file.
+func importBatch(plans []importPlan, now int64, newID func() string) store.StatementBatch {
+	batch := store.StatementBatch{UpdatedAt: now}
+	for _, plan := range plans {
+		for _, text := range plan.Statements {
+			batch.Upserts = append(batch.Upserts, models.Statement{
+				ID:         newID(),
+				CategoryID: plan.ID,
+				Text:       text,
+				Created:    now + int64(len(batch.Upserts)),
+				UpdatedAt:  now,
+			})
+		}
+	}
+	return batch
+}
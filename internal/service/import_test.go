@@ -0,0 +1,133 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/boardfile"
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestPlanImportSkipsDuplicates(t *testing.T) {
+	board := boardfile.Board{Categories: []boardfile.Category{
+		{Label: "food", Parent: -1, Phrases: []string{"Tea", "Bread", "bread"}},
+		{Label: "Drinks", Parent: 0, Phrases: []string{"Water"}},
+		{Label: "Food", Parent: -1, Phrases: []string{"Soup"}},
+	}}
+	categories := []models.Category{{ID: "c1", Label: "Food"}}
+	statements := []models.Statement{{ID: "s1", CategoryID: "c1", Text: "tea"}}
+
+	plans, duplicates := planImport(board, categories, statements)
+	if duplicates != 2 {
+		t.Fatalf("expected 2 duplicates, got %d", duplicates)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 categories, got %+v", plans)
+	}
+	food := plans[0]
+	if !food.Existing || food.ID != "c1" || food.Label != "Food" {
+		t.Fatalf("expected existing Food, got %+v", food)
+	}
+	if got := food.Statements; len(got) != 2 || got[0] != "Bread" || got[1] != "Soup" {
+		t.Fatalf("unexpected Food phrases %v", got)
+	}
+	drinks := plans[1]
+	if drinks.Existing || drinks.parent != 0 || drinks.Parent != "Food" {
+		t.Fatalf("expected new Drinks under Food, got %+v", drinks)
+	}
+}
+
+func TestPlanImportMatchesUnderParent(t *testing.T) {
+	categories := []models.Category{
+		{ID: "food", Label: "Food"},
+		{ID: "drinks", Label: "Drinks"},
+		{ID: "hot", Label: "Hot", ParentID: "food"},
+	}
+	cases := []struct {
+		name     string
+		board    []boardfile.Category
+		wantIDs  []string
+		wantPlan int
+	}{
+		{
+			name:     "top level matches top level",
+			board:    []boardfile.Category{{Label: "drinks", Parent: -1}},
+			wantIDs:  []string{"drinks"},
+			wantPlan: 1,
+		},
+		{
+			name:     "nested is not the top level one",
+			board:    []boardfile.Category{{Label: "Food", Parent: -1}, {Label: "Drinks", Parent: 0}},
+			wantIDs:  []string{"food", ""},
+			wantPlan: 2,
+		},
+		{
+			name:     "nested matches nested",
+			board:    []boardfile.Category{{Label: "Food", Parent: -1}, {Label: "hot", Parent: 0}},
+			wantIDs:  []string{"food", "hot"},
+			wantPlan: 2,
+		},
+		{
+			name:     "under a new parent",
+			board:    []boardfile.Category{{Label: "Games", Parent: -1}, {Label: "Hot", Parent: 0}},
+			wantIDs:  []string{"", ""},
+			wantPlan: 2,
+		},
+		{
+			name: "same label under different parents",
+			board: []boardfile.Category{
+				{Label: "Games", Parent: -1}, {Label: "Cards", Parent: 0},
+				{Label: "Toys", Parent: -1}, {Label: "Cards", Parent: 2},
+				{Label: "cards", Parent: 0},
+			},
+			wantIDs:  []string{"", "", "", ""},
+			wantPlan: 4,
+		},
+	}
+	for _, tc := range cases {
+		plans, _ := planImport(boardfile.Board{Categories: tc.board}, categories, nil)
+		if len(plans) != tc.wantPlan {
+			t.Fatalf("%s: expected %d categories, got %+v", tc.name, tc.wantPlan, plans)
+		}
+		for i, want := range tc.wantIDs {
+			if plans[i].ID != want || plans[i].Existing != (want != "") {
+				t.Fatalf("%s: expected category %d to match %q, got %+v", tc.name, i, want, plans[i])
+			}
+		}
+	}
+}
+
+func TestPlanImportDedupsPerNewCategory(t *testing.T) {
+	board := boardfile.Board{Categories: []boardfile.Category{
+		{Label: "Games", Parent: -1}, {Label: "Cards", Parent: 0, Phrases: []string{"Uno"}},
+		{Label: "Toys", Parent: -1}, {Label: "Cards", Parent: 2, Phrases: []string{"Uno"}},
+	}}
+	plans, duplicates := planImport(board, nil, nil)
+	if duplicates != 0 || len(plans[1].Statements) != 1 || len(plans[3].Statements) != 1 {
+		t.Fatalf("expected Uno in both Cards categories, got %+v (%d duplicates)", plans, duplicates)
+	}
+}
+
+func TestImportBatch(t *testing.T) {
+	plans := []importPlan{
+		{ImportedCategory: ImportedCategory{ID: "a", Statements: []string{"Tea", "Bread"}}},
+		{ImportedCategory: ImportedCategory{ID: "b", Statements: []string{}}},
+		{ImportedCategory: ImportedCategory{ID: "c", Statements: []string{"Soup"}}},
+	}
+	next := 0
+	batch := importBatch(plans, 100, func() string {
+		next++
+		return "s" + string(rune('0'+next))
+	})
+	if batch.UpdatedAt != 100 || len(batch.Upserts) != 3 || len(batch.Removed) != 0 || len(batch.Deleted) != 0 {
+		t.Fatalf("expected three new statements, got %+v", batch)
+	}
+	for i, want := range []models.Statement{
+		{ID: "s1", CategoryID: "a", Text: "Tea", Created: 100, UpdatedAt: 100},
+		{ID: "s2", CategoryID: "a", Text: "Bread", Created: 101, UpdatedAt: 100},
+		{ID: "s3", CategoryID: "c", Text: "Soup", Created: 102, UpdatedAt: 100},
+	} {
+		if batch.Upserts[i] != want {
+			t.Fatalf("statement %d: expected %+v, got %+v", i, want, batch.Upserts[i])
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/imaging"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// maxIconRunes bounds the emoji or icon name stored on an entity.
+const maxIconRunes = 32
+
+var (
+	ErrMediaDisabled = errors.New("image uploads are not configured")
+	ErrInvalidMedia  = errors.New("invalid image")
+	ErrMediaTooLarge = errors.New("image is too large")
+	ErrMediaInUse    = errors.New("image is still in use")
+	ErrInvalidVisual = errors.New("invalid color or icon")
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+var mediaExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// UploadMedia stores an image with its thumbnail and returns its metadata.
+func (s *Service) UploadMedia(ctx context.Context, userID string, data []byte) (models.Media, error) {
+	if s.Blobs == nil {
+		return models.Media{}, ErrMediaDisabled
+	}
+	if limit := s.Media.MaxUploadBytes; limit > 0 && int64(len(data)) > limit {
+		return models.Media{}, ErrMediaTooLarge
+	}
+	info, err := imaging.Inspect(data)
+	if err != nil {
+		return models.Media{}, ErrInvalidMedia
+	}
+	size := s.Media.ThumbnailSize
+	if size <= 0 {
+		size = 256
+	}
+	thumbnail, err := imaging.Thumbnail(data, size)
+	if err != nil {
+		return models.Media{}, ErrInvalidMedia
+	}
+
+	mediaID := id.NewShort()
+	media := models.Media{
+		ID:           mediaID,
+		ContentType:  info.ContentType,
+		Size:         int64(len(data)),
+		Width:        info.Width,
+		Height:       info.Height,
+		BlobKey:      fmt.Sprintf("media/%s/%s%s", userID, mediaID, mediaExtensions[info.ContentType]),
+		ThumbnailKey: fmt.Sprintf("media/%s/%s_thumb.png", userID, mediaID),
+		Created:      time.Now().UnixMilli(),
+	}
+	if err := s.Blobs.Put(ctx, media.BlobKey, media.ContentType, data); err != nil {
+		return models.Media{}, err
+	}
+	if err := s.Blobs.Put(ctx, media.ThumbnailKey, "image/png", thumbnail); err != nil {
+		return models.Media{}, err
+	}
+	if err := s.Store.CreateMedia(ctx, userID, media); err != nil {
+		return models.Media{}, err
+	}
+	return media, nil
+}
+
+// ListMedia returns the user's uploaded images.
+func (s *Service) ListMedia(ctx context.Context, userID string) ([]models.Media, error) {
+	return s.Store.ListMedia(ctx, userID)
+}
+
+// OpenMedia returns the image or its thumbnail with its content type.
+func (s *Service) OpenMedia(ctx context.Context, userID, mediaID string, thumbnail bool) (io.ReadCloser, string, error) {
+	if s.Blobs == nil {
+		return nil, "", ErrMediaDisabled
+	}
+	media, err := s.Store.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, "", err
+	}
+	if thumbnail {
+		body, err := s.Blobs.Get(ctx, media.ThumbnailKey)
+		return body, "image/png", err
+	}
+	body, err := s.Blobs.Get(ctx, media.BlobKey)
+	return body, media.ContentType, err
+}
+
+// DeleteMedia removes an image that no category or statement uses.
+func (s *Service) DeleteMedia(ctx context.Context, userID, mediaID string) error {
+	media, err := s.Store.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return err
+	}
+	refs, err := s.Store.CountMediaReferences(ctx, userID, mediaID)
+	if err != nil {
+		return err
+	}
+	if refs > 0 {
+		return ErrMediaInUse
+	}
+	return s.removeMedia(ctx, userID, media)
+}
+
+// releaseMedia deletes an image once nothing references it. Failures are
+// logged: a leftover blob is harmless.
+func (s *Service) releaseMedia(ctx context.Context, userID, mediaID string) {
+	if mediaID == "" || s.Blobs == nil {
+		return
+	}
+	media, err := s.Store.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			slog.Warn("media lookup failed", "user_id", userID, "media_id", mediaID, "error", err)
+		}
+		return
+	}
+	refs, err := s.Store.CountMediaReferences(ctx, userID, mediaID)
+	if err != nil || refs > 0 {
+		return
+	}
+	if err := s.removeMedia(ctx, userID, media); err != nil {
+		slog.Warn("media cleanup failed", "user_id", userID, "media_id", mediaID, "error", err)
+	}
+}
+
+func (s *Service) removeMedia(ctx context.Context, userID string, media models.Media) error {
+	if s.Blobs != nil {
+		if err := s.Blobs.Delete(ctx, media.BlobKey); err != nil {
+			return err
+		}
+		if err := s.Blobs.Delete(ctx, media.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+	return s.Store.DeleteMedia(ctx, userID, media.ID)
+}
+
+// deleteUserMedia removes every stored blob of the user.
+func (s *Service) deleteUserMedia(ctx context.Context, userID string) error {
+	if s.Blobs == nil {
+		return nil
+	}
+	items, err := s.Store.ListMedia(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, media := range items {
+		if err := s.Blobs.Delete(ctx, media.BlobKey); err != nil {
+			return err
+		}
+		if err := s.Blobs.Delete(ctx, media.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateVisual checks the presentation fields of a category or statement.
+func (s *Service) validateVisual(ctx context.Context, userID, color, icon, imageID string) error {
+	if color != "" && !colorPattern.MatchString(color) {
+		return ErrInvalidVisual
+	}
+	if utf8.RuneCountInString(icon) > maxIconRunes {
+		return ErrInvalidVisual
+	}
+	if imageID == "" {
+		return nil
+	}
+	if _, err := s.Store.GetMedia(ctx, userID, imageID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrInvalidMedia
+		}
+		return err
+	}
+	return nil
+}
+
+// knownMediaID returns mediaID when the user owns it and "" otherwise.
+func (s *Service) knownMediaID(ctx context.Context, userID, mediaID string) string {
+	if mediaID == "" {
+		return ""
+	}
+	if _, err := s.Store.GetMedia(ctx, userID, mediaID); err != nil {
+		return ""
+	}
+	return mediaID
+}
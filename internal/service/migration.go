@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/feature"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+const (
+	maxMigrationCohort = 500
+	// maxMigrationCacheEntries bounds the in-memory migration state cache.
+	maxMigrationCacheEntries = 10000
+)
+
+var (
+	ErrInvalidMigrationState      = errors.New("invalid migration state")
+	ErrMigrationTransitionRefused = errors.New("migration transition not allowed")
+	ErrMigrationNotVerified       = errors.New("user data has not been verified")
+)
+
+// MigrationInput describes a requested state change.
+type MigrationInput struct {
+	State string
+	Note  string
+	Force bool
+}
+
+// MigrationCohortInput selects users to move. Either UserIDs or FromState
+// must be set; FromState picks up to Limit users currently in that state.
+type MigrationCohortInput struct {
+	UserIDs   []string
+	FromState string
+	Limit     int
+	MigrationInput
+}
+
+// MigrationCohortResult reports a cohort move.
+type MigrationCohortResult struct {
+	Moved  []string          `json:"moved"`
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// MigrationVerification compares Firebase and YDB copies of a user.
+type MigrationVerification struct {
+	Migration          models.UserMigration `json:"migration"`
+	Verified           bool                 `json:"verified"`
+	FirebaseCategories int                  `json:"firebase_categories"`
+	YDBCategories      int                  `json:"ydb_categories"`
+	FirebaseStatements int                  `json:"firebase_statements"`
+	YDBStatements      int                  `json:"ydb_statements"`
+}
+
+type migrationEntry struct {
+	state     string
+	expiresAt time.Time
+}
+
+// migrationCache keeps recently resolved migration states in memory.
+type migrationCache struct {
+	mu      sync.Mutex
+	entries map[string]migrationEntry
+}
+
+func (c *migrationCache) get(userID string, now time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[userID]
+	if !ok || now.After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.state, true
+}
+
+// put caches a state. Once the cache is full, expired entries are dropped;
+// if none have expired, the cache starts over.
+func (c *migrationCache) put(userID, state string, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]migrationEntry)
+	}
+	if _, ok := c.entries[userID]; !ok && len(c.entries) >= maxMigrationCacheEntries {
+		now := time.Now()
+		for id, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= maxMigrationCacheEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[userID] = migrationEntry{state: state, expiresAt: expiresAt}
+}
+
+func (c *migrationCache) forget(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+}
+
+// migrationState resolves the user's migration state, falling back to the
+// read-source flag when no row exists or the lookup fails.
+func (s *Service) migrationState(ctx context.Context, userID string) string {
+	now := time.Now()
+	if state, ok := s.migrations.get(userID, now); ok {
+		return state
+	}
+
+	state := feature.DefaultMigrationState(userID, s.Feature)
+	if s.Store != nil {
+		migration, err := s.Store.GetUserMigration(ctx, userID)
+		switch {
+		case err == nil:
+			state = migration.State
+		case errors.Is(err, store.ErrNotFound):
+		default:
+			slog.Warn("migration state lookup failed", "user_id", userID, "error", err)
+			return state
+		}
+	}
+
+	if ttl := s.Feature.MigrationCacheTTL; ttl > 0 {
+		s.migrations.put(userID, state, now.Add(ttl))
+	}
+	return state
+}
+
+// useYDB returns true when the user's reads are served from YDB.
+func (s *Service) useYDB(ctx context.Context, userID string) bool {
+	return feature.MigrationReadsYDB(s.migrationState(ctx, userID))
+}
+
+// legacyWriter returns the Firebase mirror for the user, or nil once their
+// Firebase copy has been decommissioned.
+func (s *Service) legacyWriter(ctx context.Context, userID string) store.LegacyWriter {
+	if s.LegacyWriter == nil {
+		return nil
+	}
+	if !feature.MigrationUsesFirebase(s.migrationState(ctx, userID)) {
+		return nil
+	}
+	return s.LegacyWriter
+}
+
+// legacyReader returns the Firebase reader for the user, or nil once their
+// Firebase copy has been decommissioned.
+func (s *Service) legacyReader(ctx context.Context, userID string) store.LegacyReader {
+	if s.LegacyReader == nil {
+		return nil
+	}
+	if !feature.MigrationUsesFirebase(s.migrationState(ctx, userID)) {
+		return nil
+	}
+	return s.LegacyReader
+}
+
+// GetUserMigration returns the stored migration row or the flag-derived default.
+func (s *Service) GetUserMigration(ctx context.Context, userID string) (models.UserMigration, error) {
+	migration, err := s.Store.GetUserMigration(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.UserMigration{
+			UserID: userID,
+			State:  feature.DefaultMigrationState(userID, s.Feature),
+		}, nil
+	}
+	return migration, err
+}
+
+// ListUserMigrations returns explicit migration rows and per-state counts.
+func (s *Service) ListUserMigrations(ctx context.Context, state string, limit int) ([]models.UserMigration, map[string]int64, error) {
+	if state != "" && !feature.ValidMigrationState(state) {
+		return nil, nil, ErrInvalidMigrationState
+	}
+	items, err := s.Store.ListUserMigrations(ctx, state, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts, err := s.Store.CountUserMigrations(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, counts, nil
+}
+
+// SetUserMigration moves a user to a new state. Entering seeding copies
+// Firebase data into YDB; entering ydb_primary requires a verified copy.
+// Force skips both the transition and verification checks.
+func (s *Service) SetUserMigration(ctx context.Context, actorID, userID string, input MigrationInput) (models.UserMigration, error) {
+	if !feature.ValidMigrationState(input.State) {
+		return models.UserMigration{}, ErrInvalidMigrationState
+	}
+	current, err := s.GetUserMigration(ctx, userID)
+	if err != nil {
+		return models.UserMigration{}, err
+	}
+
+	if !input.Force {
+		if !feature.MigrationTransitionAllowed(current.State, input.State) {
+			return current, fmt.Errorf("%w: %s -> %s", ErrMigrationTransitionRefused, current.State, input.State)
+		}
+		if input.State == feature.MigrationYDBPrimary && current.State != feature.MigrationYDBPrimary && current.VerifiedAt == 0 {
+			return current, ErrMigrationNotVerified
+		}
+	}
+
+	next := current
+	next.State = input.State
+	next.UpdatedAt = time.Now().UnixMilli()
+	next.UpdatedBy = actorID
+	if strings.TrimSpace(input.Note) != "" {
+		next.Note = strings.TrimSpace(input.Note)
+	}
+	if !feature.MigrationReadsYDB(next.State) {
+		// A rollback to Firebase reads invalidates any earlier verification.
+		next.VerifiedAt = 0
+	}
+
+	if err := s.Store.SetUserMigration(ctx, next); err != nil {
+		return current, err
+	}
+	s.migrations.forget(userID)
+
+	if next.State == feature.MigrationSeeding && current.State != feature.MigrationSeeding {
+		if err := s.seedFromFirebase(ctx, userID); err != nil {
+			return next, fmt.Errorf("seed user data: %w", err)
+		}
+	}
+
+	next.Explicit = true
+	return next, nil
+}
+
+// MoveMigrationCohort applies the same transition to many users.
+func (s *Service) MoveMigrationCohort(ctx context.Context, actorID string, input MigrationCohortInput) (MigrationCohortResult, error) {
+	result := MigrationCohortResult{Moved: []string{}, Failed: map[string]string{}}
+
+	userIDs := input.UserIDs
+	if len(userIDs) == 0 {
+		if !feature.ValidMigrationState(input.FromState) {
+			return result, ErrInvalidMigrationState
+		}
+		limit := input.Limit
+		if limit <= 0 || limit > maxMigrationCohort {
+			limit = maxMigrationCohort
+		}
+		rows, err := s.Store.ListUserMigrations(ctx, input.FromState, limit)
+		if err != nil {
+			return result, err
+		}
+		for _, row := range rows {
+			userIDs = append(userIDs, row.UserID)
+		}
+	}
+	if len(userIDs) > maxMigrationCohort {
+		return result, fmt.Errorf("cohort exceeds %d users", maxMigrationCohort)
+	}
+
+	for _, userID := range userIDs {
+		userID = strings.TrimSpace(userID)
+		if userID == "" {
+			continue
+		}
+		if _, err := s.SetUserMigration(ctx, actorID, userID, input.MigrationInput); err != nil {
+			result.Failed[userID] = err.Error()
+			continue
+		}
+		result.Moved = append(result.Moved, userID)
+	}
+	return result, nil
+}
+
+// VerifyUserMigration compares Firebase and YDB counts and records the
+// verification time when they match.
+func (s *Service) VerifyUserMigration(ctx context.Context, actorID, userID string) (MigrationVerification, error) {
+	result := MigrationVerification{}
+	migration, err := s.GetUserMigration(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	if s.LegacyReader == nil {
+		return result, errors.New("firebase reader is not configured")
+	}
+
+	fbCategories, fbStatements, err := s.LegacyReader.FetchUserData(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	categories, err := s.Store.ListCategories(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	statements, err := s.Store.ListAllStatements(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+
+	result.FirebaseCategories = len(fbCategories)
+	result.FirebaseStatements = len(fbStatements)
+	result.YDBCategories = len(categories)
+	result.YDBStatements = len(statements)
+	result.Verified = result.FirebaseCategories == result.YDBCategories &&
+		result.FirebaseStatements == result.YDBStatements
+
+	if result.Verified {
+		migration.VerifiedAt = time.Now().UnixMilli()
+		migration.UpdatedAt = migration.VerifiedAt
+		migration.UpdatedBy = actorID
+		if err := s.Store.SetUserMigration(ctx, migration); err != nil {
+			return result, err
+		}
+		migration.Explicit = true
+		s.migrations.forget(userID)
+	}
+	result.Migration = migration
+	return result, nil
+}
+
+func (s *Service) seedFromFirebase(ctx context.Context, userID string) error {
+	if s.LegacyReader == nil {
+		return nil
+	}
+	categories, statements, err := s.LegacyReader.FetchUserData(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.seedUserData(ctx, userID, categories, statements); err != nil {
+		return err
+	}
+	state, err := s.LegacyReader.GetUserState(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.seedUserState(ctx, userID, state)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMigrationCacheIsBounded(t *testing.T) {
+	var cache migrationCache
+	now := time.Now()
+	for i := 0; i < maxMigrationCacheEntries; i++ {
+		cache.put(fmt.Sprintf("old-%d", i), "dual", now.Add(-time.Second))
+	}
+	cache.put("fresh", "dual", now.Add(time.Minute))
+	if len(cache.entries) != 1 {
+		t.Fatalf("expired entries should be pruned, have %d", len(cache.entries))
+	}
+
+	for i := 0; len(cache.entries) < maxMigrationCacheEntries; i++ {
+		cache.put(fmt.Sprintf("live-%d", i), "dual", now.Add(time.Minute))
+	}
+	cache.put("overflow", "dual", now.Add(time.Minute))
+	if len(cache.entries) > maxMigrationCacheEntries {
+		t.Fatalf("cache grew past its bound: %d", len(cache.entries))
+	}
+	if state, ok := cache.get("overflow", now); !ok || state != "dual" {
+		t.Fatalf("latest entry should be cached")
+	}
+}
@@ -0,0 +1,468 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+// Question types. Questions without a type are text questions.
+const (
+	QuestionText   = "text"
+	QuestionSingle = "single"
+	QuestionMulti  = "multi"
+	QuestionNumber = "number"
+	QuestionDate   = "date"
+)
+
+// maxAnswerLength bounds a text answer.
+const maxAnswerLength = 200
+
+var (
+	ErrInvalidAnswer   = errors.New("invalid onboarding answer")
+	ErrInvalidQuestion = errors.New("invalid factory question")
+)
+
+// placeholderPattern matches %% and {name} style placeholders in phrase
+// templates; placeholderKey checks a question's placeholder name.
+var (
+	placeholderPattern = regexp.MustCompile(`%%|\{[A-Za-z0-9_]+\}`)
+	placeholderKey     = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+// Onboarding is a user's progress through the questionnaire. Questions are
+// those shown given the answers so far; Pending lists the ones still
+// unanswered.
+type Onboarding struct {
+	Questions []models.FactoryQuestion  `json:"questions"`
+	Answers   []models.OnboardingAnswer `json:"answers"`
+	Pending   []string                  `json:"pending"`
+	Inited    bool                      `json:"inited"`
+}
+
+// AnswerInput is one answer. Value is shorthand for a single value; no
+// values clears the answer.
+type AnswerInput struct {
+	QuestionID string   `json:"question_id"`
+	Value      string   `json:"value"`
+	Values     []string `json:"values"`
+}
+
+func (a AnswerInput) values() []string {
+	if len(a.Values) == 0 && strings.TrimSpace(a.Value) != "" {
+		return []string{a.Value}
+	}
+	return a.Values
+}
+
+// onboardingPhrase is a phrase generated from the answers.
+type onboardingPhrase struct {
+	Category string
+	Text     string
+}
+
+// questionType returns how answers to question are checked. Choice
+// questions that lost their options, such as ones copied from RTDB, take
+// free text rather than rejecting every answer.
+func questionType(question models.FactoryQuestion) string {
+	switch question.Type {
+	case QuestionSingle, QuestionMulti:
+		if len(question.Options) > 0 {
+			return question.Type
+		}
+	case QuestionNumber, QuestionDate:
+		return question.Type
+	}
+	return QuestionText
+}
+
+// validateQuestion checks a factory question's type, options and follow-up
+// condition. Unknown types from the legacy catalogue are kept as text.
+func validateQuestion(question models.FactoryQuestion) error {
+	switch question.Type {
+	case "", QuestionText, QuestionNumber, QuestionDate:
+	case QuestionSingle, QuestionMulti:
+		if len(question.Options) == 0 {
+			return fmt.Errorf("%w: %s questions need options", ErrInvalidQuestion, question.Type)
+		}
+		seen := make(map[string]bool, len(question.Options))
+		for _, option := range question.Options {
+			if option.Value == "" || option.Label == "" || seen[option.Value] {
+				return fmt.Errorf("%w: options need a unique value and a label", ErrInvalidQuestion)
+			}
+			seen[option.Value] = true
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidQuestion, question.Type)
+	}
+	if question.Placeholder != "" && !placeholderKey.MatchString(question.Placeholder) {
+		return fmt.Errorf("%w: placeholder may only use letters, digits and _", ErrInvalidQuestion)
+	}
+	if question.ShowIf != nil && (question.ShowIf.QuestionID == "" || question.ShowIf.QuestionID == question.ID) {
+		return fmt.Errorf("%w: show_if needs another question", ErrInvalidQuestion)
+	}
+	return nil
+}
+
+// normalizeAnswer checks values against the question type and returns them
+// trimmed. Choice answers keep option order.
+func normalizeAnswer(question models.FactoryQuestion, values []string) ([]string, error) {
+	var out []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	switch questionType(question) {
+	case QuestionSingle, QuestionMulti:
+		if questionType(question) == QuestionSingle && len(out) > 1 {
+			return nil, fmt.Errorf("%w: %s takes one option", ErrInvalidAnswer, question.ID)
+		}
+		for _, value := range out {
+			if !slices.ContainsFunc(question.Options, func(option models.QuestionOption) bool { return option.Value == value }) {
+				return nil, fmt.Errorf("%w: %s has no option %q", ErrInvalidAnswer, question.ID, value)
+			}
+		}
+		var picked []string
+		for _, option := range question.Options {
+			if slices.Contains(out, option.Value) {
+				picked = append(picked, option.Value)
+			}
+		}
+		return picked, nil
+	case QuestionNumber:
+		if len(out) > 1 {
+			return nil, fmt.Errorf("%w: %s takes one number", ErrInvalidAnswer, question.ID)
+		}
+		if _, err := strconv.ParseFloat(strings.ReplaceAll(out[0], ",", "."), 64); err != nil {
+			return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidAnswer, question.ID)
+		}
+	case QuestionDate:
+		if len(out) > 1 {
+			return nil, fmt.Errorf("%w: %s takes one date", ErrInvalidAnswer, question.ID)
+		}
+		if _, err := time.Parse(time.DateOnly, out[0]); err != nil {
+			return nil, fmt.Errorf("%w: %s must be a YYYY-MM-DD date", ErrInvalidAnswer, question.ID)
+		}
+	default:
+		if len(out) > 1 {
+			out = []string{strings.Join(out, ", ")}
+		}
+		if len([]rune(out[0])) > maxAnswerLength {
+			return nil, fmt.Errorf("%w: %s is too long", ErrInvalidAnswer, question.ID)
+		}
+	}
+	return out, nil
+}
+
+// visibleQuestions returns the questions shown given the answers: those
+// without a condition, and follow-ups whose earlier, visible question was
+// answered with a matching value.
+func visibleQuestions(questions []models.FactoryQuestion, answers map[string][]string) []models.FactoryQuestion {
+	visible := make(map[string]bool, len(questions))
+	var out []models.FactoryQuestion
+	for _, question := range questions {
+		if cond := question.ShowIf; cond != nil {
+			answer := answers[cond.QuestionID]
+			if !visible[cond.QuestionID] || len(answer) == 0 {
+				continue
+			}
+			if len(cond.Values) > 0 && !slices.ContainsFunc(answer, func(v string) bool { return slices.Contains(cond.Values, v) }) {
+				continue
+			}
+		}
+		visible[question.ID] = true
+		out = append(out, question)
+	}
+	return out
+}
+
+// answerLabels returns the text an answer stands for in phrases: option
+// labels for choice questions, the values otherwise.
+func answerLabels(question models.FactoryQuestion, values []string) []string {
+	if len(question.Options) == 0 {
+		return values
+	}
+	labels := make([]string, 0, len(values))
+	for _, value := range values {
+		label := value
+		for _, option := range question.Options {
+			if option.Value == value {
+				label = option.Label
+				break
+			}
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+// renderPhrase fills %% with own and {key} with answers. A phrase that
+// refers to an unanswered placeholder is dropped.
+func renderPhrase(template, own string, vars map[string]string) (string, bool) {
+	complete := true
+	text := placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		if match == "%%" {
+			return own
+		}
+		value, ok := vars[match[1:len(match)-1]]
+		if !ok {
+			complete = false
+		}
+		return value
+	})
+	text = strings.TrimSpace(text)
+	return text, complete && text != ""
+}
+
+// planOnboardingPhrases renders the phrases of the visible answered
+// questions. Multi choice phrases are rendered once per picked option.
+func planOnboardingPhrases(questions []models.FactoryQuestion, answers map[string][]string) []onboardingPhrase {
+	visible := visibleQuestions(questions, answers)
+	vars := make(map[string]string)
+	for _, question := range visible {
+		if values := answers[question.ID]; len(values) > 0 {
+			text := strings.Join(answerLabels(question, values), ", ")
+			vars[question.ID] = text
+			if question.Placeholder != "" {
+				vars[question.Placeholder] = text
+			}
+		}
+	}
+
+	var out []onboardingPhrase
+	for _, question := range visible {
+		values := answers[question.ID]
+		if len(values) == 0 || question.Category == "" {
+			continue
+		}
+		owns := []string{vars[question.ID]}
+		if questionType(question) == QuestionMulti {
+			owns = answerLabels(question, values)
+		}
+		for _, own := range owns {
+			for _, template := range question.Phrases {
+				if text, ok := renderPhrase(template, own, vars); ok {
+					out = append(out, onboardingPhrase{Category: question.Category, Text: text})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// GetOnboarding returns the questionnaire in loc with the user's answers.
+func (s *Service) GetOnboarding(ctx context.Context, userID, loc string) (Onboarding, error) {
+	state, err := s.GetUserState(ctx, userID)
+	if err != nil {
+		return Onboarding{}, err
+	}
+	questions, err := s.ListFactoryQuestions(ctx, loc)
+	if err != nil {
+		return Onboarding{}, err
+	}
+	answers, err := s.Store.ListOnboardingAnswers(ctx, userID)
+	if err != nil {
+		return Onboarding{}, err
+	}
+	return onboardingProgress(questions, answers, state.Inited), nil
+}
+
+func onboardingProgress(questions []models.FactoryQuestion, answers []models.OnboardingAnswer, inited bool) Onboarding {
+	byID := answerValues(answers)
+	progress := Onboarding{
+		Questions: visibleQuestions(questions, byID),
+		Answers:   answers,
+		Pending:   []string{},
+		Inited:    inited,
+	}
+	if progress.Questions == nil {
+		progress.Questions = []models.FactoryQuestion{}
+	}
+	if progress.Answers == nil {
+		progress.Answers = []models.OnboardingAnswer{}
+	}
+	for _, question := range progress.Questions {
+		if len(byID[question.ID]) == 0 {
+			progress.Pending = append(progress.Pending, question.ID)
+		}
+	}
+	return progress
+}
+
+func answerValues(answers []models.OnboardingAnswer) map[string][]string {
+	byID := make(map[string][]string, len(answers))
+	for _, answer := range answers {
+		byID[answer.QuestionID] = answer.Values
+	}
+	return byID
+}
+
+// SaveOnboardingAnswers validates and stores answers, so onboarding can be
+// resumed later. An answer without values is removed.
+func (s *Service) SaveOnboardingAnswers(ctx context.Context, userID string, inputs []AnswerInput, loc string) (Onboarding, error) {
+	questions, err := s.ListFactoryQuestions(ctx, loc)
+	if err != nil {
+		return Onboarding{}, err
+	}
+	byID := make(map[string]models.FactoryQuestion, len(questions))
+	for _, question := range questions {
+		byID[question.ID] = question
+	}
+
+	answers := make([]models.OnboardingAnswer, 0, len(inputs))
+	now := time.Now().UnixMilli()
+	for _, input := range inputs {
+		question, ok := byID[input.QuestionID]
+		if !ok {
+			return Onboarding{}, fmt.Errorf("%w: unknown question %q", ErrInvalidAnswer, input.QuestionID)
+		}
+		values, err := normalizeAnswer(question, input.values())
+		if err != nil {
+			return Onboarding{}, err
+		}
+		answers = append(answers, models.OnboardingAnswer{QuestionID: question.ID, Values: values, UpdatedAt: now})
+	}
+	for _, answer := range answers {
+		if len(answer.Values) == 0 {
+			err = s.Store.DeleteOnboardingAnswer(ctx, userID, answer.QuestionID)
+		} else {
+			err = s.Store.UpsertOnboardingAnswer(ctx, userID, answer)
+		}
+		if err != nil {
+			return Onboarding{}, err
+		}
+	}
+	return s.GetOnboarding(ctx, userID, loc)
+}
+
+// OnboardingPhrases stores the given answers, then creates the phrases of
+// all stored answers using the question templates in loc, and marks the
+// user as inited. Phrases the category already has are skipped, so
+// regenerate can re-run it after onboarding. Questions with inline phrases
+// or category are used as given and not stored. It returns how many
+// phrases were created.
+func (s *Service) OnboardingPhrases(ctx context.Context, userID string, questions []QuestionInput, regenerate bool, loc string) (int, error) {
+	state, err := s.GetUserState(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if state.Inited && !regenerate {
+		return 0, nil
+	}
+
+	factory, err := s.ListFactoryQuestions(ctx, loc)
+	if err != nil {
+		return 0, err
+	}
+	factoryByID := make(map[string]models.FactoryQuestion, len(factory))
+	for _, q := range factory {
+		factoryByID[q.ID] = q
+	}
+
+	var stored []AnswerInput
+	var inline []models.FactoryQuestion
+	inlineAnswers := make(map[string][]string)
+	for i, q := range questions {
+		questionID := q.QuestionID
+		if questionID == "" {
+			questionID = q.UID
+		}
+		input := AnswerInput{QuestionID: questionID, Value: q.Value, Values: q.Values}
+		tmpl, ok := factoryByID[questionID]
+		if ok && q.Phrases == nil && q.Category == "" {
+			stored = append(stored, input)
+			continue
+		}
+		if q.Phrases != nil {
+			tmpl.Phrases = q.Phrases
+		}
+		if q.Category != "" {
+			tmpl.Category = q.Category
+		}
+		tmpl.ID, tmpl.Type, tmpl.Options, tmpl.Placeholder, tmpl.ShowIf = "inline-"+strconv.Itoa(i), QuestionText, nil, "", nil
+		values, err := normalizeAnswer(tmpl, input.values())
+		if err != nil {
+			return 0, err
+		}
+		inline = append(inline, tmpl)
+		inlineAnswers[tmpl.ID] = values
+	}
+	if len(stored) > 0 {
+		if _, err := s.SaveOnboardingAnswers(ctx, userID, stored, loc); err != nil {
+			return 0, err
+		}
+	}
+
+	answers, err := s.Store.ListOnboardingAnswers(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	phrases := planOnboardingPhrases(factory, answerValues(answers))
+	phrases = append(phrases, planOnboardingPhrases(inline, inlineAnswers)...)
+	created, err := s.createOnboardingPhrases(ctx, userID, phrases)
+	if err != nil {
+		return created, err
+	}
+
+	if !state.Inited {
+		if _, err := s.UpdateUserState(ctx, userID, UserStatePatch{Inited: boolPtr(true)}); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// createOnboardingPhrases adds the phrases to categories matched by label,
+// creating missing ones, and skips phrases a category already has.
+func (s *Service) createOnboardingPhrases(ctx context.Context, userID string, phrases []onboardingPhrase) (int, error) {
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	categoryByLabel := make(map[string]models.Category, len(categories))
+	for _, cat := range categories {
+		categoryByLabel[cat.Label] = cat
+	}
+
+	existing := make(map[string]bool)
+	loaded := make(map[string]bool)
+	created := 0
+	for _, phrase := range phrases {
+		catID, err := s.getOrCreateCategory(ctx, userID, phrase.Category, categoryByLabel)
+		if err != nil {
+			return created, err
+		}
+		if !loaded[catID] {
+			statements, err := s.listStatements(ctx, userID, catID)
+			if err != nil {
+				return created, err
+			}
+			for _, stmt := range statements {
+				existing[statementDedupKey(catID, stmt.Text)] = true
+			}
+			loaded[catID] = true
+		}
+		key := statementDedupKey(catID, phrase.Text)
+		if existing[key] {
+			continue
+		}
+		if _, err := s.CreateStatement(ctx, userID, StatementInput{CategoryID: catID, Text: phrase.Text}); err != nil {
+			return created, err
+		}
+		existing[key] = true
+		created++
+	}
+	return created, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+var onboardingQuestions = []models.FactoryQuestion{
+	{ID: "name", Type: QuestionText, Placeholder: "name", Category: "Обо мне", Phrases: []string{"Меня зовут %%"}},
+	{ID: "city", Type: QuestionText, Placeholder: "city", Category: "Обо мне", Phrases: []string{"Я {name} из {city}"}},
+	{ID: "pets", Type: QuestionSingle, Options: []models.QuestionOption{{Value: "yes", Label: "Да"}, {Value: "no", Label: "Нет"}}},
+	{ID: "pet_kind", Type: QuestionMulti, Category: "Питомцы", Phrases: []string{"У меня есть %%"},
+		Options: []models.QuestionOption{{Value: "cat", Label: "кошка"}, {Value: "dog", Label: "собака"}},
+		ShowIf:  &models.QuestionCondition{QuestionID: "pets", Values: []string{"yes"}}},
+	{ID: "pet_name", Type: QuestionText, Category: "Питомцы", Phrases: []string{"Моего питомца зовут %%", "{missing} ждёт"},
+		ShowIf: &models.QuestionCondition{QuestionID: "pet_kind"}},
+}
+
+func TestNormalizeAnswer(t *testing.T) {
+	byID := make(map[string]models.FactoryQuestion)
+	for _, q := range onboardingQuestions {
+		byID[q.ID] = q
+	}
+	got, err := normalizeAnswer(byID["pet_kind"], []string{"dog", " cat "})
+	if err != nil || !slices.Equal(got, []string{"cat", "dog"}) {
+		t.Fatalf("multi answer: %v %v", got, err)
+	}
+	if got, err := normalizeAnswer(byID["name"], []string{"  "}); err != nil || got != nil {
+		t.Fatalf("blank answer should clear: %v %v", got, err)
+	}
+
+	invalid := []struct {
+		question models.FactoryQuestion
+		values   []string
+	}{
+		{byID["pets"], []string{"yes", "no"}},
+		{byID["pet_kind"], []string{"fish"}},
+		{models.FactoryQuestion{ID: "age", Type: QuestionNumber}, []string{"ten"}},
+		{models.FactoryQuestion{ID: "born", Type: QuestionDate}, []string{"01.02.2010"}},
+	}
+	for _, tc := range invalid {
+		if _, err := normalizeAnswer(tc.question, tc.values); !errors.Is(err, ErrInvalidAnswer) {
+			t.Errorf("%s %v: expected ErrInvalidAnswer, got %v", tc.question.ID, tc.values, err)
+		}
+	}
+	if got, err := normalizeAnswer(models.FactoryQuestion{ID: "age", Type: QuestionNumber}, []string{"7,5"}); err != nil || got[0] != "7,5" {
+		t.Fatalf("number answer: %v %v", got, err)
+	}
+	if got, err := normalizeAnswer(models.FactoryQuestion{ID: "color", Type: QuestionSingle}, []string{"синий"}); err != nil || got[0] != "синий" {
+		t.Fatalf("choice without options should take text: %v %v", got, err)
+	}
+}
+
+func TestVisibleQuestions(t *testing.T) {
+	ids := func(questions []models.FactoryQuestion) []string {
+		var out []string
+		for _, q := range questions {
+			out = append(out, q.ID)
+		}
+		return out
+	}
+	if got := ids(visibleQuestions(onboardingQuestions, nil)); !slices.Equal(got, []string{"name", "city", "pets"}) {
+		t.Fatalf("unexpected visible questions: %v", got)
+	}
+	answers := map[string][]string{"pets": {"yes"}, "pet_kind": {"cat"}}
+	if got := ids(visibleQuestions(onboardingQuestions, answers)); len(got) != 5 {
+		t.Fatalf("expected follow-ups shown, got %v", got)
+	}
+	answers["pets"] = []string{"no"}
+	if got := ids(visibleQuestions(onboardingQuestions, answers)); len(got) != 3 {
+		t.Fatalf("expected follow-ups hidden with their parent, got %v", got)
+	}
+}
+
+func TestPlanOnboardingPhrases(t *testing.T) {
+	answers := map[string][]string{
+		"name":     {"Аня"},
+		"pets":     {"yes"},
+		"pet_kind": {"cat", "dog"},
+		"pet_name": {"Барсик"},
+	}
+	var got []string
+	for _, phrase := range planOnboardingPhrases(onboardingQuestions, answers) {
+		got = append(got, phrase.Category+": "+phrase.Text)
+	}
+	want := []string{
+		"Обо мне: Меня зовут Аня",
+		"Питомцы: У меня есть кошка",
+		"Питомцы: У меня есть собака",
+		"Питомцы: Моего питомца зовут Барсик",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected phrases:\n%v\nwant\n%v", got, want)
+	}
+
+	answers["city"] = []string{"Алматы"}
+	phrases := planOnboardingPhrases(onboardingQuestions, answers)
+	if phrases[1].Text != "Я Аня из Алматы" {
+		t.Fatalf("expected placeholders filled, got %q", phrases[1].Text)
+	}
+}
+
+func TestValidateQuestion(t *testing.T) {
+	valid := onboardingQuestions[3]
+	if err := validateQuestion(valid); err != nil {
+		t.Fatalf("valid question: %v", err)
+	}
+	invalid := []models.FactoryQuestion{
+		{ID: "a", Type: "slider"},
+		{ID: "b", Type: QuestionSingle},
+		{ID: "c", Type: QuestionMulti, Options: []models.QuestionOption{{Value: "x", Label: "X"}, {Value: "x", Label: "Y"}}},
+		{ID: "d", Placeholder: "my city"},
+		{ID: "e", ShowIf: &models.QuestionCondition{QuestionID: "e"}},
+	}
+	for _, q := range invalid {
+		if err := validateQuestion(q); !errors.Is(err, ErrInvalidQuestion) {
+			t.Errorf("%s: expected ErrInvalidQuestion, got %v", q.ID, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/rank"
+)
+
+// ErrInvalidOrder is returned when a reorder request references unknown or duplicate items.
+var ErrInvalidOrder = errors.New("invalid order")
+
+// ReorderInput describes a reorder request. Either IDs lists the new order
+// (unlisted items keep their relative order after them), or ID is moved
+// directly after AfterID (to the front when AfterID is empty).
+type ReorderInput struct {
+	IDs     []string
+	ID      string
+	AfterID string
+}
+
+type orderedItem struct {
+	ID       string
+	Position string
+}
+
+// ReorderCategories changes the order of the user's categories.
+func (s *Service) ReorderCategories(ctx context.Context, userID string, input ReorderInput) ([]models.Category, error) {
+	mirror := s.legacyWriter(ctx, userID)
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]orderedItem, len(categories))
+	for i, cat := range categories {
+		items[i] = orderedItem{ID: cat.ID, Position: cat.Position}
+	}
+	changed, err := planPositions(items, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return categories, nil
+	}
+
+	updatedAt := time.Now().UnixMilli()
+	if err := s.Store.SetCategoryPositions(ctx, userID, changed, updatedAt); err != nil {
+		return nil, err
+	}
+	if mirror != nil {
+		if err := mirror.SetCategoryPositions(ctx, userID, changed); err != nil {
+			return nil, err
+		}
+	}
+	_ = s.appendChange(ctx, userID, "category_order", userID, "reorder", models.OrderChange{Positions: changed}, updatedAt)
+
+	for i := range categories {
+		if position, ok := changed[categories[i].ID]; ok {
+			categories[i].Position = position
+			categories[i].UpdatedAt = updatedAt
+		}
+	}
+	sortCategories(categories)
+	return categories, nil
+}
+
+// ReorderStatements changes the order of statements within a category.
+func (s *Service) ReorderStatements(ctx context.Context, userID, categoryID string, input ReorderInput) ([]models.Statement, error) {
+	mirror := s.legacyWriter(ctx, userID)
+	if _, err := s.findCategory(ctx, userID, categoryID); err != nil {
+		return nil, err
+	}
+	statements, err := s.ListStatements(ctx, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]orderedItem, len(statements))
+	for i, stmt := range statements {
+		items[i] = orderedItem{ID: stmt.ID, Position: stmt.Position}
+	}
+	changed, err := planPositions(items, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return statements, nil
+	}
+
+	updatedAt := time.Now().UnixMilli()
+	if err := s.Store.SetStatementPositions(ctx, userID, categoryID, changed, updatedAt); err != nil {
+		return nil, err
+	}
+	if mirror != nil {
+		if err := mirror.SetStatementPositions(ctx, userID, categoryID, changed); err != nil {
+			return nil, err
+		}
+	}
+	_ = s.appendChange(ctx, userID, "statement_order", categoryID, "reorder", models.OrderChange{CategoryID: categoryID, Positions: changed}, updatedAt)
+
+	for i := range statements {
+		if position, ok := changed[statements[i].ID]; ok {
+			statements[i].Position = position
+			statements[i].UpdatedAt = updatedAt
+		}
+	}
+	sortStatements(statements)
+	return statements, nil
+}
+
+// planPositions returns the position keys that must change for items,
+// given in their current order, to follow input. A single move rewrites one
+// key when the neighbours are already ranked; otherwise the list is re-spread.
+func planPositions(items []orderedItem, input ReorderInput) (map[string]string, error) {
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+	}
+
+	var order []orderedItem
+	if len(input.IDs) > 0 {
+		seen := make(map[string]bool, len(input.IDs))
+		for _, id := range input.IDs {
+			i, ok := index[id]
+			if !ok || seen[id] {
+				return nil, ErrInvalidOrder
+			}
+			seen[id] = true
+			order = append(order, items[i])
+		}
+		for _, item := range items {
+			if !seen[item.ID] {
+				order = append(order, item)
+			}
+		}
+	} else {
+		from, ok := index[input.ID]
+		if !ok || input.ID == input.AfterID {
+			return nil, ErrInvalidOrder
+		}
+		if _, ok := index[input.AfterID]; input.AfterID != "" && !ok {
+			return nil, ErrInvalidOrder
+		}
+
+		moved := items[from]
+		rest := make([]orderedItem, 0, len(items))
+		for _, item := range items {
+			if item.ID != moved.ID {
+				rest = append(rest, item)
+			}
+		}
+		at := 0
+		for i, item := range rest {
+			if item.ID == input.AfterID {
+				at = i + 1
+				break
+			}
+		}
+		order = append(append(append(order, rest[:at]...), moved), rest[at:]...)
+
+		if ranked(rest) {
+			lo, hi := "", ""
+			if at > 0 {
+				lo = rest[at-1].Position
+			}
+			if at < len(rest) {
+				hi = rest[at].Position
+			}
+			if moved.Position != "" && (lo == "" || lo < moved.Position) && (hi == "" || moved.Position < hi) {
+				return nil, nil
+			}
+			position, err := rank.Between(lo, hi)
+			if err == nil {
+				return map[string]string{moved.ID: position}, nil
+			}
+		}
+	}
+
+	if ranked(order) {
+		return nil, nil
+	}
+	changed := make(map[string]string)
+	for i, key := range rank.Sequence(len(order)) {
+		if order[i].Position != key {
+			changed[order[i].ID] = key
+		}
+	}
+	return changed, nil
+}
+
+// ranked reports whether every item has a valid position in ascending order.
+func ranked(items []orderedItem) bool {
+	for i, item := range items {
+		if !rank.Valid(item.Position) {
+			return false
+		}
+		if i > 0 && items[i-1].Position >= item.Position {
+			return false
+		}
+	}
+	return true
+}
+
+func sortCategories(categories []models.Category) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if a.Position != b.Position {
+			return rank.Less(a.Position, b.Position)
+		}
+		return a.Created < b.Created
+	})
+}
+
+func sortStatements(statements []models.Statement) {
+	sort.SliceStable(statements, func(i, j int) bool {
+		a, b := statements[i], statements[j]
+		if a.Position != b.Position {
+			return rank.Less(a.Position, b.Position)
+		}
+		return a.Created < b.Created
+	})
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+func applyPlan(items []orderedItem, changed map[string]string) []orderedItem {
+	out := make([]orderedItem, len(items))
+	for i, item := range items {
+		if position, ok := changed[item.ID]; ok {
+			item.Position = position
+		}
+		out[i] = item
+	}
+	return out
+}
+
+func orderOf(items []orderedItem) string {
+	sorted := append([]orderedItem(nil), items...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+	out := ""
+	for _, item := range sorted {
+		out += item.ID
+	}
+	return out
+}
+
+func TestPlanPositionsFullOrderSpreadsUnranked(t *testing.T) {
+	items := []orderedItem{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	changed, err := planPositions(items, ReorderInput{IDs: []string{"c", "a"}})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(changed) != 3 {
+		t.Fatalf("expected every item to get a position, got %v", changed)
+	}
+	if got := orderOf(applyPlan(items, changed)); got != "cab" {
+		t.Fatalf("expected order cab, got %s", got)
+	}
+}
+
+func TestPlanPositionsMoveRewritesOneKey(t *testing.T) {
+	items := []orderedItem{{ID: "a", Position: "5"}, {ID: "b", Position: "i"}, {ID: "c", Position: "t"}}
+
+	changed, err := planPositions(items, ReorderInput{ID: "c", AfterID: "a"})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(changed) != 1 || changed["c"] == "" {
+		t.Fatalf("expected only c to move, got %v", changed)
+	}
+	if got := orderOf(applyPlan(items, changed)); got != "acb" {
+		t.Fatalf("expected order acb, got %s", got)
+	}
+
+	changed, err = planPositions(items, ReorderInput{ID: "b"})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if got := orderOf(applyPlan(items, changed)); got != "bac" {
+		t.Fatalf("expected order bac, got %s", got)
+	}
+}
+
+func TestPlanPositionsNoop(t *testing.T) {
+	items := []orderedItem{{ID: "a", Position: "5"}, {ID: "b", Position: "i"}}
+	changed, err := planPositions(items, ReorderInput{ID: "b", AfterID: "a"})
+	if err != nil || len(changed) != 0 {
+		t.Fatalf("expected no changes, got %v (%v)", changed, err)
+	}
+}
+
+func TestPlanPositionsRejectsUnknownIDs(t *testing.T) {
+	items := []orderedItem{{ID: "a"}, {ID: "b"}}
+	for _, input := range []ReorderInput{
+		{IDs: []string{"a", "a"}},
+		{IDs: []string{"x"}},
+		{ID: "x"},
+		{ID: "a", AfterID: "a"},
+		{ID: "a", AfterID: "x"},
+	} {
+		if _, err := planPositions(items, input); !errors.Is(err, ErrInvalidOrder) {
+			t.Fatalf("expected ErrInvalidOrder for %+v, got %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// Organization roles. Owners manage members and the library, therapists
+// manage the library and clients, and clients receive library categories.
+const (
+	OrgOwner     = "owner"
+	OrgTherapist = "therapist"
+	OrgClient    = "client"
+)
+
+// Organization membership states.
+const (
+	OrgInvited = "invited"
+	OrgActive  = "active"
+)
+
+var (
+	ErrInvalidOrg   = errors.New("name, role or library entry is invalid")
+	ErrOrgForbidden = errors.New("not allowed for this organization role")
+	ErrLastOwner    = errors.New("organization needs at least one owner")
+)
+
+// OrgMembership is an organization as seen by one of its members.
+type OrgMembership struct {
+	models.Organization
+	Role   string `json:"role"`
+	Status string `json:"status"`
+}
+
+// OrgLibrary is an organization's shared categories and statements.
+type OrgLibrary struct {
+	Categories []models.Category  `json:"categories"`
+	Statements []models.Statement `json:"statements"`
+}
+
+// OrgCategoryInput is a library category as written by staff.
+type OrgCategoryInput struct {
+	Label    string `json:"label"`
+	ParentID string `json:"parentId"`
+	Color    string `json:"color"`
+	Icon     string `json:"icon"`
+}
+
+// OrgStatementInput is a library statement as written by staff.
+type OrgStatementInput struct {
+	CategoryID string `json:"categoryId"`
+	Text       string `json:"text"`
+	Color      string `json:"color"`
+	Icon       string `json:"icon"`
+}
+
+// OrgAssignment is the outcome of copying one library category to a client.
+type OrgAssignment struct {
+	UserID     string `json:"userId"`
+	CategoryID string `json:"categoryId"`
+	Status     string `json:"status"`
+}
+
+// CreateOrganization creates an organization owned by the user.
+func (s *Service) CreateOrganization(ctx context.Context, userID, name string) (OrgMembership, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return OrgMembership{}, ErrInvalidOrg
+	}
+	now := time.Now().UnixMilli()
+	org := models.Organization{ID: id.NewShort(), Name: name, Created: now, UpdatedAt: now}
+	if err := s.Store.UpsertOrganization(ctx, org); err != nil {
+		return OrgMembership{}, err
+	}
+	owner := models.OrgMember{OrgID: org.ID, UserID: userID, Role: OrgOwner, Status: OrgActive, Created: now}
+	if err := s.Store.UpsertOrgMember(ctx, owner); err != nil {
+		return OrgMembership{}, err
+	}
+	return OrgMembership{Organization: org, Role: OrgOwner, Status: OrgActive}, nil
+}
+
+// ListOrganizations returns the organizations the user belongs to or is
+// invited to.
+func (s *Service) ListOrganizations(ctx context.Context, userID string) ([]OrgMembership, error) {
+	members, err := s.Store.ListUserOrgMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]OrgMembership, 0, len(members))
+	for _, member := range members {
+		org, err := s.Store.GetOrganization(ctx, member.OrgID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, OrgMembership{Organization: org, Role: member.Role, Status: member.Status})
+	}
+	return out, nil
+}
+
+// orgMember returns the user's active membership, failing with ErrNotFound
+// for outsiders and ErrOrgForbidden for roles not listed.
+func (s *Service) orgMember(ctx context.Context, orgID, userID string, roles ...string) (models.OrgMember, error) {
+	member, err := s.Store.GetOrgMember(ctx, orgID, userID)
+	if err != nil {
+		return models.OrgMember{}, err
+	}
+	if member.Status != OrgActive {
+		return models.OrgMember{}, store.ErrNotFound
+	}
+	if len(roles) > 0 && !slices.Contains(roles, member.Role) {
+		return models.OrgMember{}, ErrOrgForbidden
+	}
+	return member, nil
+}
+
+// ListOrgMembers returns all members of the organization to its staff.
+func (s *Service) ListOrgMembers(ctx context.Context, userID, orgID string) ([]models.OrgMember, error) {
+	if _, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist); err != nil {
+		return nil, err
+	}
+	members, err := s.Store.ListOrgMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.OrgMember{}, members...), nil
+}
+
+// SetOrgMember invites a user with the role, or changes the role of an
+// existing member. Therapists may only manage clients.
+func (s *Service) SetOrgMember(ctx context.Context, userID, orgID, memberID, role string) (models.OrgMember, error) {
+	memberID = strings.TrimSpace(memberID)
+	if memberID == "" || (role != OrgOwner && role != OrgTherapist && role != OrgClient) {
+		return models.OrgMember{}, ErrInvalidOrg
+	}
+	actor, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist)
+	if err != nil {
+		return models.OrgMember{}, err
+	}
+	member, err := s.Store.GetOrgMember(ctx, orgID, memberID)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		member = models.OrgMember{OrgID: orgID, UserID: memberID, Status: OrgInvited, Created: time.Now().UnixMilli()}
+	case err != nil:
+		return models.OrgMember{}, err
+	}
+	if actor.Role != OrgOwner && (role != OrgClient || (member.Role != "" && member.Role != OrgClient)) {
+		return models.OrgMember{}, ErrOrgForbidden
+	}
+	if member.Role == OrgOwner && role != OrgOwner {
+		if err := s.keepOwner(ctx, orgID, memberID); err != nil {
+			return models.OrgMember{}, err
+		}
+	}
+	member.Role = role
+	if err := s.Store.UpsertOrgMember(ctx, member); err != nil {
+		return models.OrgMember{}, err
+	}
+	return member, nil
+}
+
+// AcceptOrgInvite activates the user's pending membership.
+func (s *Service) AcceptOrgInvite(ctx context.Context, userID, orgID string) (models.OrgMember, error) {
+	member, err := s.Store.GetOrgMember(ctx, orgID, userID)
+	if err != nil {
+		return models.OrgMember{}, err
+	}
+	if member.Status == OrgActive {
+		return member, nil
+	}
+	member.Status = OrgActive
+	if err := s.Store.UpsertOrgMember(ctx, member); err != nil {
+		return models.OrgMember{}, err
+	}
+	return member, nil
+}
+
+// RemoveOrgMember removes a member. Anyone may leave or decline an invite;
+// therapists may only remove clients.
+func (s *Service) RemoveOrgMember(ctx context.Context, userID, orgID, memberID string) error {
+	member, err := s.Store.GetOrgMember(ctx, orgID, memberID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID {
+		actor, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist)
+		if err != nil {
+			return err
+		}
+		if actor.Role != OrgOwner && member.Role != OrgClient {
+			return ErrOrgForbidden
+		}
+	}
+	if member.Role == OrgOwner {
+		if err := s.keepOwner(ctx, orgID, memberID); err != nil {
+			return err
+		}
+	}
+	return s.Store.DeleteOrgMember(ctx, orgID, memberID)
+}
+
+// keepOwner fails when memberID is the organization's only active owner.
+func (s *Service) keepOwner(ctx context.Context, orgID, memberID string) error {
+	members, err := s.Store.ListOrgMembers(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.UserID != memberID && member.Role == OrgOwner && member.Status == OrgActive {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
+
+// GetOrgLibrary returns the organization's library to any active member.
+func (s *Service) GetOrgLibrary(ctx context.Context, userID, orgID string) (OrgLibrary, error) {
+	if _, err := s.orgMember(ctx, orgID, userID); err != nil {
+		return OrgLibrary{}, err
+	}
+	return s.orgLibrary(ctx, orgID)
+}
+
+func (s *Service) orgLibrary(ctx context.Context, orgID string) (OrgLibrary, error) {
+	categories, err := s.Store.ListOrgCategories(ctx, orgID)
+	if err != nil {
+		return OrgLibrary{}, err
+	}
+	statements, err := s.Store.ListOrgStatements(ctx, orgID)
+	if err != nil {
+		return OrgLibrary{}, err
+	}
+	return OrgLibrary{
+		Categories: append([]models.Category{}, categories...),
+		Statements: append([]models.Statement{}, statements...),
+	}, nil
+}
+
+// SaveOrgCategory creates a library category, or replaces one when
+// categoryID is set.
+func (s *Service) SaveOrgCategory(ctx context.Context, userID, orgID, categoryID string, input OrgCategoryInput) (models.Category, error) {
+	input.Label = strings.TrimSpace(input.Label)
+	if input.Label == "" {
+		return models.Category{}, ErrInvalidOrg
+	}
+	if _, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist); err != nil {
+		return models.Category{}, err
+	}
+	if err := s.validateVisual(ctx, userID, input.Color, input.Icon, ""); err != nil {
+		return models.Category{}, err
+	}
+	categories, err := s.Store.ListOrgCategories(ctx, orgID)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	now := time.Now().UnixMilli()
+	category := models.Category{ID: categoryID, Created: now}
+	if categoryID == "" {
+		category.ID = id.NewShort()
+	} else {
+		index := slices.IndexFunc(categories, func(cat models.Category) bool { return cat.ID == categoryID })
+		if index < 0 {
+			return models.Category{}, store.ErrNotFound
+		}
+		category.Created = categories[index].Created
+	}
+	if err := validateParent(categoryParents(categories), category.ID, input.ParentID); err != nil {
+		return models.Category{}, err
+	}
+	category.Label, category.ParentID = input.Label, input.ParentID
+	category.Color, category.Icon = input.Color, input.Icon
+	category.UpdatedAt = now
+	if err := s.Store.UpsertOrgCategory(ctx, orgID, category); err != nil {
+		return models.Category{}, err
+	}
+	return category, nil
+}
+
+// DeleteOrgCategory removes a library category with its sub-categories and
+// statements. Copies already assigned to clients stay.
+func (s *Service) DeleteOrgCategory(ctx context.Context, userID, orgID, categoryID string) error {
+	if _, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist); err != nil {
+		return err
+	}
+	categories, err := s.Store.ListOrgCategories(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	parents := categoryParents(categories)
+	if _, ok := parents[categoryID]; !ok {
+		return store.ErrNotFound
+	}
+	return s.Store.DeleteOrgCategories(ctx, orgID, append(descendantIDs(parents, categoryID), categoryID))
+}
+
+// SaveOrgStatement creates a library statement, or replaces one when
+// statementID is set.
+func (s *Service) SaveOrgStatement(ctx context.Context, userID, orgID, statementID string, input OrgStatementInput) (models.Statement, error) {
+	input.Text = strings.TrimSpace(input.Text)
+	if input.Text == "" || input.CategoryID == "" {
+		return models.Statement{}, ErrInvalidOrg
+	}
+	if _, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist); err != nil {
+		return models.Statement{}, err
+	}
+	if err := s.validateVisual(ctx, userID, input.Color, input.Icon, ""); err != nil {
+		return models.Statement{}, err
+	}
+	library, err := s.orgLibrary(ctx, orgID)
+	if err != nil {
+		return models.Statement{}, err
+	}
+	if !slices.ContainsFunc(library.Categories, func(cat models.Category) bool { return cat.ID == input.CategoryID }) {
+		return models.Statement{}, ErrInvalidOrg
+	}
+
+	now := time.Now().UnixMilli()
+	statement := models.Statement{ID: statementID, Created: now}
+	if statementID == "" {
+		statement.ID = id.NewShort()
+	} else {
+		index := slices.IndexFunc(library.Statements, func(stmt models.Statement) bool { return stmt.ID == statementID })
+		if index < 0 {
+			return models.Statement{}, store.ErrNotFound
+		}
+		statement.Created = library.Statements[index].Created
+	}
+	statement.CategoryID, statement.Text = input.CategoryID, input.Text
+	statement.Color, statement.Icon = input.Color, input.Icon
+	statement.UpdatedAt = now
+	if err := s.Store.UpsertOrgStatement(ctx, orgID, statement); err != nil {
+		return models.Statement{}, err
+	}
+	return statement, nil
+}
+
+// DeleteOrgStatement removes a library statement.
+func (s *Service) DeleteOrgStatement(ctx context.Context, userID, orgID, statementID string) error {
+	if _, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist); err != nil {
+		return err
+	}
+	return s.Store.DeleteOrgStatement(ctx, orgID, statementID)
+}
+
+// AssignOrgCategories copies library categories, with their sub-categories
+// and statements, into the boards of active clients under the library IDs.
+// A client who already has a category gets "exists" unless force is set;
+// forcing brings their copy back in line with the library.
+func (s *Service) AssignOrgCategories(ctx context.Context, userID, orgID string, categoryIDs, clientIDs []string, force bool) ([]OrgAssignment, error) {
+	if len(categoryIDs) == 0 || len(clientIDs) == 0 {
+		return nil, ErrInvalidOrg
+	}
+	if _, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist); err != nil {
+		return nil, err
+	}
+	for _, clientID := range clientIDs {
+		if _, err := s.orgMember(ctx, orgID, clientID, OrgClient); err != nil {
+			return nil, ErrInvalidOrg
+		}
+	}
+	library, err := s.orgLibrary(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	trees := make([]OrgLibrary, len(categoryIDs))
+	for i, categoryID := range categoryIDs {
+		trees[i] = libraryTree(library, categoryID)
+		if len(trees[i].Categories) == 0 {
+			return nil, store.ErrNotFound
+		}
+	}
+
+	out := make([]OrgAssignment, 0, len(categoryIDs)*len(clientIDs))
+	for _, clientID := range clientIDs {
+		for i, categoryID := range categoryIDs {
+			assignment := OrgAssignment{UserID: clientID, CategoryID: categoryID, Status: "ok"}
+			if !force {
+				if _, err := s.findCategory(ctx, clientID, categoryID); err == nil {
+					assignment.Status = "exists"
+					out = append(out, assignment)
+					continue
+				} else if !errors.Is(err, store.ErrNotFound) {
+					return nil, err
+				}
+			}
+			if err := s.copyTree(ctx, clientID, trees[i].Categories, trees[i].Statements, nil); err != nil {
+				return nil, err
+			}
+			out = append(out, assignment)
+		}
+	}
+	return out, nil
+}
+
+// libraryTree returns the library category rootID with its descendants,
+// parents first, and their statements.
+func libraryTree(library OrgLibrary, rootID string) OrgLibrary {
+	categories := categorySubtree(library.Categories, rootID)
+	inTree := make(map[string]bool, len(categories))
+	for _, cat := range categories {
+		inTree[cat.ID] = true
+	}
+	statements := []models.Statement{}
+	for _, stmt := range library.Statements {
+		if inTree[stmt.CategoryID] {
+			statements = append(statements, stmt)
+		}
+	}
+	sortStatements(statements)
+	return OrgLibrary{Categories: categories, Statements: statements}
+}
+
+// OrgStats holds an organization's dashboard counters.
+type OrgStats struct {
+	TotalStaff        int64  `json:"total_staff"`
+	TotalClients      int64  `json:"total_clients"`
+	NewClients        int64  `json:"new_clients"`
+	PendingInvites    int64  `json:"pending_invites"`
+	LibraryCategories int    `json:"library_categories"`
+	LibraryStatements int    `json:"library_statements"`
+	TotalCategories   int64  `json:"total_categories"`
+	TotalStatements   int64  `json:"total_statements"`
+	Since             string `json:"since"`
+	WindowSeconds     int    `json:"window_seconds"`
+}
+
+// OrgStats returns dashboard counters for the organization, in the style of
+// AdminStats. Board counters cover active clients and count items created
+// since the given time.
+func (s *Service) OrgStats(ctx context.Context, userID, orgID string, since time.Time) (OrgStats, error) {
+	if _, err := s.orgMember(ctx, orgID, userID, OrgOwner, OrgTherapist); err != nil {
+		return OrgStats{}, err
+	}
+	members, err := s.Store.ListOrgMembers(ctx, orgID)
+	if err != nil {
+		return OrgStats{}, err
+	}
+	library, err := s.orgLibrary(ctx, orgID)
+	if err != nil {
+		return OrgStats{}, err
+	}
+
+	stats, clientIDs := orgMemberStats(members, since.UnixMilli())
+	stats.TotalCategories, stats.TotalStatements, err = s.Store.CountBoardItems(ctx, clientIDs, since)
+	if err != nil {
+		return OrgStats{}, err
+	}
+	stats.LibraryCategories = len(library.Categories)
+	stats.LibraryStatements = len(library.Statements)
+	stats.Since = since.Format(time.RFC3339)
+	stats.WindowSeconds = int(time.Since(since).Seconds())
+	return stats, nil
+}
+
+// orgMemberStats fills the member counters of OrgStats and returns the ids
+// of active clients, whose boards the stats cover.
+func orgMemberStats(members []models.OrgMember, sinceMs int64) (OrgStats, []string) {
+	var stats OrgStats
+	var clientIDs []string
+	for _, member := range members {
+		if member.Status != OrgActive {
+			stats.PendingInvites++
+			continue
+		}
+		switch member.Role {
+		case OrgOwner, OrgTherapist:
+			stats.TotalStaff++
+			continue
+		}
+		stats.TotalClients++
+		if member.Created >= sinceMs {
+			stats.NewClients++
+		}
+		clientIDs = append(clientIDs, member.UserID)
+	}
+	return stats, clientIDs
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestLibraryTree(t *testing.T) {
+	library := OrgLibrary{
+		Categories: []models.Category{
+			{ID: "food"},
+			{ID: "fruit", ParentID: "food"},
+			{ID: "greetings"},
+		},
+		Statements: []models.Statement{
+			{ID: "s1", CategoryID: "fruit", Text: "Apple", Created: 2},
+			{ID: "s2", CategoryID: "greetings", Text: "Hello", Created: 1},
+			{ID: "s3", CategoryID: "food", Text: "I am hungry", Created: 3},
+		},
+	}
+	tree := libraryTree(library, "food")
+	if len(tree.Categories) != 2 || tree.Categories[0].ID != "food" || tree.Categories[1].ID != "fruit" {
+		t.Fatalf("expected food, fruit; got %+v", tree.Categories)
+	}
+	if len(tree.Statements) != 2 {
+		t.Fatalf("expected the two food statements, got %+v", tree.Statements)
+	}
+	for _, stmt := range tree.Statements {
+		if stmt.CategoryID == "greetings" {
+			t.Fatalf("statement outside the tree copied: %+v", stmt)
+		}
+	}
+	if tree := libraryTree(library, "missing"); len(tree.Categories) != 0 {
+		t.Fatalf("expected nothing for an unknown root, got %+v", tree.Categories)
+	}
+}
+
+func TestOrgMemberStats(t *testing.T) {
+	members := []models.OrgMember{
+		{UserID: "owner", Role: OrgOwner, Status: OrgActive, Created: 1},
+		{UserID: "therapist", Role: OrgTherapist, Status: OrgActive, Created: 1},
+		{UserID: "old", Role: OrgClient, Status: OrgActive, Created: 5},
+		{UserID: "new", Role: OrgClient, Status: OrgActive, Created: 20},
+		{UserID: "invited", Role: OrgClient, Status: OrgInvited, Created: 30},
+	}
+
+	stats, clientIDs := orgMemberStats(members, 10)
+	if stats.TotalStaff != 2 || stats.TotalClients != 2 || stats.NewClients != 1 || stats.PendingInvites != 1 {
+		t.Fatalf("unexpected member counters: %+v", stats)
+	}
+	if len(clientIDs) != 2 || clientIDs[0] != "old" || clientIDs[1] != "new" {
+		t.Fatalf("expected active client ids [old new], got %v", clientIDs)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// ErrPreconditionFailed is returned when an If-Match write names a version
+// that is no longer current, or the entity is gone.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// IfMatch is the precondition of a category or statement write. Any accepts
+// every live version (If-Match: *); otherwise the entity's updated_at must be
+// one of Versions. The zero value means no precondition.
+type IfMatch struct {
+	Any      bool
+	Versions []int64
+}
+
+func (m IfMatch) active() bool {
+	return m.Any || m.Versions != nil
+}
+
+// allows reports whether version satisfies the precondition.
+func (m IfMatch) allows(version int64) bool {
+	return !m.active() || m.Any || slices.Contains(m.Versions, version)
+}
+
+// The write helpers below check an active IfMatch against version, the one
+// read before, to fail fast, then let the store compare the stored version
+// in the same transaction as the write.
+
+// writeCategory upserts category under ifMatch.
+func (s *Service) writeCategory(ctx context.Context, userID string, category models.Category, version int64, ifMatch IfMatch) (models.Category, error) {
+	if !ifMatch.active() {
+		return s.Store.UpsertCategory(ctx, userID, category)
+	}
+	if !ifMatch.allows(version) {
+		return models.Category{}, ErrPreconditionFailed
+	}
+	category, err := s.Store.UpsertCategoryIfVersion(ctx, userID, category, ifMatch.Versions)
+	return category, preconditionError(err)
+}
+
+// removeCategory soft-deletes a category under ifMatch.
+func (s *Service) removeCategory(ctx context.Context, userID, categoryID string, version int64, ifMatch IfMatch, updatedAt int64) error {
+	if !ifMatch.active() {
+		return s.Store.DeleteCategory(ctx, userID, categoryID, updatedAt)
+	}
+	if !ifMatch.allows(version) {
+		return ErrPreconditionFailed
+	}
+	return preconditionError(s.Store.DeleteCategoryIfVersion(ctx, userID, categoryID, ifMatch.Versions, updatedAt))
+}
+
+// writeStatement upserts statement under ifMatch, or moves it when it left
+// fromCategoryID.
+func (s *Service) writeStatement(ctx context.Context, userID, fromCategoryID string, statement models.Statement, version int64, ifMatch IfMatch) (models.Statement, error) {
+	moved := statement.CategoryID != fromCategoryID
+	if !ifMatch.active() {
+		if moved {
+			return statement, s.Store.MoveStatement(ctx, userID, fromCategoryID, statement)
+		}
+		return s.Store.UpsertStatement(ctx, userID, statement)
+	}
+	if !ifMatch.allows(version) {
+		return models.Statement{}, ErrPreconditionFailed
+	}
+	if moved {
+		err := s.Store.MoveStatementIfVersion(ctx, userID, fromCategoryID, statement, ifMatch.Versions)
+		return statement, preconditionError(err)
+	}
+	statement, err := s.Store.UpsertStatementIfVersion(ctx, userID, statement, ifMatch.Versions)
+	return statement, preconditionError(err)
+}
+
+// removeStatement soft-deletes a statement under ifMatch.
+func (s *Service) removeStatement(ctx context.Context, userID, categoryID, statementID string, version int64, ifMatch IfMatch, updatedAt int64) error {
+	if !ifMatch.active() {
+		return s.Store.DeleteStatement(ctx, userID, categoryID, statementID, updatedAt)
+	}
+	if !ifMatch.allows(version) {
+		return ErrPreconditionFailed
+	}
+	return preconditionError(s.Store.DeleteStatementIfVersion(ctx, userID, categoryID, statementID, ifMatch.Versions, updatedAt))
+}
+
+func preconditionError(err error) error {
+	if errors.Is(err, store.ErrVersionMismatch) {
+		return ErrPreconditionFailed
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+func TestIfMatchAllows(t *testing.T) {
+	if !(IfMatch{}).allows(5) {
+		t.Fatalf("expected no precondition to allow any version")
+	}
+	if !(IfMatch{Any: true}).allows(5) {
+		t.Fatalf("expected * to allow any version")
+	}
+	m := IfMatch{Versions: []int64{3, 5}}
+	if !m.allows(5) || m.allows(4) {
+		t.Fatalf("expected only listed versions to be allowed")
+	}
+	if (IfMatch{Versions: []int64{}}).allows(5) {
+		t.Fatalf("expected an empty version list to allow nothing")
+	}
+}
+
+func TestConditionalWriteFailsOnStaleRead(t *testing.T) {
+	// A stale version is rejected before the store write is attempted.
+	s := &Service{}
+	ctx := context.Background()
+	stale := IfMatch{Versions: []int64{1}}
+	if _, err := s.writeCategory(ctx, "u1", models.Category{ID: "c1"}, 2, stale); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed for a category write, got %v", err)
+	}
+	if err := s.removeCategory(ctx, "u1", "c1", 2, stale, 3); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed for a category delete, got %v", err)
+	}
+	moved := models.Statement{ID: "s1", CategoryID: "c2"}
+	if _, err := s.writeStatement(ctx, "u1", "c1", moved, 2, stale); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed for a statement move, got %v", err)
+	}
+	if err := s.removeStatement(ctx, "u1", "c1", "s1", 2, stale, 3); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed for a statement delete, got %v", err)
+	}
+}
+
+func TestPreconditionError(t *testing.T) {
+	if err := preconditionError(fmt.Errorf("swap: %w", store.ErrVersionMismatch)); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected a version mismatch to become ErrPreconditionFailed, got %v", err)
+	}
+	if err := preconditionError(store.ErrNotFound); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("expected other errors to pass through, got %v", err)
+	}
+}
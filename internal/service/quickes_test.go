@@ -8,7 +8,7 @@ import (
 
 func TestNormalizeQuickes(t *testing.T) {
 	input := []string{"Привет", "", "Да"}
-	got := normalizeQuickes(input)
+	got := normalizeQuickes(input, "ru")
 	if len(got) != len(defaults.DefaultQuickes) {
 		t.Fatalf("expected %d quickes, got %d", len(defaults.DefaultQuickes), len(got))
 	}
@@ -22,3 +22,10 @@ func TestNormalizeQuickes(t *testing.T) {
 		t.Fatalf("expected custom quickes preserved for slot 2")
 	}
 }
+
+func TestNormalizeQuickesUsesLocale(t *testing.T) {
+	got := normalizeQuickes([]string{"Сәлем"}, "kk")
+	if got[1] != defaults.Quickes("kk")[1] {
+		t.Fatalf("expected kk default for empty slot, got %q", got[1])
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/linkasu/linka.type-backend/internal/device"
+	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// Revision ops.
+const (
+	revisionCreate  = "create"
+	revisionUpdate  = "update"
+	revisionMove    = "move"
+	revisionDelete  = "delete"
+	revisionRestore = "restore"
+	revisionRevert  = "revert"
+)
+
+// maxRevisionScan bounds how far back history and revert look.
+const maxRevisionScan = 200
+
+var (
+	ErrInvalidRevision = errors.New("revision cannot be restored")
+	ErrInvalidCategory = errors.New("target category does not exist")
+)
+
+// recordRevision appends a snapshot of entity to its revision log, tagged
+// with the device of the request.
+func (s *Service) recordRevision(ctx context.Context, userID, entityType, entityID, op string, entity any, at int64) error {
+	if s.Store == nil {
+		return nil
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	return s.Store.AppendRevision(ctx, userID, models.Revision{
+		ID:         id.New(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Op:         op,
+		Snapshot:   data,
+		Device:     device.FromContext(ctx),
+		At:         at,
+	})
+}
+
+// StatementHistory returns the statement's revisions, newest first.
+// Statements created before history was recorded have none.
+func (s *Service) StatementHistory(ctx context.Context, userID, statementID string, limit int) ([]models.StatementRevision, error) {
+	revisions, err := s.Store.ListRevisions(ctx, userID, "statement", statementID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		if _, err := s.findStatement(ctx, userID, statementID); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]models.StatementRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		var snapshot models.Statement
+		if err := json.Unmarshal(revision.Snapshot, &snapshot); err != nil {
+			return nil, err
+		}
+		out = append(out, models.StatementRevision{
+			ID:         revision.ID,
+			Op:         revision.Op,
+			Text:       snapshot.Text,
+			CategoryID: snapshot.CategoryID,
+			Device:     revision.Device,
+			At:         revision.At,
+		})
+	}
+	return out, nil
+}
+
+// RevertStatement brings a live statement back to the text, category and
+// look it had at the given revision.
+func (s *Service) RevertStatement(ctx context.Context, userID, statementID, revisionID string) (models.Statement, error) {
+	revisions, err := s.Store.ListRevisions(ctx, userID, "statement", statementID, maxRevisionScan)
+	if err != nil {
+		return models.Statement{}, err
+	}
+	for _, revision := range revisions {
+		if revision.ID != revisionID {
+			continue
+		}
+		if revision.Op == revisionDelete {
+			return models.Statement{}, ErrInvalidRevision
+		}
+		var snapshot models.Statement
+		if err := json.Unmarshal(revision.Snapshot, &snapshot); err != nil {
+			return models.Statement{}, err
+		}
+		return s.updateStatement(ctx, userID, statementID, s.statementSnapshotPatch(ctx, userID, snapshot), revisionRevert)
+	}
+	return models.Statement{}, store.ErrNotFound
+}
+
+func (s *Service) statementSnapshotPatch(ctx context.Context, userID string, snapshot models.Statement) StatementPatch {
+	imageID := s.knownMediaID(ctx, userID, snapshot.ImageID)
+	return StatementPatch{
+		Text:       &snapshot.Text,
+		CategoryID: &snapshot.CategoryID,
+		Color:      &snapshot.Color,
+		Icon:       &snapshot.Icon,
+		ImageID:    &imageID,
+	}
+}
+
+func (s *Service) categorySnapshotPatch(ctx context.Context, userID string, snapshot models.Category) CategoryPatch {
+	imageID := s.knownMediaID(ctx, userID, snapshot.ImageID)
+	return CategoryPatch{
+		Label:    &snapshot.Label,
+		Default:  snapshot.Default,
+		AIUse:    &snapshot.AIUse,
+		ParentID: &snapshot.ParentID,
+		Color:    &snapshot.Color,
+		Icon:     &snapshot.Icon,
+		ImageID:  &imageID,
+	}
+}
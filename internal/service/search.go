@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/search"
+)
+
+// ErrEmptyQuery is returned when a search query has no letters or digits.
+var ErrEmptyQuery = errors.New("empty search query")
+
+const (
+	// minSearchCoverage drops hits matching less than this share of the query.
+	minSearchCoverage = 0.3
+	// substringBonus favours hits containing the query as typed.
+	substringBonus = 0.5
+	// usageBoost weights how often a statement has been spoken.
+	usageBoost = 0.1
+)
+
+// SearchResult holds ranked statement and category hits.
+type SearchResult struct {
+	Statements []models.StatementHit `json:"statements"`
+	Categories []models.CategoryHit  `json:"categories"`
+}
+
+// Search finds the user's statements and categories matching q, ranked by
+// term coverage and, for statements, by how often they were spoken.
+func (s *Service) Search(ctx context.Context, userID, q string, limit int) (SearchResult, error) {
+	result := SearchResult{Statements: []models.StatementHit{}, Categories: []models.CategoryHit{}}
+	normalized := search.Normalize(q)
+	terms := search.Terms(q)
+	if len(terms) == 0 {
+		return result, ErrEmptyQuery
+	}
+	maxScore := float64(search.MaxScore(terms))
+
+	statements, categories, err := s.Store.SearchIndex(ctx, userID, search.Keys(terms), limit*3)
+	if err != nil {
+		return result, err
+	}
+
+	score := func(raw float64, text string) (float64, bool) {
+		coverage := raw / maxScore
+		if coverage < minSearchCoverage {
+			return 0, false
+		}
+		if strings.Contains(search.Normalize(text), normalized) {
+			coverage += substringBonus
+		}
+		return coverage, true
+	}
+
+	for _, hit := range statements {
+		value, ok := score(hit.Score, hit.Text)
+		if !ok {
+			continue
+		}
+		hit.Score = value + usageBoost*math.Log1p(float64(hit.SpokenCount))
+		result.Statements = append(result.Statements, hit)
+	}
+	for _, hit := range categories {
+		value, ok := score(hit.Score, hit.Label)
+		if !ok {
+			continue
+		}
+		hit.Score = value
+		result.Categories = append(result.Categories, hit)
+	}
+
+	sort.SliceStable(result.Statements, func(i, j int) bool {
+		return result.Statements[i].Score > result.Statements[j].Score
+	})
+	sort.SliceStable(result.Categories, func(i, j int) bool {
+		return result.Categories[i].Score > result.Categories[j].Score
+	})
+	if len(result.Statements) > limit {
+		result.Statements = result.Statements[:limit]
+	}
+	if len(result.Categories) > limit {
+		result.Categories = result.Categories[:limit]
+	}
+	return result, nil
+}
+
+// ReindexUserSearch rebuilds the user's search index from stored data.
+func (s *Service) ReindexUserSearch(ctx context.Context, userID string) error {
+	return s.Store.RebuildSearchIndex(ctx, userID)
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/linkasu/linka.type-backend/internal/blob"
 	"github.com/linkasu/linka.type-backend/internal/config"
 	"github.com/linkasu/linka.type-backend/internal/defaults"
 	"github.com/linkasu/linka.type-backend/internal/dialoghelper"
-	"github.com/linkasu/linka.type-backend/internal/feature"
 	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/locale"
 	"github.com/linkasu/linka.type-backend/internal/models"
 	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/linkasu/linka.type-backend/internal/userctx"
 	ydbsdk "github.com/ydb-platform/ydb-go-sdk/v3"
 )
 
@@ -24,22 +28,36 @@ type Service struct {
 	LegacyReader store.LegacyReader
 	Feature      config.FeatureConfig
 	DialogHelper *dialoghelper.Client
+	Blobs        blob.Store
+	Media        config.MediaConfig
+
+	migrations migrationCache
 }
 
 // CategoryInput captures category creation payload.
 type CategoryInput struct {
-	ID      string
-	Label   string
-	Created int64
-	Default *bool
-	AIUse   bool
+	ID       string
+	Label    string
+	Created  int64
+	Default  *bool
+	AIUse    bool
+	ParentID string
+	Color    string
+	Icon     string
+	ImageID  string
 }
 
-// CategoryPatch captures category updates.
+// CategoryPatch captures category updates. An empty ParentID moves the
+// category to the top level; empty Color, Icon or ImageID clear them.
 type CategoryPatch struct {
-	Label   *string
-	Default *bool
-	AIUse   *bool
+	Label    *string
+	Default  *bool
+	AIUse    *bool
+	ParentID *string
+	Color    *string
+	Icon     *string
+	ImageID  *string
+	IfMatch  IfMatch
 }
 
 // StatementInput captures statement creation payload.
@@ -48,11 +66,20 @@ type StatementInput struct {
 	CategoryID string
 	Text       string
 	Created    int64
+	Color      string
+	Icon       string
+	ImageID    string
 }
 
-// StatementPatch captures statement updates.
+// StatementPatch captures statement updates. Empty Color, Icon or ImageID
+// clear them; a different CategoryID moves the statement, keeping its id.
 type StatementPatch struct {
-	Text *string
+	Text       *string
+	CategoryID *string
+	Color      *string
+	Icon       *string
+	ImageID    *string
+	IfMatch    IfMatch
 }
 
 // UserStatePatch captures user state updates.
@@ -62,6 +89,9 @@ type UserStatePatch struct {
 	QuickesSet     bool
 	Preferences    map[string]any
 	PreferencesSet bool
+	// IfMatch, when set, must accept the current state as GetUserState
+	// returns it; it is checked in the same transaction as the write.
+	IfMatch func(current models.UserState) bool
 }
 
 // UserBootstrapSnapshot carries local guest data for account bootstrap.
@@ -73,7 +103,8 @@ type UserBootstrapSnapshot struct {
 	Preferences map[string]any
 }
 
-// UserBootstrapResult describes imported entities.
+// UserBootstrapResult describes imported entities. In a preview nothing is
+// written and IDMap only holds entities that map onto existing ones.
 type UserBootstrapResult struct {
 	Imported struct {
 		Categories  int
@@ -82,9 +113,11 @@ type UserBootstrapResult struct {
 		Preferences bool
 		Inited      bool
 	}
-	Conflicts int
-	IDMap     struct {
+	Conflicts     int
+	ConflictsList []BootstrapConflict
+	IDMap         struct {
 		Categories map[string]string
+		Statements map[string]string
 	}
 }
 
@@ -93,6 +126,7 @@ type QuestionInput struct {
 	UID        string   `json:"uid"`
 	QuestionID string   `json:"question_id"`
 	Value      string   `json:"value"`
+	Values     []string `json:"values"`
 	Label      string   `json:"label"`
 	Phrases    []string `json:"phrases"`
 	Category   string   `json:"category"`
@@ -101,46 +135,66 @@ type QuestionInput struct {
 
 // GlobalCategoryInput captures global category creation payload.
 type GlobalCategoryInput struct {
-	ID      string
-	Label   string
-	Created int64
-	Default *bool
+	ID       string
+	Label    string
+	Created  int64
+	Default  *bool
+	ParentID string
 }
 
 // GlobalCategoryPatch captures global category updates.
 type GlobalCategoryPatch struct {
-	Label   *string
-	Default *bool
+	Label    *string
+	Default  *bool
+	ParentID *string
 }
 
 // FactoryQuestionInput captures factory question creation payload.
 type FactoryQuestionInput struct {
-	ID         string
-	Label      string
-	Phrases    []string
-	Category   string
-	Type       string
-	OrderIndex int
+	ID          string
+	Label       string
+	Phrases     []string
+	Category    string
+	Type        string
+	OrderIndex  int
+	Options     []models.QuestionOption
+	Placeholder string
+	ShowIf      *models.QuestionCondition
 }
 
-// FactoryQuestionPatch captures factory question updates.
+// FactoryQuestionPatch captures factory question updates. ShowIfSet with a
+// nil ShowIf removes the condition.
 type FactoryQuestionPatch struct {
-	Label      *string
-	Phrases    []string
-	Category   *string
-	Type       *string
-	OrderIndex *int
+	Label       *string
+	Phrases     []string
+	Category    *string
+	Type        *string
+	OrderIndex  *int
+	Options     []models.QuestionOption
+	Placeholder *string
+	ShowIf      *models.QuestionCondition
+	ShowIfSet   bool
 }
 
-// ListCategories returns categories using the configured read source.
+// ListCategories returns categories in the user's order using the configured read source.
 func (s *Service) ListCategories(ctx context.Context, userID string) ([]models.Category, error) {
-	if s.useYDB(userID) {
+	categories, err := s.listCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sortCategories(categories)
+	return categories, nil
+}
+
+func (s *Service) listCategories(ctx context.Context, userID string) ([]models.Category, error) {
+	legacy := s.legacyReader(ctx, userID)
+	if s.useYDB(ctx, userID) {
 		categories, err := s.Store.ListCategories(ctx, userID)
 		if err != nil {
 			return nil, err
 		}
-		if len(categories) == 0 && s.LegacyReader != nil {
-			legacyCategories, legacyStatements, err := s.LegacyReader.FetchUserData(ctx, userID)
+		if len(categories) == 0 && legacy != nil {
+			legacyCategories, legacyStatements, err := legacy.FetchUserData(ctx, userID)
 			if err != nil {
 				return nil, err
 			}
@@ -152,8 +206,8 @@ func (s *Service) ListCategories(ctx context.Context, userID string) ([]models.C
 		return categories, nil
 	}
 
-	if s.LegacyReader != nil {
-		categories, _, err := s.LegacyReader.FetchUserData(ctx, userID)
+	if legacy != nil {
+		categories, _, err := legacy.FetchUserData(ctx, userID)
 		if err != nil {
 			return nil, err
 		}
@@ -165,6 +219,7 @@ func (s *Service) ListCategories(ctx context.Context, userID string) ([]models.C
 
 // CreateCategory creates a category and mirrors it to Firebase.
 func (s *Service) CreateCategory(ctx context.Context, userID string, input CategoryInput) (models.Category, error) {
+	mirror := s.legacyWriter(ctx, userID)
 	now := time.Now().UnixMilli()
 	if input.ID == "" {
 		input.ID = id.NewShort()
@@ -172,12 +227,28 @@ func (s *Service) CreateCategory(ctx context.Context, userID string, input Categ
 	if input.Created == 0 {
 		input.Created = now
 	}
+	if input.ParentID != "" {
+		categories, err := s.ListCategories(ctx, userID)
+		if err != nil {
+			return models.Category{}, err
+		}
+		if err := validateParent(categoryParents(categories), input.ID, input.ParentID); err != nil {
+			return models.Category{}, err
+		}
+	}
+	if err := s.validateVisual(ctx, userID, input.Color, input.Icon, input.ImageID); err != nil {
+		return models.Category{}, err
+	}
 	category := models.Category{
 		ID:        input.ID,
 		Label:     input.Label,
 		Created:   input.Created,
 		Default:   input.Default,
 		AIUse:     input.AIUse,
+		ParentID:  input.ParentID,
+		Color:     input.Color,
+		Icon:      input.Icon,
+		ImageID:   input.ImageID,
 		UpdatedAt: now,
 	}
 
@@ -186,23 +257,33 @@ func (s *Service) CreateCategory(ctx context.Context, userID string, input Categ
 		return models.Category{}, err
 	}
 
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.UpsertCategory(ctx, userID, category); err != nil {
+	if mirror != nil {
+		if err := mirror.UpsertCategory(ctx, userID, category); err != nil {
 			return models.Category{}, err
 		}
 	}
 
 	_ = s.appendChange(ctx, userID, "category", category.ID, "upsert", category, now)
+	_ = s.recordRevision(ctx, userID, "category", category.ID, revisionCreate, category, now)
 
 	return category, nil
 }
 
 // UpdateCategory patches a category.
 func (s *Service) UpdateCategory(ctx context.Context, userID, categoryID string, patch CategoryPatch) (models.Category, error) {
+	return s.updateCategory(ctx, userID, categoryID, patch, revisionUpdate)
+}
+
+func (s *Service) updateCategory(ctx context.Context, userID, categoryID string, patch CategoryPatch, op string) (models.Category, error) {
+	mirror := s.legacyWriter(ctx, userID)
 	category, err := s.findCategory(ctx, userID, categoryID)
+	if errors.Is(err, store.ErrNotFound) && patch.IfMatch.active() {
+		return models.Category{}, ErrPreconditionFailed
+	}
 	if err != nil {
 		return models.Category{}, err
 	}
+	version := category.UpdatedAt
 
 	if patch.Label != nil {
 		category.Label = *patch.Label
@@ -213,27 +294,112 @@ func (s *Service) UpdateCategory(ctx context.Context, userID, categoryID string,
 	if patch.AIUse != nil {
 		category.AIUse = *patch.AIUse
 	}
+	if patch.ParentID != nil && *patch.ParentID != category.ParentID {
+		categories, err := s.ListCategories(ctx, userID)
+		if err != nil {
+			return models.Category{}, err
+		}
+		if err := validateParent(categoryParents(categories), categoryID, *patch.ParentID); err != nil {
+			return models.Category{}, err
+		}
+		category.ParentID = *patch.ParentID
+	}
+	previousImage := category.ImageID
+	if patch.Color != nil {
+		category.Color = *patch.Color
+	}
+	if patch.Icon != nil {
+		category.Icon = *patch.Icon
+	}
+	if patch.ImageID != nil {
+		category.ImageID = *patch.ImageID
+	}
+	if err := s.validateVisual(ctx, userID, category.Color, category.Icon, category.ImageID); err != nil {
+		return models.Category{}, err
+	}
 	category.UpdatedAt = time.Now().UnixMilli()
-
-	category, err = s.Store.UpsertCategory(ctx, userID, category)
+	category, err = s.writeCategory(ctx, userID, category, version, patch.IfMatch)
 	if err != nil {
 		return models.Category{}, err
 	}
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.UpsertCategory(ctx, userID, category); err != nil {
+	if mirror != nil {
+		if err := mirror.UpsertCategory(ctx, userID, category); err != nil {
 			return models.Category{}, err
 		}
 	}
 
 	_ = s.appendChange(ctx, userID, "category", category.ID, "upsert", category, category.UpdatedAt)
+	_ = s.recordRevision(ctx, userID, "category", category.ID, op, category, category.UpdatedAt)
 
+	if previousImage != category.ImageID {
+		s.releaseMedia(ctx, userID, previousImage)
+	}
 	return category, nil
 }
 
-// DeleteCategory deletes a category and its statements.
-func (s *Service) DeleteCategory(ctx context.Context, userID, categoryID string) error {
+// DeleteCategory deletes a category and its statements. Sub-categories are
+// deleted with it when cascade is set, otherwise they move up to its parent.
+func (s *Service) DeleteCategory(ctx context.Context, userID, categoryID string, cascade bool, ifMatch IfMatch) error {
+	mirror := s.legacyWriter(ctx, userID)
 	updatedAt := time.Now().UnixMilli()
 
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	parents := categoryParents(categories)
+	descendants := descendantIDs(parents, categoryID)
+	byID := make(map[string]models.Category, len(categories))
+	for _, cat := range categories {
+		byID[cat.ID] = cat
+	}
+	if ifMatch.active() {
+		current, ok := byID[categoryID]
+		if !ok {
+			return ErrPreconditionFailed
+		}
+		// The category row goes first so the check and its delete are
+		// atomic; deleteCategory below repeats the delete with the rest.
+		if err := s.removeCategory(ctx, userID, categoryID, current.UpdatedAt, ifMatch, updatedAt); err != nil {
+			return err
+		}
+	}
+
+	if cascade {
+		for _, id := range descendants {
+			if err := s.deleteCategory(ctx, userID, byID[id], mirror, updatedAt); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, cat := range categories {
+			if cat.ParentID != categoryID || cat.ID == categoryID {
+				continue
+			}
+			cat.ParentID = parents[categoryID]
+			cat.UpdatedAt = updatedAt
+			if _, err := s.Store.UpsertCategory(ctx, userID, cat); err != nil {
+				return err
+			}
+			if mirror != nil {
+				if err := mirror.UpsertCategory(ctx, userID, cat); err != nil {
+					return err
+				}
+			}
+			_ = s.appendChange(ctx, userID, "category", cat.ID, "upsert", cat, updatedAt)
+			_ = s.recordRevision(ctx, userID, "category", cat.ID, revisionUpdate, cat, updatedAt)
+		}
+	}
+
+	category, ok := byID[categoryID]
+	if !ok {
+		category = models.Category{ID: categoryID}
+	}
+	return s.deleteCategory(ctx, userID, category, mirror, updatedAt)
+}
+
+func (s *Service) deleteCategory(ctx context.Context, userID string, category models.Category, mirror store.LegacyWriter, updatedAt int64) error {
+	categoryID := category.ID
 	statements, _ := s.Store.ListStatements(ctx, userID, categoryID)
 	if err := s.Store.DeleteCategory(ctx, userID, categoryID, updatedAt); err != nil {
 		return err
@@ -242,26 +408,37 @@ func (s *Service) DeleteCategory(ctx context.Context, userID, categoryID string)
 		_ = s.Store.DeleteStatement(ctx, userID, categoryID, stmt.ID, updatedAt)
 	}
 
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.DeleteCategory(ctx, userID, categoryID); err != nil {
+	if mirror != nil {
+		if err := mirror.DeleteCategory(ctx, userID, categoryID); err != nil {
 			return err
 		}
 	}
 
 	_ = s.appendChange(ctx, userID, "category", categoryID, "delete", map[string]string{"id": categoryID}, updatedAt)
+	_ = s.recordRevision(ctx, userID, "category", categoryID, revisionDelete, category, updatedAt)
 
 	return nil
 }
 
-// ListStatements returns statements for a category.
+// ListStatements returns statements for a category in the user's order.
 func (s *Service) ListStatements(ctx context.Context, userID, categoryID string) ([]models.Statement, error) {
-	if s.useYDB(userID) {
+	statements, err := s.listStatements(ctx, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	sortStatements(statements)
+	return statements, nil
+}
+
+func (s *Service) listStatements(ctx context.Context, userID, categoryID string) ([]models.Statement, error) {
+	legacy := s.legacyReader(ctx, userID)
+	if s.useYDB(ctx, userID) {
 		statements, err := s.Store.ListStatements(ctx, userID, categoryID)
 		if err != nil {
 			return nil, err
 		}
-		if len(statements) == 0 && s.LegacyReader != nil {
-			legacyCategories, legacyStatements, err := s.LegacyReader.FetchUserData(ctx, userID)
+		if len(statements) == 0 && legacy != nil {
+			legacyCategories, legacyStatements, err := legacy.FetchUserData(ctx, userID)
 			if err != nil {
 				return nil, err
 			}
@@ -273,8 +450,8 @@ func (s *Service) ListStatements(ctx context.Context, userID, categoryID string)
 		return statements, nil
 	}
 
-	if s.LegacyReader != nil {
-		_, statements, err := s.LegacyReader.FetchUserData(ctx, userID)
+	if legacy != nil {
+		_, statements, err := legacy.FetchUserData(ctx, userID)
 		if err != nil {
 			return nil, err
 		}
@@ -286,6 +463,7 @@ func (s *Service) ListStatements(ctx context.Context, userID, categoryID string)
 
 // CreateStatement creates a statement or runs onboarding generation.
 func (s *Service) CreateStatement(ctx context.Context, userID string, input StatementInput) (models.Statement, error) {
+	mirror := s.legacyWriter(ctx, userID)
 	now := time.Now().UnixMilli()
 	if input.ID == "" {
 		input.ID = id.NewShort()
@@ -294,11 +472,18 @@ func (s *Service) CreateStatement(ctx context.Context, userID string, input Stat
 		input.Created = now
 	}
 
+	if err := s.validateVisual(ctx, userID, input.Color, input.Icon, input.ImageID); err != nil {
+		return models.Statement{}, err
+	}
+
 	statement := models.Statement{
 		ID:         input.ID,
 		CategoryID: input.CategoryID,
 		Text:       input.Text,
 		Created:    input.Created,
+		Color:      input.Color,
+		Icon:       input.Icon,
+		ImageID:    input.ImageID,
 		UpdatedAt:  now,
 	}
 
@@ -306,77 +491,143 @@ func (s *Service) CreateStatement(ctx context.Context, userID string, input Stat
 	if err != nil {
 		return models.Statement{}, err
 	}
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.UpsertStatement(ctx, userID, statement); err != nil {
+	if mirror != nil {
+		if err := mirror.UpsertStatement(ctx, userID, statement); err != nil {
 			return models.Statement{}, err
 		}
 	}
 
 	_ = s.appendChange(ctx, userID, "statement", statement.ID, "upsert", statement, now)
+	_ = s.recordRevision(ctx, userID, "statement", statement.ID, revisionCreate, statement, now)
 
 	return statement, nil
 }
 
 // UpdateStatement patches a statement.
 func (s *Service) UpdateStatement(ctx context.Context, userID, statementID string, patch StatementPatch) (models.Statement, error) {
+	return s.updateStatement(ctx, userID, statementID, patch, "")
+}
+
+// updateStatement applies patch and records a revision with op, or with
+// update or move when op is empty.
+func (s *Service) updateStatement(ctx context.Context, userID, statementID string, patch StatementPatch, op string) (models.Statement, error) {
+	mirror := s.legacyWriter(ctx, userID)
 	statement, err := s.findStatement(ctx, userID, statementID)
+	if errors.Is(err, store.ErrNotFound) && patch.IfMatch.active() {
+		return models.Statement{}, ErrPreconditionFailed
+	}
 	if err != nil {
 		return models.Statement{}, err
 	}
-	if patch.Text != nil {
-		statement.Text = *patch.Text
+	version := statement.UpdatedAt
+	fromCategoryID := statement.CategoryID
+	if patch.CategoryID != nil && *patch.CategoryID != fromCategoryID {
+		if _, err := s.findCategory(ctx, userID, *patch.CategoryID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return models.Statement{}, ErrInvalidCategory
+			}
+			return models.Statement{}, err
+		}
+	}
+	previousImage := statement.ImageID
+	statement = patchStatement(statement, patch)
+	if err := s.validateVisual(ctx, userID, statement.Color, statement.Icon, statement.ImageID); err != nil {
+		return models.Statement{}, err
 	}
 	statement.UpdatedAt = time.Now().UnixMilli()
-
-	statement, err = s.Store.UpsertStatement(ctx, userID, statement)
+	moved := statement.CategoryID != fromCategoryID
+	statement, err = s.writeStatement(ctx, userID, fromCategoryID, statement, version, patch.IfMatch)
 	if err != nil {
 		return models.Statement{}, err
 	}
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.UpsertStatement(ctx, userID, statement); err != nil {
+	if mirror != nil {
+		if moved {
+			if err := mirror.DeleteStatement(ctx, userID, fromCategoryID, statement.ID); err != nil {
+				return models.Statement{}, err
+			}
+		}
+		if err := mirror.UpsertStatement(ctx, userID, statement); err != nil {
 			return models.Statement{}, err
 		}
 	}
 
+	if op == "" {
+		op = revisionUpdate
+		if moved {
+			op = revisionMove
+		}
+	}
 	_ = s.appendChange(ctx, userID, "statement", statement.ID, "upsert", statement, statement.UpdatedAt)
+	_ = s.recordRevision(ctx, userID, "statement", statement.ID, op, statement, statement.UpdatedAt)
 
+	if previousImage != statement.ImageID {
+		s.releaseMedia(ctx, userID, previousImage)
+	}
 	return statement, nil
 }
 
+// patchStatement applies the set fields of patch. A move to another category
+// keeps the statement's id and created time but drops its position.
+func patchStatement(statement models.Statement, patch StatementPatch) models.Statement {
+	if patch.Text != nil {
+		statement.Text = *patch.Text
+	}
+	if patch.CategoryID != nil && *patch.CategoryID != statement.CategoryID {
+		statement.CategoryID = *patch.CategoryID
+		// The old rank key means nothing among the new siblings.
+		statement.Position = ""
+	}
+	if patch.Color != nil {
+		statement.Color = *patch.Color
+	}
+	if patch.Icon != nil {
+		statement.Icon = *patch.Icon
+	}
+	if patch.ImageID != nil {
+		statement.ImageID = *patch.ImageID
+	}
+	return statement
+}
+
 // DeleteStatement deletes a statement by ID.
-func (s *Service) DeleteStatement(ctx context.Context, userID, statementID string) error {
+func (s *Service) DeleteStatement(ctx context.Context, userID, statementID string, ifMatch IfMatch) error {
+	mirror := s.legacyWriter(ctx, userID)
 	statement, err := s.findStatement(ctx, userID, statementID)
+	if errors.Is(err, store.ErrNotFound) && ifMatch.active() {
+		return ErrPreconditionFailed
+	}
 	if err != nil {
 		return err
 	}
 	updatedAt := time.Now().UnixMilli()
-
-	if err := s.Store.DeleteStatement(ctx, userID, statement.CategoryID, statementID, updatedAt); err != nil {
+	if err := s.removeStatement(ctx, userID, statement.CategoryID, statementID, statement.UpdatedAt, ifMatch, updatedAt); err != nil {
 		return err
 	}
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.DeleteStatement(ctx, userID, statement.CategoryID, statementID); err != nil {
+	if mirror != nil {
+		if err := mirror.DeleteStatement(ctx, userID, statement.CategoryID, statementID); err != nil {
 			return err
 		}
 	}
 
-	_ = s.appendChange(ctx, userID, "statement", statementID, "delete", map[string]string{"id": statementID}, updatedAt)
+	_ = s.appendChange(ctx, userID, "statement", statementID, "delete", map[string]string{"id": statementID, "categoryId": statement.CategoryID}, updatedAt)
+	_ = s.recordRevision(ctx, userID, "statement", statementID, revisionDelete, statement, updatedAt)
 
 	return nil
 }
 
 // GetUserState returns inited and quickes with default fallbacks.
 func (s *Service) GetUserState(ctx context.Context, userID string) (models.UserState, error) {
+	legacy := s.legacyReader(ctx, userID)
 	state := models.UserState{}
 	var err error
 
-	if s.useYDB(userID) {
+	if s.useYDB(ctx, userID) {
 		state, err = s.Store.GetUserState(ctx, userID)
 		if err != nil {
 			return state, err
 		}
-		if s.LegacyReader != nil && len(state.Quickes) == 0 {
-			legacyState, err := s.LegacyReader.GetUserState(ctx, userID)
+		if legacy != nil && len(state.Quickes) == 0 {
+			legacyState, err := legacy.GetUserState(ctx, userID)
 			if err != nil {
 				return state, err
 			}
@@ -385,8 +636,8 @@ func (s *Service) GetUserState(ctx context.Context, userID string) (models.UserS
 				state = legacyState
 			}
 		}
-	} else if s.LegacyReader != nil {
-		state, err = s.LegacyReader.GetUserState(ctx, userID)
+	} else if legacy != nil {
+		state, err = legacy.GetUserState(ctx, userID)
 		if err != nil {
 			return state, err
 		}
@@ -397,24 +648,42 @@ func (s *Service) GetUserState(ctx context.Context, userID string) (models.UserS
 		}
 	}
 
-	state.Quickes = normalizeQuickes(state.Quickes)
+	return servedUserState(ctx, state), nil
+}
 
-	return state, nil
+// servedUserState fills blank quick phrase slots the way GetUserState
+// returns them.
+func servedUserState(ctx context.Context, state models.UserState) models.UserState {
+	state.Quickes = normalizeQuickes(state.Quickes, locale.Resolve(state.Preferences, locale.FromContext(ctx)))
+	return state
+}
+
+// storeUserState writes state, checking ifMatch against the stored state in
+// the same transaction when it is set.
+func (s *Service) storeUserState(ctx context.Context, userID string, state models.UserState, updatedAt int64, ifMatch func(models.UserState) bool) (models.UserState, error) {
+	if ifMatch == nil {
+		return s.Store.SetUserState(ctx, userID, state, updatedAt)
+	}
+	updated, err := s.Store.SetUserStateIf(ctx, userID, state, updatedAt, func(current models.UserState) bool {
+		return ifMatch(servedUserState(ctx, current))
+	})
+	return updated, preconditionError(err)
 }
 
 // UpdateUserState updates inited/quickes and mirrors the result.
 func (s *Service) UpdateUserState(ctx context.Context, userID string, patch UserStatePatch) (models.UserState, error) {
+	mirror := s.legacyWriter(ctx, userID)
 	current, err := s.GetUserState(ctx, userID)
 	if err != nil {
 		return current, err
 	}
+	if patch.IfMatch != nil && !patch.IfMatch(current) {
+		return current, ErrPreconditionFailed
+	}
 
 	if patch.Inited != nil {
 		current.Inited = *patch.Inited
 	}
-	if patch.QuickesSet {
-		current.Quickes = normalizeQuickes(patch.Quickes)
-	}
 	if patch.PreferencesSet {
 		if patch.Preferences == nil {
 			current.Preferences = map[string]any{}
@@ -427,49 +696,56 @@ func (s *Service) UpdateUserState(ctx context.Context, userID string, patch User
 			}
 		}
 	}
+	loc := locale.Resolve(current.Preferences, locale.FromContext(ctx))
+	if patch.QuickesSet {
+		current.Quickes = normalizeQuickes(patch.Quickes, loc)
+	}
 
 	updatedAt := time.Now().UnixMilli()
-	updated, err := s.Store.SetUserState(ctx, userID, current, updatedAt)
+	updated, err := s.storeUserState(ctx, userID, current, updatedAt, patch.IfMatch)
 	if err != nil {
-		if s.LegacyWriter != nil && isYDBNotFound(err) {
-			if err := s.LegacyWriter.SetUserState(ctx, userID, current); err != nil {
+		if mirror != nil && isYDBNotFound(err) {
+			if err := mirror.SetUserState(ctx, userID, current); err != nil {
 				return current, err
 			}
-			current.Quickes = normalizeQuickes(current.Quickes)
+			current.Quickes = normalizeQuickes(current.Quickes, loc)
 			return current, nil
 		}
 		return current, err
 	}
 
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.SetUserState(ctx, userID, updated); err != nil {
+	if mirror != nil {
+		if err := mirror.SetUserState(ctx, userID, updated); err != nil {
 			return updated, err
 		}
 	}
 
 	_ = s.appendChange(ctx, userID, "user_state", userID, "upsert", updated, updatedAt)
 
-	updated.Quickes = normalizeQuickes(updated.Quickes)
+	updated.Quickes = normalizeQuickes(updated.Quickes, loc)
 	return updated, nil
 }
 
-// SetQuickes updates quick phrases.
-func (s *Service) SetQuickes(ctx context.Context, userID string, quickes []string) ([]string, error) {
+// SetQuickes updates quick phrases. A non-nil ifMatch must accept the
+// current quickes as GetUserState returns them; it is checked in the same
+// transaction as the write.
+func (s *Service) SetQuickes(ctx context.Context, userID string, quickes []string, ifMatch func(current []string) bool) ([]string, error) {
+	mirror := s.legacyWriter(ctx, userID)
 	updatedAt := time.Now().UnixMilli()
-	quickes = normalizeQuickes(quickes)
+	quickes = normalizeQuickes(quickes, s.UserLocale(ctx, userID))
 
-	updated, err := s.Store.SetQuickes(ctx, userID, quickes, updatedAt)
+	updated, err := s.storeQuickes(ctx, userID, quickes, updatedAt, ifMatch)
 	if err != nil {
-		if s.LegacyWriter != nil && isYDBNotFound(err) {
-			if err := s.LegacyWriter.SetQuickes(ctx, userID, quickes); err != nil {
+		if mirror != nil && isYDBNotFound(err) {
+			if err := mirror.SetQuickes(ctx, userID, quickes); err != nil {
 				return nil, err
 			}
 			return quickes, nil
 		}
 		return nil, err
 	}
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.SetQuickes(ctx, userID, updated); err != nil {
+	if mirror != nil {
+		if err := mirror.SetQuickes(ctx, userID, updated); err != nil {
 			return nil, err
 		}
 	}
@@ -479,137 +755,28 @@ func (s *Service) SetQuickes(ctx context.Context, userID string, quickes []strin
 	return updated, nil
 }
 
-// BootstrapUserData imports a local snapshot into the user's cloud account.
-func (s *Service) BootstrapUserData(
-	ctx context.Context,
-	userID string,
-	snapshot UserBootstrapSnapshot,
-	mergeStrategy string,
-) (UserBootstrapResult, error) {
-	if mergeStrategy == "" {
-		mergeStrategy = "local_wins"
-	}
-	if mergeStrategy != "local_wins" {
-		return UserBootstrapResult{}, errors.New("unsupported merge strategy")
-	}
-
-	result := UserBootstrapResult{}
-	result.IDMap.Categories = make(map[string]string)
-
-	existingCategories, err := s.ListCategories(ctx, userID)
-	if err != nil {
-		return result, err
-	}
-
-	categoryByLabel := make(map[string]models.Category, len(existingCategories))
-	for _, category := range existingCategories {
-		categoryByLabel[normalizeLabel(category.Label)] = category
-	}
-
-	snapshotCategoryLabelByID := make(map[string]string, len(snapshot.Categories))
-	for _, category := range snapshot.Categories {
-		label := strings.TrimSpace(category.Label)
-		if label == "" {
-			continue
-		}
-		snapshotCategoryLabelByID[category.ID] = label
-		normalizedLabel := normalizeLabel(label)
-
-		if existing, ok := categoryByLabel[normalizedLabel]; ok {
-			if category.ID != "" {
-				result.IDMap.Categories[category.ID] = existing.ID
-			}
-			continue
-		}
-
-		created, err := s.CreateCategory(ctx, userID, CategoryInput{
-			Label:   label,
-			Created: category.Created,
-			Default: category.Default,
-			AIUse:   category.AIUse,
-		})
-		if err != nil {
-			return result, err
-		}
-
-		categoryByLabel[normalizedLabel] = created
-		result.Imported.Categories++
-		if category.ID != "" {
-			result.IDMap.Categories[category.ID] = created.ID
-		}
+// storeQuickes writes quickes, checking ifMatch first against the state read
+// now to fail fast, then against the stored state in the write transaction.
+func (s *Service) storeQuickes(ctx context.Context, userID string, quickes []string, updatedAt int64, ifMatch func([]string) bool) ([]string, error) {
+	if ifMatch == nil {
+		return s.Store.SetQuickes(ctx, userID, quickes, updatedAt)
 	}
-
-	existingStatements, err := s.Store.ListAllStatements(ctx, userID)
+	current, err := s.GetUserState(ctx, userID)
 	if err != nil {
-		return result, err
-	}
-
-	existingStatementKeys := make(map[string]struct{}, len(existingStatements))
-	for _, statement := range existingStatements {
-		existingStatementKeys[statementDedupKey(statement.CategoryID, statement.Text)] = struct{}{}
-	}
-
-	for _, statement := range snapshot.Statements {
-		text := strings.TrimSpace(statement.Text)
-		if text == "" {
-			continue
-		}
-
-		resolvedCategoryID := statement.CategoryID
-		if mapped, ok := result.IDMap.Categories[statement.CategoryID]; ok {
-			resolvedCategoryID = mapped
-		} else if label, ok := snapshotCategoryLabelByID[statement.CategoryID]; ok {
-			if resolved, exists := categoryByLabel[normalizeLabel(label)]; exists {
-				resolvedCategoryID = resolved.ID
-			}
-		}
-
-		if strings.TrimSpace(resolvedCategoryID) == "" {
-			continue
-		}
-
-		key := statementDedupKey(resolvedCategoryID, text)
-		if _, exists := existingStatementKeys[key]; exists {
-			continue
-		}
-
-		_, err := s.CreateStatement(ctx, userID, StatementInput{
-			CategoryID: resolvedCategoryID,
-			Text:       text,
-			Created:    statement.Created,
-		})
-		if err != nil {
-			return result, err
-		}
-		existingStatementKeys[key] = struct{}{}
-		result.Imported.Statements++
-	}
-
-	patch := UserStatePatch{
-		Inited: &snapshot.Inited,
-	}
-	result.Imported.Inited = true
-
-	if snapshot.Preferences != nil {
-		patch.PreferencesSet = true
-		patch.Preferences = snapshot.Preferences
-		result.Imported.Preferences = true
-	}
-	if len(snapshot.Quickes) > 0 {
-		patch.QuickesSet = true
-		patch.Quickes = snapshot.Quickes
-		result.Imported.Quickes = true
+		return nil, err
 	}
-
-	if _, err := s.UpdateUserState(ctx, userID, patch); err != nil {
-		return result, err
+	if !ifMatch(current.Quickes) {
+		return nil, ErrPreconditionFailed
 	}
-
-	return result, nil
+	updated, err := s.Store.SetQuickesIf(ctx, userID, quickes, updatedAt, func(current models.UserState) bool {
+		return ifMatch(servedUserState(ctx, current).Quickes)
+	})
+	return updated, preconditionError(err)
 }
 
-// ListGlobalCategories returns global categories (optionally seeded from Firebase).
-func (s *Service) ListGlobalCategories(ctx context.Context, includeStatements bool) ([]models.GlobalCategory, error) {
+// ListGlobalCategories returns global categories (optionally seeded from
+// Firebase) translated into loc. An empty loc keeps the canonical text.
+func (s *Service) ListGlobalCategories(ctx context.Context, includeStatements bool, loc string) ([]models.GlobalCategory, error) {
 	categories, err := s.Store.ListGlobalCategories(ctx, includeStatements)
 	if err != nil {
 		return nil, err
@@ -626,16 +793,55 @@ func (s *Service) ListGlobalCategories(ctx context.Context, includeStatements bo
 		}
 		return legacyCats, nil
 	}
+	translations, err := s.translations(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		sortStatements(categories[i].Statements)
+		categories[i] = translations.category(categories[i])
+	}
 	return categories, nil
 }
 
-// ListGlobalStatements returns global statements for a category.
-func (s *Service) ListGlobalStatements(ctx context.Context, categoryID string) ([]models.Statement, error) {
-	return s.Store.ListGlobalStatements(ctx, categoryID)
+// ListGlobalStatements returns global statements for a category,
+// translated into loc.
+func (s *Service) ListGlobalStatements(ctx context.Context, categoryID, loc string) ([]models.Statement, error) {
+	statements, err := s.Store.ListGlobalStatements(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := s.translations(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	sortStatements(statements)
+	return translations.statements(categoryID, statements), nil
 }
 
-// ImportGlobalCategory mirrors global category into user data.
-func (s *Service) ImportGlobalCategory(ctx context.Context, userID, categoryID string, force bool) (string, error) {
+// ImportGlobalCategory mirrors a global category and its sub-categories
+// into user data, translated into loc. Without force, sub-categories the
+// user already has are kept.
+func (s *Service) ImportGlobalCategory(ctx context.Context, userID, categoryID string, force bool, loc string) (string, error) {
+	mirror := s.legacyWriter(ctx, userID)
+	translations, err := s.translations(ctx, loc)
+	if err != nil {
+		return "", err
+	}
+
+	var imported []string
+	if mirror != nil || len(translations) > 0 {
+		globals, err := s.Store.ListGlobalCategories(ctx, false)
+		if err != nil {
+			return "", err
+		}
+		existing, err := s.ListCategories(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		imported = importedCategoryIDs(store.GlobalSubtree(globals, categoryID), existing, force)
+	}
+
 	status, err := s.Store.ImportGlobalCategory(ctx, userID, categoryID, force)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) && s.LegacyReader != nil {
@@ -646,21 +852,36 @@ func (s *Service) ImportGlobalCategory(ctx context.Context, userID, categoryID s
 	if status == "exists" {
 		return status, nil
 	}
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.ImportGlobalCategory(ctx, userID, categoryID); err != nil {
+	if mirror != nil {
+		for _, id := range imported {
+			if err := mirror.ImportGlobalCategory(ctx, userID, id); err != nil {
+				return "", err
+			}
+		}
+	}
+	if len(translations) > 0 {
+		if err := s.translateImport(ctx, userID, imported, translations); err != nil {
 			return "", err
 		}
 	}
+	if err := s.trackGlobalImport(ctx, userID, categoryID, force, translations); err != nil {
+		slog.Warn("global import tracking failed", "user_id", userID, "category_id", categoryID, "error", err)
+	}
 
 	return status, nil
 }
 
 func (s *Service) importGlobalFromLegacy(ctx context.Context, userID, categoryID string, force bool) (string, error) {
+	mirror := s.legacyWriter(ctx, userID)
+	existing, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return "", err
+	}
 	if !force {
-		if _, err := s.findCategory(ctx, userID, categoryID); err == nil {
-			return "exists", nil
-		} else if !errors.Is(err, store.ErrNotFound) {
-			return "", err
+		for _, cat := range existing {
+			if cat.ID == categoryID {
+				return "exists", nil
+			}
 		}
 	}
 
@@ -668,47 +889,69 @@ func (s *Service) importGlobalFromLegacy(ctx context.Context, userID, categoryID
 	if err != nil {
 		return "", err
 	}
-	var global *models.GlobalCategory
-	for i := range globals {
-		if globals[i].ID == categoryID {
-			global = &globals[i]
-			break
-		}
-	}
-	if global == nil {
+	subtree := store.GlobalSubtree(globals, categoryID)
+	if len(subtree) == 0 {
 		return "", store.ErrNotFound
 	}
+	imported := importedCategoryIDs(subtree, existing, force)
+	parents := categoryParents(existing)
 
 	updatedAt := time.Now().UnixMilli()
-	cat := models.Category{
-		ID:        global.ID,
-		Label:     global.Label,
-		Created:   global.Created,
-		Default:   global.Default,
-		UpdatedAt: updatedAt,
-	}
-	if _, err := s.Store.UpsertCategory(ctx, userID, cat); err != nil {
-		return "", err
-	}
-
-	for _, stmt := range global.Statements {
-		stmt.CategoryID = global.ID
-		stmt.UpdatedAt = updatedAt
-		if _, err := s.Store.UpsertStatement(ctx, userID, stmt); err != nil {
+	for i, global := range subtree {
+		if !slices.Contains(imported, global.ID) {
+			continue
+		}
+		cat := models.Category{
+			ID:        global.ID,
+			Label:     global.Label,
+			Created:   global.Created,
+			Default:   global.Default,
+			ParentID:  global.ParentID,
+			UpdatedAt: updatedAt,
+		}
+		if _, ok := parents[cat.ParentID]; i == 0 && !ok {
+			cat.ParentID = ""
+		}
+		if _, err := s.Store.UpsertCategory(ctx, userID, cat); err != nil {
 			return "", err
 		}
+
+		for _, stmt := range global.Statements {
+			stmt.CategoryID = global.ID
+			stmt.UpdatedAt = updatedAt
+			if _, err := s.Store.UpsertStatement(ctx, userID, stmt); err != nil {
+				return "", err
+			}
+		}
 	}
 
-	if s.LegacyWriter != nil {
-		if err := s.LegacyWriter.ImportGlobalCategory(ctx, userID, categoryID); err != nil {
-			return "", err
+	if mirror != nil {
+		for _, id := range imported {
+			if err := mirror.ImportGlobalCategory(ctx, userID, id); err != nil {
+				return "", err
+			}
 		}
 	}
 	return "ok", nil
 }
 
-// ListFactoryQuestions returns onboarding question templates.
-func (s *Service) ListFactoryQuestions(ctx context.Context) ([]models.FactoryQuestion, error) {
+// importedCategoryIDs lists the subtree categories an import writes: the
+// root always, descendants only when missing or when forced.
+func importedCategoryIDs(subtree []models.GlobalCategory, existing []models.Category, force bool) []string {
+	have := categoryParents(existing)
+	var ids []string
+	for i, cat := range subtree {
+		if _, ok := have[cat.ID]; i > 0 && ok && !force {
+			continue
+		}
+		ids = append(ids, cat.ID)
+	}
+	return ids
+}
+
+// ListFactoryQuestions returns onboarding question templates translated
+// into loc.
+func (s *Service) ListFactoryQuestions(ctx context.Context, loc string) ([]models.FactoryQuestion, error) {
 	questions, err := s.Store.ListFactoryQuestions(ctx)
 	if err != nil {
 		return nil, err
@@ -720,6 +963,13 @@ func (s *Service) ListFactoryQuestions(ctx context.Context) ([]models.FactoryQue
 		}
 		return legacy, nil
 	}
+	translations, err := s.translations(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range questions {
+		questions[i] = translations.question(questions[i])
+	}
 	return questions, nil
 }
 
@@ -738,6 +988,12 @@ func (s *Service) IsAdmin(ctx context.Context, userID string) (bool, error) {
 // DeleteUser deletes YDB data and optionally Firebase RTDB data.
 func (s *Service) DeleteUser(ctx context.Context, userID string, deleteFirebase bool) error {
 	updatedAt := time.Now().UnixMilli()
+	if err := s.deleteUserMedia(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.deleteUserExports(ctx, userID); err != nil {
+		return err
+	}
 	if err := s.Store.DeleteUser(ctx, userID, updatedAt); err != nil {
 		return err
 	}
@@ -747,102 +1003,6 @@ func (s *Service) DeleteUser(ctx context.Context, userID string, deleteFirebase
 	return nil
 }
 
-// OnboardingPhrases generates initial phrases based on questions.
-func (s *Service) OnboardingPhrases(ctx context.Context, userID string, questions []QuestionInput) (string, error) {
-	state, err := s.GetUserState(ctx, userID)
-	if err != nil {
-		return "", err
-	}
-	if state.Inited {
-		return "ok", nil
-	}
-
-	factory, err := s.ListFactoryQuestions(ctx)
-	if err != nil {
-		return "", err
-	}
-	factoryByID := make(map[string]models.FactoryQuestion, len(factory))
-	for _, q := range factory {
-		factoryByID[q.ID] = q
-	}
-
-	categories, err := s.ListCategories(ctx, userID)
-	if err != nil {
-		return "", err
-	}
-	categoryByLabel := make(map[string]models.Category, len(categories))
-	for _, cat := range categories {
-		categoryByLabel[cat.Label] = cat
-	}
-
-	for _, q := range questions {
-		value := strings.TrimSpace(q.Value)
-		if value == "" {
-			continue
-		}
-
-		phrases := q.Phrases
-		categoryName := q.Category
-		if phrases == nil || categoryName == "" {
-			if q.QuestionID != "" {
-				if tmpl, ok := factoryByID[q.QuestionID]; ok {
-					if phrases == nil {
-						phrases = tmpl.Phrases
-					}
-					if categoryName == "" {
-						categoryName = tmpl.Category
-					}
-				}
-			}
-			if q.UID != "" {
-				if tmpl, ok := factoryByID[q.UID]; ok {
-					if phrases == nil {
-						phrases = tmpl.Phrases
-					}
-					if categoryName == "" {
-						categoryName = tmpl.Category
-					}
-				}
-			}
-		}
-
-		if len(phrases) == 0 || categoryName == "" {
-			continue
-		}
-
-		catID, err := s.getOrCreateCategory(ctx, userID, categoryName, categoryByLabel)
-		if err != nil {
-			return "", err
-		}
-
-		for _, phrase := range phrases {
-			text := strings.ReplaceAll(phrase, "%%", value)
-			if strings.TrimSpace(text) == "" {
-				continue
-			}
-			_, err := s.CreateStatement(ctx, userID, StatementInput{
-				CategoryID: catID,
-				Text:       text,
-			})
-			if err != nil {
-				return "", err
-			}
-		}
-	}
-
-	patch := UserStatePatch{Inited: boolPtr(true)}
-	_, err = s.UpdateUserState(ctx, userID, patch)
-	if err != nil {
-		return "", err
-	}
-
-	return "ok", nil
-}
-
-func (s *Service) useYDB(userID string) bool {
-	return feature.UseYDB(userID, s.Feature)
-}
-
 func isYDBNotFound(err error) bool {
 	if err == nil {
 		return false
@@ -861,14 +1021,22 @@ func (s *Service) appendChange(ctx context.Context, userID, entityType, entityID
 	if err != nil {
 		return err
 	}
-	return s.Store.AppendChange(ctx, userID, models.ChangeEvent{
+	err = s.Store.AppendChange(ctx, userID, models.ChangeEvent{
 		Cursor:     id.New(),
 		EntityType: entityType,
 		EntityID:   entityID,
 		Op:         op,
 		Payload:    data,
 		UpdatedAt:  updatedAt,
+		Origin:     changeOrigin(ctx),
+		Actor:      userctx.Actor(ctx),
 	})
+	// Board edits may need to reach live copies shared by this user.
+	switch entityType {
+	case "category", "statement", "category_order", "statement_order":
+		s.markShareSync(ctx, userID)
+	}
+	return err
 }
 
 func (s *Service) seedUserData(ctx context.Context, userID string, categories []models.Category, statements []models.Statement) error {
@@ -898,11 +1066,21 @@ func (s *Service) seedUserData(ctx context.Context, userID string, categories []
 }
 
 func (s *Service) seedUserState(ctx context.Context, userID string, state models.UserState) error {
-	state.Quickes = normalizeQuickes(state.Quickes)
+	state.Quickes = normalizeQuickes(state.Quickes, locale.Resolve(state.Preferences, locale.FromContext(ctx)))
 	_, err := s.Store.SetUserState(ctx, userID, state, time.Now().UnixMilli())
 	return err
 }
 
+// GetCategory returns one live category.
+func (s *Service) GetCategory(ctx context.Context, userID, categoryID string) (models.Category, error) {
+	return s.findCategory(ctx, userID, categoryID)
+}
+
+// GetStatement returns one live statement.
+func (s *Service) GetStatement(ctx context.Context, userID, statementID string) (models.Statement, error) {
+	return s.findStatement(ctx, userID, statementID)
+}
+
 func (s *Service) findCategory(ctx context.Context, userID, categoryID string) (models.Category, error) {
 	categories, err := s.ListCategories(ctx, userID)
 	if err != nil {
@@ -917,8 +1095,9 @@ func (s *Service) findCategory(ctx context.Context, userID, categoryID string) (
 }
 
 func (s *Service) findStatement(ctx context.Context, userID, statementID string) (models.Statement, error) {
-	if s.LegacyReader != nil && !s.useYDB(userID) {
-		_, statements, err := s.LegacyReader.FetchUserData(ctx, userID)
+	legacy := s.legacyReader(ctx, userID)
+	if legacy != nil && !s.useYDB(ctx, userID) {
+		_, statements, err := legacy.FetchUserData(ctx, userID)
 		if err != nil {
 			return models.Statement{}, err
 		}
@@ -934,8 +1113,8 @@ func (s *Service) findStatement(ctx context.Context, userID, statementID string)
 	if err != nil {
 		return models.Statement{}, err
 	}
-	if len(categories) == 0 && s.LegacyReader != nil {
-		legacyCategories, statements, err := s.LegacyReader.FetchUserData(ctx, userID)
+	if len(categories) == 0 && legacy != nil {
+		legacyCategories, statements, err := legacy.FetchUserData(ctx, userID)
 		if err != nil {
 			return models.Statement{}, err
 		}
@@ -980,8 +1159,10 @@ func (s *Service) getOrCreateCategory(ctx context.Context, userID, label string,
 	return category.ID, nil
 }
 
-func normalizeQuickes(quickes []string) []string {
-	defaultsList := defaults.DefaultQuickes
+// normalizeQuickes returns the six quick phrase slots, filling blank ones
+// with the defaults for loc.
+func normalizeQuickes(quickes []string, loc string) []string {
+	defaultsList := defaults.Quickes(loc)
 	out := make([]string, len(defaultsList))
 	for i := range defaultsList {
 		if i < len(quickes) && strings.TrimSpace(quickes[i]) != "" {
@@ -1077,11 +1258,21 @@ func (s *Service) CreateGlobalCategory(ctx context.Context, input GlobalCategory
 	if input.Created == 0 {
 		input.Created = now
 	}
+	if input.ParentID != "" {
+		categories, err := s.Store.ListGlobalCategories(ctx, false)
+		if err != nil {
+			return models.GlobalCategory{}, err
+		}
+		if err := validateParent(globalCategoryParents(categories), input.ID, input.ParentID); err != nil {
+			return models.GlobalCategory{}, err
+		}
+	}
 	category := models.GlobalCategory{
 		ID:        input.ID,
 		Label:     input.Label,
 		Created:   input.Created,
 		Default:   input.Default,
+		ParentID:  input.ParentID,
 		UpdatedAt: now,
 	}
 	return s.Store.UpsertGlobalCategory(ctx, category)
@@ -1110,14 +1301,36 @@ func (s *Service) UpdateGlobalCategory(ctx context.Context, categoryID string, p
 	if patch.Default != nil {
 		category.Default = patch.Default
 	}
+	if patch.ParentID != nil && *patch.ParentID != category.ParentID {
+		if err := validateParent(globalCategoryParents(categories), categoryID, *patch.ParentID); err != nil {
+			return models.GlobalCategory{}, err
+		}
+		category.ParentID = *patch.ParentID
+	}
 	category.UpdatedAt = time.Now().UnixMilli()
 
 	return s.Store.UpsertGlobalCategory(ctx, *category)
 }
 
-// DeleteGlobalCategory deletes a global category.
+// DeleteGlobalCategory deletes a global category. Its sub-categories move
+// up to its parent.
 func (s *Service) DeleteGlobalCategory(ctx context.Context, categoryID string) error {
 	updatedAt := time.Now().UnixMilli()
+	categories, err := s.Store.ListGlobalCategories(ctx, false)
+	if err != nil {
+		return err
+	}
+	parents := globalCategoryParents(categories)
+	for _, cat := range categories {
+		if cat.ParentID != categoryID || cat.ID == categoryID {
+			continue
+		}
+		cat.ParentID = parents[categoryID]
+		cat.UpdatedAt = updatedAt
+		if _, err := s.Store.UpsertGlobalCategory(ctx, cat); err != nil {
+			return err
+		}
+	}
 	return s.Store.DeleteGlobalCategory(ctx, categoryID, updatedAt)
 }
 
@@ -1127,12 +1340,18 @@ func (s *Service) CreateFactoryQuestion(ctx context.Context, input FactoryQuesti
 		input.ID = id.NewShort()
 	}
 	question := models.FactoryQuestion{
-		ID:         input.ID,
-		Label:      input.Label,
-		Phrases:    input.Phrases,
-		Category:   input.Category,
-		Type:       input.Type,
-		OrderIndex: input.OrderIndex,
+		ID:          input.ID,
+		Label:       input.Label,
+		Phrases:     input.Phrases,
+		Category:    input.Category,
+		Type:        input.Type,
+		OrderIndex:  input.OrderIndex,
+		Options:     input.Options,
+		Placeholder: input.Placeholder,
+		ShowIf:      input.ShowIf,
+	}
+	if err := validateQuestion(question); err != nil {
+		return models.FactoryQuestion{}, err
 	}
 	return s.Store.UpsertFactoryQuestion(ctx, question)
 }
@@ -1169,11 +1388,23 @@ func (s *Service) UpdateFactoryQuestion(ctx context.Context, questionID string,
 	if patch.OrderIndex != nil {
 		question.OrderIndex = *patch.OrderIndex
 	}
+	if patch.Options != nil {
+		question.Options = patch.Options
+	}
+	if patch.Placeholder != nil {
+		question.Placeholder = *patch.Placeholder
+	}
+	if patch.ShowIfSet {
+		question.ShowIf = patch.ShowIf
+	}
+	if err := validateQuestion(*question); err != nil {
+		return models.FactoryQuestion{}, err
+	}
 
 	return s.Store.UpsertFactoryQuestion(ctx, *question)
 }
 
 // DeleteFactoryQuestion deletes a factory question.
 func (s *Service) DeleteFactoryQuestion(ctx context.Context, questionID string) error {
-	return s.Store.DeleteFactoryQuestion(ctx, questionID)
+	return s.Store.DeleteFactoryQuestion(ctx, questionID, time.Now().UnixMilli())
 }
@@ -0,0 +1,525 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// Share modes.
+const (
+	ShareCopy = "copy"
+	ShareLive = "live"
+)
+
+// Share link lifetimes.
+const (
+	DefaultShareTTL = 7 * 24 * time.Hour
+	MaxShareTTL     = 30 * 24 * time.Hour
+)
+
+// Live share sync. An owner's edits are batched for shareSyncDelay, and
+// pending owners are polled at the same rate. A claimed batch is hidden from
+// other instances until every owner in it had shareSyncTimeout to finish.
+const (
+	shareSyncDelay   = 2 * time.Second
+	shareSyncBatch   = 10
+	shareSyncTimeout = time.Minute
+	shareSyncLease   = shareSyncBatch*shareSyncTimeout + time.Minute
+)
+
+var (
+	ErrInvalidShare = errors.New("mode must be copy or live and the link may last up to 30 days")
+	ErrShareExpired = errors.New("share link has expired")
+	ErrOwnShare     = errors.New("cannot accept your own share")
+)
+
+// SharePreview is what a recipient sees before accepting a share.
+type SharePreview struct {
+	Share      models.Share       `json:"share"`
+	Categories []models.Category  `json:"categories"`
+	Statements []models.Statement `json:"statements"`
+}
+
+// CreateShare creates an invite link to the category and its sub-categories.
+// A zero ttl means DefaultShareTTL.
+func (s *Service) CreateShare(ctx context.Context, userID, categoryID, mode string, ttl time.Duration) (models.Share, error) {
+	if mode == "" {
+		mode = ShareCopy
+	}
+	if ttl == 0 {
+		ttl = DefaultShareTTL
+	}
+	if (mode != ShareCopy && mode != ShareLive) || ttl < 0 || ttl > MaxShareTTL {
+		return models.Share{}, ErrInvalidShare
+	}
+	if _, err := s.findCategory(ctx, userID, categoryID); err != nil {
+		return models.Share{}, err
+	}
+
+	now := time.Now()
+	share := models.Share{
+		Token:      id.NewToken(),
+		OwnerID:    userID,
+		CategoryID: categoryID,
+		Mode:       mode,
+		ExpiresAt:  now.Add(ttl).UnixMilli(),
+		Created:    now.UnixMilli(),
+	}
+	if err := s.Store.UpsertShare(ctx, share); err != nil {
+		return models.Share{}, err
+	}
+	return share, nil
+}
+
+// PreviewShare returns the categories and statements a share would copy.
+func (s *Service) PreviewShare(ctx context.Context, token string) (SharePreview, error) {
+	share, err := s.activeShare(ctx, token)
+	if err != nil {
+		return SharePreview{}, err
+	}
+	categories, statements, err := s.sharedTree(ctx, share.OwnerID, share.CategoryID)
+	if err != nil {
+		return SharePreview{}, err
+	}
+	return SharePreview{Share: share, Categories: categories, Statements: statements}, nil
+}
+
+// AcceptShare copies the shared category with its sub-categories and
+// statements into the user's account under the owner's IDs, the way global
+// categories are imported. It returns "exists" when the user already has the
+// category, unless force is set. Live shares keep the copy in sync with the
+// owner's later edits.
+func (s *Service) AcceptShare(ctx context.Context, userID, token string, force bool) (string, error) {
+	share, err := s.activeShare(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if share.OwnerID == userID {
+		return "", ErrOwnShare
+	}
+	if !force {
+		if _, err := s.findCategory(ctx, userID, share.CategoryID); err == nil {
+			return "exists", nil
+		} else if !errors.Is(err, store.ErrNotFound) {
+			return "", err
+		}
+	}
+
+	if err := s.copyShare(ctx, share.OwnerID, share.CategoryID, userID); err != nil {
+		return "", err
+	}
+	if share.Mode == ShareLive {
+		err := s.Store.UpsertShareSubscription(ctx, models.ShareSubscription{
+			OwnerID:     share.OwnerID,
+			RecipientID: userID,
+			CategoryID:  share.CategoryID,
+			Token:       share.Token,
+			Created:     time.Now().UnixMilli(),
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return "ok", nil
+}
+
+// RevokeShare deletes the owner's share link. Live copies made through it
+// stop following the owner's edits and stay with their recipients as they
+// are.
+func (s *Service) RevokeShare(ctx context.Context, userID, token string) error {
+	share, err := s.Store.GetShare(ctx, token)
+	if err != nil {
+		return err
+	}
+	if share.OwnerID != userID {
+		return store.ErrNotFound
+	}
+	return s.Store.DeleteShare(ctx, userID, token)
+}
+
+// UnsubscribeShare stops the caller's live copy made through token from
+// following the owner. The copy stays with the caller as it is.
+func (s *Service) UnsubscribeShare(ctx context.Context, userID, token string) error {
+	share, err := s.Store.GetShare(ctx, token)
+	if err != nil {
+		return err
+	}
+	subs, err := s.Store.ListShareSubscriptions(ctx, share.OwnerID)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if sub.RecipientID == userID && sub.Token == token {
+			return s.Store.DeleteShareSubscription(ctx, share.OwnerID, userID, sub.CategoryID)
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (s *Service) activeShare(ctx context.Context, token string) (models.Share, error) {
+	share, err := s.Store.GetShare(ctx, token)
+	if err != nil {
+		return models.Share{}, err
+	}
+	if time.Now().UnixMilli() > share.ExpiresAt {
+		return models.Share{}, ErrShareExpired
+	}
+	return share, nil
+}
+
+// sharedTree returns the owner's category rootID with its descendants,
+// parents first, and their statements. Images belong to the owner's media,
+// so copies leave them out.
+func (s *Service) sharedTree(ctx context.Context, ownerID, rootID string) ([]models.Category, []models.Statement, error) {
+	allCategories, err := s.ListCategories(ctx, ownerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	categories := categorySubtree(allCategories, rootID)
+	if len(categories) == 0 {
+		return nil, nil, store.ErrNotFound
+	}
+	inTree := make(map[string]bool, len(categories))
+	for i := range categories {
+		categories[i].ImageID = ""
+		inTree[categories[i].ID] = true
+	}
+
+	allStatements, err := s.Store.ListAllStatements(ctx, ownerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	statements := []models.Statement{}
+	for _, stmt := range allStatements {
+		if inTree[stmt.CategoryID] {
+			stmt.ImageID = ""
+			statements = append(statements, stmt)
+		}
+	}
+	sortStatements(statements)
+	return categories, statements, nil
+}
+
+// copyShare makes the recipient's copy of rootID match the owner's subtree.
+// Categories and statements the recipient added to the copy are kept.
+func (s *Service) copyShare(ctx context.Context, ownerID, rootID, recipientID string) error {
+	categories, statements, err := s.sharedTree(ctx, ownerID, rootID)
+	if err != nil {
+		return err
+	}
+	source, err := s.ownerBoardIDs(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	return s.copyTree(ctx, recipientID, categories, statements, source)
+}
+
+// ownerBoardIDs returns the ids of every category and statement the owner
+// has, live or in the trash. A copied row outside this set was added by the
+// recipient.
+func (s *Service) ownerBoardIDs(ctx context.Context, ownerID string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	categories, err := s.ListCategories(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, cat := range categories {
+		ids[cat.ID] = true
+	}
+	statements, err := s.Store.ListAllStatements(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range statements {
+		ids[stmt.ID] = true
+	}
+	deletedCategories, err := s.Store.ListDeletedCategories(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, cat := range deletedCategories {
+		ids[cat.ID] = true
+	}
+	deletedStatements, err := s.Store.ListDeletedStatements(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range deletedStatements {
+		ids[stmt.ID] = true
+	}
+	return ids, nil
+}
+
+// copyTree makes the recipient's copy of a category subtree, given parents
+// first, match it: changed categories and statements are written, and ones
+// the source no longer has are deleted. Rows whose id is not in source were
+// added by the recipient and stay; a nil source treats every row of the copy
+// as the source's. The copied root keeps the recipient's place for it.
+func (s *Service) copyTree(ctx context.Context, recipientID string, categories []models.Category, statements []models.Statement, source map[string]bool) error {
+	rootID := categories[0].ID
+	existing, err := s.ListCategories(ctx, recipientID)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]models.Category, len(existing))
+	for _, cat := range existing {
+		current[cat.ID] = cat
+	}
+	copiedCategories := categorySubtree(existing, rootID)
+	copied := make(map[string]bool, len(copiedCategories))
+	for _, cat := range copiedCategories {
+		copied[cat.ID] = true
+	}
+	existingStatements, err := s.Store.ListAllStatements(ctx, recipientID)
+	if err != nil {
+		return err
+	}
+	currentStatements := make(map[string]models.Statement)
+	for _, stmt := range existingStatements {
+		if copied[stmt.CategoryID] {
+			currentStatements[stmt.ID] = stmt
+		}
+	}
+
+	mirror := s.legacyWriter(ctx, recipientID)
+	updatedAt := time.Now().UnixMilli()
+	kept := make(map[string]bool, len(categories))
+	for i, cat := range categories {
+		kept[cat.ID] = true
+		have, ok := current[cat.ID]
+		if i == 0 {
+			cat.ParentID, cat.Position = "", ""
+			if ok {
+				cat.ParentID, cat.Position = have.ParentID, have.Position
+			} else if _, found := current[categories[0].ParentID]; found {
+				cat.ParentID = categories[0].ParentID
+			}
+		}
+		if ok && sameSharedCategory(have, cat) {
+			continue
+		}
+		cat.UpdatedAt = updatedAt
+		if _, err := s.Store.UpsertCategory(ctx, recipientID, cat); err != nil {
+			return err
+		}
+		if mirror != nil {
+			if err := mirror.UpsertCategory(ctx, recipientID, cat); err != nil {
+				return err
+			}
+		}
+		op := revisionUpdate
+		if !ok {
+			op = revisionCreate
+		}
+		_ = s.appendChange(ctx, recipientID, "category", cat.ID, "upsert", cat, updatedAt)
+		_ = s.recordRevision(ctx, recipientID, "category", cat.ID, op, cat, updatedAt)
+	}
+	owned := make(map[string]bool, len(statements))
+	for _, stmt := range statements {
+		owned[stmt.ID] = true
+	}
+	staleCategories, staleStatements := staleCopies(copiedCategories, currentStatements, kept, owned, source)
+	for _, cat := range staleCategories {
+		if err := s.deleteCategory(ctx, recipientID, cat, mirror, updatedAt); err != nil {
+			return err
+		}
+	}
+
+	batch := store.StatementBatch{UpdatedAt: updatedAt, Deleted: staleStatements}
+	for _, stmt := range statements {
+		have, ok := currentStatements[stmt.ID]
+		if ok && have.CategoryID != stmt.CategoryID {
+			batch.Removed = append(batch.Removed, have)
+		} else if ok && sameSharedStatement(have, stmt) {
+			continue
+		}
+		stmt.UpdatedAt = updatedAt
+		batch.Upserts = append(batch.Upserts, stmt)
+	}
+	if len(batch.Upserts) == 0 && len(batch.Deleted) == 0 {
+		return nil
+	}
+	if err := s.Store.ApplyStatementBatch(ctx, recipientID, batch); err != nil {
+		return err
+	}
+	if err := s.mirrorStatementBatch(ctx, recipientID, batch); err != nil {
+		return err
+	}
+	for _, stmt := range batch.Upserts {
+		op := revisionUpdate
+		if have, ok := currentStatements[stmt.ID]; !ok {
+			op = revisionCreate
+		} else if have.CategoryID != stmt.CategoryID {
+			op = revisionMove
+		}
+		_ = s.appendChange(ctx, recipientID, "statement", stmt.ID, "upsert", stmt, updatedAt)
+		_ = s.recordRevision(ctx, recipientID, "statement", stmt.ID, op, stmt, updatedAt)
+	}
+	for _, stmt := range batch.Deleted {
+		_ = s.appendChange(ctx, recipientID, "statement", stmt.ID, "delete", map[string]string{"id": stmt.ID, "categoryId": stmt.CategoryID}, updatedAt)
+		_ = s.recordRevision(ctx, recipientID, "statement", stmt.ID, revisionDelete, stmt, updatedAt)
+	}
+	return nil
+}
+
+// staleCopies picks the copied categories and statements to delete: those
+// the source had but no longer sends. Rows outside source (when it is set)
+// are the recipient's own. Statements of deleted categories go with them, so
+// they are left out; the rest are sorted by id.
+func staleCopies(copied []models.Category, current map[string]models.Statement, kept, owned, source map[string]bool) ([]models.Category, []models.Statement) {
+	fromSource := func(id string) bool {
+		return source == nil || source[id]
+	}
+	var categories []models.Category
+	dropped := make(map[string]bool)
+	for _, cat := range copied {
+		if !kept[cat.ID] && fromSource(cat.ID) {
+			categories = append(categories, cat)
+			dropped[cat.ID] = true
+		}
+	}
+	var statements []models.Statement
+	for _, stmt := range current {
+		if !owned[stmt.ID] && fromSource(stmt.ID) && !dropped[stmt.CategoryID] {
+			statements = append(statements, stmt)
+		}
+	}
+	slices.SortFunc(statements, func(a, b models.Statement) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return categories, statements
+}
+
+func sameSharedCategory(a, b models.Category) bool {
+	return a.Label == b.Label && a.ParentID == b.ParentID && a.Position == b.Position &&
+		a.AIUse == b.AIUse && a.Color == b.Color && a.Icon == b.Icon && a.ImageID == b.ImageID &&
+		(a.Default == nil) == (b.Default == nil) && (a.Default == nil || *a.Default == *b.Default)
+}
+
+func sameSharedStatement(a, b models.Statement) bool {
+	return a.Text == b.Text && a.CategoryID == b.CategoryID && a.Position == b.Position &&
+		a.Color == b.Color && a.Icon == b.Icon && a.ImageID == b.ImageID
+}
+
+// categorySubtree returns rootID followed by its descendants, parents before
+// children. It returns nil when rootID is unknown.
+func categorySubtree(categories []models.Category, rootID string) []models.Category {
+	byID := make(map[string]models.Category, len(categories))
+	for _, cat := range categories {
+		byID[cat.ID] = cat
+	}
+	root, ok := byID[rootID]
+	if !ok {
+		return nil
+	}
+	ids := descendantIDs(categoryParents(categories), rootID)
+	slices.Reverse(ids)
+	out := []models.Category{root}
+	for _, id := range ids {
+		out = append(out, byID[id])
+	}
+	return out
+}
+
+// markShareSync queues the owner's live shares for an update shortly after
+// an edit. Most users share nothing, so a read-only lookup keeps their
+// edits from writing to the queue.
+func (s *Service) markShareSync(ctx context.Context, ownerID string) {
+	shared, err := s.Store.HasShareSubscriptions(ctx, ownerID)
+	if err != nil {
+		slog.Warn("share sync lookup failed", "user_id", ownerID, "error", err)
+		return
+	}
+	if !shared {
+		return
+	}
+	now := time.Now()
+	if err := s.Store.MarkShareSyncPending(ctx, ownerID, now.Add(shareSyncDelay).UnixMilli(), now.UnixMilli()); err != nil {
+		slog.Warn("share sync mark failed", "user_id", ownerID, "error", err)
+	}
+}
+
+// RunShareSync updates live copies for owners queued by markShareSync until
+// ctx is done. Every API instance runs it; claims keep an owner on one of
+// them at a time, and an owner left claimed by a stopped instance is picked
+// up again once its claim lapses.
+func (s *Service) RunShareSync(ctx context.Context) error {
+	ticker := time.NewTicker(shareSyncDelay)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		claims, err := s.Store.ClaimShareSyncPending(ctx, now.UnixMilli(), now.Add(shareSyncLease).UnixMilli(), shareSyncBatch)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("share sync claim failed", "error", err)
+		}
+		for _, claim := range claims {
+			syncCtx, cancel := context.WithTimeout(ctx, shareSyncTimeout)
+			s.syncLiveShares(syncCtx, claim.OwnerID)
+			cancel()
+			if err := s.Store.ReleaseShareSyncPending(ctx, claim); err != nil {
+				slog.Warn("share sync release failed", "user_id", claim.OwnerID, "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncLiveShares updates every live copy of the owner's categories. A copy
+// follows the owner until the link is revoked, the recipient unsubscribes,
+// or either side deletes the shared category; the link's expiry only ends
+// the time it can be accepted.
+func (s *Service) syncLiveShares(ctx context.Context, ownerID string) {
+	subs, err := s.Store.ListShareSubscriptions(ctx, ownerID)
+	if err != nil {
+		slog.Warn("share sync failed", "user_id", ownerID, "error", err)
+		return
+	}
+	links := make(map[string]error)
+	for _, sub := range subs {
+		linkErr, checked := links[sub.Token]
+		if !checked {
+			linkErr = s.liveLink(ctx, ownerID, sub.Token)
+			links[sub.Token] = linkErr
+		}
+		err := linkErr
+		if err == nil {
+			_, err = s.findCategory(ctx, sub.RecipientID, sub.CategoryID)
+		}
+		if err == nil {
+			err = s.copyShare(ctx, ownerID, sub.CategoryID, sub.RecipientID)
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			err = s.Store.DeleteShareSubscription(ctx, ownerID, sub.RecipientID, sub.CategoryID)
+		}
+		if err != nil {
+			slog.Warn("share sync failed", "user_id", ownerID, "recipient_id", sub.RecipientID, "category_id", sub.CategoryID, "error", err)
+		}
+	}
+}
+
+// liveLink checks that the link behind a subscription has not been revoked
+// and belongs to the owner.
+func (s *Service) liveLink(ctx context.Context, ownerID, token string) error {
+	share, err := s.Store.GetShare(ctx, token)
+	if err != nil {
+		return err
+	}
+	if share.OwnerID != ownerID {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestCategorySubtree(t *testing.T) {
+	categories := []models.Category{
+		{ID: "other"},
+		{ID: "leaf", ParentID: "mid"},
+		{ID: "root", ParentID: "other"},
+		{ID: "mid", ParentID: "root"},
+	}
+	subtree := categorySubtree(categories, "root")
+	var ids []string
+	for _, cat := range subtree {
+		ids = append(ids, cat.ID)
+	}
+	if len(ids) != 3 || ids[0] != "root" || ids[1] != "mid" || ids[2] != "leaf" {
+		t.Fatalf("expected root, mid, leaf; got %v", ids)
+	}
+	if categorySubtree(categories, "missing") != nil {
+		t.Fatalf("expected nil for an unknown root")
+	}
+}
+
+func TestStaleCopiesKeepsRecipientRows(t *testing.T) {
+	copied := []models.Category{{ID: "root"}, {ID: "gone"}, {ID: "mine", ParentID: "root"}}
+	current := map[string]models.Statement{
+		"s1":     {ID: "s1", CategoryID: "root"},
+		"s2":     {ID: "s2", CategoryID: "root"},
+		"own":    {ID: "own", CategoryID: "root"},
+		"inMine": {ID: "inMine", CategoryID: "mine"},
+		"inGone": {ID: "inGone", CategoryID: "gone"},
+	}
+	kept := map[string]bool{"root": true}
+	owned := map[string]bool{"s1": true}
+	source := map[string]bool{"root": true, "gone": true, "s1": true, "s2": true, "inGone": true}
+
+	categories, statements := staleCopies(copied, current, kept, owned, source)
+	if len(categories) != 1 || categories[0].ID != "gone" {
+		t.Fatalf("expected only the owner's removed category, got %+v", categories)
+	}
+	// s2 was removed by the owner; own and inMine were added by the recipient;
+	// inGone goes with its category.
+	if len(statements) != 1 || statements[0].ID != "s2" {
+		t.Fatalf("expected only s2 to be deleted, got %+v", statements)
+	}
+
+	categories, statements = staleCopies(copied, current, kept, owned, nil)
+	if len(categories) != 2 || len(statements) != 2 {
+		t.Fatalf("expected a nil source to own the whole copy, got %+v %+v", categories, statements)
+	}
+	if statements[0].ID != "own" || statements[1].ID != "s2" {
+		t.Fatalf("expected statements sorted by id, got %+v", statements)
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/locale"
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// Translation kinds.
+const (
+	TranslationCategory  = "category"
+	TranslationStatement = "statement"
+	TranslationQuestion  = "question"
+)
+
+var ErrInvalidTranslation = errors.New("invalid translation")
+
+// TranslationGap is a catalogue entry without an up-to-date translation.
+// Stale marks a translation written before the canonical text last changed.
+type TranslationGap struct {
+	Kind     string `json:"kind"`
+	EntityID string `json:"entity_id"`
+	Text     string `json:"text"`
+	Stale    bool   `json:"stale,omitempty"`
+}
+
+// translationSet indexes one locale's translations by kind and entity id.
+// A nil set leaves everything in the canonical locale.
+type translationSet map[string]models.GlobalTranslation
+
+func translationKey(kind, entityID string) string {
+	return kind + "|" + entityID
+}
+
+func newTranslationSet(translations []models.GlobalTranslation) translationSet {
+	set := make(translationSet, len(translations))
+	for _, translation := range translations {
+		set[translationKey(translation.Kind, translation.EntityID)] = translation
+	}
+	return set
+}
+
+// category returns cat with its label and nested statements translated.
+// A translation counts as a change to the entry it translates.
+func (t translationSet) category(cat models.GlobalCategory) models.GlobalCategory {
+	if translation, ok := t[translationKey(TranslationCategory, cat.ID)]; ok {
+		cat.Label = translation.Text
+		cat.UpdatedAt = max(cat.UpdatedAt, translation.UpdatedAt)
+	}
+	if len(cat.Statements) > 0 {
+		cat.Statements = t.statements(cat.ID, append([]models.Statement(nil), cat.Statements...))
+	}
+	return cat
+}
+
+// statements translates the statements of a global category in place.
+func (t translationSet) statements(categoryID string, statements []models.Statement) []models.Statement {
+	for i, stmt := range statements {
+		if translation, ok := t[translationKey(TranslationStatement, categoryID+"/"+stmt.ID)]; ok {
+			statements[i].Text = translation.Text
+			statements[i].UpdatedAt = max(stmt.UpdatedAt, translation.UpdatedAt)
+		}
+	}
+	return statements
+}
+
+func (t translationSet) question(question models.FactoryQuestion) models.FactoryQuestion {
+	translation, ok := t[translationKey(TranslationQuestion, question.ID)]
+	if !ok {
+		return question
+	}
+	question.Label = translation.Text
+	if len(translation.Phrases) > 0 {
+		question.Phrases = translation.Phrases
+	}
+	if translation.Category != "" {
+		question.Category = translation.Category
+	}
+	if len(translation.Options) > 0 {
+		options := make([]models.QuestionOption, len(question.Options))
+		for i, option := range question.Options {
+			for _, translated := range translation.Options {
+				if translated.Value == option.Value && translated.Label != "" {
+					option.Label = translated.Label
+				}
+			}
+			options[i] = option
+		}
+		question.Options = options
+	}
+	return question
+}
+
+// UserLocale returns the locale to serve the user: their locale
+// preference, then the request's Accept-Language, then the default.
+func (s *Service) UserLocale(ctx context.Context, userID string) string {
+	requested := locale.FromContext(ctx)
+	state, err := s.GetUserState(ctx, userID)
+	if err != nil {
+		return locale.Resolve(nil, requested)
+	}
+	return locale.Resolve(state.Preferences, requested)
+}
+
+// translations loads the translations for loc. The default locale, and
+// "", need none.
+func (s *Service) translations(ctx context.Context, loc string) (translationSet, error) {
+	if loc == "" || loc == locale.Default {
+		return nil, nil
+	}
+	translations, err := s.Store.ListGlobalTranslations(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	return newTranslationSet(translations), nil
+}
+
+// ListGlobalTranslations returns the translations for loc, or for every
+// locale when loc is empty.
+func (s *Service) ListGlobalTranslations(ctx context.Context, loc string) ([]models.GlobalTranslation, error) {
+	translations, err := s.Store.ListGlobalTranslations(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(translations, func(i, j int) bool {
+		a, b := translations[i], translations[j]
+		if a.Locale != b.Locale {
+			return a.Locale < b.Locale
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.EntityID < b.EntityID
+	})
+	return translations, nil
+}
+
+// SaveGlobalTranslation writes a translation of an existing catalogue entry
+// into a supported non-default locale.
+func (s *Service) SaveGlobalTranslation(ctx context.Context, translation models.GlobalTranslation) (models.GlobalTranslation, error) {
+	translation.Locale = locale.Normalize(translation.Locale)
+	translation.Text = strings.TrimSpace(translation.Text)
+	translation.Category = strings.TrimSpace(translation.Category)
+	if translation.Locale == "" || translation.Locale == locale.Default || translation.Text == "" {
+		return models.GlobalTranslation{}, ErrInvalidTranslation
+	}
+	if translation.Kind != TranslationQuestion {
+		translation.Phrases, translation.Category, translation.Options = nil, "", nil
+	}
+	if err := s.globalEntityExists(ctx, translation.Kind, translation.EntityID); err != nil {
+		return models.GlobalTranslation{}, err
+	}
+	translation.UpdatedAt = time.Now().UnixMilli()
+	if err := s.Store.UpsertGlobalTranslation(ctx, translation); err != nil {
+		return models.GlobalTranslation{}, err
+	}
+	return translation, nil
+}
+
+// DeleteGlobalTranslation removes a translation; the entry falls back to
+// the canonical text.
+func (s *Service) DeleteGlobalTranslation(ctx context.Context, kind, entityID, loc string) error {
+	return s.Store.DeleteGlobalTranslation(ctx, kind, entityID, loc)
+}
+
+func (s *Service) globalEntityExists(ctx context.Context, kind, entityID string) error {
+	switch kind {
+	case TranslationCategory:
+		categories, err := s.Store.ListGlobalCategories(ctx, false)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(categories, func(cat models.GlobalCategory) bool { return cat.ID == entityID }) {
+			return store.ErrNotFound
+		}
+	case TranslationStatement:
+		categoryID, statementID, ok := strings.Cut(entityID, "/")
+		if !ok {
+			return ErrInvalidTranslation
+		}
+		statements, err := s.globalCategoryStatements(ctx, categoryID)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(statements, func(stmt models.Statement) bool { return stmt.ID == statementID }) {
+			return store.ErrNotFound
+		}
+	case TranslationQuestion:
+		questions, err := s.Store.ListFactoryQuestions(ctx)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(questions, func(q models.FactoryQuestion) bool { return q.ID == entityID }) {
+			return store.ErrNotFound
+		}
+	default:
+		return ErrInvalidTranslation
+	}
+	return nil
+}
+
+// MissingTranslations reports, per non-default locale, the catalogue
+// entries with no translation or a stale one. An empty loc reports every
+// supported locale.
+func (s *Service) MissingTranslations(ctx context.Context, loc string) (map[string][]TranslationGap, error) {
+	locales := slices.DeleteFunc(slices.Clone(locale.Supported), func(l string) bool { return l == locale.Default })
+	if loc != "" {
+		loc = locale.Normalize(loc)
+		if loc == "" || loc == locale.Default {
+			return nil, ErrInvalidTranslation
+		}
+		locales = []string{loc}
+	}
+	categories, err := s.Store.ListGlobalCategories(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.Store.ListFactoryQuestions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := s.Store.ListGlobalTranslations(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return translationGaps(categories, questions, translations, locales), nil
+}
+
+// translationGaps lists, per locale, the categories, statements and
+// questions whose translation is missing or older than the entry.
+func translationGaps(categories []models.GlobalCategory, questions []models.FactoryQuestion, translations []models.GlobalTranslation, locales []string) map[string][]TranslationGap {
+	byLocale := make(map[string][]models.GlobalTranslation, len(locales))
+	for _, translation := range translations {
+		byLocale[translation.Locale] = append(byLocale[translation.Locale], translation)
+	}
+
+	report := make(map[string][]TranslationGap, len(locales))
+	for _, loc := range locales {
+		set := newTranslationSet(byLocale[loc])
+		gaps := []TranslationGap{}
+		check := func(kind, entityID, text string, updatedAt int64) {
+			translation, ok := set[translationKey(kind, entityID)]
+			if ok && translation.UpdatedAt >= updatedAt {
+				return
+			}
+			gaps = append(gaps, TranslationGap{Kind: kind, EntityID: entityID, Text: text, Stale: ok})
+		}
+		for _, cat := range categories {
+			check(TranslationCategory, cat.ID, cat.Label, cat.UpdatedAt)
+			for _, stmt := range cat.Statements {
+				check(TranslationStatement, cat.ID+"/"+stmt.ID, stmt.Text, stmt.UpdatedAt)
+			}
+		}
+		for _, question := range questions {
+			// Questions carry no timestamp, so only missing ones are reported.
+			check(TranslationQuestion, question.ID, question.Label, 0)
+		}
+		report[loc] = gaps
+	}
+	return report
+}
+
+// translateImport rewrites the categories just imported, and their
+// statements, with the translated text.
+func (s *Service) translateImport(ctx context.Context, userID string, categoryIDs []string, translations translationSet) error {
+	mirror := s.legacyWriter(ctx, userID)
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, cat := range categories {
+		translation, ok := translations[translationKey(TranslationCategory, cat.ID)]
+		if !ok || !slices.Contains(categoryIDs, cat.ID) {
+			continue
+		}
+		cat.Label = translation.Text
+		if _, err := s.Store.UpsertCategory(ctx, userID, cat); err != nil {
+			return err
+		}
+		if mirror != nil {
+			if err := mirror.UpsertCategory(ctx, userID, cat); err != nil {
+				return err
+			}
+		}
+	}
+
+	statements, err := s.Store.ListAllStatements(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		translation, ok := translations[translationKey(TranslationStatement, stmt.CategoryID+"/"+stmt.ID)]
+		if !ok || !slices.Contains(categoryIDs, stmt.CategoryID) {
+			continue
+		}
+		stmt.Text = translation.Text
+		if _, err := s.Store.UpsertStatement(ctx, userID, stmt); err != nil {
+			return err
+		}
+		if mirror != nil {
+			if err := mirror.UpsertStatement(ctx, userID, stmt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestTranslationSet(t *testing.T) {
+	set := newTranslationSet([]models.GlobalTranslation{
+		{Kind: TranslationCategory, EntityID: "food", Locale: "en", Text: "Food", UpdatedAt: 300},
+		{Kind: TranslationStatement, EntityID: "food/water", Locale: "en", Text: "Water", UpdatedAt: 50},
+		{Kind: TranslationQuestion, EntityID: "name", Locale: "en", Text: "Your name?", Phrases: []string{"My name is %%"}},
+	})
+
+	global := models.GlobalCategory{ID: "food", Label: "Еда", UpdatedAt: 100, Statements: []models.Statement{
+		{ID: "water", Text: "Вода", UpdatedAt: 100},
+		{ID: "bread", Text: "Хлеб", UpdatedAt: 100},
+	}}
+	got := set.category(global)
+	if got.Label != "Food" || got.UpdatedAt != 300 {
+		t.Fatalf("unexpected category: %+v", got)
+	}
+	if got.Statements[0].Text != "Water" || got.Statements[0].UpdatedAt != 100 || got.Statements[1].Text != "Хлеб" {
+		t.Fatalf("unexpected statements: %+v", got.Statements)
+	}
+	if global.Statements[0].Text != "Вода" {
+		t.Fatalf("category must not change the input statements")
+	}
+
+	question := set.question(models.FactoryQuestion{ID: "name", Label: "Как вас зовут?", Phrases: []string{"Меня зовут %%"}, Category: "Обо мне"})
+	if question.Label != "Your name?" || question.Phrases[0] != "My name is %%" || question.Category != "Обо мне" {
+		t.Fatalf("unexpected question: %+v", question)
+	}
+
+	var none translationSet
+	if none.category(global).Label != "Еда" {
+		t.Fatalf("nil set must keep canonical text")
+	}
+}
+
+func TestTranslationGaps(t *testing.T) {
+	categories := []models.GlobalCategory{{ID: "food", Label: "Еда", UpdatedAt: 100, Statements: []models.Statement{
+		{ID: "water", Text: "Вода", UpdatedAt: 200},
+		{ID: "bread", Text: "Хлеб", UpdatedAt: 100},
+	}}}
+	questions := []models.FactoryQuestion{{ID: "name", Label: "Как вас зовут?"}}
+	translations := []models.GlobalTranslation{
+		{Kind: TranslationCategory, EntityID: "food", Locale: "en", Text: "Food", UpdatedAt: 150},
+		{Kind: TranslationStatement, EntityID: "food/water", Locale: "en", Text: "Water", UpdatedAt: 150},
+		{Kind: TranslationQuestion, EntityID: "name", Locale: "kk", Text: "Атыңыз кім?", UpdatedAt: 150},
+	}
+
+	report := translationGaps(categories, questions, translations, []string{"kk", "en"})
+	en := report["en"]
+	if len(en) != 3 {
+		t.Fatalf("expected three en gaps, got %+v", en)
+	}
+	if en[0].EntityID != "food/water" || !en[0].Stale || en[1].EntityID != "food/bread" || en[1].Stale || en[2].Kind != TranslationQuestion {
+		t.Fatalf("unexpected en gaps: %+v", en)
+	}
+	if len(report["kk"]) != 3 {
+		t.Fatalf("expected category and statements missing in kk, got %+v", report["kk"])
+	}
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+var (
+	ErrNotInTrash      = errors.New("item is not in the trash")
+	ErrCategoryInTrash = errors.New("statement category is in the trash")
+)
+
+// TrashSelection picks trash entries by id. An empty selection means
+// everything for PurgeTrash.
+type TrashSelection struct {
+	CategoryIDs  []string
+	StatementIDs []string
+}
+
+// RestoreResult lists restored entities.
+type RestoreResult struct {
+	Categories []models.Category  `json:"categories"`
+	Statements []models.Statement `json:"statements"`
+}
+
+// trashIndex flattens the trash for lookups by id.
+type trashIndex struct {
+	trash      models.Trash
+	categories map[string]*models.TrashedCategory
+	statements map[string]models.Statement
+}
+
+// ListTrash returns deleted categories, each with the statements and
+// sub-categories removed in the same delete, and statements deleted on
+// their own.
+func (s *Service) ListTrash(ctx context.Context, userID string) (models.Trash, error) {
+	index, err := s.loadTrash(ctx, userID)
+	if err != nil {
+		return models.Trash{}, err
+	}
+	return index.trash, nil
+}
+
+func (s *Service) loadTrash(ctx context.Context, userID string) (trashIndex, error) {
+	categories, err := s.Store.ListDeletedCategories(ctx, userID)
+	if err != nil {
+		return trashIndex{}, err
+	}
+	statements, err := s.Store.ListDeletedStatements(ctx, userID)
+	if err != nil {
+		return trashIndex{}, err
+	}
+	return buildTrash(categories, statements), nil
+}
+
+// buildTrash groups rows that share a deletion time with their deleted
+// parent category. Both inputs are newest first.
+func buildTrash(categories []models.TrashedCategory, statements []models.TrashedStatement) trashIndex {
+	byID := make(map[string]*models.TrashedCategory, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+	deletedWith := func(categoryID string, deletedAt int64) bool {
+		parent, ok := byID[categoryID]
+		return ok && parent.DeletedAt == deletedAt
+	}
+
+	index := trashIndex{
+		trash: models.Trash{
+			Categories: []models.TrashedCategory{},
+			Statements: []models.TrashedStatement{},
+		},
+		categories: byID,
+		statements: make(map[string]models.Statement, len(statements)),
+	}
+	for _, stmt := range statements {
+		index.statements[stmt.ID] = stmt.Statement
+		if deletedWith(stmt.CategoryID, stmt.DeletedAt) {
+			cat := byID[stmt.CategoryID]
+			cat.Statements = append(cat.Statements, stmt.Statement)
+			continue
+		}
+		index.trash.Statements = append(index.trash.Statements, stmt)
+	}
+
+	children := make(map[string][]string)
+	var roots []string
+	for _, cat := range categories {
+		if cat.ParentID != cat.ID && deletedWith(cat.ParentID, cat.DeletedAt) {
+			children[cat.ParentID] = append(children[cat.ParentID], cat.ID)
+			continue
+		}
+		roots = append(roots, cat.ID)
+	}
+	visited := make(map[string]bool, len(categories))
+	var nest func(id string) models.TrashedCategory
+	nest = func(id string) models.TrashedCategory {
+		visited[id] = true
+		node := *byID[id]
+		for _, childID := range children[id] {
+			if !visited[childID] {
+				node.Subcategories = append(node.Subcategories, nest(childID))
+			}
+		}
+		return node
+	}
+	for _, id := range roots {
+		index.trash.Categories = append(index.trash.Categories, nest(id))
+	}
+	// Stored rows never form cycles; this only guards against bad data.
+	for _, cat := range categories {
+		if !visited[cat.ID] {
+			index.trash.Categories = append(index.trash.Categories, nest(cat.ID))
+		}
+	}
+	return index
+}
+
+// subtree returns the trashed category and everything deleted with it,
+// parents first.
+func (t trashIndex) subtree(categoryID string) []*models.TrashedCategory {
+	var out []*models.TrashedCategory
+	var walk func(node models.TrashedCategory)
+	walk = func(node models.TrashedCategory) {
+		out = append(out, t.categories[node.ID])
+		for _, child := range node.Subcategories {
+			walk(child)
+		}
+	}
+	for _, root := range t.trash.Categories {
+		if found, ok := findTrashed(root, categoryID); ok {
+			walk(found)
+			break
+		}
+	}
+	return out
+}
+
+func findTrashed(node models.TrashedCategory, categoryID string) (models.TrashedCategory, bool) {
+	if node.ID == categoryID {
+		return node, true
+	}
+	for _, child := range node.Subcategories {
+		if found, ok := findTrashed(child, categoryID); ok {
+			return found, true
+		}
+	}
+	return models.TrashedCategory{}, false
+}
+
+// RestoreTrash brings back the selected categories, with everything deleted
+// together with them, and single statements. A restored category whose
+// parent is gone moves to the top level.
+func (s *Service) RestoreTrash(ctx context.Context, userID string, selection TrashSelection) (RestoreResult, error) {
+	result := RestoreResult{Categories: []models.Category{}, Statements: []models.Statement{}}
+	mirror := s.legacyWriter(ctx, userID)
+	index, err := s.loadTrash(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	live, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	alive := make(map[string]bool, len(live))
+	for _, cat := range live {
+		alive[cat.ID] = true
+	}
+
+	var categories []*models.TrashedCategory
+	queued := make(map[string]bool)
+	for _, categoryID := range selection.CategoryIDs {
+		if _, ok := index.categories[categoryID]; !ok {
+			return result, ErrNotInTrash
+		}
+		for _, cat := range index.subtree(categoryID) {
+			if !queued[cat.ID] {
+				queued[cat.ID] = true
+				categories = append(categories, cat)
+			}
+		}
+	}
+	for _, statementID := range selection.StatementIDs {
+		stmt, ok := index.statements[statementID]
+		if !ok {
+			return result, ErrNotInTrash
+		}
+		if !alive[stmt.CategoryID] && !queued[stmt.CategoryID] {
+			return result, ErrCategoryInTrash
+		}
+	}
+
+	updatedAt := time.Now().UnixMilli()
+	restoredStatements := make(map[string]bool)
+	restoreStatement := func(stmt models.Statement) error {
+		if restoredStatements[stmt.ID] {
+			return nil
+		}
+		restoredStatements[stmt.ID] = true
+		stmt.UpdatedAt = updatedAt
+		if err := s.Store.RestoreStatement(ctx, userID, stmt); err != nil {
+			return err
+		}
+		if mirror != nil {
+			if err := mirror.UpsertStatement(ctx, userID, stmt); err != nil {
+				return err
+			}
+		}
+		_ = s.appendChange(ctx, userID, "statement", stmt.ID, "upsert", stmt, updatedAt)
+		_ = s.recordRevision(ctx, userID, "statement", stmt.ID, revisionRestore, stmt, updatedAt)
+		result.Statements = append(result.Statements, stmt)
+		return nil
+	}
+
+	for _, trashed := range categories {
+		cat := trashed.Category
+		if cat.ParentID != "" && !alive[cat.ParentID] && !queued[cat.ParentID] {
+			cat.ParentID = ""
+		}
+		cat.UpdatedAt = updatedAt
+		if err := s.Store.RestoreCategory(ctx, userID, cat); err != nil {
+			return result, err
+		}
+		if mirror != nil {
+			if err := mirror.UpsertCategory(ctx, userID, cat); err != nil {
+				return result, err
+			}
+		}
+		_ = s.appendChange(ctx, userID, "category", cat.ID, "upsert", cat, updatedAt)
+		_ = s.recordRevision(ctx, userID, "category", cat.ID, revisionRestore, cat, updatedAt)
+		result.Categories = append(result.Categories, cat)
+
+		for _, stmt := range trashed.Statements {
+			if err := restoreStatement(stmt); err != nil {
+				return result, err
+			}
+		}
+	}
+	for _, statementID := range selection.StatementIDs {
+		if err := restoreStatement(index.statements[statementID]); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// PurgeTrash permanently removes the selected trash entries, or the whole
+// trash for an empty selection, and returns how many rows were removed.
+// Images used only by purged rows are deleted too.
+func (s *Service) PurgeTrash(ctx context.Context, userID string, selection TrashSelection) (int, error) {
+	index, err := s.loadTrash(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var categories []*models.TrashedCategory
+	var statements []models.Statement
+	if len(selection.CategoryIDs) == 0 && len(selection.StatementIDs) == 0 {
+		for _, cat := range index.categories {
+			categories = append(categories, cat)
+		}
+		for _, stmt := range index.statements {
+			statements = append(statements, stmt)
+		}
+	} else {
+		for _, categoryID := range selection.CategoryIDs {
+			if _, ok := index.categories[categoryID]; !ok {
+				return 0, ErrNotInTrash
+			}
+			categories = append(categories, index.subtree(categoryID)...)
+		}
+		for _, statementID := range selection.StatementIDs {
+			stmt, ok := index.statements[statementID]
+			if !ok {
+				return 0, ErrNotInTrash
+			}
+			statements = append(statements, stmt)
+		}
+	}
+
+	purgedCategories := make(map[string]bool)
+	purgedStatements := make(map[string]bool)
+	var images []string
+	for _, cat := range categories {
+		if purgedCategories[cat.ID] {
+			continue
+		}
+		if err := s.Store.PurgeCategory(ctx, userID, cat.ID); err != nil {
+			return 0, err
+		}
+		purgedCategories[cat.ID] = true
+		images = append(images, cat.ImageID)
+		// PurgeCategory also drops every trashed statement of the category.
+		for _, stmt := range index.statements {
+			if stmt.CategoryID == cat.ID && !purgedStatements[stmt.ID] {
+				purgedStatements[stmt.ID] = true
+				images = append(images, stmt.ImageID)
+			}
+		}
+	}
+	for _, stmt := range statements {
+		if purgedStatements[stmt.ID] {
+			continue
+		}
+		if err := s.Store.PurgeStatement(ctx, userID, stmt.CategoryID, stmt.ID); err != nil {
+			return 0, err
+		}
+		purgedStatements[stmt.ID] = true
+		images = append(images, stmt.ImageID)
+	}
+
+	for _, imageID := range images {
+		s.releaseMedia(ctx, userID, imageID)
+	}
+	return len(purgedCategories) + len(purgedStatements), nil
+}
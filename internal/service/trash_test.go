@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func trashedCategory(id, parentID string, deletedAt int64) models.TrashedCategory {
+	return models.TrashedCategory{
+		Category:   models.Category{ID: id, ParentID: parentID},
+		DeletedAt:  deletedAt,
+		Statements: []models.Statement{},
+	}
+}
+
+func trashedStatement(id, categoryID string, deletedAt int64) models.TrashedStatement {
+	return models.TrashedStatement{
+		Statement: models.Statement{ID: id, CategoryID: categoryID},
+		DeletedAt: deletedAt,
+	}
+}
+
+func TestBuildTrashGroupsRowsDeletedTogether(t *testing.T) {
+	index := buildTrash(
+		[]models.TrashedCategory{
+			trashedCategory("food", "", 200),
+			trashedCategory("drinks", "food", 200),
+			trashedCategory("old", "food", 100),
+		},
+		[]models.TrashedStatement{
+			trashedStatement("tea", "drinks", 200),
+			trashedStatement("soup", "food", 200),
+			trashedStatement("bread", "food", 150),
+			trashedStatement("hello", "live", 120),
+		},
+	)
+
+	trash := index.trash
+	if len(trash.Categories) != 2 || trash.Categories[0].ID != "food" || trash.Categories[1].ID != "old" {
+		t.Fatalf("unexpected trash roots: %+v", trash.Categories)
+	}
+	food := trash.Categories[0]
+	if len(food.Statements) != 1 || food.Statements[0].ID != "soup" {
+		t.Fatalf("unexpected statements of food: %+v", food.Statements)
+	}
+	if len(food.Subcategories) != 1 || food.Subcategories[0].ID != "drinks" || len(food.Subcategories[0].Statements) != 1 {
+		t.Fatalf("unexpected subcategories of food: %+v", food.Subcategories)
+	}
+	if len(trash.Statements) != 2 || trash.Statements[0].ID != "bread" || trash.Statements[1].ID != "hello" {
+		t.Fatalf("unexpected single statements: %+v", trash.Statements)
+	}
+
+	subtree := index.subtree("food")
+	if len(subtree) != 2 || subtree[0].ID != "food" || subtree[1].ID != "drinks" {
+		t.Fatalf("unexpected subtree: %+v", subtree)
+	}
+}
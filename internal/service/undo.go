@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+const (
+	// MaxUndo caps the number of mutations one undo call reverts.
+	MaxUndo = 20
+	// undoWindow is how many recent changes undo looks at.
+	undoWindow = 500
+	// undoOriginPrefix marks changes written by undo; the rest of the origin
+	// is the newest cursor of the reverted mutation.
+	undoOriginPrefix = "undo:"
+)
+
+// UndoResult reports an undo call. Stopped explains why fewer mutations
+// than requested were reverted.
+type UndoResult struct {
+	Undone   int                  `json:"undone"`
+	Reverted []models.ChangeEvent `json:"reverted"`
+	Stopped  string               `json:"stopped,omitempty"`
+}
+
+type changeOriginKey struct{}
+
+func withChangeOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, changeOriginKey{}, origin)
+}
+
+func changeOrigin(ctx context.Context) string {
+	if origin, ok := ctx.Value(changeOriginKey{}).(string); ok {
+		return origin
+	}
+	return ""
+}
+
+// undoGroups splits recent changes, newest first, into mutations: runs of
+// changes written with the same timestamp. Changes written by undo, and the
+// mutations they reverted, are left out.
+func undoGroups(changes []models.ChangeEvent) [][]models.ChangeEvent {
+	undone := make(map[string]bool)
+	var groups [][]models.ChangeEvent
+	var current []models.ChangeEvent
+	skip := false
+	flush := func() {
+		if len(current) > 0 && !skip {
+			groups = append(groups, current)
+		}
+		current = nil
+	}
+	for _, change := range changes {
+		if reverted, ok := strings.CutPrefix(change.Origin, undoOriginPrefix); ok {
+			undone[reverted] = true
+			continue
+		}
+		if len(current) > 0 && current[0].UpdatedAt == change.UpdatedAt {
+			current = append(current, change)
+			continue
+		}
+		flush()
+		current = []models.ChangeEvent{change}
+		skip = undone[change.Cursor]
+	}
+	flush()
+	return groups
+}
+
+// undoStep is one planned revert of a change.
+type undoStep struct {
+	change models.ChangeEvent
+	// restore brings the entity back from the trash; otherwise a nil
+	// snapshot deletes it and a set one is applied.
+	restore  bool
+	snapshot json.RawMessage
+}
+
+// Undo reverts the user's last count mutations of categories and statements,
+// newest first. It stops early at a mutation it cannot revert, such as a
+// reorder or an edit made before history was recorded.
+func (s *Service) Undo(ctx context.Context, userID string, count int) (UndoResult, error) {
+	result := UndoResult{Reverted: []models.ChangeEvent{}}
+	if count <= 0 {
+		count = 1
+	}
+	if count > MaxUndo {
+		count = MaxUndo
+	}
+
+	changes, err := s.Store.ListRecentChanges(ctx, userID, undoWindow)
+	if err != nil {
+		return result, err
+	}
+	for _, group := range undoGroups(changes) {
+		if result.Undone == count {
+			break
+		}
+		steps, reason, err := s.planUndo(ctx, userID, group)
+		if err != nil {
+			return result, err
+		}
+		if reason != "" {
+			result.Stopped = reason
+			break
+		}
+		groupCtx := withChangeOrigin(ctx, undoOriginPrefix+group[0].Cursor)
+		if err := s.applyUndo(groupCtx, userID, steps); err != nil {
+			return result, err
+		}
+		result.Undone++
+		result.Reverted = append(result.Reverted, group...)
+	}
+	return result, nil
+}
+
+// planUndo works out how to revert every change of a mutation before any of
+// them is touched, so a mutation is either reverted whole or not at all.
+func (s *Service) planUndo(ctx context.Context, userID string, group []models.ChangeEvent) ([]undoStep, string, error) {
+	var trash *trashIndex
+	steps := make([]undoStep, 0, len(group))
+	for _, change := range group {
+		if change.EntityType != "category" && change.EntityType != "statement" {
+			return nil, fmt.Sprintf("%s changes cannot be undone", change.EntityType), nil
+		}
+		switch change.Op {
+		case "delete":
+			if trash == nil {
+				index, err := s.loadTrash(ctx, userID)
+				if err != nil {
+					return nil, "", err
+				}
+				trash = &index
+			}
+			_, inCategories := trash.categories[change.EntityID]
+			_, inStatements := trash.statements[change.EntityID]
+			if !inCategories && !inStatements {
+				return nil, fmt.Sprintf("%s %s was purged from the trash", change.EntityType, change.EntityID), nil
+			}
+			steps = append(steps, undoStep{change: change, restore: true})
+		case "upsert":
+			revisions, err := s.Store.ListRevisions(ctx, userID, change.EntityType, change.EntityID, maxRevisionScan)
+			if err != nil {
+				return nil, "", err
+			}
+			current := -1
+			for i, revision := range revisions {
+				if revision.At == change.UpdatedAt {
+					current = i
+					break
+				}
+			}
+			if current < 0 {
+				return nil, fmt.Sprintf("%s %s has no recorded history", change.EntityType, change.EntityID), nil
+			}
+			step := undoStep{change: change}
+			switch op := revisions[current].Op; {
+			case current+1 < len(revisions) && revisions[current+1].Op != revisionDelete:
+				step.snapshot = revisions[current+1].Snapshot
+			case op == revisionCreate || op == revisionRestore:
+				// Undoing a create or a restore deletes the entity again.
+			default:
+				return nil, fmt.Sprintf("%s %s has no earlier history", change.EntityType, change.EntityID), nil
+			}
+			steps = append(steps, step)
+		default:
+			return nil, fmt.Sprintf("%s changes cannot be undone", change.Op), nil
+		}
+	}
+	return steps, "", nil
+}
+
+// applyUndo restores trashed entities first, so later steps can move
+// things back under them.
+func (s *Service) applyUndo(ctx context.Context, userID string, steps []undoStep) error {
+	var selection TrashSelection
+	for _, step := range steps {
+		if !step.restore {
+			continue
+		}
+		if step.change.EntityType == "category" {
+			selection.CategoryIDs = append(selection.CategoryIDs, step.change.EntityID)
+		} else {
+			selection.StatementIDs = append(selection.StatementIDs, step.change.EntityID)
+		}
+	}
+	if len(selection.CategoryIDs) > 0 || len(selection.StatementIDs) > 0 {
+		if _, err := s.RestoreTrash(ctx, userID, selection); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range steps {
+		if step.restore {
+			continue
+		}
+		entityID := step.change.EntityID
+		switch {
+		case step.change.EntityType == "category" && step.snapshot == nil:
+			if err := s.DeleteCategory(ctx, userID, entityID, false, IfMatch{}); err != nil {
+				return err
+			}
+		case step.change.EntityType == "category":
+			var snapshot models.Category
+			if err := json.Unmarshal(step.snapshot, &snapshot); err != nil {
+				return err
+			}
+			if _, err := s.updateCategory(ctx, userID, entityID, s.categorySnapshotPatch(ctx, userID, snapshot), revisionRevert); err != nil {
+				return err
+			}
+		case step.snapshot == nil:
+			if err := s.DeleteStatement(ctx, userID, entityID, IfMatch{}); err != nil {
+				return err
+			}
+		default:
+			var snapshot models.Statement
+			if err := json.Unmarshal(step.snapshot, &snapshot); err != nil {
+				return err
+			}
+			if _, err := s.updateStatement(ctx, userID, entityID, s.statementSnapshotPatch(ctx, userID, snapshot), revisionRevert); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestUndoGroupsSkipsRevertedMutations(t *testing.T) {
+	groups := undoGroups([]models.ChangeEvent{
+		{Cursor: "07", UpdatedAt: 700, Origin: undoOriginPrefix + "05"},
+		{Cursor: "06", UpdatedAt: 600, Origin: undoOriginPrefix + "05"},
+		{Cursor: "05", UpdatedAt: 500},
+		{Cursor: "04", UpdatedAt: 500},
+		{Cursor: "03", UpdatedAt: 300},
+		{Cursor: "02", UpdatedAt: 200, Origin: "rtdb"},
+		{Cursor: "01", UpdatedAt: 200},
+	})
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	if len(groups[0]) != 1 || groups[0][0].Cursor != "03" {
+		t.Fatalf("unexpected first group: %+v", groups[0])
+	}
+	if len(groups[1]) != 2 || groups[1][0].Cursor != "02" || groups[1][1].Cursor != "01" {
+		t.Fatalf("unexpected second group: %+v", groups[1])
+	}
+}
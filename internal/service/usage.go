@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// MaxSpokenBatch caps the number of events accepted in one batch.
+const MaxSpokenBatch = 500
+
+// maxSpokenEventID caps the length of a client event id.
+const maxSpokenEventID = 128
+
+// maxSpokenAttempts bounds how often a batch is planned again after a
+// concurrent request recorded some of its event ids.
+const maxSpokenAttempts = 3
+
+// Statement usage orderings.
+const (
+	usageFrequent = "frequent"
+	usageRecent   = "recent"
+)
+
+// ErrInvalidSpokenEvent is returned for events without exactly one target
+// or with an oversized event id.
+var ErrInvalidSpokenEvent = errors.New("invalid spoken event")
+
+// SpokenEvent records that a statement or a quicke slot was spoken at At.
+// Exactly one of StatementID and QuickeSlot must be set; a zero At means now.
+// EventID is an optional client id; an event with an id is counted once.
+type SpokenEvent struct {
+	EventID     string
+	StatementID string
+	QuickeSlot  *int
+	At          int64
+}
+
+// SpokenResult reports what RecordSpoken did with a batch. Skipped counts
+// events naming a statement or quicke slot that no longer exists; events
+// already recorded under the same id are in neither count.
+type SpokenResult struct {
+	Recorded int `json:"recorded"`
+	Skipped  int `json:"skipped"`
+}
+
+// RecordSpoken aggregates events into usage counters. Events for unknown
+// statements or slots are skipped, so an offline batch mentioning a phrase
+// deleted since is still recorded; events already recorded under the same
+// id, including by a concurrent retry, are not counted again.
+func (s *Service) RecordSpoken(ctx context.Context, userID string, events []SpokenEvent) (SpokenResult, error) {
+	if len(events) > MaxSpokenBatch {
+		return SpokenResult{}, ErrInvalidSpokenEvent
+	}
+
+	eventIDs := make(map[string]bool)
+	statementIDs := make(map[string]bool)
+	needQuickes := false
+	for _, event := range events {
+		if len(event.EventID) > maxSpokenEventID {
+			return SpokenResult{}, fmt.Errorf("%w: event id is too long", ErrInvalidSpokenEvent)
+		}
+		if event.EventID != "" {
+			eventIDs[event.EventID] = true
+		}
+		if event.StatementID != "" {
+			statementIDs[event.StatementID] = true
+		}
+		if event.QuickeSlot != nil {
+			needQuickes = true
+		}
+	}
+
+	known, err := s.Store.ListStatementIDs(ctx, userID, setKeys(statementIDs))
+	if err != nil {
+		return SpokenResult{}, err
+	}
+	var quickes []string
+	if needQuickes {
+		state, err := s.GetUserState(ctx, userID)
+		if err != nil {
+			return SpokenResult{}, err
+		}
+		quickes = state.Quickes
+	}
+
+	for attempt := 1; ; attempt++ {
+		seen, err := s.Store.ListSpokenEvents(ctx, userID, setKeys(eventIDs))
+		if err != nil {
+			return SpokenResult{}, err
+		}
+		now := time.Now().UnixMilli()
+		usage, result, err := planSpoken(events, quickes, stringSet(known), stringSet(seen), now)
+		if err != nil {
+			return SpokenResult{}, err
+		}
+		err = s.Store.RecordSpokenUsage(ctx, userID, usage, now)
+		if errors.Is(err, store.ErrEventRecorded) && attempt < maxSpokenAttempts {
+			continue
+		}
+		if err != nil {
+			return SpokenResult{}, err
+		}
+		return result, nil
+	}
+}
+
+// planSpoken aggregates events into per-statement and per-slot deltas.
+// Events whose id is in seen or repeats an earlier id in the batch are
+// ignored, and events naming an unknown statement or quicke slot are
+// skipped. It returns the deltas and what became of the events.
+func planSpoken(events []SpokenEvent, quickes []string, known, seen map[string]bool, now int64) (store.SpokenUsage, SpokenResult, error) {
+	var usage store.SpokenUsage
+	var result SpokenResult
+	statementDeltas := make(map[string]*models.StatementUsage)
+	quickeDeltas := make(map[int]*models.QuickeUsage)
+	var statementOrder []string
+	var quickeOrder []int
+	batchIDs := make(map[string]bool)
+
+	for _, event := range events {
+		if event.EventID != "" {
+			if seen[event.EventID] || batchIDs[event.EventID] {
+				continue
+			}
+		}
+		at := event.At
+		if at <= 0 || at > now {
+			at = now
+		}
+
+		switch {
+		case event.StatementID != "" && event.QuickeSlot == nil:
+			if !known[event.StatementID] {
+				result.Skipped++
+				continue
+			}
+			delta, ok := statementDeltas[event.StatementID]
+			if !ok {
+				delta = &models.StatementUsage{Statement: models.Statement{ID: event.StatementID}}
+				statementDeltas[event.StatementID] = delta
+				statementOrder = append(statementOrder, event.StatementID)
+			}
+			delta.SpokenCount++
+			delta.LastSpokenAt = max(delta.LastSpokenAt, at)
+		case event.StatementID == "" && event.QuickeSlot != nil:
+			slot := *event.QuickeSlot
+			if slot < 0 || slot >= len(quickes) {
+				result.Skipped++
+				continue
+			}
+			delta, ok := quickeDeltas[slot]
+			if !ok {
+				delta = &models.QuickeUsage{Slot: slot, Text: quickes[slot]}
+				quickeDeltas[slot] = delta
+				quickeOrder = append(quickeOrder, slot)
+			}
+			delta.SpokenCount++
+			delta.LastSpokenAt = max(delta.LastSpokenAt, at)
+		default:
+			return store.SpokenUsage{}, SpokenResult{}, ErrInvalidSpokenEvent
+		}
+
+		if event.EventID != "" {
+			batchIDs[event.EventID] = true
+			usage.EventIDs = append(usage.EventIDs, event.EventID)
+		}
+		result.Recorded++
+	}
+
+	for _, statementID := range statementOrder {
+		usage.Statements = append(usage.Statements, *statementDeltas[statementID])
+	}
+	for _, slot := range quickeOrder {
+		usage.Quickes = append(usage.Quickes, *quickeDeltas[slot])
+	}
+	return usage, result, nil
+}
+
+// ListFrequentStatements returns the most spoken statements.
+func (s *Service) ListFrequentStatements(ctx context.Context, userID string, limit int) ([]models.StatementUsage, error) {
+	items, err := s.Store.ListStatementUsage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return rankStatementUsage(items, usageFrequent, limit), nil
+}
+
+// ListRecentStatements returns the most recently spoken statements.
+func (s *Service) ListRecentStatements(ctx context.Context, userID string, limit int) ([]models.StatementUsage, error) {
+	items, err := s.Store.ListStatementUsage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return rankStatementUsage(items, usageRecent, limit), nil
+}
+
+// rankStatementUsage sorts items by spoken count (frequent) or by last spoken
+// time (recent) and keeps the first limit. Ties fall back to the more recent
+// statement, then to the id, so the order is stable between calls.
+func rankStatementUsage(items []models.StatementUsage, order string, limit int) []models.StatementUsage {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if order == usageFrequent && a.SpokenCount != b.SpokenCount {
+			return a.SpokenCount > b.SpokenCount
+		}
+		if a.LastSpokenAt != b.LastSpokenAt {
+			return a.LastSpokenAt > b.LastSpokenAt
+		}
+		return a.ID < b.ID
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// ListQuickeUsage returns counters for every current quicke slot. Counters
+// recorded for a different text in the same slot are ignored.
+func (s *Service) ListQuickeUsage(ctx context.Context, userID string) ([]models.QuickeUsage, error) {
+	state, err := s.GetUserState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.Store.ListQuickeUsage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	bySlot := make(map[int]models.QuickeUsage, len(rows))
+	for _, row := range rows {
+		bySlot[row.Slot] = row
+	}
+
+	out := make([]models.QuickeUsage, len(state.Quickes))
+	for slot, text := range state.Quickes {
+		out[slot] = models.QuickeUsage{Slot: slot, Text: text}
+		if row, ok := bySlot[slot]; ok && row.Text == text {
+			out[slot] = row
+		}
+	}
+	return out, nil
+}
+
+func setKeys(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for key := range set {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
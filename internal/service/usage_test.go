@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestPlanSpokenAggregates(t *testing.T) {
+	slot := 1
+	events := []SpokenEvent{
+		{StatementID: "s1", At: 100},
+		{StatementID: "s2", At: 300},
+		{StatementID: "s1", At: 200},
+		{QuickeSlot: &slot, At: 50},
+		{StatementID: "s1", At: 5000},
+	}
+	known := map[string]bool{"s1": true, "s2": true}
+
+	usage, result, err := planSpoken(events, []string{"yes", "no"}, known, nil, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != (SpokenResult{Recorded: 5}) {
+		t.Fatalf("expected 5 recorded, got %+v", result)
+	}
+	if len(usage.Statements) != 2 || usage.Statements[0].ID != "s1" || usage.Statements[1].ID != "s2" {
+		t.Fatalf("expected s1, s2 deltas in event order, got %+v", usage.Statements)
+	}
+	if usage.Statements[0].SpokenCount != 3 || usage.Statements[0].LastSpokenAt != 1000 {
+		t.Fatalf("expected s1 spoken 3 times, future time clamped to now; got %+v", usage.Statements[0])
+	}
+	if len(usage.Quickes) != 1 || usage.Quickes[0].Text != "no" || usage.Quickes[0].SpokenCount != 1 {
+		t.Fatalf("expected one delta for slot 1, got %+v", usage.Quickes)
+	}
+	if len(usage.EventIDs) != 0 {
+		t.Fatalf("expected no event ids, got %v", usage.EventIDs)
+	}
+}
+
+func TestPlanSpokenSkipsSeenEvents(t *testing.T) {
+	events := []SpokenEvent{
+		{EventID: "e1", StatementID: "s1", At: 10},
+		{EventID: "e2", StatementID: "s1", At: 20},
+		{EventID: "e2", StatementID: "s1", At: 20},
+		{EventID: "e3", StatementID: "gone", At: 30},
+	}
+	known := map[string]bool{"s1": true}
+	seen := map[string]bool{"e1": true, "e3": true}
+
+	usage, result, err := planSpoken(events, nil, known, seen, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != (SpokenResult{Recorded: 1}) {
+		t.Fatalf("expected only e2 to be recorded, got %+v", result)
+	}
+	if len(usage.EventIDs) != 1 || usage.EventIDs[0] != "e2" {
+		t.Fatalf("expected event ids [e2], got %v", usage.EventIDs)
+	}
+	if len(usage.Statements) != 1 || usage.Statements[0].SpokenCount != 1 || usage.Statements[0].LastSpokenAt != 20 {
+		t.Fatalf("expected one s1 delta, got %+v", usage.Statements)
+	}
+}
+
+func TestPlanSpokenSkipsUnknownTargets(t *testing.T) {
+	slot := 2
+	events := []SpokenEvent{
+		{EventID: "e1", StatementID: "missing", At: 10},
+		{EventID: "e2", QuickeSlot: &slot, At: 20},
+		{EventID: "e3", StatementID: "s1", At: 30},
+	}
+
+	usage, result, err := planSpoken(events, []string{"yes", "no"}, map[string]bool{"s1": true}, nil, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != (SpokenResult{Recorded: 1, Skipped: 2}) {
+		t.Fatalf("expected 1 recorded and 2 skipped, got %+v", result)
+	}
+	if len(usage.EventIDs) != 1 || usage.EventIDs[0] != "e3" {
+		t.Fatalf("expected event ids [e3], got %v", usage.EventIDs)
+	}
+	if len(usage.Statements) != 1 || len(usage.Quickes) != 0 {
+		t.Fatalf("expected only the s1 delta, got %+v", usage)
+	}
+}
+
+func TestPlanSpokenRejects(t *testing.T) {
+	both := 0
+	cases := map[string]SpokenEvent{
+		"no target":   {},
+		"two targets": {StatementID: "s1", QuickeSlot: &both},
+	}
+	for name, event := range cases {
+		_, _, err := planSpoken([]SpokenEvent{event}, []string{"yes", "no"}, map[string]bool{"s1": true}, nil, 1000)
+		if !errors.Is(err, ErrInvalidSpokenEvent) {
+			t.Fatalf("%s: expected ErrInvalidSpokenEvent, got %v", name, err)
+		}
+	}
+}
+
+func TestRankStatementUsage(t *testing.T) {
+	rows := func() []models.StatementUsage {
+		return []models.StatementUsage{
+			{Statement: models.Statement{ID: "a"}, SpokenCount: 2, LastSpokenAt: 300},
+			{Statement: models.Statement{ID: "b"}, SpokenCount: 5, LastSpokenAt: 100},
+			{Statement: models.Statement{ID: "c"}, SpokenCount: 2, LastSpokenAt: 400},
+			{Statement: models.Statement{ID: "d"}, SpokenCount: 1, LastSpokenAt: 400},
+		}
+	}
+	ids := func(items []models.StatementUsage) string {
+		out := ""
+		for _, item := range items {
+			out += item.ID
+		}
+		return out
+	}
+
+	if got := ids(rankStatementUsage(rows(), usageFrequent, 0)); got != "bcad" {
+		t.Fatalf("frequent: expected bcad, got %s", got)
+	}
+	if got := ids(rankStatementUsage(rows(), usageRecent, 0)); got != "cdab" {
+		t.Fatalf("recent: expected cdab, got %s", got)
+	}
+	if got := ids(rankStatementUsage(rows(), usageFrequent, 2)); got != "bc" {
+		t.Fatalf("limit: expected bc, got %s", got)
+	}
+}
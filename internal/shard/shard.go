@@ -0,0 +1,53 @@
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Space is the size of the user hash space.
+const Space uint64 = 1 << 32
+
+// All covers the whole hash space.
+var All = Range{Lo: 0, Hi: Space}
+
+// Range is a half-open interval [Lo, Hi) of user hash buckets.
+type Range struct {
+	Lo uint64
+	Hi uint64
+}
+
+// Bucket returns the stable hash bucket of a user ID.
+func Bucket(userID string) uint64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(userID))
+	return uint64(h.Sum32())
+}
+
+// For returns the range owned by instance index out of count instances.
+func For(index, count int) (Range, error) {
+	if count <= 0 {
+		return Range{}, fmt.Errorf("shard count must be positive")
+	}
+	if index < 0 || index >= count {
+		return Range{}, fmt.Errorf("shard index %d out of range [0, %d)", index, count)
+	}
+	size := Space / uint64(count)
+	lo := size * uint64(index)
+	hi := lo + size
+	if index == count-1 {
+		hi = Space
+	}
+	return Range{Lo: lo, Hi: hi}, nil
+}
+
+// Contains reports whether the user hashes into the range.
+func (r Range) Contains(userID string) bool {
+	bucket := Bucket(userID)
+	return bucket >= r.Lo && bucket < r.Hi
+}
+
+// String formats the range for logs.
+func (r Range) String() string {
+	return fmt.Sprintf("[%d, %d)", r.Lo, r.Hi)
+}
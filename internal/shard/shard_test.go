@@ -0,0 +1,43 @@
+package shard
+
+import "testing"
+
+func TestForCoversSpace(t *testing.T) {
+	var next uint64
+	for i := 0; i < 3; i++ {
+		r, err := For(i, 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.Lo != next {
+			t.Fatalf("expected range %d to start at %d, got %d", i, next, r.Lo)
+		}
+		next = r.Hi
+	}
+	if next != Space {
+		t.Fatalf("expected ranges to end at %d, got %d", Space, next)
+	}
+
+	if _, err := For(3, 3); err == nil {
+		t.Fatalf("expected error for index out of range")
+	}
+}
+
+func TestContainsExactlyOne(t *testing.T) {
+	users := []string{"user", "another", "Xy3kq0a9b8c7d6e5", "-NfQx1"}
+	for _, userID := range users {
+		owners := 0
+		for i := 0; i < 4; i++ {
+			r, _ := For(i, 4)
+			if r.Contains(userID) {
+				owners++
+			}
+		}
+		if owners != 1 {
+			t.Fatalf("expected %q to belong to one shard, got %d", userID, owners)
+		}
+		if !All.Contains(userID) {
+			t.Fatalf("expected All to contain %q", userID)
+		}
+	}
+}
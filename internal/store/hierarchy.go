@@ -0,0 +1,33 @@
+package store
+
+import "github.com/linkasu/linka.type-backend/internal/models"
+
+// GlobalSubtree returns the global category rootID followed by all of its
+// descendants, parents before children. It returns nil when rootID is unknown.
+func GlobalSubtree(categories []models.GlobalCategory, rootID string) []models.GlobalCategory {
+	byID := make(map[string]models.GlobalCategory, len(categories))
+	children := make(map[string][]string)
+	for _, cat := range categories {
+		byID[cat.ID] = cat
+		if cat.ParentID != "" {
+			children[cat.ParentID] = append(children[cat.ParentID], cat.ID)
+		}
+	}
+	root, ok := byID[rootID]
+	if !ok {
+		return nil
+	}
+
+	out := []models.GlobalCategory{root}
+	seen := map[string]bool{rootID: true}
+	for i := 0; i < len(out); i++ {
+		for _, childID := range children[out[i].ID] {
+			if seen[childID] {
+				continue
+			}
+			seen[childID] = true
+			out = append(out, byID[childID])
+		}
+	}
+	return out
+}
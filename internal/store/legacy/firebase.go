@@ -33,6 +33,14 @@ func (w *Writer) UpsertCategory(ctx context.Context, userID string, category mod
 	if category.Default != nil {
 		payload["default"] = *category.Default
 	}
+	// Legacy clients ignore parentId and show the categories as a flat list.
+	if category.ParentID != "" {
+		payload["parentId"] = category.ParentID
+	}
+	if category.Position != "" {
+		payload["position"] = category.Position
+	}
+	setVisual(payload, category.Color, category.Icon, category.ImageID)
 	return ref.Set(ctx, payload)
 }
 
@@ -49,14 +57,55 @@ func (w *Writer) UpsertStatement(ctx context.Context, userID string, statement m
 		"text":       statement.Text,
 		"created":    statement.Created,
 	}
+	if statement.Position != "" {
+		payload["position"] = statement.Position
+	}
+	setVisual(payload, statement.Color, statement.Icon, statement.ImageID)
 	return ref.Set(ctx, payload)
 }
 
+// setVisual adds display metadata that legacy clients ignore.
+func setVisual(payload map[string]any, color, icon, imageID string) {
+	if color != "" {
+		payload["color"] = color
+	}
+	if icon != "" {
+		payload["icon"] = icon
+	}
+	if imageID != "" {
+		payload["imageId"] = imageID
+	}
+}
+
 func (w *Writer) DeleteStatement(ctx context.Context, userID, categoryID, statementID string) error {
 	ref := w.db.NewRef(fmt.Sprintf("users/%s/Category/%s/statements/%s", userID, categoryID, statementID))
 	return ref.Delete(ctx)
 }
 
+func (w *Writer) SetCategoryPositions(ctx context.Context, userID string, positions map[string]string) error {
+	if len(positions) == 0 {
+		return nil
+	}
+	ref := w.db.NewRef(fmt.Sprintf("users/%s/Category", userID))
+	updates := make(map[string]any, len(positions))
+	for categoryID, position := range positions {
+		updates[categoryID+"/position"] = position
+	}
+	return ref.Update(ctx, updates)
+}
+
+func (w *Writer) SetStatementPositions(ctx context.Context, userID, categoryID string, positions map[string]string) error {
+	if len(positions) == 0 {
+		return nil
+	}
+	ref := w.db.NewRef(fmt.Sprintf("users/%s/Category/%s/statements", userID, categoryID))
+	updates := make(map[string]any, len(positions))
+	for statementID, position := range positions {
+		updates[statementID+"/position"] = position
+	}
+	return ref.Update(ctx, updates)
+}
+
 func (w *Writer) SetUserState(ctx context.Context, userID string, state models.UserState) error {
 	ref := w.db.NewRef(fmt.Sprintf("users/%s", userID))
 	updates := map[string]any{
@@ -31,6 +31,11 @@ type firebaseCategory struct {
 	Created    int64                        `json:"created"`
 	Default    *bool                        `json:"default,omitempty"`
 	AIUse      *bool                        `json:"aiUse,omitempty"`
+	ParentID   string                       `json:"parentId,omitempty"`
+	Position   string                       `json:"position,omitempty"`
+	Color      string                       `json:"color,omitempty"`
+	Icon       string                       `json:"icon,omitempty"`
+	ImageID    string                       `json:"imageId,omitempty"`
 	Statements map[string]firebaseStatement `json:"statements,omitempty"`
 }
 
@@ -39,6 +44,10 @@ type firebaseStatement struct {
 	CategoryID string `json:"categoryId"`
 	Text       string `json:"text"`
 	Created    int64  `json:"created"`
+	Position   string `json:"position,omitempty"`
+	Color      string `json:"color,omitempty"`
+	Icon       string `json:"icon,omitempty"`
+	ImageID    string `json:"imageId,omitempty"`
 }
 
 type firebaseQuestion struct {
@@ -79,6 +88,11 @@ func (r *Reader) FetchUserData(ctx context.Context, userID string) ([]models.Cat
 			Created:   created,
 			Default:   cat.Default,
 			AIUse:     cat.AIUse != nil && *cat.AIUse,
+			ParentID:  cat.ParentID,
+			Position:  cat.Position,
+			Color:     cat.Color,
+			Icon:      cat.Icon,
+			ImageID:   cat.ImageID,
 			UpdatedAt: created,
 		})
 
@@ -100,6 +114,10 @@ func (r *Reader) FetchUserData(ctx context.Context, userID string) ([]models.Cat
 				CategoryID: stmtCatID,
 				Text:       stmt.Text,
 				Created:    stmtCreated,
+				Position:   stmt.Position,
+				Color:      stmt.Color,
+				Icon:       stmt.Icon,
+				ImageID:    stmt.ImageID,
 				UpdatedAt:  stmtCreated,
 			})
 		}
@@ -159,6 +177,7 @@ func (r *Reader) ListGlobalCategories(ctx context.Context) ([]models.GlobalCateg
 			Label:     cat.Label,
 			Created:   created,
 			Default:   cat.Default,
+			ParentID:  cat.ParentID,
 			UpdatedAt: created,
 		}
 
@@ -10,6 +10,14 @@ import (
 
 var ErrNotFound = errors.New("not found")
 
+// ErrVersionMismatch is returned by conditional writes when the row is gone
+// or its updated_at is not one of the expected versions.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// ErrEventRecorded is returned by RecordSpokenUsage when one of the batch's
+// event ids was recorded since the caller checked.
+var ErrEventRecorded = errors.New("event already recorded")
+
 // ClientKey represents a client API key.
 type ClientKey struct {
 	KeyHash  string
@@ -19,6 +27,46 @@ type ClientKey struct {
 	RevokedAt *int64
 }
 
+// ShareSyncClaim is an owner whose live shares need updating. Version is
+// the pending row's updated_at when it was claimed; a release drops the row
+// only if no edit marked it again since.
+type ShareSyncClaim struct {
+	OwnerID string
+	Version int64
+}
+
+// SpokenUsage is a batch of phrase usage deltas written in one transaction.
+// EventIDs are the client event ids the deltas were built from; the write
+// fails with ErrEventRecorded if any of them was already recorded.
+type SpokenUsage struct {
+	EventIDs   []string
+	Statements []models.StatementUsage
+	Quickes    []models.QuickeUsage
+}
+
+// StatementBatch is a bulk statement change written in one transaction.
+// Upserts are written as given, Removed rows are dropped outright (the old
+// rows of moved statements) and Deleted rows move to the trash.
+type StatementBatch struct {
+	Upserts   []models.Statement
+	Removed   []models.Statement
+	Deleted   []models.Statement
+	UpdatedAt int64
+}
+
+// CatalogueChange is a global catalogue update written in one transaction.
+// Removed categories and statements are soft-deleted; removed factory
+// questions are dropped.
+type CatalogueChange struct {
+	Categories        []models.GlobalCategory
+	Statements        []models.Statement
+	Questions         []models.FactoryQuestion
+	RemovedCategories []string
+	RemovedStatements []models.Statement
+	RemovedQuestions  []string
+	UpdatedAt         int64
+}
+
 // Store defines the core data operations backed by YDB.
 type Store interface {
 	ListCategories(ctx context.Context, userID string) ([]models.Category, error)
@@ -29,26 +77,63 @@ type Store interface {
 	ListAllStatements(ctx context.Context, userID string) ([]models.Statement, error)
 	UpsertStatement(ctx context.Context, userID string, statement models.Statement) (models.Statement, error)
 	DeleteStatement(ctx context.Context, userID, categoryID, statementID string, updatedAt int64) error
+	MoveStatement(ctx context.Context, userID, fromCategoryID string, statement models.Statement) error
+	ApplyStatementBatch(ctx context.Context, userID string, batch StatementBatch) error
+
+	// Custom ordering
+	SetCategoryPositions(ctx context.Context, userID string, positions map[string]string, updatedAt int64) error
+	SetStatementPositions(ctx context.Context, userID, categoryID string, positions map[string]string, updatedAt int64) error
+
+	// Conditional writes do what the writes above do when the live row's
+	// updated_at is one of expected, or when expected is empty, checking it in
+	// the same transaction as the write. Otherwise they write nothing and
+	// return ErrVersionMismatch.
+	UpsertCategoryIfVersion(ctx context.Context, userID string, category models.Category, expected []int64) (models.Category, error)
+	DeleteCategoryIfVersion(ctx context.Context, userID, categoryID string, expected []int64, updatedAt int64) error
+	UpsertStatementIfVersion(ctx context.Context, userID string, statement models.Statement, expected []int64) (models.Statement, error)
+	MoveStatementIfVersion(ctx context.Context, userID, fromCategoryID string, statement models.Statement, expected []int64) error
+	DeleteStatementIfVersion(ctx context.Context, userID, categoryID, statementID string, expected []int64, updatedAt int64) error
 
 	GetUserState(ctx context.Context, userID string) (models.UserState, error)
 	SetUserState(ctx context.Context, userID string, state models.UserState, updatedAt int64) (models.UserState, error)
 	SetQuickes(ctx context.Context, userID string, quickes []string, updatedAt int64) ([]string, error)
+	// SetUserStateIf and SetQuickesIf pass the stored state to match in the
+	// same transaction as the write, and return ErrVersionMismatch without
+	// writing when it is rejected.
+	SetUserStateIf(ctx context.Context, userID string, state models.UserState, updatedAt int64, match func(current models.UserState) bool) (models.UserState, error)
+	SetQuickesIf(ctx context.Context, userID string, quickes []string, updatedAt int64, match func(current models.UserState) bool) ([]string, error)
 
 	ListGlobalCategories(ctx context.Context, includeStatements bool) ([]models.GlobalCategory, error)
 	ListGlobalStatements(ctx context.Context, categoryID string) ([]models.Statement, error)
 	ImportGlobalCategory(ctx context.Context, userID, categoryID string, force bool) (string, error)
+	UpsertGlobalImport(ctx context.Context, userID string, imp models.GlobalImport) error
+	ListGlobalImports(ctx context.Context, userID string) ([]models.GlobalImport, error)
 	UpsertGlobalCategory(ctx context.Context, category models.GlobalCategory) (models.GlobalCategory, error)
 	DeleteGlobalCategory(ctx context.Context, categoryID string, updatedAt int64) error
+	UpsertGlobalStatements(ctx context.Context, statements []models.Statement) error
+	DeleteGlobalStatement(ctx context.Context, categoryID, statementID string, updatedAt int64) error
+	// SetGlobalStatementPositions leaves updated_at alone: reordering does not
+	// change what users who imported the category have.
+	SetGlobalStatementPositions(ctx context.Context, categoryID string, positions map[string]string) error
+	ApplyCatalogueChange(ctx context.Context, change CatalogueChange) error
+
+	ListGlobalTranslations(ctx context.Context, locale string) ([]models.GlobalTranslation, error)
+	UpsertGlobalTranslation(ctx context.Context, translation models.GlobalTranslation) error
+	DeleteGlobalTranslation(ctx context.Context, kind, entityID, locale string) error
 
 	ListFactoryQuestions(ctx context.Context) ([]models.FactoryQuestion, error)
+	ListOnboardingAnswers(ctx context.Context, userID string) ([]models.OnboardingAnswer, error)
+	UpsertOnboardingAnswer(ctx context.Context, userID string, answer models.OnboardingAnswer) error
+	DeleteOnboardingAnswer(ctx context.Context, userID, questionID string) error
 	UpsertFactoryQuestion(ctx context.Context, question models.FactoryQuestion) (models.FactoryQuestion, error)
-	DeleteFactoryQuestion(ctx context.Context, questionID string) error
+	DeleteFactoryQuestion(ctx context.Context, questionID string, updatedAt int64) error
 
 	IsAdmin(ctx context.Context, userID string) (bool, error)
 	DeleteUser(ctx context.Context, userID string, updatedAt int64) error
 
 	AppendChange(ctx context.Context, userID string, change models.ChangeEvent) error
 	ListChanges(ctx context.Context, userID, cursor string, limit int) (nextCursor string, changes []models.ChangeEvent, err error)
+	ListRecentChanges(ctx context.Context, userID string, limit int) ([]models.ChangeEvent, error)
 
 	// Admin methods
 	CountUsers(ctx context.Context, since time.Time) (int64, error)
@@ -85,7 +170,98 @@ type Store interface {
 
 	// Usage limits
 	GetUsageLimit(ctx context.Context, userID, month string) (models.UsageLimit, error)
+	ListUsageLimits(ctx context.Context, userID string) ([]models.UsageLimit, error)
 	IncrementUsage(ctx context.Context, userID, month string, defaultLimit int64) (models.UsageLimit, error)
+
+	// Phrase usage. RecordSpokenUsage takes per-item deltas in SpokenCount
+	// and the latest spoken time in LastSpokenAt.
+	RecordSpokenUsage(ctx context.Context, userID string, usage SpokenUsage, updatedAt int64) error
+	ListSpokenEvents(ctx context.Context, userID string, eventIDs []string) ([]string, error)
+	ListStatementIDs(ctx context.Context, userID string, statementIDs []string) ([]string, error)
+	ListStatementUsage(ctx context.Context, userID string) ([]models.StatementUsage, error)
+	ListQuickeUsage(ctx context.Context, userID string) ([]models.QuickeUsage, error)
+
+	// Trash: soft-deleted rows that can be restored or purged
+	ListDeletedCategories(ctx context.Context, userID string) ([]models.TrashedCategory, error)
+	ListDeletedStatements(ctx context.Context, userID string) ([]models.TrashedStatement, error)
+	RestoreCategory(ctx context.Context, userID string, category models.Category) error
+	RestoreStatement(ctx context.Context, userID string, statement models.Statement) error
+	PurgeCategory(ctx context.Context, userID, categoryID string) error
+	PurgeStatement(ctx context.Context, userID, categoryID, statementID string) error
+
+	// Revision log of categories and statements
+	AppendRevision(ctx context.Context, userID string, revision models.Revision) error
+	ListRevisions(ctx context.Context, userID, entityType, entityID string, limit int) ([]models.Revision, error)
+
+	// Uploaded images
+	CreateMedia(ctx context.Context, userID string, media models.Media) error
+	GetMedia(ctx context.Context, userID, mediaID string) (models.Media, error)
+	ListMedia(ctx context.Context, userID string) ([]models.Media, error)
+	DeleteMedia(ctx context.Context, userID, mediaID string) error
+	CountMediaReferences(ctx context.Context, userID, mediaID string) (int64, error)
+
+	// Background data exports
+	UpsertExportJob(ctx context.Context, userID string, job models.ExportJob) error
+	GetExportJob(ctx context.Context, userID, jobID string) (models.ExportJob, error)
+	ListExportJobs(ctx context.Context, userID string) ([]models.ExportJob, error)
+	DeleteExportJob(ctx context.Context, userID, jobID string) error
+	// ListDialogMessagesAfter pages a chat's messages oldest first, starting
+	// after the (created, id) cursor; an empty afterID starts at the top.
+	ListDialogMessagesAfter(ctx context.Context, userID, chatID string, afterCreated int64, afterID string, limit int) ([]models.DialogMessage, error)
+	// ListDialogSuggestionsAfter pages all of a user's suggestions by id.
+	ListDialogSuggestionsAfter(ctx context.Context, userID, afterID string, limit int) ([]models.DialogSuggestion, error)
+
+	// Category shares
+	UpsertShare(ctx context.Context, share models.Share) error
+	GetShare(ctx context.Context, token string) (models.Share, error)
+	UpsertShareSubscription(ctx context.Context, sub models.ShareSubscription) error
+	ListShareSubscriptions(ctx context.Context, ownerID string) ([]models.ShareSubscription, error)
+	HasShareSubscriptions(ctx context.Context, ownerID string) (bool, error)
+	DeleteShareSubscription(ctx context.Context, ownerID, recipientID, categoryID string) error
+	// DeleteShare removes an owner's share and the live subscriptions made
+	// through it.
+	DeleteShare(ctx context.Context, ownerID, token string) error
+	// Live share sync queue. Marking is a no-op for owners without live
+	// subscriptions; a claim hides owners until claimedUntil and returns
+	// them with the version to release.
+	MarkShareSyncPending(ctx context.Context, ownerID string, dueAt, updatedAt int64) error
+	ClaimShareSyncPending(ctx context.Context, now, claimedUntil int64, limit int) ([]ShareSyncClaim, error)
+	ReleaseShareSyncPending(ctx context.Context, claim ShareSyncClaim) error
+
+	// Delegated access
+	UpsertGrant(ctx context.Context, grant models.Grant) error
+	GetGrant(ctx context.Context, ownerID, delegateID string) (models.Grant, error)
+	ListGrantsByOwner(ctx context.Context, ownerID string) ([]models.Grant, error)
+	ListGrantsByDelegate(ctx context.Context, delegateID string) ([]models.Grant, error)
+	DeleteGrant(ctx context.Context, ownerID, delegateID string) error
+
+	// Organizations and their category libraries
+	UpsertOrganization(ctx context.Context, org models.Organization) error
+	GetOrganization(ctx context.Context, orgID string) (models.Organization, error)
+	UpsertOrgMember(ctx context.Context, member models.OrgMember) error
+	GetOrgMember(ctx context.Context, orgID, userID string) (models.OrgMember, error)
+	ListOrgMembers(ctx context.Context, orgID string) ([]models.OrgMember, error)
+	ListUserOrgMemberships(ctx context.Context, userID string) ([]models.OrgMember, error)
+	DeleteOrgMember(ctx context.Context, orgID, userID string) error
+	ListOrgCategories(ctx context.Context, orgID string) ([]models.Category, error)
+	UpsertOrgCategory(ctx context.Context, orgID string, category models.Category) error
+	DeleteOrgCategories(ctx context.Context, orgID string, categoryIDs []string) error
+	ListOrgStatements(ctx context.Context, orgID string) ([]models.Statement, error)
+	UpsertOrgStatement(ctx context.Context, orgID string, statement models.Statement) error
+	DeleteOrgStatement(ctx context.Context, orgID, statementID string) error
+	// CountBoardItems counts live categories and statements created since
+	// the given time on the given users' boards.
+	CountBoardItems(ctx context.Context, userIDs []string, since time.Time) (categories, statements int64, err error)
+
+	// Search index, maintained by category and statement writes
+	SearchIndex(ctx context.Context, userID string, terms []string, limit int) ([]models.StatementHit, []models.CategoryHit, error)
+	RebuildSearchIndex(ctx context.Context, userID string) error
+
+	// Per-user migration state
+	GetUserMigration(ctx context.Context, userID string) (models.UserMigration, error)
+	SetUserMigration(ctx context.Context, migration models.UserMigration) error
+	ListUserMigrations(ctx context.Context, state string, limit int) ([]models.UserMigration, error)
+	CountUserMigrations(ctx context.Context) (map[string]int64, error)
 }
 
 // LegacyWriter mirrors writes to Firebase RTDB.
@@ -94,6 +270,8 @@ type LegacyWriter interface {
 	DeleteCategory(ctx context.Context, userID, categoryID string) error
 	UpsertStatement(ctx context.Context, userID string, statement models.Statement) error
 	DeleteStatement(ctx context.Context, userID, categoryID, statementID string) error
+	SetCategoryPositions(ctx context.Context, userID string, positions map[string]string) error
+	SetStatementPositions(ctx context.Context, userID, categoryID string, positions map[string]string) error
 	SetUserState(ctx context.Context, userID string, state models.UserState) error
 	SetQuickes(ctx context.Context, userID string, quickes []string) error
 	ImportGlobalCategory(ctx context.Context, userID, categoryID string) error
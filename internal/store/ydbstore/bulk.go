@@ -0,0 +1,93 @@
+package ydbstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// ApplyStatementBatch writes the whole batch in a single query, so it
+// commits or fails as one transaction. The search index follows afterwards.
+func (s *Store) ApplyStatementBatch(ctx context.Context, userID string, batch store.StatementBatch) error {
+	var declares, body strings.Builder
+	declares.WriteString("DECLARE $user_id AS Utf8;\nDECLARE $updated_at AS Int64;\n")
+	params := []table.ParameterOption{
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$updated_at", types.Int64Value(batch.UpdatedAt)),
+	}
+
+	if len(batch.Removed) > 0 {
+		rows := make([]types.Value, 0, len(batch.Removed))
+		for _, statement := range batch.Removed {
+			rows = append(rows, statementKey(statement.CategoryID, statement.ID))
+		}
+		declares.WriteString("DECLARE $removed AS List<Struct<category_id: Utf8, statement_id: Utf8>>;\n")
+		body.WriteString(`DELETE FROM statements ON
+SELECT $user_id AS user_id, category_id, statement_id
+FROM AS_TABLE($removed);
+`)
+		params = append(params, table.ValueParam("$removed", types.ListValue(rows...)))
+	}
+
+	if len(batch.Deleted) > 0 {
+		rows := make([]types.Value, 0, len(batch.Deleted))
+		for _, statement := range batch.Deleted {
+			rows = append(rows, statementKey(statement.CategoryID, statement.ID))
+		}
+		declares.WriteString("DECLARE $deleted AS List<Struct<category_id: Utf8, statement_id: Utf8>>;\n")
+		body.WriteString(`UPDATE statements ON
+SELECT $user_id AS user_id, category_id, statement_id, $updated_at AS deleted_at, $updated_at AS updated_at
+FROM AS_TABLE($deleted);
+`)
+		params = append(params, table.ValueParam("$deleted", types.ListValue(rows...)))
+	}
+
+	if len(batch.Upserts) > 0 {
+		rows := make([]types.Value, 0, len(batch.Upserts))
+		for _, statement := range batch.Upserts {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("category_id", types.UTF8Value(statement.CategoryID)),
+				types.StructFieldValue("statement_id", types.UTF8Value(statement.ID)),
+				types.StructFieldValue("text", types.UTF8Value(statement.Text)),
+				types.StructFieldValue("created_at", types.Int64Value(statement.Created)),
+				types.StructFieldValue("position", optionalString(statement.Position)),
+				types.StructFieldValue("color", optionalString(statement.Color)),
+				types.StructFieldValue("icon", optionalString(statement.Icon)),
+				types.StructFieldValue("image_id", optionalString(statement.ImageID)),
+			))
+		}
+		declares.WriteString("DECLARE $rows AS List<Struct<category_id: Utf8, statement_id: Utf8, text: Utf8, created_at: Int64, position: Utf8?, color: Utf8?, icon: Utf8?, image_id: Utf8?>>;\n")
+		body.WriteString(`UPSERT INTO statements
+SELECT $user_id AS user_id, category_id, statement_id, text, created_at, position, color, icon, image_id, $updated_at AS updated_at
+FROM AS_TABLE($rows);
+`)
+		params = append(params, table.ValueParam("$rows", types.ListValue(rows...)))
+	}
+
+	if err := s.execWrite(ctx, s.withPrefix(declares.String()+body.String()), table.NewQueryParameters(params...)); err != nil {
+		return err
+	}
+
+	for _, statement := range batch.Deleted {
+		if err := s.unindexSearch(ctx, userID, searchStatement, statement.ID); err != nil {
+			return err
+		}
+	}
+	for _, statement := range batch.Upserts {
+		if err := s.indexSearch(ctx, userID, searchStatement, statement.ID, statement.CategoryID, statement.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func statementKey(categoryID, statementID string) types.Value {
+	return types.StructValue(
+		types.StructFieldValue("category_id", types.UTF8Value(categoryID)),
+		types.StructFieldValue("statement_id", types.UTF8Value(statementID)),
+	)
+}
@@ -0,0 +1,143 @@
+package ydbstore
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/linkasu/linka.type-backend/internal/store"
+)
+
+// ApplyCatalogueChange writes the whole change in a single query, so it
+// commits or fails as one transaction.
+func (s *Store) ApplyCatalogueChange(ctx context.Context, change store.CatalogueChange) error {
+	var declares, body strings.Builder
+	declares.WriteString("DECLARE $updated_at AS Int64;\n")
+	params := []table.ParameterOption{
+		table.ValueParam("$updated_at", types.Int64Value(change.UpdatedAt)),
+	}
+
+	if len(change.Categories) > 0 {
+		rows := make([]types.Value, 0, len(change.Categories))
+		for _, cat := range change.Categories {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("category_id", types.UTF8Value(cat.ID)),
+				types.StructFieldValue("label", types.UTF8Value(cat.Label)),
+				types.StructFieldValue("created_at", types.Int64Value(cat.Created)),
+				types.StructFieldValue("is_default", optionalBool(cat.Default)),
+				types.StructFieldValue("parent_id", optionalString(cat.ParentID)),
+			))
+		}
+		declares.WriteString("DECLARE $categories AS List<Struct<category_id: Utf8, label: Utf8, created_at: Int64, is_default: Bool?, parent_id: Utf8?>>;\n")
+		body.WriteString(`UPSERT INTO global_categories
+SELECT category_id, label, created_at, is_default, parent_id, $updated_at AS updated_at, CAST(NULL AS Int64?) AS deleted_at
+FROM AS_TABLE($categories);
+`)
+		params = append(params, table.ValueParam("$categories", types.ListValue(rows...)))
+	}
+
+	if len(change.RemovedCategories) > 0 {
+		rows := make([]types.Value, 0, len(change.RemovedCategories))
+		for _, categoryID := range change.RemovedCategories {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("category_id", types.UTF8Value(categoryID)),
+			))
+		}
+		declares.WriteString("DECLARE $removed_categories AS List<Struct<category_id: Utf8>>;\n")
+		body.WriteString(`UPDATE global_categories ON
+SELECT category_id, $updated_at AS deleted_at, $updated_at AS updated_at
+FROM AS_TABLE($removed_categories);
+`)
+		params = append(params, table.ValueParam("$removed_categories", types.ListValue(rows...)))
+	}
+
+	if len(change.Statements) > 0 {
+		rows := make([]types.Value, 0, len(change.Statements))
+		for _, stmt := range change.Statements {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("category_id", types.UTF8Value(stmt.CategoryID)),
+				types.StructFieldValue("statement_id", types.UTF8Value(stmt.ID)),
+				types.StructFieldValue("text", types.UTF8Value(stmt.Text)),
+				types.StructFieldValue("created_at", types.Int64Value(stmt.Created)),
+				types.StructFieldValue("position", optionalString(stmt.Position)),
+			))
+		}
+		declares.WriteString("DECLARE $statements AS List<Struct<category_id: Utf8, statement_id: Utf8, text: Utf8, created_at: Int64, position: Utf8?>>;\n")
+		body.WriteString(`UPSERT INTO global_statements
+SELECT category_id, statement_id, text, created_at, position, $updated_at AS updated_at, CAST(NULL AS Int64?) AS deleted_at
+FROM AS_TABLE($statements);
+`)
+		params = append(params, table.ValueParam("$statements", types.ListValue(rows...)))
+	}
+
+	if len(change.RemovedStatements) > 0 {
+		rows := make([]types.Value, 0, len(change.RemovedStatements))
+		for _, stmt := range change.RemovedStatements {
+			rows = append(rows, statementKey(stmt.CategoryID, stmt.ID))
+		}
+		declares.WriteString("DECLARE $removed_statements AS List<Struct<category_id: Utf8, statement_id: Utf8>>;\n")
+		body.WriteString(`UPDATE global_statements ON
+SELECT category_id, statement_id, $updated_at AS deleted_at, $updated_at AS updated_at
+FROM AS_TABLE($removed_statements);
+`)
+		params = append(params, table.ValueParam("$removed_statements", types.ListValue(rows...)))
+	}
+
+	if len(change.Questions) > 0 {
+		rows := make([]types.Value, 0, len(change.Questions))
+		for _, question := range change.Questions {
+			phrasesJSON, err := json.Marshal(question.Phrases)
+			if err != nil {
+				return err
+			}
+			options, err := optionalJSON(question.Options)
+			if err != nil {
+				return err
+			}
+			showIf, err := optionalJSON(question.ShowIf)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("question_id", types.UTF8Value(question.ID)),
+				types.StructFieldValue("label", types.UTF8Value(question.Label)),
+				types.StructFieldValue("phrases", types.JSONDocumentValue(string(phrasesJSON))),
+				types.StructFieldValue("category", types.UTF8Value(question.Category)),
+				types.StructFieldValue("type", types.UTF8Value(question.Type)),
+				types.StructFieldValue("order_index", types.Int64Value(int64(question.OrderIndex))),
+				types.StructFieldValue("options", options),
+				types.StructFieldValue("placeholder", optionalString(question.Placeholder)),
+				types.StructFieldValue("show_if", showIf),
+			))
+		}
+		declares.WriteString("DECLARE $questions AS List<Struct<question_id: Utf8, label: Utf8, phrases: JsonDocument, category: Utf8, type: Utf8, order_index: Int64, options: Optional<JsonDocument>, placeholder: Optional<Utf8>, show_if: Optional<JsonDocument>>>;\n")
+		body.WriteString(`UPSERT INTO factory_questions
+SELECT question_id, label, phrases, category, type, order_index, options, placeholder, show_if, CAST(NULL AS Int64?) AS deleted_at
+FROM AS_TABLE($questions);
+`)
+		params = append(params, table.ValueParam("$questions", types.ListValue(rows...)))
+	}
+
+	if len(change.RemovedQuestions) > 0 {
+		rows := make([]types.Value, 0, len(change.RemovedQuestions))
+		for _, questionID := range change.RemovedQuestions {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("question_id", types.UTF8Value(questionID)),
+			))
+		}
+		declares.WriteString("DECLARE $removed_questions AS List<Struct<question_id: Utf8>>;\n")
+		body.WriteString(`UPDATE factory_questions ON
+SELECT question_id, $updated_at AS deleted_at
+FROM AS_TABLE($removed_questions);
+`)
+		params = append(params, table.ValueParam("$removed_questions", types.ListValue(rows...)))
+	}
+
+	if body.Len() == 0 {
+		return nil
+	}
+	return s.execWrite(ctx, s.withPrefix(declares.String()+body.String()), table.NewQueryParameters(params...))
+}
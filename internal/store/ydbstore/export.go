@@ -0,0 +1,338 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) ListUsageLimits(ctx context.Context, userID string) ([]models.UsageLimit, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT month, inference_count, max_limit, updated_at
+FROM usage_limits
+WHERE user_id = $user_id
+ORDER BY month;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.UsageLimit
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			usage := models.UsageLimit{UserID: userID}
+			if err := res.ScanNamed(
+				named.Required("month", &usage.Month),
+				named.Required("inference_count", &usage.InferenceCount),
+				named.Required("max_limit", &usage.Limit),
+				named.Required("updated_at", &usage.UpdatedAt),
+			); err != nil {
+				return err
+			}
+			out = append(out, usage)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) UpsertExportJob(ctx context.Context, userID string, job models.ExportJob) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $job_id AS Utf8;
+DECLARE $format AS Utf8;
+DECLARE $status AS Utf8;
+DECLARE $size AS Int64?;
+DECLARE $error AS Utf8?;
+DECLARE $blob_key AS Utf8?;
+DECLARE $created_at AS Int64;
+DECLARE $updated_at AS Int64;
+UPSERT INTO export_jobs (user_id, job_id, format, status, size, error, blob_key, created_at, updated_at)
+VALUES ($user_id, $job_id, $format, $status, $size, $error, $blob_key, $created_at, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$job_id", types.UTF8Value(job.ID)),
+		table.ValueParam("$format", types.UTF8Value(job.Format)),
+		table.ValueParam("$status", types.UTF8Value(job.Status)),
+		table.ValueParam("$size", optionalInt64(job.Size)),
+		table.ValueParam("$error", optionalString(job.Error)),
+		table.ValueParam("$blob_key", optionalString(job.BlobKey)),
+		table.ValueParam("$created_at", types.Int64Value(job.Created)),
+		table.ValueParam("$updated_at", types.Int64Value(job.UpdatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) GetExportJob(ctx context.Context, userID, jobID string) (models.ExportJob, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $job_id AS Utf8;
+SELECT job_id, format, status, size, error, blob_key, created_at, updated_at
+FROM export_jobs
+WHERE user_id = $user_id AND job_id = $job_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$job_id", types.UTF8Value(jobID)),
+	)
+
+	var (
+		job   models.ExportJob
+		found bool
+	)
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		found = false
+		if res.NextRow() {
+			if job, err = scanExportJob(res); err != nil {
+				return err
+			}
+			found = true
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+	if !found {
+		return models.ExportJob{}, store.ErrNotFound
+	}
+	return job, nil
+}
+
+func (s *Store) ListExportJobs(ctx context.Context, userID string) ([]models.ExportJob, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT job_id, format, status, size, error, blob_key, created_at, updated_at
+FROM export_jobs
+WHERE user_id = $user_id
+ORDER BY created_at DESC;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.ExportJob
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			job, err := scanExportJob(res)
+			if err != nil {
+				return err
+			}
+			out = append(out, job)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) DeleteExportJob(ctx context.Context, userID, jobID string) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $job_id AS Utf8;
+DELETE FROM export_jobs WHERE user_id = $user_id AND job_id = $job_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$job_id", types.UTF8Value(jobID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) ListDialogMessagesAfter(ctx context.Context, userID, chatID string, afterCreated int64, afterID string, limit int) ([]models.DialogMessage, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 500
+	}
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $chat_id AS Utf8;
+DECLARE $after_created AS Int64;
+DECLARE $after_id AS Utf8;
+DECLARE $limit AS Uint64;
+SELECT message_id, role, content, source, created_at, updated_at
+FROM dialog_messages
+WHERE user_id = $user_id AND chat_id = $chat_id AND deleted_at IS NULL
+  AND ($after_id = "" OR created_at > $after_created OR (created_at = $after_created AND message_id > $after_id))
+ORDER BY created_at, message_id
+LIMIT $limit;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$chat_id", types.UTF8Value(chatID)),
+		table.ValueParam("$after_created", types.Int64Value(afterCreated)),
+		table.ValueParam("$after_id", types.UTF8Value(afterID)),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	)
+
+	var out []models.DialogMessage
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				msg     = models.DialogMessage{ChatID: chatID}
+				source  *string
+				updated *int64
+			)
+			if err := res.ScanNamed(
+				named.Required("message_id", &msg.ID),
+				named.Required("role", &msg.Role),
+				named.Required("content", &msg.Content),
+				named.Optional("source", &source),
+				named.Required("created_at", &msg.Created),
+				named.Optional("updated_at", &updated),
+			); err != nil {
+				return err
+			}
+			msg.Source = stringValue(source)
+			msg.UpdatedAt = msg.Created
+			if updated != nil {
+				msg.UpdatedAt = *updated
+			}
+			out = append(out, msg)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) ListDialogSuggestionsAfter(ctx context.Context, userID, afterID string, limit int) ([]models.DialogSuggestion, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 500
+	}
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $after_id AS Utf8;
+DECLARE $limit AS Uint64;
+SELECT suggestion_id, chat_id, message_id, text, status, category_id, created_at, updated_at
+FROM dialog_suggestions
+WHERE user_id = $user_id AND suggestion_id > $after_id
+ORDER BY suggestion_id
+LIMIT $limit;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$after_id", types.UTF8Value(afterID)),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	)
+
+	var out []models.DialogSuggestion
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				suggestion        models.DialogSuggestion
+				chatID, messageID *string
+				updated           *int64
+			)
+			if err := res.ScanNamed(
+				named.Required("suggestion_id", &suggestion.ID),
+				named.Optional("chat_id", &chatID),
+				named.Optional("message_id", &messageID),
+				named.Required("text", &suggestion.Text),
+				named.Required("status", &suggestion.Status),
+				named.Optional("category_id", &suggestion.CategoryID),
+				named.Required("created_at", &suggestion.Created),
+				named.Optional("updated_at", &updated),
+			); err != nil {
+				return err
+			}
+			suggestion.ChatID = stringValue(chatID)
+			suggestion.MessageID = stringValue(messageID)
+			suggestion.UpdatedAt = suggestion.Created
+			if updated != nil {
+				suggestion.UpdatedAt = *updated
+			}
+			out = append(out, suggestion)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func scanExportJob(res result.Result) (models.ExportJob, error) {
+	var (
+		job              models.ExportJob
+		size             *int64
+		errText, blobKey *string
+	)
+	if err := res.ScanNamed(
+		named.Required("job_id", &job.ID),
+		named.Required("format", &job.Format),
+		named.Required("status", &job.Status),
+		named.Optional("size", &size),
+		named.Optional("error", &errText),
+		named.Optional("blob_key", &blobKey),
+		named.Required("created_at", &job.Created),
+		named.Required("updated_at", &job.UpdatedAt),
+	); err != nil {
+		return models.ExportJob{}, err
+	}
+	if size != nil {
+		job.Size = *size
+	}
+	job.Error = stringValue(errText)
+	job.BlobKey = stringValue(blobKey)
+	return job, nil
+}
@@ -0,0 +1,70 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) UpsertGlobalImport(ctx context.Context, userID string, imp models.GlobalImport) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $version AS Int64;
+DECLARE $imported_at AS Int64;
+UPSERT INTO global_imports (user_id, category_id, version, imported_at)
+VALUES ($user_id, $category_id, $version, $imported_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$category_id", types.UTF8Value(imp.CategoryID)),
+		table.ValueParam("$version", types.Int64Value(imp.Version)),
+		table.ValueParam("$imported_at", types.Int64Value(imp.ImportedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) ListGlobalImports(ctx context.Context, userID string) ([]models.GlobalImport, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT category_id, version, imported_at
+FROM global_imports
+WHERE user_id = $user_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.GlobalImport
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var imp models.GlobalImport
+			if err := res.ScanNamed(
+				named.Required("category_id", &imp.CategoryID),
+				named.Required("version", &imp.Version),
+				named.Required("imported_at", &imp.ImportedAt),
+			); err != nil {
+				return err
+			}
+			out = append(out, imp)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
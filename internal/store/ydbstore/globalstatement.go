@@ -0,0 +1,85 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// UpsertGlobalStatements writes global statements in one transaction,
+// restoring any that were deleted.
+func (s *Store) UpsertGlobalStatements(ctx context.Context, statements []models.Statement) error {
+	if len(statements) == 0 {
+		return nil
+	}
+
+	query := s.withPrefix(`
+DECLARE $rows AS List<Struct<category_id: Utf8, statement_id: Utf8, text: Utf8, created_at: Int64, updated_at: Int64, position: Utf8?>>;
+UPSERT INTO global_statements (category_id, statement_id, text, created_at, updated_at, position, deleted_at)
+SELECT category_id, statement_id, text, created_at, updated_at, position, CAST(NULL AS Int64?) AS deleted_at
+FROM AS_TABLE($rows);`)
+
+	rows := make([]types.Value, 0, len(statements))
+	for _, stmt := range statements {
+		rows = append(rows, types.StructValue(
+			types.StructFieldValue("category_id", types.UTF8Value(stmt.CategoryID)),
+			types.StructFieldValue("statement_id", types.UTF8Value(stmt.ID)),
+			types.StructFieldValue("text", types.UTF8Value(stmt.Text)),
+			types.StructFieldValue("created_at", types.Int64Value(stmt.Created)),
+			types.StructFieldValue("updated_at", types.Int64Value(stmt.UpdatedAt)),
+			types.StructFieldValue("position", optionalString(stmt.Position)),
+		))
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$rows", types.ListValue(rows...)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) DeleteGlobalStatement(ctx context.Context, categoryID, statementID string, updatedAt int64) error {
+	query := s.withPrefix(`
+DECLARE $category_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DECLARE $deleted_at AS Int64;
+UPDATE global_statements
+SET deleted_at = $deleted_at, updated_at = $deleted_at
+WHERE category_id = $category_id AND statement_id = $statement_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+		table.ValueParam("$statement_id", types.UTF8Value(statementID)),
+		table.ValueParam("$deleted_at", types.Int64Value(updatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) SetGlobalStatementPositions(ctx context.Context, categoryID string, positions map[string]string) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	query := s.withPrefix(`
+DECLARE $category_id AS Utf8;
+DECLARE $rows AS List<Struct<statement_id: Utf8, position: Utf8>>;
+UPDATE global_statements ON
+SELECT $category_id AS category_id, statement_id, position
+FROM AS_TABLE($rows);`)
+
+	rows := make([]types.Value, 0, len(positions))
+	for _, statementID := range sortedKeys(positions) {
+		rows = append(rows, types.StructValue(
+			types.StructFieldValue("statement_id", types.UTF8Value(statementID)),
+			types.StructFieldValue("position", types.UTF8Value(positions[statementID])),
+		))
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+		table.ValueParam("$rows", types.ListValue(rows...)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
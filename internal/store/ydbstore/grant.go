@@ -0,0 +1,139 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) UpsertGrant(ctx context.Context, grant models.Grant) error {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+DECLARE $delegate_id AS Utf8;
+DECLARE $access AS Utf8;
+DECLARE $created_at AS Int64;
+DECLARE $updated_at AS Int64;
+UPSERT INTO grants (owner_id, delegate_id, access, created_at, updated_at)
+VALUES ($owner_id, $delegate_id, $access, $created_at, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(grant.OwnerID)),
+		table.ValueParam("$delegate_id", types.UTF8Value(grant.DelegateID)),
+		table.ValueParam("$access", types.UTF8Value(grant.Access)),
+		table.ValueParam("$created_at", types.Int64Value(grant.Created)),
+		table.ValueParam("$updated_at", types.Int64Value(grant.UpdatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) GetGrant(ctx context.Context, ownerID, delegateID string) (models.Grant, error) {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+DECLARE $delegate_id AS Utf8;
+SELECT owner_id, delegate_id, access, created_at, updated_at
+FROM grants
+WHERE owner_id = $owner_id AND delegate_id = $delegate_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(ownerID)),
+		table.ValueParam("$delegate_id", types.UTF8Value(delegateID)),
+	)
+
+	grants, err := s.queryGrants(ctx, query, params)
+	if err != nil {
+		return models.Grant{}, err
+	}
+	if len(grants) == 0 {
+		return models.Grant{}, store.ErrNotFound
+	}
+	return grants[0], nil
+}
+
+func (s *Store) ListGrantsByOwner(ctx context.Context, ownerID string) ([]models.Grant, error) {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+SELECT owner_id, delegate_id, access, created_at, updated_at
+FROM grants
+WHERE owner_id = $owner_id
+ORDER BY created_at;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(ownerID)),
+	)
+
+	return s.queryGrants(ctx, query, params)
+}
+
+func (s *Store) ListGrantsByDelegate(ctx context.Context, delegateID string) ([]models.Grant, error) {
+	query := s.withPrefix(`
+DECLARE $delegate_id AS Utf8;
+SELECT owner_id, delegate_id, access, created_at, updated_at
+FROM grants VIEW idx_delegate
+WHERE delegate_id = $delegate_id
+ORDER BY created_at;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$delegate_id", types.UTF8Value(delegateID)),
+	)
+
+	return s.queryGrants(ctx, query, params)
+}
+
+func (s *Store) DeleteGrant(ctx context.Context, ownerID, delegateID string) error {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+DECLARE $delegate_id AS Utf8;
+DELETE FROM grants WHERE owner_id = $owner_id AND delegate_id = $delegate_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(ownerID)),
+		table.ValueParam("$delegate_id", types.UTF8Value(delegateID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) queryGrants(ctx context.Context, query string, params *table.QueryParameters) ([]models.Grant, error) {
+	var out []models.Grant
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			grant, err := scanGrant(res)
+			if err != nil {
+				return err
+			}
+			out = append(out, grant)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func scanGrant(res result.Result) (models.Grant, error) {
+	var grant models.Grant
+	err := res.ScanNamed(
+		named.Required("owner_id", &grant.OwnerID),
+		named.Required("delegate_id", &grant.DelegateID),
+		named.Required("access", &grant.Access),
+		named.Required("created_at", &grant.Created),
+		named.Required("updated_at", &grant.UpdatedAt),
+	)
+	return grant, err
+}
@@ -0,0 +1,202 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) CreateMedia(ctx context.Context, userID string, media models.Media) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $media_id AS Utf8;
+DECLARE $content_type AS Utf8;
+DECLARE $size AS Int64;
+DECLARE $width AS Int64;
+DECLARE $height AS Int64;
+DECLARE $blob_key AS Utf8;
+DECLARE $thumbnail_key AS Utf8;
+DECLARE $created_at AS Int64;
+UPSERT INTO media (user_id, media_id, content_type, size, width, height, blob_key, thumbnail_key, created_at)
+VALUES ($user_id, $media_id, $content_type, $size, $width, $height, $blob_key, $thumbnail_key, $created_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$media_id", types.UTF8Value(media.ID)),
+		table.ValueParam("$content_type", types.UTF8Value(media.ContentType)),
+		table.ValueParam("$size", types.Int64Value(media.Size)),
+		table.ValueParam("$width", types.Int64Value(int64(media.Width))),
+		table.ValueParam("$height", types.Int64Value(int64(media.Height))),
+		table.ValueParam("$blob_key", types.UTF8Value(media.BlobKey)),
+		table.ValueParam("$thumbnail_key", types.UTF8Value(media.ThumbnailKey)),
+		table.ValueParam("$created_at", types.Int64Value(media.Created)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) GetMedia(ctx context.Context, userID, mediaID string) (models.Media, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $media_id AS Utf8;
+SELECT media_id, content_type, size, width, height, blob_key, thumbnail_key, created_at
+FROM media
+WHERE user_id = $user_id AND media_id = $media_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$media_id", types.UTF8Value(mediaID)),
+	)
+
+	var (
+		media models.Media
+		found bool
+	)
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		found = false
+		if res.NextRow() {
+			if media, err = scanMedia(res); err != nil {
+				return err
+			}
+			found = true
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return models.Media{}, err
+	}
+	if !found {
+		return models.Media{}, store.ErrNotFound
+	}
+	return media, nil
+}
+
+func (s *Store) ListMedia(ctx context.Context, userID string) ([]models.Media, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT media_id, content_type, size, width, height, blob_key, thumbnail_key, created_at
+FROM media
+WHERE user_id = $user_id
+ORDER BY created_at;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.Media
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			media, err := scanMedia(res)
+			if err != nil {
+				return err
+			}
+			out = append(out, media)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) DeleteMedia(ctx context.Context, userID, mediaID string) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $media_id AS Utf8;
+DELETE FROM media WHERE user_id = $user_id AND media_id = $media_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$media_id", types.UTF8Value(mediaID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+// CountMediaReferences counts trashed rows too, so a restore keeps its image.
+func (s *Store) CountMediaReferences(ctx context.Context, userID, mediaID string) (int64, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $media_id AS Utf8;
+$categories = (
+  SELECT COUNT(*) FROM categories
+  WHERE user_id = $user_id AND image_id = $media_id
+);
+$statements = (
+  SELECT COUNT(*) FROM statements
+  WHERE user_id = $user_id AND image_id = $media_id
+);
+SELECT $categories + $statements AS cnt;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$media_id", types.UTF8Value(mediaID)),
+	)
+
+	var count uint64
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		if res.NextRow() {
+			if err := res.ScanNamed(named.OptionalWithDefault("cnt", &count)); err != nil {
+				return err
+			}
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return 0, err
+	}
+	return int64(count), nil
+}
+
+func scanMedia(res result.Result) (models.Media, error) {
+	var (
+		media         models.Media
+		width, height int64
+	)
+	if err := res.ScanNamed(
+		named.Required("media_id", &media.ID),
+		named.Required("content_type", &media.ContentType),
+		named.Required("size", &media.Size),
+		named.Required("width", &width),
+		named.Required("height", &height),
+		named.Required("blob_key", &media.BlobKey),
+		named.Required("thumbnail_key", &media.ThumbnailKey),
+		named.Required("created_at", &media.Created),
+	); err != nil {
+		return models.Media{}, err
+	}
+	media.Width = int(width)
+	media.Height = int(height)
+	return media, nil
+}
@@ -0,0 +1,200 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) GetUserMigration(ctx context.Context, userID string) (models.UserMigration, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT user_id, state, note, verified_at, updated_at, updated_by
+FROM user_migration
+WHERE user_id = $user_id
+LIMIT 1;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var (
+		migration models.UserMigration
+		found     bool
+	)
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		found = false
+		if res.NextRow() {
+			if migration, err = scanUserMigration(res); err != nil {
+				return err
+			}
+			found = true
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return models.UserMigration{}, err
+	}
+	if !found {
+		return models.UserMigration{}, store.ErrNotFound
+	}
+	return migration, nil
+}
+
+func (s *Store) SetUserMigration(ctx context.Context, migration models.UserMigration) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $state AS Utf8;
+DECLARE $note AS Utf8?;
+DECLARE $verified_at AS Int64?;
+DECLARE $updated_at AS Int64;
+DECLARE $updated_by AS Utf8?;
+UPSERT INTO user_migration (user_id, state, note, verified_at, updated_at, updated_by)
+VALUES ($user_id, $state, $note, $verified_at, $updated_at, $updated_by);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(migration.UserID)),
+		table.ValueParam("$state", types.UTF8Value(migration.State)),
+		table.ValueParam("$note", optionalString(migration.Note)),
+		table.ValueParam("$verified_at", optionalInt64(migration.VerifiedAt)),
+		table.ValueParam("$updated_at", types.Int64Value(migration.UpdatedAt)),
+		table.ValueParam("$updated_by", optionalString(migration.UpdatedBy)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) ListUserMigrations(ctx context.Context, state string, limit int) ([]models.UserMigration, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := s.withPrefix(`
+DECLARE $limit AS Uint64;
+SELECT user_id, state, note, verified_at, updated_at, updated_by
+FROM user_migration
+ORDER BY updated_at DESC
+LIMIT $limit;`)
+	params := table.NewQueryParameters(
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	)
+	if state != "" {
+		query = s.withPrefix(`
+DECLARE $state AS Utf8;
+DECLARE $limit AS Uint64;
+SELECT user_id, state, note, verified_at, updated_at, updated_by
+FROM user_migration VIEW idx_state
+WHERE state = $state
+ORDER BY updated_at DESC
+LIMIT $limit;`)
+		params = table.NewQueryParameters(
+			table.ValueParam("$state", types.UTF8Value(state)),
+			table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+		)
+	}
+
+	var migrations []models.UserMigration
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		migrations = migrations[:0]
+		for res.NextRow() {
+			migration, err := scanUserMigration(res)
+			if err != nil {
+				return err
+			}
+			migrations = append(migrations, migration)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+func (s *Store) CountUserMigrations(ctx context.Context) (map[string]int64, error) {
+	query := s.withPrefix(`
+SELECT state, COUNT(*) AS total
+FROM user_migration
+GROUP BY state;`)
+
+	counts := make(map[string]int64)
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, nil)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		for res.NextRow() {
+			var (
+				state string
+				total uint64
+			)
+			if err := res.ScanNamed(
+				named.Required("state", &state),
+				named.Required("total", &total),
+			); err != nil {
+				return err
+			}
+			counts[state] = int64(total)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func scanUserMigration(res result.Result) (models.UserMigration, error) {
+	var (
+		migration  models.UserMigration
+		note       *string
+		verifiedAt *int64
+		updatedBy  *string
+	)
+	if err := res.ScanNamed(
+		named.Required("user_id", &migration.UserID),
+		named.Required("state", &migration.State),
+		named.Optional("note", &note),
+		named.Optional("verified_at", &verifiedAt),
+		named.Required("updated_at", &migration.UpdatedAt),
+		named.Optional("updated_by", &updatedBy),
+	); err != nil {
+		return models.UserMigration{}, err
+	}
+	if note != nil {
+		migration.Note = *note
+	}
+	if verifiedAt != nil {
+		migration.VerifiedAt = *verifiedAt
+	}
+	if updatedBy != nil {
+		migration.UpdatedBy = *updatedBy
+	}
+	migration.Explicit = true
+	return migration, nil
+}
@@ -0,0 +1,97 @@
+package ydbstore
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) ListOnboardingAnswers(ctx context.Context, userID string) ([]models.OnboardingAnswer, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT question_id, answer, updated_at
+FROM onboarding_answers
+WHERE user_id = $user_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.OnboardingAnswer
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				answer models.OnboardingAnswer
+				values string
+			)
+			if err := res.ScanNamed(
+				named.Required("question_id", &answer.QuestionID),
+				named.Required("answer", &values),
+				named.Required("updated_at", &answer.UpdatedAt),
+			); err != nil {
+				return err
+			}
+			if err := json.Unmarshal([]byte(values), &answer.Values); err != nil {
+				return err
+			}
+			out = append(out, answer)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) UpsertOnboardingAnswer(ctx context.Context, userID string, answer models.OnboardingAnswer) error {
+	values, err := json.Marshal(answer.Values)
+	if err != nil {
+		return err
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $question_id AS Utf8;
+DECLARE $answer AS JsonDocument;
+DECLARE $updated_at AS Int64;
+UPSERT INTO onboarding_answers (user_id, question_id, answer, updated_at)
+VALUES ($user_id, $question_id, $answer, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$question_id", types.UTF8Value(answer.QuestionID)),
+		table.ValueParam("$answer", types.JSONDocumentValue(string(values))),
+		table.ValueParam("$updated_at", types.Int64Value(answer.UpdatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) DeleteOnboardingAnswer(ctx context.Context, userID, questionID string) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $question_id AS Utf8;
+DELETE FROM onboarding_answers
+WHERE user_id = $user_id AND question_id = $question_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$question_id", types.UTF8Value(questionID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
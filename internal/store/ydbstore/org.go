@@ -0,0 +1,400 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) UpsertOrganization(ctx context.Context, org models.Organization) error {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+DECLARE $name AS Utf8;
+DECLARE $created_at AS Int64;
+DECLARE $updated_at AS Int64;
+UPSERT INTO organizations (org_id, name, created_at, updated_at)
+VALUES ($org_id, $name, $created_at, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(org.ID)),
+		table.ValueParam("$name", types.UTF8Value(org.Name)),
+		table.ValueParam("$created_at", types.Int64Value(org.Created)),
+		table.ValueParam("$updated_at", types.Int64Value(org.UpdatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) GetOrganization(ctx context.Context, orgID string) (models.Organization, error) {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+SELECT name, created_at, updated_at
+FROM organizations
+WHERE org_id = $org_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+	)
+
+	var (
+		org   models.Organization
+		found bool
+	)
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		found = false
+		if res.NextRow() {
+			org = models.Organization{ID: orgID}
+			if err := res.ScanNamed(
+				named.Required("name", &org.Name),
+				named.Required("created_at", &org.Created),
+				named.Required("updated_at", &org.UpdatedAt),
+			); err != nil {
+				return err
+			}
+			found = true
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return models.Organization{}, err
+	}
+	if !found {
+		return models.Organization{}, store.ErrNotFound
+	}
+	return org, nil
+}
+
+func (s *Store) UpsertOrgMember(ctx context.Context, member models.OrgMember) error {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+DECLARE $user_id AS Utf8;
+DECLARE $role AS Utf8;
+DECLARE $status AS Utf8;
+DECLARE $created_at AS Int64;
+UPSERT INTO org_members (org_id, user_id, role, status, created_at)
+VALUES ($org_id, $user_id, $role, $status, $created_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(member.OrgID)),
+		table.ValueParam("$user_id", types.UTF8Value(member.UserID)),
+		table.ValueParam("$role", types.UTF8Value(member.Role)),
+		table.ValueParam("$status", types.UTF8Value(member.Status)),
+		table.ValueParam("$created_at", types.Int64Value(member.Created)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) GetOrgMember(ctx context.Context, orgID, userID string) (models.OrgMember, error) {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+DECLARE $user_id AS Utf8;
+SELECT org_id, user_id, role, status, created_at
+FROM org_members
+WHERE org_id = $org_id AND user_id = $user_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	members, err := s.queryOrgMembers(ctx, query, params)
+	if err != nil {
+		return models.OrgMember{}, err
+	}
+	if len(members) == 0 {
+		return models.OrgMember{}, store.ErrNotFound
+	}
+	return members[0], nil
+}
+
+func (s *Store) ListOrgMembers(ctx context.Context, orgID string) ([]models.OrgMember, error) {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+SELECT org_id, user_id, role, status, created_at
+FROM org_members
+WHERE org_id = $org_id
+ORDER BY created_at;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+	)
+
+	return s.queryOrgMembers(ctx, query, params)
+}
+
+func (s *Store) ListUserOrgMemberships(ctx context.Context, userID string) ([]models.OrgMember, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT org_id, user_id, role, status, created_at
+FROM org_members VIEW idx_user
+WHERE user_id = $user_id
+ORDER BY created_at;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	return s.queryOrgMembers(ctx, query, params)
+}
+
+func (s *Store) DeleteOrgMember(ctx context.Context, orgID, userID string) error {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+DECLARE $user_id AS Utf8;
+DELETE FROM org_members WHERE org_id = $org_id AND user_id = $user_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) queryOrgMembers(ctx context.Context, query string, params *table.QueryParameters) ([]models.OrgMember, error) {
+	var out []models.OrgMember
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			member, err := scanOrgMember(res)
+			if err != nil {
+				return err
+			}
+			out = append(out, member)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func scanOrgMember(res result.Result) (models.OrgMember, error) {
+	var member models.OrgMember
+	err := res.ScanNamed(
+		named.Required("org_id", &member.OrgID),
+		named.Required("user_id", &member.UserID),
+		named.Required("role", &member.Role),
+		named.Required("status", &member.Status),
+		named.Required("created_at", &member.Created),
+	)
+	return member, err
+}
+
+func (s *Store) ListOrgCategories(ctx context.Context, orgID string) ([]models.Category, error) {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+SELECT category_id, label, parent_id, color, icon, created_at, updated_at
+FROM org_categories
+WHERE org_id = $org_id
+ORDER BY created_at;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+	)
+
+	var out []models.Category
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				cat                   models.Category
+				parentID, color, icon *string
+			)
+			if err := res.ScanNamed(
+				named.Required("category_id", &cat.ID),
+				named.Required("label", &cat.Label),
+				named.Optional("parent_id", &parentID),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Required("created_at", &cat.Created),
+				named.Required("updated_at", &cat.UpdatedAt),
+			); err != nil {
+				return err
+			}
+			cat.ParentID, cat.Color, cat.Icon = stringValue(parentID), stringValue(color), stringValue(icon)
+			out = append(out, cat)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) UpsertOrgCategory(ctx context.Context, orgID string, category models.Category) error {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $label AS Utf8;
+DECLARE $parent_id AS Utf8?;
+DECLARE $color AS Utf8?;
+DECLARE $icon AS Utf8?;
+DECLARE $created_at AS Int64;
+DECLARE $updated_at AS Int64;
+UPSERT INTO org_categories (org_id, category_id, label, parent_id, color, icon, created_at, updated_at)
+VALUES ($org_id, $category_id, $label, $parent_id, $color, $icon, $created_at, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+		table.ValueParam("$category_id", types.UTF8Value(category.ID)),
+		table.ValueParam("$label", types.UTF8Value(category.Label)),
+		table.ValueParam("$parent_id", optionalString(category.ParentID)),
+		table.ValueParam("$color", optionalString(category.Color)),
+		table.ValueParam("$icon", optionalString(category.Icon)),
+		table.ValueParam("$created_at", types.Int64Value(category.Created)),
+		table.ValueParam("$updated_at", types.Int64Value(category.UpdatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+// DeleteOrgCategories removes library categories together with their
+// statements.
+func (s *Store) DeleteOrgCategories(ctx context.Context, orgID string, categoryIDs []string) error {
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+DECLARE $category_ids AS List<Utf8>;
+DELETE FROM org_statements ON
+SELECT org_id, statement_id FROM org_statements
+WHERE org_id = $org_id AND category_id IN $category_ids;
+DELETE FROM org_categories
+WHERE org_id = $org_id AND category_id IN $category_ids;`)
+
+	ids := make([]types.Value, 0, len(categoryIDs))
+	for _, categoryID := range categoryIDs {
+		ids = append(ids, types.UTF8Value(categoryID))
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+		table.ValueParam("$category_ids", types.ListValue(ids...)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) ListOrgStatements(ctx context.Context, orgID string) ([]models.Statement, error) {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+SELECT statement_id, category_id, text, color, icon, created_at, updated_at
+FROM org_statements
+WHERE org_id = $org_id
+ORDER BY created_at;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+	)
+
+	var out []models.Statement
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				stmt        models.Statement
+				color, icon *string
+			)
+			if err := res.ScanNamed(
+				named.Required("statement_id", &stmt.ID),
+				named.Required("category_id", &stmt.CategoryID),
+				named.Required("text", &stmt.Text),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Required("created_at", &stmt.Created),
+				named.Required("updated_at", &stmt.UpdatedAt),
+			); err != nil {
+				return err
+			}
+			stmt.Color, stmt.Icon = stringValue(color), stringValue(icon)
+			out = append(out, stmt)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) UpsertOrgStatement(ctx context.Context, orgID string, statement models.Statement) error {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $text AS Utf8;
+DECLARE $color AS Utf8?;
+DECLARE $icon AS Utf8?;
+DECLARE $created_at AS Int64;
+DECLARE $updated_at AS Int64;
+UPSERT INTO org_statements (org_id, statement_id, category_id, text, color, icon, created_at, updated_at)
+VALUES ($org_id, $statement_id, $category_id, $text, $color, $icon, $created_at, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+		table.ValueParam("$statement_id", types.UTF8Value(statement.ID)),
+		table.ValueParam("$category_id", types.UTF8Value(statement.CategoryID)),
+		table.ValueParam("$text", types.UTF8Value(statement.Text)),
+		table.ValueParam("$color", optionalString(statement.Color)),
+		table.ValueParam("$icon", optionalString(statement.Icon)),
+		table.ValueParam("$created_at", types.Int64Value(statement.Created)),
+		table.ValueParam("$updated_at", types.Int64Value(statement.UpdatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) DeleteOrgStatement(ctx context.Context, orgID, statementID string) error {
+	query := s.withPrefix(`
+DECLARE $org_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DELETE FROM org_statements WHERE org_id = $org_id AND statement_id = $statement_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$org_id", types.UTF8Value(orgID)),
+		table.ValueParam("$statement_id", types.UTF8Value(statementID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
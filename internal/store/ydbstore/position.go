@@ -0,0 +1,78 @@
+package ydbstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) SetCategoryPositions(ctx context.Context, userID string, positions map[string]string, updatedAt int64) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $updated_at AS Int64;
+DECLARE $rows AS List<Struct<category_id: Utf8, position: Utf8>>;
+UPDATE categories ON
+SELECT $user_id AS user_id, category_id, position, $updated_at AS updated_at
+FROM AS_TABLE($rows);`)
+
+	rows := make([]types.Value, 0, len(positions))
+	for _, categoryID := range sortedKeys(positions) {
+		rows = append(rows, types.StructValue(
+			types.StructFieldValue("category_id", types.UTF8Value(categoryID)),
+			types.StructFieldValue("position", types.UTF8Value(positions[categoryID])),
+		))
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
+		table.ValueParam("$rows", types.ListValue(rows...)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) SetStatementPositions(ctx context.Context, userID, categoryID string, positions map[string]string, updatedAt int64) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $updated_at AS Int64;
+DECLARE $rows AS List<Struct<statement_id: Utf8, position: Utf8>>;
+UPDATE statements ON
+SELECT $user_id AS user_id, $category_id AS category_id, statement_id, position, $updated_at AS updated_at
+FROM AS_TABLE($rows);`)
+
+	rows := make([]types.Value, 0, len(positions))
+	for _, statementID := range sortedKeys(positions) {
+		rows = append(rows, types.StructValue(
+			types.StructFieldValue("statement_id", types.UTF8Value(statementID)),
+			types.StructFieldValue("position", types.UTF8Value(positions[statementID])),
+		))
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+		table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
+		table.ValueParam("$rows", types.ListValue(rows...)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+package ydbstore
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func (s *Store) AppendRevision(ctx context.Context, userID string, revision models.Revision) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $entity_type AS Utf8;
+DECLARE $entity_id AS Utf8;
+DECLARE $revision_id AS Utf8;
+DECLARE $op AS Utf8;
+DECLARE $snapshot AS JsonDocument;
+DECLARE $device AS Utf8?;
+DECLARE $created_at AS Int64;
+UPSERT INTO revisions (user_id, entity_type, entity_id, revision_id, op, snapshot, device, created_at)
+VALUES ($user_id, $entity_type, $entity_id, $revision_id, $op, $snapshot, $device, $created_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$entity_type", types.UTF8Value(revision.EntityType)),
+		table.ValueParam("$entity_id", types.UTF8Value(revision.EntityID)),
+		table.ValueParam("$revision_id", types.UTF8Value(revision.ID)),
+		table.ValueParam("$op", types.UTF8Value(revision.Op)),
+		table.ValueParam("$snapshot", types.JSONDocumentValue(string(revision.Snapshot))),
+		table.ValueParam("$device", optionalString(revision.Device)),
+		table.ValueParam("$created_at", types.Int64Value(revision.At)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+// ListRevisions returns the newest revisions of an entity first.
+func (s *Store) ListRevisions(ctx context.Context, userID, entityType, entityID string, limit int) ([]models.Revision, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $entity_type AS Utf8;
+DECLARE $entity_id AS Utf8;
+DECLARE $limit AS Uint64;
+SELECT revision_id, op, snapshot, device, created_at
+FROM revisions
+WHERE user_id = $user_id AND entity_type = $entity_type AND entity_id = $entity_id
+ORDER BY revision_id DESC
+LIMIT $limit;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$entity_type", types.UTF8Value(entityType)),
+		table.ValueParam("$entity_id", types.UTF8Value(entityID)),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	)
+
+	var out []models.Revision
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		for res.NextRow() {
+			var (
+				revisionID string
+				op         string
+				snapshot   string
+				device     *string
+				created    int64
+			)
+			if err := res.ScanNamed(
+				named.Required("revision_id", &revisionID),
+				named.Required("op", &op),
+				named.Required("snapshot", &snapshot),
+				named.Optional("device", &device),
+				named.Required("created_at", &created),
+			); err != nil {
+				return err
+			}
+			out = append(out, models.Revision{
+				ID:         revisionID,
+				EntityType: entityType,
+				EntityID:   entityID,
+				Op:         op,
+				Snapshot:   json.RawMessage(snapshot),
+				Device:     stringValue(device),
+				At:         created,
+			})
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
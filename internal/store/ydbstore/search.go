@@ -0,0 +1,296 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/search"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+const (
+	searchStatement = "statement"
+	searchCategory  = "category"
+)
+
+// indexSearch replaces the search terms of one entity.
+func (s *Store) indexSearch(ctx context.Context, userID, entityType, entityID, parentID, text string) error {
+	if err := s.unindexSearch(ctx, userID, entityType, entityID); err != nil {
+		return err
+	}
+	terms := search.Terms(text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $entity_type AS Utf8;
+DECLARE $entity_id AS Utf8;
+DECLARE $parent_id AS Utf8;
+DECLARE $rows AS List<Struct<term: Utf8, weight: Int64>>;
+UPSERT INTO search_terms
+SELECT $user_id AS user_id, $entity_type AS entity_type, $entity_id AS entity_id, term, $parent_id AS parent_id, weight
+FROM AS_TABLE($rows);`)
+
+	rows := make([]types.Value, 0, len(terms))
+	for _, term := range terms {
+		rows = append(rows, types.StructValue(
+			types.StructFieldValue("term", types.UTF8Value(term.Key)),
+			types.StructFieldValue("weight", types.Int64Value(term.Weight)),
+		))
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$entity_type", types.UTF8Value(entityType)),
+		table.ValueParam("$entity_id", types.UTF8Value(entityID)),
+		table.ValueParam("$parent_id", types.UTF8Value(parentID)),
+		table.ValueParam("$rows", types.ListValue(rows...)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) unindexSearch(ctx context.Context, userID, entityType, entityID string) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $entity_type AS Utf8;
+DECLARE $entity_id AS Utf8;
+DELETE FROM search_terms
+WHERE user_id = $user_id AND entity_type = $entity_type AND entity_id = $entity_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$entity_type", types.UTF8Value(entityType)),
+		table.ValueParam("$entity_id", types.UTF8Value(entityID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) SearchIndex(ctx context.Context, userID string, terms []string, limit int) ([]models.StatementHit, []models.CategoryHit, error) {
+	if len(terms) == 0 {
+		return nil, nil, nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $terms AS List<Utf8>;
+DECLARE $limit AS Uint64;
+
+$hits = (
+  SELECT $user_id AS user_id, entity_type, entity_id, parent_id, SUM(weight) AS score
+  FROM search_terms VIEW idx_term
+  WHERE user_id = $user_id AND term IN $terms
+  GROUP BY entity_type, entity_id, parent_id
+  ORDER BY score DESC
+  LIMIT $limit
+);
+
+SELECT
+  st.statement_id AS statement_id,
+  st.category_id AS category_id,
+  st.text AS text,
+  st.created_at AS created_at,
+  st.position AS position,
+  st.color AS color,
+  st.icon AS icon,
+  st.image_id AS image_id,
+  st.updated_at AS updated_at,
+  h.score AS score,
+  u.spoken_count AS spoken_count
+FROM $hits AS h
+JOIN statements AS st
+  ON st.user_id = h.user_id AND st.category_id = h.parent_id AND st.statement_id = h.entity_id
+LEFT JOIN statement_usage AS u
+  ON u.user_id = h.user_id AND u.statement_id = h.entity_id
+WHERE h.entity_type = "statement" AND st.deleted_at IS NULL;
+
+SELECT
+  c.category_id AS category_id,
+  c.label AS label,
+  c.created_at AS created_at,
+  c.is_default AS is_default,
+  c.ai_use AS ai_use,
+  c.parent_id AS parent_id,
+  c.position AS position,
+  c.color AS color,
+  c.icon AS icon,
+  c.image_id AS image_id,
+  c.updated_at AS updated_at,
+  h.score AS score
+FROM $hits AS h
+JOIN categories AS c
+  ON c.user_id = h.user_id AND c.category_id = h.entity_id
+WHERE h.entity_type = "category" AND c.deleted_at IS NULL;`)
+
+	values := make([]types.Value, len(terms))
+	for i, term := range terms {
+		values[i] = types.UTF8Value(term)
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$terms", types.ListValue(values...)),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	)
+
+	var (
+		statements []models.StatementHit
+		categories []models.CategoryHit
+	)
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		statements = statements[:0]
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		for res.NextRow() {
+			var (
+				hit      models.StatementHit
+				position *string
+				color    *string
+				icon     *string
+				imageID  *string
+				updated  *int64
+				score    *int64
+				spoken   *int64
+			)
+			if err := res.ScanNamed(
+				named.Required("statement_id", &hit.ID),
+				named.Required("category_id", &hit.CategoryID),
+				named.Required("text", &hit.Text),
+				named.Required("created_at", &hit.Created),
+				named.Optional("position", &position),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Optional("image_id", &imageID),
+				named.Optional("updated_at", &updated),
+				named.Optional("score", &score),
+				named.Optional("spoken_count", &spoken),
+			); err != nil {
+				return err
+			}
+			if position != nil {
+				hit.Position = *position
+			}
+			hit.Color = stringValue(color)
+			hit.Icon = stringValue(icon)
+			hit.ImageID = stringValue(imageID)
+			hit.UpdatedAt = hit.Created
+			if updated != nil {
+				hit.UpdatedAt = *updated
+			}
+			if score != nil {
+				hit.Score = float64(*score)
+			}
+			if spoken != nil {
+				hit.SpokenCount = *spoken
+			}
+			statements = append(statements, hit)
+		}
+
+		categories = categories[:0]
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		for res.NextRow() {
+			var (
+				hit       models.CategoryHit
+				isDefault *bool
+				aiUse     *bool
+				parentID  *string
+				position  *string
+				color     *string
+				icon      *string
+				imageID   *string
+				updated   *int64
+				score     *int64
+			)
+			if err := res.ScanNamed(
+				named.Required("category_id", &hit.ID),
+				named.Required("label", &hit.Label),
+				named.Required("created_at", &hit.Created),
+				named.Optional("is_default", &isDefault),
+				named.Optional("ai_use", &aiUse),
+				named.Optional("parent_id", &parentID),
+				named.Optional("position", &position),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Optional("image_id", &imageID),
+				named.Optional("updated_at", &updated),
+				named.Optional("score", &score),
+			); err != nil {
+				return err
+			}
+			hit.Default = isDefault
+			if aiUse != nil {
+				hit.AIUse = *aiUse
+			}
+			if parentID != nil {
+				hit.ParentID = *parentID
+			}
+			if position != nil {
+				hit.Position = *position
+			}
+			hit.Color = stringValue(color)
+			hit.Icon = stringValue(icon)
+			hit.ImageID = stringValue(imageID)
+			hit.UpdatedAt = hit.Created
+			if updated != nil {
+				hit.UpdatedAt = *updated
+			}
+			if score != nil {
+				hit.Score = float64(*score)
+			}
+			categories = append(categories, hit)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return statements, categories, nil
+}
+
+func (s *Store) RebuildSearchIndex(ctx context.Context, userID string) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM search_terms WHERE user_id = $user_id;`)
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+	if err := s.execWrite(ctx, query, params); err != nil {
+		return err
+	}
+
+	categories, err := s.ListCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, cat := range categories {
+		if err := s.indexSearch(ctx, userID, searchCategory, cat.ID, "", cat.Label); err != nil {
+			return err
+		}
+	}
+	statements, err := s.ListAllStatements(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		if err := s.indexSearch(ctx, userID, searchStatement, stmt.ID, stmt.CategoryID, stmt.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func (s *Store) UpsertShare(ctx context.Context, share models.Share) error {
+	query := s.withPrefix(`
+DECLARE $share_token AS Utf8;
+DECLARE $owner_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $mode AS Utf8;
+DECLARE $expires_at AS Int64;
+DECLARE $created_at AS Int64;
+UPSERT INTO shares (share_token, owner_id, category_id, mode, expires_at, created_at)
+VALUES ($share_token, $owner_id, $category_id, $mode, $expires_at, $created_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$share_token", types.UTF8Value(share.Token)),
+		table.ValueParam("$owner_id", types.UTF8Value(share.OwnerID)),
+		table.ValueParam("$category_id", types.UTF8Value(share.CategoryID)),
+		table.ValueParam("$mode", types.UTF8Value(share.Mode)),
+		table.ValueParam("$expires_at", types.Int64Value(share.ExpiresAt)),
+		table.ValueParam("$created_at", types.Int64Value(share.Created)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) GetShare(ctx context.Context, token string) (models.Share, error) {
+	query := s.withPrefix(`
+DECLARE $share_token AS Utf8;
+SELECT owner_id, category_id, mode, expires_at, created_at
+FROM shares
+WHERE share_token = $share_token;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$share_token", types.UTF8Value(token)),
+	)
+
+	var (
+		share models.Share
+		found bool
+	)
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		found = false
+		if res.NextRow() {
+			share = models.Share{Token: token}
+			if err := res.ScanNamed(
+				named.Required("owner_id", &share.OwnerID),
+				named.Required("category_id", &share.CategoryID),
+				named.Required("mode", &share.Mode),
+				named.Required("expires_at", &share.ExpiresAt),
+				named.Required("created_at", &share.Created),
+			); err != nil {
+				return err
+			}
+			found = true
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return models.Share{}, err
+	}
+	if !found {
+		return models.Share{}, store.ErrNotFound
+	}
+	return share, nil
+}
+
+func (s *Store) UpsertShareSubscription(ctx context.Context, sub models.ShareSubscription) error {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+DECLARE $recipient_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $share_token AS Utf8;
+DECLARE $created_at AS Int64;
+UPSERT INTO share_subscriptions (owner_id, recipient_id, category_id, share_token, created_at)
+VALUES ($owner_id, $recipient_id, $category_id, $share_token, $created_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(sub.OwnerID)),
+		table.ValueParam("$recipient_id", types.UTF8Value(sub.RecipientID)),
+		table.ValueParam("$category_id", types.UTF8Value(sub.CategoryID)),
+		table.ValueParam("$share_token", types.UTF8Value(sub.Token)),
+		table.ValueParam("$created_at", types.Int64Value(sub.Created)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) ListShareSubscriptions(ctx context.Context, ownerID string) ([]models.ShareSubscription, error) {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+SELECT recipient_id, category_id, share_token, created_at
+FROM share_subscriptions
+WHERE owner_id = $owner_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(ownerID)),
+	)
+
+	var out []models.ShareSubscription
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			sub := models.ShareSubscription{OwnerID: ownerID}
+			if err := res.ScanNamed(
+				named.Required("recipient_id", &sub.RecipientID),
+				named.Required("category_id", &sub.CategoryID),
+				named.Required("share_token", &sub.Token),
+				named.Required("created_at", &sub.Created),
+			); err != nil {
+				return err
+			}
+			out = append(out, sub)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) HasShareSubscriptions(ctx context.Context, ownerID string) (bool, error) {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+SELECT owner_id
+FROM share_subscriptions
+WHERE owner_id = $owner_id
+LIMIT 1;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(ownerID)),
+	)
+
+	var found bool
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		found = res.NextRow()
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+func (s *Store) DeleteShareSubscription(ctx context.Context, ownerID, recipientID, categoryID string) error {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+DECLARE $recipient_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DELETE FROM share_subscriptions
+WHERE owner_id = $owner_id AND recipient_id = $recipient_id AND category_id = $category_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(ownerID)),
+		table.ValueParam("$recipient_id", types.UTF8Value(recipientID)),
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) DeleteShare(ctx context.Context, ownerID, token string) error {
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+DECLARE $share_token AS Utf8;
+DELETE FROM shares
+WHERE share_token = $share_token AND owner_id = $owner_id;
+DELETE FROM share_subscriptions
+WHERE owner_id = $owner_id AND share_token = $share_token;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(ownerID)),
+		table.ValueParam("$share_token", types.UTF8Value(token)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) MarkShareSyncPending(ctx context.Context, ownerID string, dueAt, updatedAt int64) error {
+	// An owner already pending keeps the earlier due time, so a stream of
+	// edits cannot postpone the sync forever, and keeps its claim, so a
+	// running sync is not started twice. updated_at always grows, so a
+	// release can tell a mark made during the sync.
+	query := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+DECLARE $due_at AS Int64;
+DECLARE $updated_at AS Int64;
+UPSERT INTO share_sync_pending (owner_id, due_at, claimed_until, updated_at)
+SELECT
+  $owner_id AS owner_id,
+  COALESCE(p.due_at, $due_at) AS due_at,
+  p.claimed_until AS claimed_until,
+  MAX_OF(COALESCE(p.updated_at + 1, $updated_at), $updated_at) AS updated_at
+FROM (
+  SELECT owner_id FROM share_subscriptions WHERE owner_id = $owner_id LIMIT 1
+) AS sub
+LEFT JOIN (
+  SELECT owner_id, due_at, claimed_until, updated_at FROM share_sync_pending WHERE owner_id = $owner_id
+) AS p ON p.owner_id = sub.owner_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(ownerID)),
+		table.ValueParam("$due_at", types.Int64Value(dueAt)),
+		table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) ClaimShareSyncPending(ctx context.Context, now, claimedUntil int64, limit int) ([]store.ShareSyncClaim, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	selectQuery := s.withPrefix(`
+DECLARE $now AS Int64;
+DECLARE $limit AS Uint64;
+SELECT owner_id, due_at, updated_at
+FROM share_sync_pending
+WHERE due_at <= $now AND (claimed_until IS NULL OR claimed_until < $now)
+ORDER BY due_at
+LIMIT $limit;`)
+
+	claimQuery := s.withPrefix(`
+DECLARE $claimed_until AS Int64;
+DECLARE $rows AS List<Struct<owner_id: Utf8, due_at: Int64, updated_at: Int64>>;
+UPSERT INTO share_sync_pending (owner_id, due_at, claimed_until, updated_at)
+SELECT owner_id, due_at, $claimed_until AS claimed_until, updated_at
+FROM AS_TABLE($rows);`)
+
+	var out []store.ShareSyncClaim
+	err := s.client.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		out = out[:0]
+		res, err := tx.Execute(ctx, selectQuery, table.NewQueryParameters(
+			table.ValueParam("$now", types.Int64Value(now)),
+			table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.NextResultSetErr(ctx); err != nil {
+			_ = res.Close()
+			return err
+		}
+		var rows []types.Value
+		for res.NextRow() {
+			var (
+				claim store.ShareSyncClaim
+				dueAt int64
+			)
+			if err := res.ScanNamed(
+				named.Required("owner_id", &claim.OwnerID),
+				named.Required("due_at", &dueAt),
+				named.Required("updated_at", &claim.Version),
+			); err != nil {
+				_ = res.Close()
+				return err
+			}
+			out = append(out, claim)
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("owner_id", types.UTF8Value(claim.OwnerID)),
+				types.StructFieldValue("due_at", types.Int64Value(dueAt)),
+				types.StructFieldValue("updated_at", types.Int64Value(claim.Version)),
+			))
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		_, err = tx.Execute(ctx, claimQuery, table.NewQueryParameters(
+			table.ValueParam("$claimed_until", types.Int64Value(claimedUntil)),
+			table.ValueParam("$rows", types.ListValue(rows...)),
+		))
+		return err
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) ReleaseShareSyncPending(ctx context.Context, claim store.ShareSyncClaim) error {
+	selectQuery := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+SELECT updated_at
+FROM share_sync_pending
+WHERE owner_id = $owner_id;`)
+
+	deleteQuery := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+DELETE FROM share_sync_pending
+WHERE owner_id = $owner_id;`)
+
+	// A row marked again during the sync stays, unclaimed and due at once.
+	unclaimQuery := s.withPrefix(`
+DECLARE $owner_id AS Utf8;
+UPDATE share_sync_pending
+SET claimed_until = NULL
+WHERE owner_id = $owner_id;`)
+
+	key := table.NewQueryParameters(
+		table.ValueParam("$owner_id", types.UTF8Value(claim.OwnerID)),
+	)
+	return s.client.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, selectQuery, key)
+		if err != nil {
+			return err
+		}
+		var (
+			found   bool
+			version int64
+		)
+		if err := res.NextResultSetErr(ctx); err != nil {
+			_ = res.Close()
+			return err
+		}
+		if res.NextRow() {
+			found = true
+			if err := res.ScanNamed(named.Required("updated_at", &version)); err != nil {
+				_ = res.Close()
+				return err
+			}
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+
+		query := unclaimQuery
+		if version == claim.Version {
+			query = deleteQuery
+		}
+		_, err = tx.Execute(ctx, query, key)
+		return err
+	}, table.WithIdempotent())
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/shard"
 	"github.com/linkasu/linka.type-backend/internal/store"
 	"github.com/linkasu/linka.type-backend/internal/ydb"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
@@ -16,7 +17,8 @@ import (
 
 // Store implements store.Store backed by YDB.
 type Store struct {
-	client *ydb.Client
+	client     *ydb.Client
+	projection bool
 }
 
 // New creates a YDB-backed store.
@@ -24,10 +26,16 @@ func New(client *ydb.Client) *Store {
 	return &Store{client: client}
 }
 
+// EnableProjectionQueue makes AppendChange also queue the user for the
+// sync-worker RTDB projector. Without it nothing drains the queue.
+func (s *Store) EnableProjectionQueue() {
+	s.projection = true
+}
+
 func (s *Store) ListCategories(ctx context.Context, userID string) ([]models.Category, error) {
 	query := s.withPrefix(`
 DECLARE $user_id AS Utf8;
-SELECT category_id, label, created_at, is_default, ai_use, updated_at
+SELECT category_id, label, created_at, is_default, ai_use, parent_id, position, color, icon, image_id, updated_at
 FROM categories
 WHERE user_id = $user_id AND deleted_at IS NULL
 ORDER BY created_at;`)
@@ -55,6 +63,11 @@ ORDER BY created_at;`)
 				updated   *int64
 				isDefault *bool
 				aiUse     *bool
+				parentID  *string
+				position  *string
+				color     *string
+				icon      *string
+				imageID   *string
 			)
 			if err := res.ScanNamed(
 				named.Required("category_id", &id),
@@ -63,6 +76,11 @@ ORDER BY created_at;`)
 				named.Optional("updated_at", &updated),
 				named.Optional("is_default", &isDefault),
 				named.Optional("ai_use", &aiUse),
+				named.Optional("parent_id", &parentID),
+				named.Optional("position", &position),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Optional("image_id", &imageID),
 			); err != nil {
 				return err
 			}
@@ -71,10 +89,19 @@ ORDER BY created_at;`)
 				Label:   label,
 				Created: created,
 				Default: isDefault,
+				Color:   stringValue(color),
+				Icon:    stringValue(icon),
+				ImageID: stringValue(imageID),
 			}
 			if aiUse != nil {
 				cat.AIUse = *aiUse
 			}
+			if parentID != nil {
+				cat.ParentID = *parentID
+			}
+			if position != nil {
+				cat.Position = *position
+			}
 			if updated != nil {
 				cat.UpdatedAt = *updated
 			} else {
@@ -92,6 +119,19 @@ ORDER BY created_at;`)
 }
 
 func (s *Store) UpsertCategory(ctx context.Context, userID string, category models.Category) (models.Category, error) {
+	category, query, params := s.categoryUpsert(userID, category)
+	if err := s.execWrite(ctx, query, params); err != nil {
+		return models.Category{}, err
+	}
+	if err := s.indexSearch(ctx, userID, searchCategory, category.ID, "", category.Label); err != nil {
+		return models.Category{}, err
+	}
+
+	return category, nil
+}
+
+// categoryUpsert fills a new category's timestamps and builds its upsert.
+func (s *Store) categoryUpsert(userID string, category models.Category) (models.Category, string, *table.QueryParameters) {
 	now := time.Now().UnixMilli()
 	if category.Created == 0 {
 		category.Created = now
@@ -108,8 +148,13 @@ DECLARE $created_at AS Int64;
 DECLARE $is_default AS Bool?;
 DECLARE $updated_at AS Int64;
 DECLARE $ai_use AS Bool?;
-UPSERT INTO categories (user_id, category_id, label, created_at, is_default, ai_use, updated_at)
-VALUES ($user_id, $category_id, $label, $created_at, $is_default, $ai_use, $updated_at);`)
+DECLARE $parent_id AS Utf8?;
+DECLARE $position AS Utf8?;
+DECLARE $color AS Utf8?;
+DECLARE $icon AS Utf8?;
+DECLARE $image_id AS Utf8?;
+UPSERT INTO categories (user_id, category_id, label, created_at, is_default, ai_use, parent_id, position, color, icon, image_id, updated_at)
+VALUES ($user_id, $category_id, $label, $created_at, $is_default, $ai_use, $parent_id, $position, $color, $icon, $image_id, $updated_at);`)
 
 	params := table.NewQueryParameters(
 		table.ValueParam("$user_id", types.UTF8Value(userID)),
@@ -118,18 +163,26 @@ VALUES ($user_id, $category_id, $label, $created_at, $is_default, $ai_use, $upda
 		table.ValueParam("$created_at", types.Int64Value(category.Created)),
 		table.ValueParam("$is_default", optionalBool(category.Default)),
 		table.ValueParam("$ai_use", optionalBool(&category.AIUse)),
+		table.ValueParam("$parent_id", optionalString(category.ParentID)),
+		table.ValueParam("$position", optionalString(category.Position)),
+		table.ValueParam("$color", optionalString(category.Color)),
+		table.ValueParam("$icon", optionalString(category.Icon)),
+		table.ValueParam("$image_id", optionalString(category.ImageID)),
 		table.ValueParam("$updated_at", types.Int64Value(category.UpdatedAt)),
 	)
+	return category, query, params
+}
 
-	err := s.execWrite(ctx, query, params)
-	if err != nil {
-		return models.Category{}, err
+func (s *Store) DeleteCategory(ctx context.Context, userID, categoryID string, updatedAt int64) error {
+	query, params := s.categoryDelete(userID, categoryID, updatedAt)
+	if err := s.execWrite(ctx, query, params); err != nil {
+		return err
 	}
-
-	return category, nil
+	return s.unindexSearch(ctx, userID, searchCategory, categoryID)
 }
 
-func (s *Store) DeleteCategory(ctx context.Context, userID, categoryID string, updatedAt int64) error {
+// categoryDelete builds the soft delete of a category.
+func (s *Store) categoryDelete(userID, categoryID string, updatedAt int64) (string, *table.QueryParameters) {
 	if updatedAt == 0 {
 		updatedAt = time.Now().UnixMilli()
 	}
@@ -147,15 +200,14 @@ WHERE user_id = $user_id AND category_id = $category_id;`)
 		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
 		table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
 	)
-
-	return s.execWrite(ctx, query, params)
+	return query, params
 }
 
 func (s *Store) ListStatements(ctx context.Context, userID, categoryID string) ([]models.Statement, error) {
 	query := s.withPrefix(`
 DECLARE $user_id AS Utf8;
 DECLARE $category_id AS Utf8;
-SELECT statement_id, category_id, text, created_at, updated_at
+SELECT statement_id, category_id, text, created_at, position, color, icon, image_id, updated_at
 FROM statements
 WHERE user_id = $user_id AND category_id = $category_id AND deleted_at IS NULL
 ORDER BY created_at;`)
@@ -178,17 +230,25 @@ ORDER BY created_at;`)
 		}
 		for res.NextRow() {
 			var (
-				id      string
-				catID   string
-				text    string
-				created int64
-				updated *int64
+				id       string
+				catID    string
+				text     string
+				created  int64
+				updated  *int64
+				position *string
+				color    *string
+				icon     *string
+				imageID  *string
 			)
 			if err := res.ScanNamed(
 				named.Required("statement_id", &id),
 				named.Required("category_id", &catID),
 				named.Required("text", &text),
 				named.Required("created_at", &created),
+				named.Optional("position", &position),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Optional("image_id", &imageID),
 				named.Optional("updated_at", &updated),
 			); err != nil {
 				return err
@@ -198,6 +258,12 @@ ORDER BY created_at;`)
 				CategoryID: catID,
 				Text:       text,
 				Created:    created,
+				Color:      stringValue(color),
+				Icon:       stringValue(icon),
+				ImageID:    stringValue(imageID),
+			}
+			if position != nil {
+				stmt.Position = *position
 			}
 			if updated != nil {
 				stmt.UpdatedAt = *updated
@@ -218,7 +284,7 @@ ORDER BY created_at;`)
 func (s *Store) ListAllStatements(ctx context.Context, userID string) ([]models.Statement, error) {
 	query := s.withPrefix(`
 DECLARE $user_id AS Utf8;
-SELECT statement_id, category_id, text, created_at, updated_at
+SELECT statement_id, category_id, text, created_at, position, color, icon, image_id, updated_at
 FROM statements
 WHERE user_id = $user_id AND deleted_at IS NULL
 ORDER BY created_at;`)
@@ -240,17 +306,25 @@ ORDER BY created_at;`)
 		}
 		for res.NextRow() {
 			var (
-				id      string
-				catID   string
-				text    string
-				created int64
-				updated *int64
+				id       string
+				catID    string
+				text     string
+				created  int64
+				updated  *int64
+				position *string
+				color    *string
+				icon     *string
+				imageID  *string
 			)
 			if err := res.ScanNamed(
 				named.Required("statement_id", &id),
 				named.Required("category_id", &catID),
 				named.Required("text", &text),
 				named.Required("created_at", &created),
+				named.Optional("position", &position),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Optional("image_id", &imageID),
 				named.Optional("updated_at", &updated),
 			); err != nil {
 				return err
@@ -260,6 +334,12 @@ ORDER BY created_at;`)
 				CategoryID: catID,
 				Text:       text,
 				Created:    created,
+				Color:      stringValue(color),
+				Icon:       stringValue(icon),
+				ImageID:    stringValue(imageID),
+			}
+			if position != nil {
+				stmt.Position = *position
 			}
 			if updated != nil {
 				stmt.UpdatedAt = *updated
@@ -278,6 +358,19 @@ ORDER BY created_at;`)
 }
 
 func (s *Store) UpsertStatement(ctx context.Context, userID string, statement models.Statement) (models.Statement, error) {
+	statement, query, params := s.statementUpsert(userID, statement)
+	if err := s.execWrite(ctx, query, params); err != nil {
+		return models.Statement{}, err
+	}
+	if err := s.indexSearch(ctx, userID, searchStatement, statement.ID, statement.CategoryID, statement.Text); err != nil {
+		return models.Statement{}, err
+	}
+
+	return statement, nil
+}
+
+// statementUpsert fills a new statement's timestamps and builds its upsert.
+func (s *Store) statementUpsert(userID string, statement models.Statement) (models.Statement, string, *table.QueryParameters) {
 	now := time.Now().UnixMilli()
 	if statement.Created == 0 {
 		statement.Created = now
@@ -292,9 +385,13 @@ DECLARE $category_id AS Utf8;
 DECLARE $statement_id AS Utf8;
 DECLARE $text AS Utf8;
 DECLARE $created_at AS Int64;
+DECLARE $position AS Utf8?;
+DECLARE $color AS Utf8?;
+DECLARE $icon AS Utf8?;
+DECLARE $image_id AS Utf8?;
 DECLARE $updated_at AS Int64;
-UPSERT INTO statements (user_id, category_id, statement_id, text, created_at, updated_at)
-VALUES ($user_id, $category_id, $statement_id, $text, $created_at, $updated_at);`)
+UPSERT INTO statements (user_id, category_id, statement_id, text, created_at, position, color, icon, image_id, updated_at)
+VALUES ($user_id, $category_id, $statement_id, $text, $created_at, $position, $color, $icon, $image_id, $updated_at);`)
 
 	params := table.NewQueryParameters(
 		table.ValueParam("$user_id", types.UTF8Value(userID)),
@@ -302,18 +399,25 @@ VALUES ($user_id, $category_id, $statement_id, $text, $created_at, $updated_at);
 		table.ValueParam("$statement_id", types.UTF8Value(statement.ID)),
 		table.ValueParam("$text", types.UTF8Value(statement.Text)),
 		table.ValueParam("$created_at", types.Int64Value(statement.Created)),
+		table.ValueParam("$position", optionalString(statement.Position)),
+		table.ValueParam("$color", optionalString(statement.Color)),
+		table.ValueParam("$icon", optionalString(statement.Icon)),
+		table.ValueParam("$image_id", optionalString(statement.ImageID)),
 		table.ValueParam("$updated_at", types.Int64Value(statement.UpdatedAt)),
 	)
+	return statement, query, params
+}
 
-	err := s.execWrite(ctx, query, params)
-	if err != nil {
-		return models.Statement{}, err
+func (s *Store) DeleteStatement(ctx context.Context, userID, categoryID, statementID string, updatedAt int64) error {
+	query, params := s.statementDelete(userID, categoryID, statementID, updatedAt)
+	if err := s.execWrite(ctx, query, params); err != nil {
+		return err
 	}
-
-	return statement, nil
+	return s.unindexSearch(ctx, userID, searchStatement, statementID)
 }
 
-func (s *Store) DeleteStatement(ctx context.Context, userID, categoryID, statementID string, updatedAt int64) error {
+// statementDelete builds the soft delete of a statement.
+func (s *Store) statementDelete(userID, categoryID, statementID string, updatedAt int64) (string, *table.QueryParameters) {
 	if updatedAt == 0 {
 		updatedAt = time.Now().UnixMilli()
 	}
@@ -333,8 +437,52 @@ WHERE user_id = $user_id AND category_id = $category_id AND statement_id = $stat
 		table.ValueParam("$statement_id", types.UTF8Value(statementID)),
 		table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
 	)
+	return query, params
+}
 
-	return s.execWrite(ctx, query, params)
+// MoveStatement rewrites a statement under its new category. The old row is
+// removed outright so the statement does not also show up in the trash.
+func (s *Store) MoveStatement(ctx context.Context, userID, fromCategoryID string, statement models.Statement) error {
+	query, params := s.statementMove(userID, fromCategoryID, statement)
+	if err := s.execWrite(ctx, query, params); err != nil {
+		return err
+	}
+	return s.indexSearch(ctx, userID, searchStatement, statement.ID, statement.CategoryID, statement.Text)
+}
+
+// statementMove builds the rewrite of a statement under its new category.
+func (s *Store) statementMove(userID, fromCategoryID string, statement models.Statement) (string, *table.QueryParameters) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $from_category_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DECLARE $text AS Utf8;
+DECLARE $created_at AS Int64;
+DECLARE $position AS Utf8?;
+DECLARE $color AS Utf8?;
+DECLARE $icon AS Utf8?;
+DECLARE $image_id AS Utf8?;
+DECLARE $updated_at AS Int64;
+DELETE FROM statements
+WHERE user_id = $user_id AND category_id = $from_category_id AND statement_id = $statement_id;
+UPSERT INTO statements (user_id, category_id, statement_id, text, created_at, position, color, icon, image_id, updated_at)
+VALUES ($user_id, $category_id, $statement_id, $text, $created_at, $position, $color, $icon, $image_id, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$from_category_id", types.UTF8Value(fromCategoryID)),
+		table.ValueParam("$category_id", types.UTF8Value(statement.CategoryID)),
+		table.ValueParam("$statement_id", types.UTF8Value(statement.ID)),
+		table.ValueParam("$text", types.UTF8Value(statement.Text)),
+		table.ValueParam("$created_at", types.Int64Value(statement.Created)),
+		table.ValueParam("$position", optionalString(statement.Position)),
+		table.ValueParam("$color", optionalString(statement.Color)),
+		table.ValueParam("$icon", optionalString(statement.Icon)),
+		table.ValueParam("$image_id", optionalString(statement.ImageID)),
+		table.ValueParam("$updated_at", types.Int64Value(statement.UpdatedAt)),
+	)
+	return query, params
 }
 
 func (s *Store) GetUserState(ctx context.Context, userID string) (models.UserState, error) {
@@ -489,7 +637,7 @@ VALUES ($user_id, $slot, $text, $updated_at);`)
 
 func (s *Store) ListGlobalCategories(ctx context.Context, includeStatements bool) ([]models.GlobalCategory, error) {
 	query := s.withPrefix(`
-SELECT category_id, label, created_at, is_default, updated_at
+SELECT category_id, label, created_at, is_default, parent_id, updated_at
 FROM global_categories
 WHERE deleted_at IS NULL
 ORDER BY created_at;`)
@@ -512,6 +660,7 @@ ORDER BY created_at;`)
 				created   int64
 				updated   *int64
 				isDefault *bool
+				parentID  *string
 			)
 			if err := res.ScanNamed(
 				named.Required("category_id", &id),
@@ -519,6 +668,7 @@ ORDER BY created_at;`)
 				named.Required("created_at", &created),
 				named.Optional("updated_at", &updated),
 				named.Optional("is_default", &isDefault),
+				named.Optional("parent_id", &parentID),
 			); err != nil {
 				return err
 			}
@@ -528,6 +678,9 @@ ORDER BY created_at;`)
 				Created: created,
 				Default: isDefault,
 			}
+			if parentID != nil {
+				cat.ParentID = *parentID
+			}
 			if updated != nil {
 				cat.UpdatedAt = *updated
 			} else {
@@ -557,7 +710,7 @@ ORDER BY created_at;`)
 func (s *Store) ListGlobalStatements(ctx context.Context, categoryID string) ([]models.Statement, error) {
 	query := s.withPrefix(`
 DECLARE $category_id AS Utf8;
-SELECT statement_id, category_id, text, created_at, updated_at
+SELECT statement_id, category_id, text, created_at, updated_at, position
 FROM global_statements
 WHERE category_id = $category_id AND deleted_at IS NULL
 ORDER BY created_at;`)
@@ -579,11 +732,12 @@ ORDER BY created_at;`)
 		}
 		for res.NextRow() {
 			var (
-				id      string
-				catID   string
-				text    string
-				created int64
-				updated *int64
+				id       string
+				catID    string
+				text     string
+				created  int64
+				updated  *int64
+				position *string
 			)
 			if err := res.ScanNamed(
 				named.Required("statement_id", &id),
@@ -591,6 +745,7 @@ ORDER BY created_at;`)
 				named.Required("text", &text),
 				named.Required("created_at", &created),
 				named.Optional("updated_at", &updated),
+				named.Optional("position", &position),
 			); err != nil {
 				return err
 			}
@@ -599,6 +754,7 @@ ORDER BY created_at;`)
 				CategoryID: catID,
 				Text:       text,
 				Created:    created,
+				Position:   stringValue(position),
 			}
 			if updated != nil {
 				stmt.UpdatedAt = *updated
@@ -629,39 +785,60 @@ func (s *Store) ImportGlobalCategory(ctx context.Context, userID, categoryID str
 	if err != nil {
 		return "", err
 	}
-
-	var globalCat *models.GlobalCategory
-	for i := range globals {
-		if globals[i].ID == categoryID {
-			globalCat = &globals[i]
-			break
-		}
-	}
-	if globalCat == nil {
+	subtree := store.GlobalSubtree(globals, categoryID)
+	if len(subtree) == 0 {
 		return "", store.ErrNotFound
 	}
 
-	cat := models.Category{
-		ID:        globalCat.ID,
-		Label:     globalCat.Label,
-		Created:   globalCat.Created,
-		Default:   globalCat.Default,
-		UpdatedAt: time.Now().UnixMilli(),
-	}
-	if _, err := s.UpsertCategory(ctx, userID, cat); err != nil {
-		return "", err
+	// The imported root keeps its parent only if the user already has it.
+	rootParent := subtree[0].ParentID
+	if rootParent != "" {
+		hasParent, err := s.categoryExists(ctx, userID, rootParent)
+		if err != nil {
+			return "", err
+		}
+		if !hasParent {
+			rootParent = ""
+		}
 	}
 
-	statements, err := s.ListGlobalStatements(ctx, categoryID)
-	if err != nil {
-		return "", err
-	}
-	for _, stmt := range statements {
-		stmt.CategoryID = categoryID
-		stmt.UpdatedAt = time.Now().UnixMilli()
-		if _, err := s.UpsertStatement(ctx, userID, stmt); err != nil {
+	for i, globalCat := range subtree {
+		if i > 0 && !force {
+			exists, err := s.categoryExists(ctx, userID, globalCat.ID)
+			if err != nil {
+				return "", err
+			}
+			if exists {
+				continue
+			}
+		}
+
+		cat := models.Category{
+			ID:        globalCat.ID,
+			Label:     globalCat.Label,
+			Created:   globalCat.Created,
+			Default:   globalCat.Default,
+			ParentID:  globalCat.ParentID,
+			UpdatedAt: time.Now().UnixMilli(),
+		}
+		if i == 0 {
+			cat.ParentID = rootParent
+		}
+		if _, err := s.UpsertCategory(ctx, userID, cat); err != nil {
+			return "", err
+		}
+
+		statements, err := s.ListGlobalStatements(ctx, globalCat.ID)
+		if err != nil {
 			return "", err
 		}
+		for _, stmt := range statements {
+			stmt.CategoryID = globalCat.ID
+			stmt.UpdatedAt = time.Now().UnixMilli()
+			if _, err := s.UpsertStatement(ctx, userID, stmt); err != nil {
+				return "", err
+			}
+		}
 	}
 
 	return "ok", nil
@@ -669,8 +846,9 @@ func (s *Store) ImportGlobalCategory(ctx context.Context, userID, categoryID str
 
 func (s *Store) ListFactoryQuestions(ctx context.Context) ([]models.FactoryQuestion, error) {
 	query := s.withPrefix(`
-SELECT question_id, label, phrases, category, type, order_index
+SELECT question_id, label, phrases, category, type, order_index, options, placeholder, show_if
 FROM factory_questions
+WHERE deleted_at IS NULL
 ORDER BY order_index;`)
 
 	var out []models.FactoryQuestion
@@ -686,12 +864,15 @@ ORDER BY order_index;`)
 		}
 		for res.NextRow() {
 			var (
-				id       string
-				label    string
-				phrases  string
-				category string
-				qtype    string
-				orderIdx int64
+				id          string
+				label       string
+				phrases     string
+				category    string
+				qtype       string
+				orderIdx    int64
+				options     *string
+				placeholder *string
+				showIf      *string
 			)
 			if err := res.ScanNamed(
 				named.Required("question_id", &id),
@@ -700,6 +881,9 @@ ORDER BY order_index;`)
 				named.Required("category", &category),
 				named.Required("type", &qtype),
 				named.Required("order_index", &orderIdx),
+				named.Optional("options", &options),
+				named.Optional("placeholder", &placeholder),
+				named.Optional("show_if", &showIf),
 			); err != nil {
 				return err
 			}
@@ -709,14 +893,22 @@ ORDER BY order_index;`)
 					return err
 				}
 			}
-			out = append(out, models.FactoryQuestion{
-				ID:         id,
-				Label:      label,
-				Phrases:    list,
-				Category:   category,
-				Type:       qtype,
-				OrderIndex: int(orderIdx),
-			})
+			question := models.FactoryQuestion{
+				ID:          id,
+				Label:       label,
+				Phrases:     list,
+				Category:    category,
+				Type:        qtype,
+				OrderIndex:  int(orderIdx),
+				Placeholder: stringValue(placeholder),
+			}
+			if err := decodeOptionalJSON(options, &question.Options); err != nil {
+				return err
+			}
+			if err := decodeOptionalJSON(showIf, &question.ShowIf); err != nil {
+				return err
+			}
+			out = append(out, question)
 		}
 		return res.Err()
 	}, table.WithIdempotent())
@@ -811,6 +1003,109 @@ DELETE FROM quickes WHERE user_id = $user_id;`),
 		{
 			query: s.withPrefix(`
 DECLARE $user_id AS Utf8;
+DELETE FROM statement_usage WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM quicke_usage WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM search_terms WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM media WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM revisions WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM export_jobs WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM shares ON
+SELECT share_token FROM shares VIEW idx_owner WHERE owner_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM share_sync_pending WHERE owner_id = $user_id;
+DELETE FROM share_subscriptions WHERE owner_id = $user_id;
+DELETE FROM share_subscriptions ON
+SELECT owner_id, recipient_id, category_id FROM share_subscriptions VIEW idx_recipient WHERE recipient_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM grants WHERE owner_id = $user_id;
+DELETE FROM grants ON
+SELECT owner_id, delegate_id FROM grants VIEW idx_delegate WHERE delegate_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM org_members ON
+SELECT org_id, user_id FROM org_members VIEW idx_user WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM global_imports WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DELETE FROM onboarding_answers WHERE user_id = $user_id;`),
+			params: table.NewQueryParameters(
+				table.ValueParam("$user_id", types.UTF8Value(userID)),
+			),
+		},
+		{
+			query: s.withPrefix(`
+DECLARE $user_id AS Utf8;
 DELETE FROM changes WHERE user_id = $user_id;`),
 			params: table.NewQueryParameters(
 				table.ValueParam("$user_id", types.UTF8Value(userID)),
@@ -840,7 +1135,7 @@ func (s *Store) AppendChange(ctx context.Context, userID string, change models.C
 		payload = "{}"
 	}
 
-	query := s.withPrefix(`
+	query := `
 DECLARE $user_id AS Utf8;
 DECLARE $cursor AS Utf8;
 DECLARE $entity_type AS Utf8;
@@ -848,10 +1143,12 @@ DECLARE $entity_id AS Utf8;
 DECLARE $op AS Utf8;
 DECLARE $payload AS JsonDocument;
 DECLARE $updated_at AS Int64;
-UPSERT INTO changes (user_id, cursor, entity_type, entity_id, op, payload, updated_at)
-VALUES ($user_id, $cursor, $entity_type, $entity_id, $op, $payload, $updated_at);`)
+DECLARE $origin AS Utf8?;
+DECLARE $actor_id AS Utf8?;
+UPSERT INTO changes (user_id, cursor, entity_type, entity_id, op, payload, updated_at, origin, actor_id)
+VALUES ($user_id, $cursor, $entity_type, $entity_id, $op, $payload, $updated_at, $origin, $actor_id);`
 
-	params := table.NewQueryParameters(
+	params := []table.ParameterOption{
 		table.ValueParam("$user_id", types.UTF8Value(userID)),
 		table.ValueParam("$cursor", types.UTF8Value(change.Cursor)),
 		table.ValueParam("$entity_type", types.UTF8Value(change.EntityType)),
@@ -859,9 +1156,17 @@ VALUES ($user_id, $cursor, $entity_type, $entity_id, $op, $payload, $updated_at)
 		table.ValueParam("$op", types.UTF8Value(change.Op)),
 		table.ValueParam("$payload", types.JSONDocumentValue(payload)),
 		table.ValueParam("$updated_at", types.Int64Value(change.UpdatedAt)),
-	)
+		table.ValueParam("$origin", optionalString(change.Origin)),
+		table.ValueParam("$actor_id", optionalString(change.Actor)),
+	}
+	if s.projection {
+		query = "DECLARE $bucket AS Int64;" + query + `
+UPSERT INTO sync_projection_pending (bucket, user_id, cursor, updated_at)
+VALUES ($bucket, $user_id, $cursor, $updated_at);`
+		params = append(params, table.ValueParam("$bucket", types.Int64Value(int64(shard.Bucket(userID)))))
+	}
 
-	return s.execWrite(ctx, query, params)
+	return s.execWrite(ctx, s.withPrefix(query), table.NewQueryParameters(params...))
 }
 
 func (s *Store) ListChanges(ctx context.Context, userID, cursor string, limit int) (string, []models.ChangeEvent, error) {
@@ -873,7 +1178,7 @@ func (s *Store) ListChanges(ctx context.Context, userID, cursor string, limit in
 DECLARE $user_id AS Utf8;
 DECLARE $cursor AS Utf8;
 DECLARE $limit AS Uint64;
-SELECT cursor, entity_type, entity_id, op, payload, updated_at
+SELECT cursor, entity_type, entity_id, op, payload, updated_at, origin, actor_id
 FROM changes
 WHERE user_id = $user_id AND cursor > $cursor
 ORDER BY cursor
@@ -908,6 +1213,8 @@ LIMIT $limit;`)
 				op       string
 				payload  string
 				updated  int64
+				origin   *string
+				actor    *string
 			)
 			if err := res.ScanNamed(
 				named.Required("cursor", &curs),
@@ -916,17 +1223,24 @@ LIMIT $limit;`)
 				named.Required("op", &op),
 				named.Required("payload", &payload),
 				named.Required("updated_at", &updated),
+				named.Optional("origin", &origin),
+				named.Optional("actor_id", &actor),
 			); err != nil {
 				return err
 			}
-			changes = append(changes, models.ChangeEvent{
+			change := models.ChangeEvent{
 				Cursor:     curs,
 				EntityType: entityTy,
 				EntityID:   entityID,
 				Op:         op,
 				Payload:    json.RawMessage(payload),
 				UpdatedAt:  updated,
-			})
+			}
+			if origin != nil {
+				change.Origin = *origin
+			}
+			change.Actor = stringValue(actor)
+			changes = append(changes, change)
 			lastCursor = curs
 		}
 		return res.Err()
@@ -942,6 +1256,80 @@ LIMIT $limit;`)
 	return lastCursor, changes, nil
 }
 
+// ListRecentChanges returns the newest changes first.
+func (s *Store) ListRecentChanges(ctx context.Context, userID string, limit int) ([]models.ChangeEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $limit AS Uint64;
+SELECT cursor, entity_type, entity_id, op, payload, updated_at, origin, actor_id
+FROM changes
+WHERE user_id = $user_id
+ORDER BY cursor DESC
+LIMIT $limit;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	)
+
+	var changes []models.ChangeEvent
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		for res.NextRow() {
+			var (
+				curs     string
+				entityTy string
+				entityID string
+				op       string
+				payload  string
+				updated  int64
+				origin   *string
+				actor    *string
+			)
+			if err := res.ScanNamed(
+				named.Required("cursor", &curs),
+				named.Required("entity_type", &entityTy),
+				named.Required("entity_id", &entityID),
+				named.Required("op", &op),
+				named.Required("payload", &payload),
+				named.Required("updated_at", &updated),
+				named.Optional("origin", &origin),
+				named.Optional("actor_id", &actor),
+			); err != nil {
+				return err
+			}
+			changes = append(changes, models.ChangeEvent{
+				Cursor:     curs,
+				EntityType: entityTy,
+				EntityID:   entityID,
+				Op:         op,
+				Payload:    json.RawMessage(payload),
+				UpdatedAt:  updated,
+				Origin:     stringValue(origin),
+				Actor:      stringValue(actor),
+			})
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
 func (s *Store) withPrefix(query string) string {
 	return fmt.Sprintf("PRAGMA TablePathPrefix(\"%s\");\n%s", s.client.Database(), query)
 }
@@ -974,6 +1362,34 @@ func optionalString(val string) types.Value {
 	return types.OptionalValue(types.UTF8Value(val))
 }
 
+// optionalJSON encodes val as a JSON document, or NULL for nil and empty
+// lists.
+func optionalJSON(val any) (types.Value, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" || string(data) == "[]" {
+		return types.NullValue(types.TypeJSONDocument), nil
+	}
+	return types.OptionalValue(types.JSONDocumentValue(string(data))), nil
+}
+
+// decodeOptionalJSON decodes a nullable JSON document column into dst.
+func decodeOptionalJSON(raw *string, dst any) error {
+	if raw == nil || *raw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(*raw), dst)
+}
+
+func stringValue(val *string) string {
+	if val == nil {
+		return ""
+	}
+	return *val
+}
+
 func optionalStringPtr(val *string) types.Value {
 	if val == nil || *val == "" {
 		return types.NullValue(types.TypeUTF8)
@@ -1053,15 +1469,7 @@ ORDER BY slot;`)
 			); err != nil {
 				return err
 			}
-			if slot < 0 {
-				continue
-			}
-			if int(slot) >= len(quickes) {
-				newQuickes := make([]string, int(slot)+1)
-				copy(newQuickes, quickes)
-				quickes = newQuickes
-			}
-			quickes[slot] = text
+			quickes = placeQuicke(quickes, slot, text)
 		}
 		return res.Err()
 	}, table.WithIdempotent())
@@ -1223,6 +1631,58 @@ WHERE created_at >= $since AND deleted_at IS NULL;`)
 	return count, nil
 }
 
+func (s *Store) CountBoardItems(ctx context.Context, userIDs []string, since time.Time) (int64, int64, error) {
+	if len(userIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_ids AS List<Utf8>;
+DECLARE $since AS Int64;
+SELECT COUNT(*) AS cnt
+FROM categories
+WHERE user_id IN $user_ids AND created_at >= $since AND deleted_at IS NULL;
+SELECT COUNT(*) AS cnt
+FROM statements
+WHERE user_id IN $user_ids AND created_at >= $since AND deleted_at IS NULL;`)
+
+	ids := make([]types.Value, 0, len(userIDs))
+	for _, userID := range userIDs {
+		ids = append(ids, types.UTF8Value(userID))
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_ids", types.ListValue(ids...)),
+		table.ValueParam("$since", types.Int64Value(since.UnixMilli())),
+	)
+
+	var counts [2]int64
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for i := range counts {
+			counts[i] = 0
+			if err := res.NextResultSetErr(ctx); err != nil {
+				return err
+			}
+			if res.NextRow() {
+				if err := res.ScanNamed(named.Required("cnt", &counts[i])); err != nil {
+					return err
+				}
+			}
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return counts[0], counts[1], nil
+}
+
 func (s *Store) ListAdmins(ctx context.Context) ([]string, error) {
 	query := s.withPrefix(`
 SELECT user_id
@@ -1381,15 +1841,17 @@ DECLARE $category_id AS Utf8;
 DECLARE $label AS Utf8;
 DECLARE $created_at AS Int64;
 DECLARE $is_default AS Bool?;
+DECLARE $parent_id AS Utf8?;
 DECLARE $updated_at AS Int64;
-UPSERT INTO global_categories (category_id, label, created_at, is_default, updated_at)
-VALUES ($category_id, $label, $created_at, $is_default, $updated_at);`)
+UPSERT INTO global_categories (category_id, label, created_at, is_default, parent_id, updated_at)
+VALUES ($category_id, $label, $created_at, $is_default, $parent_id, $updated_at);`)
 
 	params := table.NewQueryParameters(
 		table.ValueParam("$category_id", types.UTF8Value(category.ID)),
 		table.ValueParam("$label", types.UTF8Value(category.Label)),
 		table.ValueParam("$created_at", types.Int64Value(category.Created)),
 		table.ValueParam("$is_default", optionalBool(category.Default)),
+		table.ValueParam("$parent_id", optionalString(category.ParentID)),
 		table.ValueParam("$updated_at", types.Int64Value(category.UpdatedAt)),
 	)
 
@@ -1450,6 +1912,14 @@ func (s *Store) UpsertFactoryQuestion(ctx context.Context, question models.Facto
 	if err != nil {
 		return models.FactoryQuestion{}, err
 	}
+	options, err := optionalJSON(question.Options)
+	if err != nil {
+		return models.FactoryQuestion{}, err
+	}
+	showIf, err := optionalJSON(question.ShowIf)
+	if err != nil {
+		return models.FactoryQuestion{}, err
+	}
 
 	query := s.withPrefix(`
 DECLARE $question_id AS Utf8;
@@ -1458,8 +1928,12 @@ DECLARE $phrases AS JsonDocument;
 DECLARE $category AS Utf8;
 DECLARE $type AS Utf8;
 DECLARE $order_index AS Int64;
-UPSERT INTO factory_questions (question_id, label, phrases, category, type, order_index)
-VALUES ($question_id, $label, $phrases, $category, $type, $order_index);`)
+DECLARE $options AS Optional<JsonDocument>;
+DECLARE $placeholder AS Optional<Utf8>;
+DECLARE $show_if AS Optional<JsonDocument>;
+DECLARE $deleted_at AS Int64?;
+UPSERT INTO factory_questions (question_id, label, phrases, category, type, order_index, options, placeholder, show_if, deleted_at)
+VALUES ($question_id, $label, $phrases, $category, $type, $order_index, $options, $placeholder, $show_if, $deleted_at);`)
 
 	params := table.NewQueryParameters(
 		table.ValueParam("$question_id", types.UTF8Value(question.ID)),
@@ -1468,6 +1942,10 @@ VALUES ($question_id, $label, $phrases, $category, $type, $order_index);`)
 		table.ValueParam("$category", types.UTF8Value(question.Category)),
 		table.ValueParam("$type", types.UTF8Value(question.Type)),
 		table.ValueParam("$order_index", types.Int64Value(int64(question.OrderIndex))),
+		table.ValueParam("$options", options),
+		table.ValueParam("$placeholder", optionalString(question.Placeholder)),
+		table.ValueParam("$show_if", showIf),
+		table.ValueParam("$deleted_at", types.NullValue(types.TypeInt64)),
 	)
 
 	err = s.execWrite(ctx, query, params)
@@ -1478,14 +1956,19 @@ VALUES ($question_id, $label, $phrases, $category, $type, $order_index);`)
 	return question, nil
 }
 
-func (s *Store) DeleteFactoryQuestion(ctx context.Context, questionID string) error {
+// DeleteFactoryQuestion soft-deletes a question, so sync does not bring
+// back one copied from RTDB.
+func (s *Store) DeleteFactoryQuestion(ctx context.Context, questionID string, updatedAt int64) error {
 	query := s.withPrefix(`
 DECLARE $question_id AS Utf8;
-DELETE FROM factory_questions
+DECLARE $deleted_at AS Int64;
+UPDATE factory_questions
+SET deleted_at = $deleted_at
 WHERE question_id = $question_id;`)
 
 	params := table.NewQueryParameters(
 		table.ValueParam("$question_id", types.UTF8Value(questionID)),
+		table.ValueParam("$deleted_at", types.Int64Value(updatedAt)),
 	)
 
 	return s.execWrite(ctx, query, params)
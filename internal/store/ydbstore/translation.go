@@ -0,0 +1,123 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// ListGlobalTranslations returns the translations for locale, or for all
+// locales when locale is empty.
+func (s *Store) ListGlobalTranslations(ctx context.Context, locale string) ([]models.GlobalTranslation, error) {
+	query := s.withPrefix(`
+DECLARE $locale AS Utf8;
+SELECT kind, entity_id, locale, text, phrases, category, options, updated_at
+FROM global_translations
+WHERE $locale = "" OR locale = $locale;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$locale", types.UTF8Value(locale)),
+	)
+
+	var out []models.GlobalTranslation
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				translation models.GlobalTranslation
+				phrases     *string
+				category    *string
+				options     *string
+			)
+			if err := res.ScanNamed(
+				named.Required("kind", &translation.Kind),
+				named.Required("entity_id", &translation.EntityID),
+				named.Required("locale", &translation.Locale),
+				named.Required("text", &translation.Text),
+				named.Optional("phrases", &phrases),
+				named.Optional("category", &category),
+				named.Optional("options", &options),
+				named.Required("updated_at", &translation.UpdatedAt),
+			); err != nil {
+				return err
+			}
+			if err := decodeOptionalJSON(phrases, &translation.Phrases); err != nil {
+				return err
+			}
+			if err := decodeOptionalJSON(options, &translation.Options); err != nil {
+				return err
+			}
+			translation.Category = stringValue(category)
+			out = append(out, translation)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) UpsertGlobalTranslation(ctx context.Context, translation models.GlobalTranslation) error {
+	phrases, err := optionalJSON(translation.Phrases)
+	if err != nil {
+		return err
+	}
+	options, err := optionalJSON(translation.Options)
+	if err != nil {
+		return err
+	}
+
+	query := s.withPrefix(`
+DECLARE $kind AS Utf8;
+DECLARE $entity_id AS Utf8;
+DECLARE $locale AS Utf8;
+DECLARE $text AS Utf8;
+DECLARE $phrases AS Optional<JsonDocument>;
+DECLARE $category AS Optional<Utf8>;
+DECLARE $options AS Optional<JsonDocument>;
+DECLARE $updated_at AS Int64;
+UPSERT INTO global_translations (kind, entity_id, locale, text, phrases, category, options, updated_at)
+VALUES ($kind, $entity_id, $locale, $text, $phrases, $category, $options, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$kind", types.UTF8Value(translation.Kind)),
+		table.ValueParam("$entity_id", types.UTF8Value(translation.EntityID)),
+		table.ValueParam("$locale", types.UTF8Value(translation.Locale)),
+		table.ValueParam("$text", types.UTF8Value(translation.Text)),
+		table.ValueParam("$phrases", phrases),
+		table.ValueParam("$category", optionalString(translation.Category)),
+		table.ValueParam("$options", options),
+		table.ValueParam("$updated_at", types.Int64Value(translation.UpdatedAt)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+func (s *Store) DeleteGlobalTranslation(ctx context.Context, kind, entityID, locale string) error {
+	query := s.withPrefix(`
+DECLARE $kind AS Utf8;
+DECLARE $entity_id AS Utf8;
+DECLARE $locale AS Utf8;
+DELETE FROM global_translations
+WHERE kind = $kind AND entity_id = $entity_id AND locale = $locale;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$kind", types.UTF8Value(kind)),
+		table.ValueParam("$entity_id", types.UTF8Value(entityID)),
+		table.ValueParam("$locale", types.UTF8Value(locale)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
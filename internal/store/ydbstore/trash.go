@@ -0,0 +1,274 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func (s *Store) ListDeletedCategories(ctx context.Context, userID string) ([]models.TrashedCategory, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT category_id, label, created_at, is_default, ai_use, parent_id, position, color, icon, image_id, updated_at, deleted_at
+FROM categories
+WHERE user_id = $user_id AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.TrashedCategory
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		for res.NextRow() {
+			var (
+				id        string
+				label     string
+				created   int64
+				updated   *int64
+				deleted   *int64
+				isDefault *bool
+				aiUse     *bool
+				parentID  *string
+				position  *string
+				color     *string
+				icon      *string
+				imageID   *string
+			)
+			if err := res.ScanNamed(
+				named.Required("category_id", &id),
+				named.Required("label", &label),
+				named.Required("created_at", &created),
+				named.Optional("updated_at", &updated),
+				named.Optional("deleted_at", &deleted),
+				named.Optional("is_default", &isDefault),
+				named.Optional("ai_use", &aiUse),
+				named.Optional("parent_id", &parentID),
+				named.Optional("position", &position),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Optional("image_id", &imageID),
+			); err != nil {
+				return err
+			}
+			cat := models.Category{
+				ID:        id,
+				Label:     label,
+				Created:   created,
+				Default:   isDefault,
+				AIUse:     aiUse != nil && *aiUse,
+				ParentID:  stringValue(parentID),
+				Position:  stringValue(position),
+				Color:     stringValue(color),
+				Icon:      stringValue(icon),
+				ImageID:   stringValue(imageID),
+				UpdatedAt: created,
+			}
+			if updated != nil {
+				cat.UpdatedAt = *updated
+			}
+			item := models.TrashedCategory{Category: cat, Statements: []models.Statement{}}
+			if deleted != nil {
+				item.DeletedAt = *deleted
+			}
+			out = append(out, item)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *Store) ListDeletedStatements(ctx context.Context, userID string) ([]models.TrashedStatement, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT statement_id, category_id, text, created_at, position, color, icon, image_id, updated_at, deleted_at
+FROM statements
+WHERE user_id = $user_id AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.TrashedStatement
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		for res.NextRow() {
+			var (
+				id       string
+				catID    string
+				text     string
+				created  int64
+				updated  *int64
+				deleted  *int64
+				position *string
+				color    *string
+				icon     *string
+				imageID  *string
+			)
+			if err := res.ScanNamed(
+				named.Required("statement_id", &id),
+				named.Required("category_id", &catID),
+				named.Required("text", &text),
+				named.Required("created_at", &created),
+				named.Optional("position", &position),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Optional("image_id", &imageID),
+				named.Optional("updated_at", &updated),
+				named.Optional("deleted_at", &deleted),
+			); err != nil {
+				return err
+			}
+			stmt := models.Statement{
+				ID:         id,
+				CategoryID: catID,
+				Text:       text,
+				Created:    created,
+				Position:   stringValue(position),
+				Color:      stringValue(color),
+				Icon:       stringValue(icon),
+				ImageID:    stringValue(imageID),
+				UpdatedAt:  created,
+			}
+			if updated != nil {
+				stmt.UpdatedAt = *updated
+			}
+			item := models.TrashedStatement{Statement: stmt}
+			if deleted != nil {
+				item.DeletedAt = *deleted
+			}
+			out = append(out, item)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// RestoreCategory clears deleted_at and writes the category's current
+// parent, which may have changed if the old parent is gone.
+func (s *Store) RestoreCategory(ctx context.Context, userID string, category models.Category) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $parent_id AS Utf8?;
+DECLARE $updated_at AS Int64;
+UPDATE categories
+SET deleted_at = NULL, parent_id = $parent_id, updated_at = $updated_at
+WHERE user_id = $user_id AND category_id = $category_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$category_id", types.UTF8Value(category.ID)),
+		table.ValueParam("$parent_id", optionalString(category.ParentID)),
+		table.ValueParam("$updated_at", types.Int64Value(category.UpdatedAt)),
+	)
+
+	if err := s.execWrite(ctx, query, params); err != nil {
+		return err
+	}
+	return s.indexSearch(ctx, userID, searchCategory, category.ID, "", category.Label)
+}
+
+func (s *Store) RestoreStatement(ctx context.Context, userID string, statement models.Statement) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DECLARE $updated_at AS Int64;
+UPDATE statements
+SET deleted_at = NULL, updated_at = $updated_at
+WHERE user_id = $user_id AND category_id = $category_id AND statement_id = $statement_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$category_id", types.UTF8Value(statement.CategoryID)),
+		table.ValueParam("$statement_id", types.UTF8Value(statement.ID)),
+		table.ValueParam("$updated_at", types.Int64Value(statement.UpdatedAt)),
+	)
+
+	if err := s.execWrite(ctx, query, params); err != nil {
+		return err
+	}
+	return s.indexSearch(ctx, userID, searchStatement, statement.ID, statement.CategoryID, statement.Text)
+}
+
+// PurgeCategory removes a trashed category and its trashed statements,
+// along with their revisions.
+func (s *Store) PurgeCategory(ctx context.Context, userID, categoryID string) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $category_id AS Utf8;
+$purged = (
+  SELECT statement_id FROM statements
+  WHERE user_id = $user_id AND category_id = $category_id AND deleted_at IS NOT NULL
+);
+DELETE FROM statement_usage
+WHERE user_id = $user_id AND statement_id IN $purged;
+DELETE FROM revisions
+WHERE user_id = $user_id AND entity_type = "statement" AND entity_id IN $purged;
+DELETE FROM revisions
+WHERE user_id = $user_id AND entity_type = "category" AND entity_id = $category_id;
+DELETE FROM statements
+WHERE user_id = $user_id AND category_id = $category_id AND deleted_at IS NOT NULL;
+DELETE FROM categories
+WHERE user_id = $user_id AND category_id = $category_id AND deleted_at IS NOT NULL;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
+
+// PurgeStatement removes a trashed statement, its usage counters and revisions.
+func (s *Store) PurgeStatement(ctx context.Context, userID, categoryID, statementID string) error {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DELETE FROM statements
+WHERE user_id = $user_id AND category_id = $category_id AND statement_id = $statement_id AND deleted_at IS NOT NULL;
+DELETE FROM statement_usage
+WHERE user_id = $user_id AND statement_id = $statement_id;
+DELETE FROM revisions
+WHERE user_id = $user_id AND entity_type = "statement" AND entity_id = $statement_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+		table.ValueParam("$statement_id", types.UTF8Value(statementID)),
+	)
+
+	return s.execWrite(ctx, query, params)
+}
@@ -0,0 +1,344 @@
+package ydbstore
+
+import (
+	"context"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// RecordSpokenUsage reads the current counters in the same transaction, so
+// it is not retried on ambiguous errors unless the batch has client event
+// ids: those are checked in the transaction, and a batch whose ids were
+// already recorded fails with store.ErrEventRecorded instead of counting
+// twice.
+func (s *Store) RecordSpokenUsage(ctx context.Context, userID string, usage store.SpokenUsage, updatedAt int64) error {
+	if len(usage.Statements) == 0 && len(usage.Quickes) == 0 {
+		return nil
+	}
+
+	declares := `
+DECLARE $user_id AS Utf8;
+DECLARE $updated_at AS Int64;`
+	var (
+		body     string
+		eventIDs types.Value
+	)
+	params := []table.ParameterOption{
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
+	}
+
+	if len(usage.EventIDs) > 0 {
+		declares += `
+DECLARE $event_ids AS List<Utf8>;`
+		body += `
+UPSERT INTO spoken_events
+SELECT $user_id AS user_id, event_id, CAST($updated_at AS Uint64) AS recorded_at
+FROM AS_TABLE(ListMap($event_ids, ($id) -> (AsStruct($id AS event_id))));`
+		ids := make([]types.Value, 0, len(usage.EventIDs))
+		for _, id := range usage.EventIDs {
+			ids = append(ids, types.UTF8Value(id))
+		}
+		eventIDs = types.ListValue(ids...)
+		params = append(params, table.ValueParam("$event_ids", eventIDs))
+	}
+
+	if len(usage.Statements) > 0 {
+		declares += `
+DECLARE $statements AS List<Struct<statement_id: Utf8, spoken: Int64, spoken_at: Int64>>;`
+		body += `
+UPSERT INTO statement_usage
+SELECT
+  $user_id AS user_id,
+  r.statement_id AS statement_id,
+  COALESCE(u.spoken_count, 0) + r.spoken AS spoken_count,
+  MAX_OF(COALESCE(u.last_spoken_at, 0), r.spoken_at) AS last_spoken_at,
+  $updated_at AS updated_at
+FROM AS_TABLE($statements) AS r
+LEFT JOIN (
+  SELECT statement_id, spoken_count, last_spoken_at
+  FROM statement_usage
+  WHERE user_id = $user_id
+) AS u ON u.statement_id = r.statement_id;`
+		rows := make([]types.Value, 0, len(usage.Statements))
+		for _, delta := range usage.Statements {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("statement_id", types.UTF8Value(delta.ID)),
+				types.StructFieldValue("spoken", types.Int64Value(delta.SpokenCount)),
+				types.StructFieldValue("spoken_at", types.Int64Value(delta.LastSpokenAt)),
+			))
+		}
+		params = append(params, table.ValueParam("$statements", types.ListValue(rows...)))
+	}
+
+	if len(usage.Quickes) > 0 {
+		// A slot whose text changed starts counting from zero again.
+		declares += `
+DECLARE $quickes AS List<Struct<slot: Int64, text: Utf8, spoken: Int64, spoken_at: Int64>>;`
+		body += `
+UPSERT INTO quicke_usage
+SELECT
+  $user_id AS user_id,
+  r.slot AS slot,
+  r.text AS text,
+  IF(COALESCE(u.text = r.text, false), u.spoken_count, 0) + r.spoken AS spoken_count,
+  IF(COALESCE(u.text = r.text, false), MAX_OF(u.last_spoken_at, r.spoken_at), r.spoken_at) AS last_spoken_at,
+  $updated_at AS updated_at
+FROM AS_TABLE($quickes) AS r
+LEFT JOIN (
+  SELECT slot, text, spoken_count, last_spoken_at
+  FROM quicke_usage
+  WHERE user_id = $user_id
+) AS u ON u.slot = r.slot;`
+		rows := make([]types.Value, 0, len(usage.Quickes))
+		for _, delta := range usage.Quickes {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("slot", types.Int64Value(int64(delta.Slot))),
+				types.StructFieldValue("text", types.UTF8Value(delta.Text)),
+				types.StructFieldValue("spoken", types.Int64Value(delta.SpokenCount)),
+				types.StructFieldValue("spoken_at", types.Int64Value(delta.LastSpokenAt)),
+			))
+		}
+		params = append(params, table.ValueParam("$quickes", types.ListValue(rows...)))
+	}
+
+	query := s.withPrefix(declares + body)
+	if len(usage.EventIDs) == 0 {
+		return s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+			_, _, err := sess.Execute(ctx, table.DefaultTxControl(), query, table.NewQueryParameters(params...))
+			return err
+		})
+	}
+
+	checkQuery := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $event_ids AS List<Utf8>;
+SELECT COUNT(*) AS recorded
+FROM spoken_events
+WHERE user_id = $user_id AND event_id IN $event_ids;`)
+	return s.client.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, checkQuery, table.NewQueryParameters(
+			table.ValueParam("$user_id", types.UTF8Value(userID)),
+			table.ValueParam("$event_ids", eventIDs),
+		))
+		if err != nil {
+			return err
+		}
+		var recorded uint64
+		if err := res.NextResultSetErr(ctx); err != nil {
+			_ = res.Close()
+			return err
+		}
+		if res.NextRow() {
+			if err := res.ScanNamed(named.Required("recorded", &recorded)); err != nil {
+				_ = res.Close()
+				return err
+			}
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+		if recorded > 0 {
+			return store.ErrEventRecorded
+		}
+		_, err = tx.Execute(ctx, query, table.NewQueryParameters(params...))
+		return err
+	}, table.WithIdempotent())
+}
+
+// ListSpokenEvents returns the given event ids that were already recorded.
+func (s *Store) ListSpokenEvents(ctx context.Context, userID string, eventIDs []string) ([]string, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $ids AS List<Utf8>;
+SELECT event_id AS id
+FROM spoken_events
+WHERE user_id = $user_id AND event_id IN $ids;`)
+	return s.listKnownIDs(ctx, query, userID, eventIDs)
+}
+
+// ListStatementIDs returns the given statement ids that exist and are not in the trash.
+func (s *Store) ListStatementIDs(ctx context.Context, userID string, statementIDs []string) ([]string, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $ids AS List<Utf8>;
+SELECT statement_id AS id
+FROM statements
+WHERE user_id = $user_id AND statement_id IN $ids AND deleted_at IS NULL;`)
+	return s.listKnownIDs(ctx, query, userID, statementIDs)
+}
+
+func (s *Store) listKnownIDs(ctx context.Context, query, userID string, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	values := make([]types.Value, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, types.UTF8Value(id))
+	}
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$ids", types.ListValue(values...)),
+	)
+
+	var out []string
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var id string
+			if err := res.ScanNamed(named.Required("id", &id)); err != nil {
+				return err
+			}
+			out = append(out, id)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *Store) ListStatementUsage(ctx context.Context, userID string) ([]models.StatementUsage, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT
+  st.statement_id AS statement_id,
+  st.category_id AS category_id,
+  st.text AS text,
+  st.created_at AS created_at,
+  st.position AS position,
+  st.color AS color,
+  st.icon AS icon,
+  st.image_id AS image_id,
+  st.updated_at AS updated_at,
+  u.spoken_count AS spoken_count,
+  u.last_spoken_at AS last_spoken_at
+FROM statement_usage AS u
+JOIN statements AS st ON st.user_id = u.user_id AND st.statement_id = u.statement_id
+WHERE u.user_id = $user_id AND st.deleted_at IS NULL;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.StatementUsage
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				usage    models.StatementUsage
+				position *string
+				color    *string
+				icon     *string
+				imageID  *string
+				updated  *int64
+			)
+			if err := res.ScanNamed(
+				named.Required("statement_id", &usage.ID),
+				named.Required("category_id", &usage.CategoryID),
+				named.Required("text", &usage.Text),
+				named.Required("created_at", &usage.Created),
+				named.Optional("position", &position),
+				named.Optional("color", &color),
+				named.Optional("icon", &icon),
+				named.Optional("image_id", &imageID),
+				named.Optional("updated_at", &updated),
+				named.Required("spoken_count", &usage.SpokenCount),
+				named.Required("last_spoken_at", &usage.LastSpokenAt),
+			); err != nil {
+				return err
+			}
+			if position != nil {
+				usage.Position = *position
+			}
+			usage.Color = stringValue(color)
+			usage.Icon = stringValue(icon)
+			usage.ImageID = stringValue(imageID)
+			if updated != nil {
+				usage.UpdatedAt = *updated
+			} else {
+				usage.UpdatedAt = usage.Created
+			}
+			out = append(out, usage)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *Store) ListQuickeUsage(ctx context.Context, userID string) ([]models.QuickeUsage, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT slot, text, spoken_count, last_spoken_at
+FROM quicke_usage
+WHERE user_id = $user_id
+ORDER BY slot;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var out []models.QuickeUsage
+	err := s.client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				usage models.QuickeUsage
+				slot  int64
+			)
+			if err := res.ScanNamed(
+				named.Required("slot", &slot),
+				named.Required("text", &usage.Text),
+				named.Required("spoken_count", &usage.SpokenCount),
+				named.Required("last_spoken_at", &usage.LastSpokenAt),
+			); err != nil {
+				return err
+			}
+			usage.Slot = int(slot)
+			out = append(out, usage)
+		}
+		return res.Err()
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
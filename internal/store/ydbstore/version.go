@@ -0,0 +1,318 @@
+package ydbstore
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// Conditional writes read the row and write it in one serializable
+// transaction, so a concurrent writer naming the same version either commits
+// first and makes this one fail the comparison, or aborts the transaction and
+// retries it. Search terms are derived data and are indexed after the commit.
+
+func (s *Store) UpsertCategoryIfVersion(ctx context.Context, userID string, category models.Category, expected []int64) (models.Category, error) {
+	category, query, params := s.categoryUpsert(userID, category)
+	if err := s.writeIfVersion(ctx, s.categoryVersionQuery(), categoryVersionKey(userID, category.ID), expected, query, params); err != nil {
+		return models.Category{}, err
+	}
+	if err := s.indexSearch(ctx, userID, searchCategory, category.ID, "", category.Label); err != nil {
+		return models.Category{}, err
+	}
+	return category, nil
+}
+
+func (s *Store) DeleteCategoryIfVersion(ctx context.Context, userID, categoryID string, expected []int64, updatedAt int64) error {
+	query, params := s.categoryDelete(userID, categoryID, updatedAt)
+	if err := s.writeIfVersion(ctx, s.categoryVersionQuery(), categoryVersionKey(userID, categoryID), expected, query, params); err != nil {
+		return err
+	}
+	return s.unindexSearch(ctx, userID, searchCategory, categoryID)
+}
+
+func (s *Store) UpsertStatementIfVersion(ctx context.Context, userID string, statement models.Statement, expected []int64) (models.Statement, error) {
+	statement, query, params := s.statementUpsert(userID, statement)
+	key := statementVersionKey(userID, statement.CategoryID, statement.ID)
+	if err := s.writeIfVersion(ctx, s.statementVersionQuery(), key, expected, query, params); err != nil {
+		return models.Statement{}, err
+	}
+	if err := s.indexSearch(ctx, userID, searchStatement, statement.ID, statement.CategoryID, statement.Text); err != nil {
+		return models.Statement{}, err
+	}
+	return statement, nil
+}
+
+// MoveStatementIfVersion compares the version of the row being moved, in
+// fromCategoryID.
+func (s *Store) MoveStatementIfVersion(ctx context.Context, userID, fromCategoryID string, statement models.Statement, expected []int64) error {
+	query, params := s.statementMove(userID, fromCategoryID, statement)
+	key := statementVersionKey(userID, fromCategoryID, statement.ID)
+	if err := s.writeIfVersion(ctx, s.statementVersionQuery(), key, expected, query, params); err != nil {
+		return err
+	}
+	return s.indexSearch(ctx, userID, searchStatement, statement.ID, statement.CategoryID, statement.Text)
+}
+
+func (s *Store) DeleteStatementIfVersion(ctx context.Context, userID, categoryID, statementID string, expected []int64, updatedAt int64) error {
+	query, params := s.statementDelete(userID, categoryID, statementID, updatedAt)
+	key := statementVersionKey(userID, categoryID, statementID)
+	if err := s.writeIfVersion(ctx, s.statementVersionQuery(), key, expected, query, params); err != nil {
+		return err
+	}
+	return s.unindexSearch(ctx, userID, searchStatement, statementID)
+}
+
+func (s *Store) categoryVersionQuery() string {
+	return s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $category_id AS Utf8;
+SELECT updated_at
+FROM categories
+WHERE user_id = $user_id AND category_id = $category_id AND deleted_at IS NULL;`)
+}
+
+func (s *Store) statementVersionQuery() string {
+	return s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $category_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+SELECT updated_at
+FROM statements
+WHERE user_id = $user_id AND category_id = $category_id AND statement_id = $statement_id AND deleted_at IS NULL;`)
+}
+
+func categoryVersionKey(userID, categoryID string) *table.QueryParameters {
+	return table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+	)
+}
+
+func statementVersionKey(userID, categoryID, statementID string) *table.QueryParameters {
+	return table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+		table.ValueParam("$statement_id", types.UTF8Value(statementID)),
+	)
+}
+
+// writeIfVersion runs query when the live row selected by versionQuery has
+// an updated_at in expected, or exists at all when expected is empty.
+func (s *Store) writeIfVersion(ctx context.Context, versionQuery string, key *table.QueryParameters, expected []int64, query string, params *table.QueryParameters) error {
+	return s.client.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, versionQuery, key)
+		if err != nil {
+			return err
+		}
+		var (
+			found   bool
+			current int64
+		)
+		if err := res.NextResultSetErr(ctx); err != nil {
+			_ = res.Close()
+			return err
+		}
+		if res.NextRow() {
+			found = true
+			if err := res.ScanNamed(named.Required("updated_at", &current)); err != nil {
+				_ = res.Close()
+				return err
+			}
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		if !found {
+			return store.ErrVersionMismatch
+		}
+		if len(expected) > 0 && !slices.Contains(expected, current) {
+			return store.ErrVersionMismatch
+		}
+
+		_, err = tx.Execute(ctx, query, params)
+		return err
+	}, table.WithIdempotent())
+}
+
+// SetUserStateIf is SetUserState after match accepts the stored state,
+// read in the same transaction as the write.
+func (s *Store) SetUserStateIf(ctx context.Context, userID string, state models.UserState, updatedAt int64, match func(current models.UserState) bool) (models.UserState, error) {
+	preferencesJSON := "{}"
+	if state.Preferences != nil {
+		serialized, err := json.Marshal(state.Preferences)
+		if err != nil {
+			return state, err
+		}
+		preferencesJSON = string(serialized)
+	}
+
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $created_at AS Int64;
+DECLARE $inited AS Bool;
+DECLARE $preferences AS JsonDocument;
+DECLARE $quickes AS List<Struct<slot: Int64, text: Utf8>>;
+DECLARE $set_quickes AS Bool;
+DECLARE $updated_at AS Int64;
+UPSERT INTO users (user_id, created_at, inited, preferences, deleted_at)
+VALUES ($user_id, $created_at, $inited, $preferences, NULL);
+DELETE FROM quickes WHERE user_id = $user_id AND $set_quickes;
+UPSERT INTO quickes
+SELECT $user_id AS user_id, slot, text, $updated_at AS updated_at
+FROM AS_TABLE($quickes);`)
+
+	err := s.writeUserStateIf(ctx, userID, match, func(createdAt int64) *table.QueryParameters {
+		if createdAt == 0 {
+			createdAt = updatedAt
+		}
+		return table.NewQueryParameters(
+			table.ValueParam("$user_id", types.UTF8Value(userID)),
+			table.ValueParam("$created_at", types.Int64Value(createdAt)),
+			table.ValueParam("$inited", types.BoolValue(state.Inited)),
+			table.ValueParam("$preferences", types.JSONDocumentValue(preferencesJSON)),
+			table.ValueParam("$quickes", quickeRows(state.Quickes)),
+			table.ValueParam("$set_quickes", types.BoolValue(len(state.Quickes) > 0)),
+			table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
+		)
+	}, query)
+	return state, err
+}
+
+// SetQuickesIf is SetQuickes after match accepts the stored state, read in
+// the same transaction as the write.
+func (s *Store) SetQuickesIf(ctx context.Context, userID string, quickes []string, updatedAt int64, match func(current models.UserState) bool) ([]string, error) {
+	query := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $quickes AS List<Struct<slot: Int64, text: Utf8>>;
+DECLARE $updated_at AS Int64;
+DELETE FROM quickes WHERE user_id = $user_id;
+UPSERT INTO quickes
+SELECT $user_id AS user_id, slot, text, $updated_at AS updated_at
+FROM AS_TABLE($quickes);`)
+
+	err := s.writeUserStateIf(ctx, userID, match, func(int64) *table.QueryParameters {
+		return table.NewQueryParameters(
+			table.ValueParam("$user_id", types.UTF8Value(userID)),
+			table.ValueParam("$quickes", quickeRows(quickes)),
+			table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
+		)
+	}, query)
+	if err != nil {
+		return nil, err
+	}
+	return quickes, nil
+}
+
+// writeUserStateIf reads the user's state, and runs query with the
+// parameters built from the user's created_at when match accepts it.
+func (s *Store) writeUserStateIf(ctx context.Context, userID string, match func(models.UserState) bool, params func(createdAt int64) *table.QueryParameters, query string) error {
+	readQuery := s.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT created_at, inited, preferences
+FROM users
+WHERE user_id = $user_id;
+SELECT slot, text
+FROM quickes
+WHERE user_id = $user_id
+ORDER BY slot;`)
+
+	return s.client.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, readQuery, table.NewQueryParameters(
+			table.ValueParam("$user_id", types.UTF8Value(userID)),
+		))
+		if err != nil {
+			return err
+		}
+		var (
+			current   models.UserState
+			createdAt int64
+		)
+		if err := res.NextResultSetErr(ctx); err != nil {
+			_ = res.Close()
+			return err
+		}
+		if res.NextRow() {
+			var preferences *string
+			if err := res.ScanNamed(
+				named.Required("created_at", &createdAt),
+				named.Required("inited", &current.Inited),
+				named.Optional("preferences", &preferences),
+			); err != nil {
+				_ = res.Close()
+				return err
+			}
+			if preferences != nil && *preferences != "" {
+				if err := json.Unmarshal([]byte(*preferences), &current.Preferences); err != nil {
+					_ = res.Close()
+					return err
+				}
+			}
+		}
+		if err := res.NextResultSetErr(ctx); err != nil {
+			_ = res.Close()
+			return err
+		}
+		for res.NextRow() {
+			var (
+				slot int64
+				text string
+			)
+			if err := res.ScanNamed(
+				named.Required("slot", &slot),
+				named.Required("text", &text),
+			); err != nil {
+				_ = res.Close()
+				return err
+			}
+			current.Quickes = placeQuicke(current.Quickes, slot, text)
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		if !match(current) {
+			return store.ErrVersionMismatch
+		}
+		_, err = tx.Execute(ctx, query, params(createdAt))
+		return err
+	}, table.WithIdempotent())
+}
+
+func quickeRows(quickes []string) types.Value {
+	rows := make([]types.Value, 0, len(quickes))
+	for slot, text := range quickes {
+		rows = append(rows, types.StructValue(
+			types.StructFieldValue("slot", types.Int64Value(int64(slot))),
+			types.StructFieldValue("text", types.UTF8Value(text)),
+		))
+	}
+	if len(rows) == 0 {
+		return types.ZeroValue(types.List(types.Struct(
+			types.StructField("slot", types.TypeInt64),
+			types.StructField("text", types.TypeUTF8),
+		)))
+	}
+	return types.ListValue(rows...)
+}
+
+// placeQuicke stores text at slot, growing quickes as needed. Negative
+// slots are dropped.
+func placeQuicke(quickes []string, slot int64, text string) []string {
+	if slot < 0 {
+		return quickes
+	}
+	if int(slot) >= len(quickes) {
+		grown := make([]string, int(slot)+1)
+		copy(grown, quickes)
+		quickes = grown
+	}
+	quickes[slot] = text
+	return quickes
+}
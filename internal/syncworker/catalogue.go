@@ -0,0 +1,506 @@
+package syncworker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// catalogueRow is a global catalogue or factory question row reduced to
+// the content RTDB carries. For stored rows Source is the hash of the RTDB
+// content last copied into the row; it is empty for rows created in YDB.
+type catalogueRow struct {
+	Key     string
+	Hash    string
+	Source  string
+	Deleted bool
+}
+
+// catalogueSync lists the keys a pass writes, marks as copied from RTDB
+// and deletes.
+type catalogueSync struct {
+	Upsert []string
+	Adopt  []string
+	Delete []string
+}
+
+// planCatalogueSync compares the RTDB rows with the stored ones. A row is
+// only written when RTDB changed since it was last copied, so edits and
+// deletes made in YDB survive until the same row is edited in RTDB, and
+// columns RTDB does not carry are left alone. Rows created in YDB are never
+// touched; stored rows that already match RTDB are adopted.
+func planCatalogueSync(remote, local []catalogueRow) catalogueSync {
+	stored := make(map[string]catalogueRow, len(local))
+	for _, row := range local {
+		stored[row.Key] = row
+	}
+	seen := make(map[string]bool, len(remote))
+	var plan catalogueSync
+	for _, row := range remote {
+		seen[row.Key] = true
+		have, ok := stored[row.Key]
+		switch {
+		case !ok:
+			plan.Upsert = append(plan.Upsert, row.Key)
+		case have.Source == row.Hash:
+		case have.Source == "":
+			if have.Hash == row.Hash {
+				plan.Adopt = append(plan.Adopt, row.Key)
+			}
+		case !have.Deleted:
+			plan.Upsert = append(plan.Upsert, row.Key)
+		}
+	}
+	for _, row := range local {
+		if !seen[row.Key] && row.Source != "" && !row.Deleted {
+			plan.Delete = append(plan.Delete, row.Key)
+		}
+	}
+	slices.Sort(plan.Upsert)
+	slices.Sort(plan.Adopt)
+	slices.Sort(plan.Delete)
+	return plan
+}
+
+// contentHash identifies the Firebase-sourced content of a row.
+func contentHash(fields ...any) string {
+	data, _ := json.Marshal(fields)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+func questionHash(q models.FactoryQuestion) string {
+	phrases := q.Phrases
+	if phrases == nil {
+		phrases = []string{}
+	}
+	return contentHash(q.Label, phrases, q.Category, q.Type, q.OrderIndex)
+}
+
+func categoryHash(cat models.GlobalCategory) string {
+	return contentHash(cat.Label, cat.Default)
+}
+
+func statementHash(stmt models.Statement) string {
+	return contentHash(stmt.Text)
+}
+
+func (w *Worker) syncFactoryQuestions(ctx context.Context) error {
+	ref := w.firebase.NewRef("factory/questions")
+	var raw any
+	if err := ref.Get(ctx, &raw); err != nil {
+		return err
+	}
+	remote := make(map[string]models.FactoryQuestion)
+	var remoteRows []catalogueRow
+	for _, q := range parseFactoryQuestions(raw) {
+		remote[q.ID] = q
+		remoteRows = append(remoteRows, catalogueRow{Key: q.ID, Hash: questionHash(q)})
+	}
+
+	local, err := w.readFactoryQuestions(ctx)
+	if err != nil {
+		return err
+	}
+	localRows := make([]catalogueRow, 0, len(local))
+	for _, q := range local {
+		localRows = append(localRows, catalogueRow{Key: q.ID, Hash: questionHash(q.FactoryQuestion), Source: q.Source, Deleted: q.Deleted})
+	}
+
+	plan := planCatalogueSync(remoteRows, localRows)
+	for _, key := range plan.Upsert {
+		q := remote[key]
+		params, err := factoryQuestionParams(q, table.ValueParam("$source_hash", types.UTF8Value(questionHash(q))))
+		if err != nil {
+			return err
+		}
+		query := w.withPrefix(`
+DECLARE $question_id AS Utf8;
+DECLARE $label AS Utf8;
+DECLARE $phrases AS JsonDocument;
+DECLARE $category AS Utf8;
+DECLARE $type AS Utf8;
+DECLARE $order_index AS Int64;
+DECLARE $source_hash AS Utf8;
+UPSERT INTO factory_questions (question_id, label, phrases, category, type, order_index, source_hash)
+VALUES ($question_id, $label, $phrases, $category, $type, $order_index, $source_hash);`)
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	for _, key := range plan.Adopt {
+		query := w.withPrefix(`
+DECLARE $question_id AS Utf8;
+DECLARE $source_hash AS Utf8;
+UPSERT INTO factory_questions (question_id, source_hash)
+VALUES ($question_id, $source_hash);`)
+		params := table.NewQueryParameters(
+			table.ValueParam("$question_id", types.UTF8Value(key)),
+			table.ValueParam("$source_hash", types.UTF8Value(questionHash(remote[key]))),
+		)
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	for _, key := range plan.Delete {
+		query := w.withPrefix(`
+DECLARE $question_id AS Utf8;
+DELETE FROM factory_questions WHERE question_id = $question_id;`)
+		params := table.NewQueryParameters(
+			table.ValueParam("$question_id", types.UTF8Value(key)),
+		)
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) syncGlobalCategories(ctx context.Context) error {
+	ref := w.firebase.NewRef("global/Category")
+	var raw map[string]any
+	if err := ref.Get(ctx, &raw); err != nil {
+		return err
+	}
+	remoteCategories, remoteStatements := parseGlobalCategories(raw)
+
+	localCategories, err := w.readGlobalCategories(ctx)
+	if err != nil {
+		return err
+	}
+	localStatements := make(map[string]storedStatement)
+	for categoryID := range localCategories {
+		statements, err := w.readGlobalStatements(ctx, categoryID)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range statements {
+			localStatements[stmt.CategoryID+"/"+stmt.ID] = stmt
+		}
+	}
+
+	// Rows are only written when their content changed, so now is the
+	// version global update tracking compares against.
+	now := time.Now().UnixMilli()
+	var remoteRows, localRows []catalogueRow
+	for key, cat := range remoteCategories {
+		if cat.Created == 0 {
+			cat.Created = localCategories[key].Created
+		}
+		if cat.Created == 0 {
+			cat.Created = now
+		}
+		cat.UpdatedAt = now
+		remoteCategories[key] = cat
+		remoteRows = append(remoteRows, catalogueRow{Key: key, Hash: categoryHash(cat)})
+	}
+	for key, cat := range localCategories {
+		localRows = append(localRows, catalogueRow{Key: key, Hash: categoryHash(cat.GlobalCategory), Source: cat.Source, Deleted: cat.Deleted})
+	}
+	categories := planCatalogueSync(remoteRows, localRows)
+
+	remoteRows, localRows = remoteRows[:0], localRows[:0]
+	for key, stmt := range remoteStatements {
+		if stmt.Created == 0 {
+			stmt.Created = localStatements[key].Created
+		}
+		if stmt.Created == 0 {
+			stmt.Created = now
+		}
+		stmt.UpdatedAt = now
+		remoteStatements[key] = stmt
+		remoteRows = append(remoteRows, catalogueRow{Key: key, Hash: statementHash(stmt)})
+	}
+	for key, stmt := range localStatements {
+		localRows = append(localRows, catalogueRow{Key: key, Hash: statementHash(stmt.Statement), Source: stmt.Source, Deleted: stmt.Deleted})
+	}
+	statements := planCatalogueSync(remoteRows, localRows)
+
+	for _, key := range categories.Upsert {
+		cat := remoteCategories[key]
+		query := w.withPrefix(`
+DECLARE $category_id AS Utf8;
+DECLARE $label AS Utf8;
+DECLARE $created_at AS Int64;
+DECLARE $is_default AS Bool?;
+DECLARE $updated_at AS Int64;
+DECLARE $source_hash AS Utf8;
+UPSERT INTO global_categories (category_id, label, created_at, is_default, updated_at, source_hash)
+VALUES ($category_id, $label, $created_at, $is_default, $updated_at, $source_hash);`)
+		params := globalCategoryParams(cat, table.ValueParam("$source_hash", types.UTF8Value(categoryHash(cat))))
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	for _, key := range categories.Adopt {
+		query := w.withPrefix(`
+DECLARE $category_id AS Utf8;
+DECLARE $source_hash AS Utf8;
+UPSERT INTO global_categories (category_id, source_hash)
+VALUES ($category_id, $source_hash);`)
+		params := table.NewQueryParameters(
+			table.ValueParam("$category_id", types.UTF8Value(key)),
+			table.ValueParam("$source_hash", types.UTF8Value(categoryHash(remoteCategories[key]))),
+		)
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	for _, key := range statements.Upsert {
+		stmt := remoteStatements[key]
+		query := w.withPrefix(`
+DECLARE $category_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DECLARE $text AS Utf8;
+DECLARE $created_at AS Int64;
+DECLARE $updated_at AS Int64;
+DECLARE $source_hash AS Utf8;
+UPSERT INTO global_statements (category_id, statement_id, text, created_at, updated_at, source_hash)
+VALUES ($category_id, $statement_id, $text, $created_at, $updated_at, $source_hash);`)
+		params := globalStatementParams(stmt, table.ValueParam("$source_hash", types.UTF8Value(statementHash(stmt))))
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	for _, key := range statements.Adopt {
+		stmt := remoteStatements[key]
+		query := w.withPrefix(`
+DECLARE $category_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DECLARE $source_hash AS Utf8;
+UPSERT INTO global_statements (category_id, statement_id, source_hash)
+VALUES ($category_id, $statement_id, $source_hash);`)
+		params := table.NewQueryParameters(
+			table.ValueParam("$category_id", types.UTF8Value(stmt.CategoryID)),
+			table.ValueParam("$statement_id", types.UTF8Value(stmt.ID)),
+			table.ValueParam("$source_hash", types.UTF8Value(statementHash(stmt))),
+		)
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	for _, key := range statements.Delete {
+		stmt := localStatements[key]
+		query := w.withPrefix(`
+DECLARE $category_id AS Utf8;
+DECLARE $statement_id AS Utf8;
+DELETE FROM global_statements WHERE category_id = $category_id AND statement_id = $statement_id;`)
+		params := table.NewQueryParameters(
+			table.ValueParam("$category_id", types.UTF8Value(stmt.CategoryID)),
+			table.ValueParam("$statement_id", types.UTF8Value(stmt.ID)),
+		)
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	for _, key := range categories.Delete {
+		query := w.withPrefix(`
+DECLARE $category_id AS Utf8;
+DELETE FROM global_categories WHERE category_id = $category_id;`)
+		params := table.NewQueryParameters(
+			table.ValueParam("$category_id", types.UTF8Value(key)),
+		)
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseGlobalCategories flattens global/Category into categories and
+// statements keyed by id and categoryId/statementId. Missing created
+// timestamps are left zero.
+func parseGlobalCategories(raw map[string]any) (map[string]models.GlobalCategory, map[string]models.Statement) {
+	categories := make(map[string]models.GlobalCategory, len(raw))
+	statements := make(map[string]models.Statement)
+	for key, rawCat := range raw {
+		cat, ok := rawCat.(map[string]any)
+		if !ok {
+			continue
+		}
+		categoryID := str(cat["id"], key)
+		categories[categoryID] = models.GlobalCategory{
+			ID:      categoryID,
+			Label:   str(cat["label"], ""),
+			Created: int64From(cat["created"], 0),
+			Default: boolPtrFrom(cat["default"]),
+		}
+
+		rawStatements, _ := cat["statements"].(map[string]any)
+		for stmtKey, rawStmt := range rawStatements {
+			stmtMap, ok := rawStmt.(map[string]any)
+			if !ok {
+				continue
+			}
+			stmt := models.Statement{
+				ID:         str(stmtMap["id"], stmtKey),
+				CategoryID: categoryID,
+				Text:       str(stmtMap["text"], ""),
+				Created:    int64From(stmtMap["created"], 0),
+			}
+			statements[categoryID+"/"+stmt.ID] = stmt
+		}
+	}
+	return categories, statements
+}
+
+// storedCategory, storedStatement and storedQuestion are catalogue rows
+// as the sync-worker sees them, deleted ones included.
+type storedCategory struct {
+	models.GlobalCategory
+	Source  string
+	Deleted bool
+}
+
+type storedStatement struct {
+	models.Statement
+	Source  string
+	Deleted bool
+}
+
+type storedQuestion struct {
+	models.FactoryQuestion
+	Source  string
+	Deleted bool
+}
+
+// readGlobalCategories loads every stored global category by id.
+func (w *Worker) readGlobalCategories(ctx context.Context) (map[string]storedCategory, error) {
+	query := w.withPrefix(`
+SELECT category_id, label, created_at, is_default, deleted_at, source_hash
+FROM global_categories;`)
+
+	var out map[string]storedCategory
+	err := w.read(ctx, query, nil, func(res result.Result) error {
+		out = make(map[string]storedCategory)
+		for res.NextRow() {
+			var (
+				cat       storedCategory
+				deletedAt *int64
+				source    *string
+			)
+			if err := res.ScanNamed(
+				named.Required("category_id", &cat.ID),
+				named.Required("label", &cat.Label),
+				named.Required("created_at", &cat.Created),
+				named.Optional("is_default", &cat.Default),
+				named.Optional("deleted_at", &deletedAt),
+				named.Optional("source_hash", &source),
+			); err != nil {
+				return err
+			}
+			cat.Deleted = deletedAt != nil
+			if source != nil {
+				cat.Source = *source
+			}
+			out[cat.ID] = cat
+		}
+		return res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readGlobalStatements loads the stored statements of one global category.
+func (w *Worker) readGlobalStatements(ctx context.Context, categoryID string) ([]storedStatement, error) {
+	query := w.withPrefix(`
+DECLARE $category_id AS Utf8;
+SELECT category_id, statement_id, text, created_at, deleted_at, source_hash
+FROM global_statements
+WHERE category_id = $category_id;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$category_id", types.UTF8Value(categoryID)),
+	)
+
+	var out []storedStatement
+	err := w.read(ctx, query, params, func(res result.Result) error {
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				stmt      storedStatement
+				deletedAt *int64
+				source    *string
+			)
+			if err := res.ScanNamed(
+				named.Required("category_id", &stmt.CategoryID),
+				named.Required("statement_id", &stmt.ID),
+				named.Required("text", &stmt.Text),
+				named.Required("created_at", &stmt.Created),
+				named.Optional("deleted_at", &deletedAt),
+				named.Optional("source_hash", &source),
+			); err != nil {
+				return err
+			}
+			stmt.Deleted = deletedAt != nil
+			if source != nil {
+				stmt.Source = *source
+			}
+			out = append(out, stmt)
+		}
+		return res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readFactoryQuestions loads every stored factory question, deleted ones
+// included.
+func (w *Worker) readFactoryQuestions(ctx context.Context) ([]storedQuestion, error) {
+	query := w.withPrefix(`
+SELECT question_id, label, phrases, category, type, order_index, deleted_at, source_hash
+FROM factory_questions;`)
+
+	var out []storedQuestion
+	err := w.read(ctx, query, nil, func(res result.Result) error {
+		out = out[:0]
+		for res.NextRow() {
+			var (
+				q          storedQuestion
+				phrases    string
+				orderIndex int64
+				deletedAt  *int64
+				source     *string
+			)
+			if err := res.ScanNamed(
+				named.Required("question_id", &q.ID),
+				named.Required("label", &q.Label),
+				named.Required("phrases", &phrases),
+				named.Required("category", &q.Category),
+				named.Required("type", &q.Type),
+				named.Required("order_index", &orderIndex),
+				named.Optional("deleted_at", &deletedAt),
+				named.Optional("source_hash", &source),
+			); err != nil {
+				return err
+			}
+			if err := json.Unmarshal([]byte(phrases), &q.Phrases); err != nil {
+				return err
+			}
+			q.OrderIndex = int(orderIndex)
+			q.Deleted = deletedAt != nil
+			if source != nil {
+				q.Source = *source
+			}
+			out = append(out, q)
+		}
+		return res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
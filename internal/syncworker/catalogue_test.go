@@ -0,0 +1,103 @@
+package syncworker
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestPlanCatalogueSync(t *testing.T) {
+	hash := func(text string) string { return statementHash(models.Statement{Text: text}) }
+	remote := []catalogueRow{
+		{Key: "same", Hash: hash("Привет")},
+		{Key: "changed", Hash: hash("Спасибо")},
+		{Key: "new", Hash: hash("Пока")},
+		{Key: "untracked", Hash: hash("Да")},
+		{Key: "edited", Hash: hash("Нет")},
+	}
+	local := []catalogueRow{
+		{Key: "same", Hash: hash("Привет"), Source: hash("Привет")},
+		{Key: "changed", Hash: hash("Спасиба"), Source: hash("Спасиба")},
+		{Key: "untracked", Hash: hash("Да")},
+		{Key: "edited", Hash: hash("Нет, спасибо")},
+		{Key: "gone", Hash: hash("Ещё"), Source: hash("Ещё")},
+		{Key: "admin", Hash: hash("Своё")},
+	}
+
+	plan := planCatalogueSync(remote, local)
+	if !slices.Equal(plan.Upsert, []string{"changed", "new"}) {
+		t.Fatalf("unexpected upserts: %v", plan.Upsert)
+	}
+	if !slices.Equal(plan.Adopt, []string{"untracked"}) {
+		t.Fatalf("unexpected adopts: %v", plan.Adopt)
+	}
+	if !slices.Equal(plan.Delete, []string{"gone"}) {
+		t.Fatalf("unexpected deletes: %v", plan.Delete)
+	}
+}
+
+func TestPlanCatalogueSyncKeepsAdminEdits(t *testing.T) {
+	rtdb := statementHash(models.Statement{Text: "Хочу пить"})
+	remote := []catalogueRow{
+		{Key: "cat/edited", Hash: rtdb},
+		{Key: "cat/deleted", Hash: rtdb},
+	}
+	local := []catalogueRow{
+		{Key: "cat/edited", Hash: statementHash(models.Statement{Text: "Я хочу пить"}), Source: rtdb},
+		{Key: "cat/deleted", Hash: rtdb, Source: rtdb, Deleted: true},
+	}
+
+	plan := planCatalogueSync(remote, local)
+	if len(plan.Upsert)+len(plan.Adopt)+len(plan.Delete) != 0 {
+		t.Fatalf("admin edits should survive a sync pass: %+v", plan)
+	}
+
+	// An RTDB edit to the same row wins; a deleted row stays deleted.
+	changed := statementHash(models.Statement{Text: "Хочу воды"})
+	plan = planCatalogueSync([]catalogueRow{{Key: "cat/edited", Hash: changed}, {Key: "cat/deleted", Hash: changed}}, local)
+	if !slices.Equal(plan.Upsert, []string{"cat/edited"}) {
+		t.Fatalf("unexpected upserts after RTDB edit: %v", plan.Upsert)
+	}
+}
+
+func TestQuestionHashIgnoresEmptyPhrases(t *testing.T) {
+	a := models.FactoryQuestion{ID: "q", Label: "Имя"}
+	b := models.FactoryQuestion{ID: "q", Label: "Имя", Phrases: []string{}}
+	if questionHash(a) != questionHash(b) {
+		t.Fatalf("nil and empty phrases should hash the same")
+	}
+}
+
+func TestPlanCatalogueSyncKeepsImportedQuestions(t *testing.T) {
+	rtdb := models.FactoryQuestion{ID: "name", Label: "Как вас зовут?", Category: "Обо мне"}
+	imported := rtdb
+	imported.Label = "Как тебя зовут?"
+	remote := []catalogueRow{
+		{Key: "name", Hash: questionHash(rtdb)},
+		{Key: "city", Hash: questionHash(models.FactoryQuestion{ID: "city", Label: "Город"})},
+	}
+	local := []catalogueRow{
+		{Key: "name", Hash: questionHash(imported), Source: questionHash(rtdb)},
+		{Key: "city", Hash: questionHash(models.FactoryQuestion{ID: "city", Label: "Город"}), Source: questionHash(models.FactoryQuestion{ID: "city", Label: "Город"}), Deleted: true},
+		{Key: "bundle", Hash: questionHash(models.FactoryQuestion{ID: "bundle", Label: "Новое"})},
+	}
+
+	plan := planCatalogueSync(remote, local)
+	if len(plan.Upsert)+len(plan.Adopt)+len(plan.Delete) != 0 {
+		t.Fatalf("imported questions should survive a sync pass: %+v", plan)
+	}
+}
+
+func TestFactoryQuestionParamsLeaveYDBColumns(t *testing.T) {
+	params, err := factoryQuestionParams(models.FactoryQuestion{ID: "pets", Type: "single", Options: []models.QuestionOption{{Value: "dog", Label: "Собака"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := paramsRow(params)
+	for _, column := range []string{"options", "placeholder", "show_if", "deleted_at"} {
+		if _, ok := row[column]; ok {
+			t.Fatalf("sync should not write %s", column)
+		}
+	}
+}
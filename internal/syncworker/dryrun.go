@@ -0,0 +1,523 @@
+package syncworker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// Recorded operations.
+const (
+	opUpsert   = "upsert"
+	opDelete   = "delete"
+	opTruncate = "truncate"
+)
+
+// Diff kinds reported per row.
+const (
+	diffInserted  = "inserted"
+	diffUpdated   = "updated"
+	diffDeleted   = "deleted"
+	diffUnchanged = "unchanged"
+)
+
+// keyColumns lists primary key parameters per table for writes issued as
+// raw YQL.
+var keyColumns = map[string][]string{
+	"admins":            {"user_id"},
+	"factory_questions": {"question_id"},
+	"global_categories": {"category_id"},
+	"global_statements": {"category_id", "statement_id"},
+}
+
+var writeQueryRe = regexp.MustCompile(`(?is)\b(UPSERT\s+INTO|DELETE\s+FROM)\s+(\w+)(.*)`)
+
+// Mutation is a single write captured in dry-run mode.
+type Mutation struct {
+	Table  string `json:"table"`
+	Op     string `json:"op"`
+	UserID string `json:"userId,omitempty"`
+	Key    string `json:"key,omitempty"`
+	Row    any    `json:"row,omitempty"`
+}
+
+// Recorder collects the writes a dry-run pass would issue.
+type Recorder struct {
+	mu        sync.Mutex
+	mutations []Mutation
+}
+
+func (r *Recorder) add(m Mutation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mutations = append(r.mutations, m)
+}
+
+// Mutations returns a copy of the recorded writes in issue order.
+func (r *Recorder) Mutations() []Mutation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Mutation(nil), r.mutations...)
+}
+
+// recordQuery captures a raw YQL write issued through exec.
+func (r *Recorder) recordQuery(query string, params *table.QueryParameters) {
+	match := writeQueryRe.FindStringSubmatch(query)
+	if match == nil {
+		return
+	}
+	tableName := match[2]
+	op := opUpsert
+	if strings.HasPrefix(strings.ToUpper(match[1]), "DELETE") {
+		op = opDelete
+		if !strings.Contains(strings.ToUpper(match[3]), "WHERE") {
+			op = opTruncate
+		}
+	}
+
+	row := paramsRow(params)
+	m := Mutation{Table: tableName, Op: op}
+	if op != opTruncate {
+		m.Key = rowKey(tableName, row)
+		if op == opUpsert {
+			m.Row = row
+		}
+	}
+	r.add(m)
+}
+
+// recordingStore passes reads through to the wrapped store and records
+// every write the sync worker issues instead of applying it.
+type recordingStore struct {
+	store.Store
+	rec *Recorder
+}
+
+func (s *recordingStore) UpsertCategory(_ context.Context, userID string, category models.Category) (models.Category, error) {
+	s.rec.add(Mutation{Table: "categories", Op: opUpsert, UserID: userID, Key: category.ID, Row: category})
+	return category, nil
+}
+
+func (s *recordingStore) DeleteCategory(_ context.Context, userID, categoryID string, _ int64) error {
+	s.rec.add(Mutation{Table: "categories", Op: opDelete, UserID: userID, Key: categoryID})
+	return nil
+}
+
+func (s *recordingStore) UpsertStatement(_ context.Context, userID string, statement models.Statement) (models.Statement, error) {
+	s.rec.add(Mutation{Table: "statements", Op: opUpsert, UserID: userID, Key: statement.CategoryID + "/" + statement.ID, Row: statement})
+	return statement, nil
+}
+
+func (s *recordingStore) DeleteStatement(_ context.Context, userID, categoryID, statementID string, _ int64) error {
+	s.rec.add(Mutation{Table: "statements", Op: opDelete, UserID: userID, Key: categoryID + "/" + statementID})
+	return nil
+}
+
+func (s *recordingStore) SetUserState(_ context.Context, userID string, state models.UserState, _ int64) (models.UserState, error) {
+	s.rec.add(Mutation{Table: "users", Op: opUpsert, UserID: userID, Key: userID, Row: userRow(state)})
+	s.rec.add(Mutation{Table: "quickes", Op: opUpsert, UserID: userID, Key: userID, Row: state.Quickes})
+	return state, nil
+}
+
+func (s *recordingStore) SetQuickes(_ context.Context, userID string, quickes []string, _ int64) ([]string, error) {
+	s.rec.add(Mutation{Table: "quickes", Op: opUpsert, UserID: userID, Key: userID, Row: quickes})
+	return quickes, nil
+}
+
+func (s *recordingStore) DeleteUser(_ context.Context, userID string, _ int64) error {
+	s.rec.add(Mutation{Table: "users", Op: opDelete, UserID: userID, Key: userID})
+	return nil
+}
+
+func (s *recordingStore) AppendChange(_ context.Context, userID string, change models.ChangeEvent) error {
+	s.rec.add(Mutation{Table: "changes", Op: opUpsert, UserID: userID, Key: change.EntityType + "/" + change.EntityID, Row: change})
+	return nil
+}
+
+func (s *recordingStore) AddAdmin(_ context.Context, userID string) error {
+	s.rec.add(Mutation{Table: "admins", Op: opUpsert, Key: userID})
+	return nil
+}
+
+func (s *recordingStore) RemoveAdmin(_ context.Context, userID string) error {
+	s.rec.add(Mutation{Table: "admins", Op: opDelete, Key: userID})
+	return nil
+}
+
+// DiffReport summarizes what a dry-run pass would change.
+type DiffReport struct {
+	StartedAt    int64                            `json:"startedAt"`
+	FinishedAt   int64                            `json:"finishedAt"`
+	Shard        string                           `json:"shard"`
+	UsersScanned int                              `json:"usersScanned"`
+	UserErrors   map[string]string                `json:"userErrors,omitempty"`
+	Tables       map[string]*TableDiff            `json:"tables"`
+	Users        map[string]map[string]*TableDiff `json:"users"`
+}
+
+// TableDiff counts row-level differences for one table. Changes lists the
+// affected rows; for per-user tables they are reported under Users only.
+type TableDiff struct {
+	Inserted  int       `json:"inserted"`
+	Updated   int       `json:"updated"`
+	Deleted   int       `json:"deleted"`
+	Unchanged int       `json:"unchanged"`
+	Changes   []RowDiff `json:"changes,omitempty"`
+}
+
+// RowDiff describes a single row difference.
+type RowDiff struct {
+	Key    string `json:"key"`
+	Kind   string `json:"kind"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+func (d *TableDiff) add(kind string, row RowDiff, keepRow bool) {
+	switch kind {
+	case diffInserted:
+		d.Inserted++
+	case diffUpdated:
+		d.Updated++
+	case diffDeleted:
+		d.Deleted++
+	default:
+		d.Unchanged++
+		return
+	}
+	if keepRow {
+		d.Changes = append(d.Changes, row)
+	}
+}
+
+// DryRun performs a full pass over global data and the given users (or
+// every RTDB user in this instance's shard when userIDs is empty) without
+// writing to YDB, and returns a diff against the current YDB contents.
+// Leases, the dirty queue and checkpoints are left untouched.
+func (w *Worker) DryRun(ctx context.Context, userIDs []string) (*DiffReport, error) {
+	if w.firebase == nil {
+		return nil, errors.New("firebase db client is nil")
+	}
+
+	report := &DiffReport{
+		StartedAt:  time.Now().UnixMilli(),
+		Shard:      w.shard.String(),
+		UserErrors: make(map[string]string),
+		Tables:     make(map[string]*TableDiff),
+		Users:      make(map[string]map[string]*TableDiff),
+	}
+
+	base := w.store
+	rec := &Recorder{}
+	w.store = &recordingStore{Store: base, rec: rec}
+	w.recorder = rec
+	defer func() {
+		w.store = base
+		w.recorder = nil
+	}()
+
+	if err := w.syncAdmins(ctx); err != nil {
+		return nil, err
+	}
+	if err := w.syncFactoryQuestions(ctx); err != nil {
+		return nil, err
+	}
+	if err := w.syncGlobalCategories(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(userIDs) == 0 {
+		var raw map[string]any
+		if err := w.firebase.NewRef("users").GetShallow(ctx, &raw); err != nil {
+			return nil, err
+		}
+		for userID := range raw {
+			if w.shard.Contains(userID) {
+				userIDs = append(userIDs, userID)
+			}
+		}
+		sort.Strings(userIDs)
+	}
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := w.syncUser(ctx, userID); err != nil {
+			report.UserErrors[userID] = err.Error()
+		}
+		report.UsersScanned++
+	}
+
+	if err := buildDiff(ctx, base, rec.Mutations(), report); err != nil {
+		return nil, err
+	}
+	report.FinishedAt = time.Now().UnixMilli()
+	return report, nil
+}
+
+type diffScope struct {
+	table  string
+	userID string
+}
+
+type scopeWrites struct {
+	truncated bool
+	order     []string
+	final     map[string]Mutation
+}
+
+// buildDiff reduces recorded writes to their net effect per table and user
+// and compares it with the rows currently stored.
+func buildDiff(ctx context.Context, base store.Store, mutations []Mutation, report *DiffReport) error {
+	scopes := make(map[diffScope]*scopeWrites)
+	var scopeOrder []diffScope
+	for _, m := range mutations {
+		scope := diffScope{table: m.Table, userID: m.UserID}
+		writes, ok := scopes[scope]
+		if !ok {
+			writes = &scopeWrites{final: make(map[string]Mutation)}
+			scopes[scope] = writes
+			scopeOrder = append(scopeOrder, scope)
+		}
+		if m.Op == opTruncate {
+			writes.truncated = true
+			continue
+		}
+		if _, seen := writes.final[m.Key]; !seen {
+			writes.order = append(writes.order, m.Key)
+		}
+		writes.final[m.Key] = m
+	}
+
+	for _, scope := range scopeOrder {
+		writes := scopes[scope]
+		before, err := baselineRows(ctx, base, scope.table, scope.userID)
+		if err != nil {
+			return err
+		}
+
+		total := report.Tables[scope.table]
+		if total == nil {
+			total = &TableDiff{}
+			report.Tables[scope.table] = total
+		}
+		var perUser *TableDiff
+		if scope.userID != "" {
+			perUser = &TableDiff{}
+		}
+
+		record := func(kind string, row RowDiff) {
+			total.add(kind, row, perUser == nil)
+			if perUser != nil {
+				perUser.add(kind, row, true)
+			}
+		}
+
+		for _, key := range writes.order {
+			m := writes.final[key]
+			prev, existed := before[key]
+			switch kind := classifyRow(m, prev, existed); kind {
+			case "":
+			case diffDeleted:
+				record(kind, RowDiff{Key: key, Kind: kind, Before: prev})
+			case diffInserted:
+				record(kind, RowDiff{Key: key, Kind: kind, After: m.Row})
+			case diffUnchanged:
+				record(kind, RowDiff{})
+			default:
+				record(kind, RowDiff{Key: key, Kind: kind, Before: prev, After: m.Row})
+			}
+		}
+		if writes.truncated {
+			keys := make([]string, 0, len(before))
+			for key := range before {
+				if _, ok := writes.final[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				record(diffDeleted, RowDiff{Key: key, Kind: diffDeleted, Before: before[key]})
+			}
+		}
+
+		if perUser != nil && perUser.Inserted+perUser.Updated+perUser.Deleted > 0 {
+			tables := report.Users[scope.userID]
+			if tables == nil {
+				tables = make(map[string]*TableDiff)
+				report.Users[scope.userID] = tables
+			}
+			tables[scope.table] = perUser
+		}
+	}
+	return nil
+}
+
+// baselineRows loads the rows currently stored in YDB for a table, keyed
+// the same way recorded mutations are.
+func baselineRows(ctx context.Context, base store.Store, tableName, userID string) (map[string]any, error) {
+	rows := make(map[string]any)
+	switch tableName {
+	case "categories":
+		categories, err := base.ListCategories(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, cat := range categories {
+			rows[cat.ID] = cat
+		}
+	case "statements":
+		statements, err := base.ListAllStatements(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, stmt := range statements {
+			rows[stmt.CategoryID+"/"+stmt.ID] = stmt
+		}
+	case "users", "quickes":
+		state, err := base.GetUserState(ctx, userID)
+		if errors.Is(err, store.ErrNotFound) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tableName == "users" {
+			rows[userID] = userRow(state)
+		} else {
+			rows[userID] = state.Quickes
+		}
+	case "admins":
+		admins, err := base.ListAdmins(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, userID := range admins {
+			rows[userID] = nil
+		}
+	case "factory_questions":
+		questions, err := base.ListFactoryQuestions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range questions {
+			params, err := factoryQuestionParams(q)
+			if err != nil {
+				return nil, err
+			}
+			rows[q.ID] = paramsRow(params)
+		}
+	case "global_categories", "global_statements":
+		categories, err := base.ListGlobalCategories(ctx, tableName == "global_statements")
+		if err != nil {
+			return nil, err
+		}
+		for _, cat := range categories {
+			if tableName == "global_categories" {
+				rows[cat.ID] = paramsRow(globalCategoryParams(cat))
+				continue
+			}
+			for _, stmt := range cat.Statements {
+				stmt.CategoryID = cat.ID
+				rows[cat.ID+"/"+stmt.ID] = paramsRow(globalStatementParams(stmt))
+			}
+		}
+	}
+	return rows, nil
+}
+
+func userRow(state models.UserState) map[string]any {
+	return map[string]any{"inited": state.Inited, "preferences": state.Preferences}
+}
+
+// paramsRow renders query parameters as column -> YQL literal.
+func paramsRow(params *table.QueryParameters) map[string]string {
+	row := make(map[string]string)
+	if params == nil {
+		return row
+	}
+	params.Each(func(name string, v types.Value) {
+		row[strings.TrimPrefix(name, "$")] = v.Yql()
+	})
+	return row
+}
+
+func rowKey(tableName string, row map[string]string) string {
+	columns, ok := keyColumns[tableName]
+	if !ok {
+		columns = []string{"user_id"}
+	}
+	parts := make([]string, 0, len(columns))
+	for _, column := range columns {
+		parts = append(parts, unquoteYql(row[column]))
+	}
+	return strings.Join(parts, "/")
+}
+
+// unquoteYql turns a Utf8 literal such as "abc"u back into abc.
+func unquoteYql(literal string) string {
+	if unquoted, err := strconv.Unquote(strings.TrimSuffix(literal, "u")); err == nil {
+		return unquoted
+	}
+	return literal
+}
+
+// classifyRow compares a recorded write with the stored row. Deleting a
+// missing row is a no-op and yields "".
+func classifyRow(m Mutation, prev any, existed bool) string {
+	switch {
+	case m.Op == opDelete && existed:
+		return diffDeleted
+	case m.Op == opDelete:
+		return ""
+	case !existed:
+		return diffInserted
+	case sameContent(prev, m.Row):
+		return diffUnchanged
+	default:
+		return diffUpdated
+	}
+}
+
+// sameContent compares categories and statements on the content RTDB
+// carries, ignoring updated_at and the YDB-only columns RTDB rows omit
+// (see mergeCategory). Other rows are compared in full.
+func sameContent(stored, row any) bool {
+	switch stored := stored.(type) {
+	case models.Category:
+		if row, ok := row.(models.Category); ok {
+			_, changed := mergeCategory(stored, row)
+			return !changed
+		}
+	case models.Statement:
+		if row, ok := row.(models.Statement); ok {
+			_, changed := mergeStatement(stored, row)
+			return !changed
+		}
+	}
+	return sameRow(stored, row)
+}
+
+func sameRow(a, b any) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
+}
@@ -0,0 +1,67 @@
+package syncworker
+
+import (
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestRecordQuery(t *testing.T) {
+	rec := &Recorder{}
+	rec.recordQuery("PRAGMA TablePathPrefix(\"/db\");\nDELETE FROM global_statements;", nil)
+	rec.recordQuery("DECLARE $category_id AS Utf8;\nUPSERT INTO global_statements (category_id) VALUES ($category_id);",
+		globalStatementParams(models.Statement{ID: "s1", CategoryID: "user\"cat", Text: "hi"}))
+
+	mutations := rec.Mutations()
+	if len(mutations) != 2 {
+		t.Fatalf("expected 2 mutations, got %d", len(mutations))
+	}
+	if mutations[0].Table != "global_statements" || mutations[0].Op != opTruncate {
+		t.Fatalf("unexpected truncate: %+v", mutations[0])
+	}
+	if mutations[1].Op != opUpsert || mutations[1].Key != "user\"cat/s1" {
+		t.Fatalf("unexpected upsert: %+v", mutations[1])
+	}
+}
+
+func TestClassifyRow(t *testing.T) {
+	stored := models.Category{ID: "c1", Label: "Еда", Created: 1, Position: "a0", Color: "red", UpdatedAt: 50}
+	// The RTDB copy: updated_at = created and no YDB-only columns.
+	remote := models.Category{ID: "c1", Label: "Еда", Created: 1, UpdatedAt: 1}
+	renamed := remote
+	renamed.Label = "Напитки"
+	storedStatement := models.Statement{ID: "s1", CategoryID: "c1", Text: "Хлеб", Created: 2, Icon: "bread", UpdatedAt: 60}
+	remoteStatement := models.Statement{ID: "s1", CategoryID: "c1", Text: "Хлеб", Created: 2, UpdatedAt: 2}
+
+	cases := []struct {
+		name    string
+		m       Mutation
+		prev    any
+		existed bool
+		want    string
+	}{
+		{"same category", Mutation{Op: opUpsert, Row: remote}, stored, true, diffUnchanged},
+		{"renamed category", Mutation{Op: opUpsert, Row: renamed}, stored, true, diffUpdated},
+		{"same statement", Mutation{Op: opUpsert, Row: remoteStatement}, storedStatement, true, diffUnchanged},
+		{"new row", Mutation{Op: opUpsert, Row: remote}, nil, false, diffInserted},
+		{"deleted row", Mutation{Op: opDelete}, stored, true, diffDeleted},
+		{"delete of missing row", Mutation{Op: opDelete}, nil, false, ""},
+		{"same quickes", Mutation{Op: opUpsert, Row: []string{"Да"}}, []string{"Да"}, true, diffUnchanged},
+		{"other quickes", Mutation{Op: opUpsert, Row: []string{"Нет"}}, []string{"Да"}, true, diffUpdated},
+	}
+	for _, tc := range cases {
+		if got := classifyRow(tc.m, tc.prev, tc.existed); got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestTableDiffKeepsChangedRowsOnly(t *testing.T) {
+	diff := &TableDiff{}
+	diff.add(diffInserted, RowDiff{Key: "a", Kind: diffInserted}, true)
+	diff.add(diffUnchanged, RowDiff{}, true)
+	diff.add(diffUpdated, RowDiff{Key: "b", Kind: diffUpdated}, true)
+	if diff.Inserted != 1 || diff.Updated != 1 || diff.Unchanged != 1 || len(diff.Changes) != 2 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+}
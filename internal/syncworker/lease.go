@@ -0,0 +1,101 @@
+package syncworker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// globalLease guards admins, factory questions and global categories.
+const globalLease = "sync-worker/global"
+
+// acquireLease takes or renews a named lease for this instance.
+// It returns false when another live instance holds the lease.
+func (w *Worker) acquireLease(ctx context.Context, name string) (bool, error) {
+	selectQuery := w.withPrefix(`
+DECLARE $name AS Utf8;
+SELECT holder, expires_at
+FROM sync_leases
+WHERE name = $name;`)
+
+	upsertQuery := w.withPrefix(`
+DECLARE $name AS Utf8;
+DECLARE $holder AS Utf8;
+DECLARE $expires_at AS Int64;
+DECLARE $updated_at AS Int64;
+UPSERT INTO sync_leases (name, holder, expires_at, updated_at)
+VALUES ($name, $holder, $expires_at, $updated_at);`)
+
+	var acquired bool
+	err := w.ydbClient.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		acquired = false
+		now := time.Now().UnixMilli()
+
+		res, err := tx.Execute(ctx, selectQuery, table.NewQueryParameters(
+			table.ValueParam("$name", types.UTF8Value(name)),
+		))
+		if err != nil {
+			return err
+		}
+		var (
+			holder    string
+			expiresAt int64
+		)
+		if err := res.NextResultSetErr(ctx); err != nil {
+			_ = res.Close()
+			return err
+		}
+		if res.NextRow() {
+			if err := res.ScanNamed(
+				named.Required("holder", &holder),
+				named.Required("expires_at", &expiresAt),
+			); err != nil {
+				_ = res.Close()
+				return err
+			}
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		if holder != "" && holder != w.instanceID && expiresAt > now {
+			return nil
+		}
+
+		_, err = tx.Execute(ctx, upsertQuery, table.NewQueryParameters(
+			table.ValueParam("$name", types.UTF8Value(name)),
+			table.ValueParam("$holder", types.UTF8Value(w.instanceID)),
+			table.ValueParam("$expires_at", types.Int64Value(now+w.leaseTTL.Milliseconds())),
+			table.ValueParam("$updated_at", types.Int64Value(now)),
+		))
+		if err != nil {
+			return err
+		}
+		acquired = true
+		return nil
+	}, table.WithIdempotent())
+	if err != nil {
+		return false, err
+	}
+
+	return acquired, nil
+}
+
+// releaseLease drops a lease held by this instance so a peer can take over
+// without waiting for expiry.
+func (w *Worker) releaseLease(ctx context.Context, name string) error {
+	query := w.withPrefix(`
+DECLARE $name AS Utf8;
+DECLARE $holder AS Utf8;
+DELETE FROM sync_leases
+WHERE name = $name AND holder = $holder;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$name", types.UTF8Value(name)),
+		table.ValueParam("$holder", types.UTF8Value(w.instanceID)),
+	)
+	return w.exec(ctx, query, params)
+}
@@ -0,0 +1,215 @@
+package syncworker
+
+import (
+	"slices"
+
+	"github.com/linkasu/linka.type-backend/internal/defaults"
+	"github.com/linkasu/linka.type-backend/internal/feature"
+	"github.com/linkasu/linka.type-backend/internal/locale"
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+// RTDB user rows have no updated_at, and rows written by legacy clients
+// lack the columns added in YDB (parent, position and visuals). A pass
+// therefore compares only the content RTDB carries, and leaves a stored
+// row and its updated_at version alone unless that content changed.
+
+// userRows lists the categories and statements a user sync writes.
+type userRows struct {
+	Categories []models.Category
+	Statements []models.Statement
+}
+
+// planUserRows merges the RTDB rows into the stored ones and returns those
+// that changed. New rows keep updated_at = created; changed rows are
+// stamped with now so ETags and newest-wins merges see the edit.
+func planUserRows(remoteCategories []models.Category, remoteStatements []models.Statement, storedCategories []models.Category, storedStatements []models.Statement, now int64) userRows {
+	categories := make(map[string]models.Category, len(storedCategories))
+	for _, cat := range storedCategories {
+		categories[cat.ID] = cat
+	}
+	statements := make(map[string]models.Statement, len(storedStatements))
+	for _, stmt := range storedStatements {
+		statements[stmt.CategoryID+"/"+stmt.ID] = stmt
+	}
+
+	var plan userRows
+	for _, cat := range remoteCategories {
+		stored, ok := categories[cat.ID]
+		if !ok {
+			plan.Categories = append(plan.Categories, newCategoryRow(cat, now))
+			continue
+		}
+		if merged, changed := mergeCategory(stored, cat); changed {
+			merged.UpdatedAt = now
+			plan.Categories = append(plan.Categories, merged)
+		}
+	}
+	for _, stmt := range remoteStatements {
+		stored, ok := statements[stmt.CategoryID+"/"+stmt.ID]
+		if !ok {
+			plan.Statements = append(plan.Statements, newStatementRow(stmt, now))
+			continue
+		}
+		if merged, changed := mergeStatement(stored, stmt); changed {
+			merged.UpdatedAt = now
+			plan.Statements = append(plan.Statements, merged)
+		}
+	}
+	return plan
+}
+
+func newCategoryRow(cat models.Category, now int64) models.Category {
+	if cat.Created == 0 {
+		cat.Created = now
+	}
+	cat.UpdatedAt = cat.Created
+	return cat
+}
+
+func newStatementRow(stmt models.Statement, now int64) models.Statement {
+	if stmt.Created == 0 {
+		stmt.Created = now
+	}
+	stmt.UpdatedAt = stmt.Created
+	return stmt
+}
+
+// mergeCategory applies the RTDB copy of a category to the stored row and
+// reports whether its content changed. Created and updated_at stay as
+// stored; columns legacy clients omit are only taken when RTDB has them.
+func mergeCategory(stored, remote models.Category) (models.Category, bool) {
+	merged := stored
+	merged.Label = remote.Label
+	merged.AIUse = remote.AIUse
+	if remote.Default != nil {
+		merged.Default = remote.Default
+	}
+	mergeString(&merged.ParentID, remote.ParentID)
+	mergeString(&merged.Position, remote.Position)
+	mergeString(&merged.Color, remote.Color)
+	mergeString(&merged.Icon, remote.Icon)
+	mergeString(&merged.ImageID, remote.ImageID)
+	return merged, !sameCategoryContent(stored, merged)
+}
+
+// mergeStatement is mergeCategory for a statement.
+func mergeStatement(stored, remote models.Statement) (models.Statement, bool) {
+	merged := stored
+	merged.Text = remote.Text
+	mergeString(&merged.Position, remote.Position)
+	mergeString(&merged.Color, remote.Color)
+	mergeString(&merged.Icon, remote.Icon)
+	mergeString(&merged.ImageID, remote.ImageID)
+	return merged, !sameStatementContent(stored, merged)
+}
+
+func mergeString(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+func sameCategoryContent(a, b models.Category) bool {
+	return a.Label == b.Label &&
+		a.AIUse == b.AIUse &&
+		sameBool(a.Default, b.Default) &&
+		a.ParentID == b.ParentID &&
+		a.Position == b.Position &&
+		a.Color == b.Color &&
+		a.Icon == b.Icon &&
+		a.ImageID == b.ImageID
+}
+
+func sameStatementContent(a, b models.Statement) bool {
+	return a.Text == b.Text &&
+		a.Position == b.Position &&
+		a.Color == b.Color &&
+		a.Icon == b.Icon &&
+		a.ImageID == b.ImageID
+}
+
+func sameBool(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// mergeUserState applies the RTDB user state to the stored one and reports
+// whether it changed. Quickes and preferences are only taken when RTDB has
+// them; a user with no quickes on either side gets the defaults for their
+// preferred locale.
+func mergeUserState(stored, remote models.UserState) (models.UserState, bool) {
+	merged := stored
+	merged.Inited = remote.Inited
+	if remote.Preferences != nil {
+		merged.Preferences = remote.Preferences
+	}
+	switch {
+	case len(remote.Quickes) > 0:
+		merged.Quickes = remote.Quickes
+	case len(merged.Quickes) == 0:
+		merged.Quickes = defaults.Quickes(locale.Resolve(merged.Preferences, ""))
+	}
+	changed := merged.Inited != stored.Inited ||
+		!slices.Equal(merged.Quickes, stored.Quickes) ||
+		!sameRow(merged.Preferences, stored.Preferences)
+	return merged, changed
+}
+
+// migrationSync says what the worker may do for a user in a migration state.
+// Pull copies RTDB data into YDB and Overwrite lets it change rows YDB
+// already has; Project writes YDB changes back into RTDB.
+type migrationSync struct {
+	Pull      bool
+	Overwrite bool
+	Project   bool
+}
+
+// syncForState maps a migration state onto worker behaviour. Once YDB is
+// primary RTDB may only add rows YDB never had; once Firebase is
+// decommissioned it is neither read nor written.
+func syncForState(state string) migrationSync {
+	switch state {
+	case feature.MigrationFirebaseDecommissioned:
+		return migrationSync{}
+	case feature.MigrationYDBPrimary:
+		return migrationSync{Pull: true, Project: true}
+	default:
+		return migrationSync{Pull: true, Overwrite: true, Project: true}
+	}
+}
+
+// planMissingRows returns the RTDB rows YDB has never had. known holds the
+// ids of live and trashed categories and statements; statements are matched
+// by id alone, so one moved in YDB is not copied back into its old category.
+func planMissingRows(remoteCategories []models.Category, remoteStatements []models.Statement, knownCategories, knownStatements map[string]bool, now int64) userRows {
+	var plan userRows
+	for _, cat := range remoteCategories {
+		if !knownCategories[cat.ID] {
+			plan.Categories = append(plan.Categories, newCategoryRow(cat, now))
+		}
+	}
+	for _, stmt := range remoteStatements {
+		if !knownStatements[stmt.ID] {
+			plan.Statements = append(plan.Statements, newStatementRow(stmt, now))
+		}
+	}
+	return plan
+}
+
+// trashedIDs collects the ids of trashed categories, their subcategories
+// and statements.
+func trashedIDs(categories []models.TrashedCategory, statements []models.TrashedStatement, categoryIDs, statementIDs map[string]bool) {
+	for _, cat := range categories {
+		categoryIDs[cat.ID] = true
+		for _, stmt := range cat.Statements {
+			statementIDs[stmt.ID] = true
+		}
+		trashedIDs(cat.Subcategories, nil, categoryIDs, statementIDs)
+	}
+	for _, stmt := range statements {
+		statementIDs[stmt.ID] = true
+	}
+}
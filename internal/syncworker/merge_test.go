@@ -0,0 +1,130 @@
+package syncworker
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/linkasu/linka.type-backend/internal/defaults"
+	"github.com/linkasu/linka.type-backend/internal/feature"
+	"github.com/linkasu/linka.type-backend/internal/models"
+)
+
+func TestPlanUserRows(t *testing.T) {
+	storedCategories := []models.Category{
+		{ID: "same", Label: "Еда", Created: 1, Position: "a0", Color: "red", UpdatedAt: 50},
+		{ID: "renamed", Label: "Питьё", Created: 2, ParentID: "same", UpdatedAt: 60},
+	}
+	storedStatements := []models.Statement{
+		{ID: "s1", CategoryID: "same", Text: "Хлеб", Created: 3, Position: "a0", UpdatedAt: 70},
+		{ID: "s2", CategoryID: "same", Text: "Сыр", Created: 4, UpdatedAt: 80},
+	}
+	// RTDB rows as the legacy reader builds them: updated_at = created and
+	// no YDB-only columns.
+	remoteCategories := []models.Category{
+		{ID: "same", Label: "Еда", Created: 1, UpdatedAt: 1},
+		{ID: "renamed", Label: "Напитки", Created: 2, UpdatedAt: 2},
+		{ID: "new", Label: "Игры", Created: 5, UpdatedAt: 5},
+	}
+	remoteStatements := []models.Statement{
+		{ID: "s1", CategoryID: "same", Text: "Хлеб", Created: 3, UpdatedAt: 3},
+		{ID: "s2", CategoryID: "same", Text: "Сыр с плесенью", Created: 4, UpdatedAt: 4},
+	}
+
+	plan := planUserRows(remoteCategories, remoteStatements, storedCategories, storedStatements, 1000)
+	if len(plan.Categories) != 2 {
+		t.Fatalf("expected renamed and new categories, got %+v", plan.Categories)
+	}
+	renamed := plan.Categories[0]
+	if renamed.ID != "renamed" || renamed.Label != "Напитки" || renamed.ParentID != "same" || renamed.UpdatedAt != 1000 {
+		t.Fatalf("expected renamed category to keep its parent and get updated_at now, got %+v", renamed)
+	}
+	if created := plan.Categories[1]; created.ID != "new" || created.UpdatedAt != 5 {
+		t.Fatalf("expected new category with updated_at = created, got %+v", created)
+	}
+	if len(plan.Statements) != 1 {
+		t.Fatalf("expected only the edited statement, got %+v", plan.Statements)
+	}
+	if stmt := plan.Statements[0]; stmt.ID != "s2" || stmt.Text != "Сыр с плесенью" || stmt.UpdatedAt != 1000 {
+		t.Fatalf("unexpected statement write: %+v", stmt)
+	}
+}
+
+func TestMergeUserState(t *testing.T) {
+	stored := models.UserState{
+		Inited:      true,
+		Quickes:     []string{"Привет"},
+		Preferences: map[string]any{"locale": "kk"},
+	}
+
+	if _, changed := mergeUserState(stored, models.UserState{Inited: true}); changed {
+		t.Fatalf("expected RTDB state without quickes and preferences to change nothing")
+	}
+
+	merged, changed := mergeUserState(stored, models.UserState{Inited: true, Quickes: []string{"Пока"}})
+	if !changed || !slices.Equal(merged.Quickes, []string{"Пока"}) || merged.Preferences["locale"] != "kk" {
+		t.Fatalf("expected new quickes with stored preferences kept, got %+v", merged)
+	}
+
+	merged, changed = mergeUserState(models.UserState{}, models.UserState{})
+	if !changed || !slices.Equal(merged.Quickes, defaults.DefaultQuickes) {
+		t.Fatalf("expected a new user to get default quickes, got %+v", merged)
+	}
+
+	merged, _ = mergeUserState(models.UserState{}, models.UserState{Preferences: map[string]any{"locale": "kk"}})
+	if !slices.Equal(merged.Quickes, defaults.Quickes("kk")) {
+		t.Fatalf("expected a kk user to get kk default quickes, got %+v", merged)
+	}
+}
+
+func TestSyncForState(t *testing.T) {
+	cases := []struct {
+		state string
+		want  migrationSync
+	}{
+		{feature.MigrationFirebase, migrationSync{Pull: true, Overwrite: true, Project: true}},
+		{feature.MigrationSeeding, migrationSync{Pull: true, Overwrite: true, Project: true}},
+		{feature.MigrationDual, migrationSync{Pull: true, Overwrite: true, Project: true}},
+		{feature.MigrationYDBPrimary, migrationSync{Pull: true, Project: true}},
+		{feature.MigrationFirebaseDecommissioned, migrationSync{}},
+	}
+	for _, tc := range cases {
+		if got := syncForState(tc.state); got != tc.want {
+			t.Fatalf("%s: expected %+v, got %+v", tc.state, tc.want, got)
+		}
+	}
+}
+
+func TestPlanMissingRows(t *testing.T) {
+	knownCategories := map[string]bool{"live": true}
+	knownStatements := map[string]bool{"moved": true}
+	trashedIDs(
+		[]models.TrashedCategory{{
+			Category:      models.Category{ID: "trashed"},
+			Statements:    []models.Statement{{ID: "inTrashed"}},
+			Subcategories: []models.TrashedCategory{{Category: models.Category{ID: "trashedChild"}}},
+		}},
+		[]models.TrashedStatement{{Statement: models.Statement{ID: "deleted"}}},
+		knownCategories, knownStatements,
+	)
+
+	remoteCategories := []models.Category{
+		{ID: "live", Label: "Renamed in RTDB"},
+		{ID: "trashed"},
+		{ID: "trashedChild"},
+		{ID: "new", Created: 7},
+	}
+	remoteStatements := []models.Statement{
+		{ID: "moved", CategoryID: "live", Text: "Old place"},
+		{ID: "inTrashed", CategoryID: "trashed"},
+		{ID: "deleted", CategoryID: "live"},
+		{ID: "fresh", CategoryID: "new", Created: 8},
+	}
+
+	plan := planMissingRows(remoteCategories, remoteStatements, knownCategories, knownStatements, 1000)
+	if len(plan.Categories) != 1 || plan.Categories[0].ID != "new" || plan.Categories[0].UpdatedAt != 7 {
+		t.Fatalf("expected only the new category, got %+v", plan.Categories)
+	}
+	if len(plan.Statements) != 1 || plan.Statements[0].ID != "fresh" {
+		t.Fatalf("expected only the fresh statement, got %+v", plan.Statements)
+	}
+}
@@ -0,0 +1,264 @@
+package syncworker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/store"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// OriginRTDB marks changes that were copied from RTDB by the stream; the
+// projector skips them so Firebase writes are not echoed back.
+const OriginRTDB = "rtdb"
+
+const projectionPageSize = 200
+
+type pendingProjection struct {
+	Bucket int64
+	UserID string
+	Cursor string
+}
+
+// EnableProjection makes the worker tail the changes table and materialize
+// categories, statements, their order, quickes and inited into RTDB through writer.
+func (w *Worker) EnableProjection(writer store.LegacyWriter) {
+	w.projection = writer
+}
+
+// projectChanges drains the pending projection queue for this shard.
+func (w *Worker) projectChanges(ctx context.Context) error {
+	if w.projection == nil {
+		return nil
+	}
+
+	for {
+		pending, err := w.listPendingProjections(ctx, w.batchSize)
+		if err != nil {
+			return err
+		}
+		projected := 0
+		for _, item := range pending {
+			if err := w.projectUser(ctx, item); err != nil {
+				slog.Warn("projection failed", "user_id", item.UserID, "error", err)
+				if err := w.deferProjection(ctx, item); err != nil {
+					return err
+				}
+				continue
+			}
+			projected++
+		}
+		if len(pending) < w.batchSize || projected == 0 {
+			return nil
+		}
+	}
+}
+
+// projectUser applies every change after the user's checkpoint to RTDB.
+// Users whose Firebase copy is decommissioned are dropped from the queue.
+func (w *Worker) projectUser(ctx context.Context, item pendingProjection) error {
+	mode, err := w.migrationSync(ctx, item.UserID)
+	if err != nil {
+		return err
+	}
+	if !mode.Project {
+		return w.ackProjection(ctx, item)
+	}
+
+	cursor, err := w.getProjectionCheckpoint(ctx, item.UserID)
+	if err != nil {
+		return err
+	}
+
+	for {
+		next, changes, err := w.store.ListChanges(ctx, item.UserID, cursor, projectionPageSize)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if change.Origin == OriginRTDB {
+				continue
+			}
+			if err := w.projectChange(ctx, item.UserID, change); err != nil {
+				return fmt.Errorf("change %s: %w", change.Cursor, err)
+			}
+			w.status.recordProjection(change.EntityType)
+		}
+		if next != "" && next != cursor {
+			cursor = next
+			if err := w.setProjectionCheckpoint(ctx, item.UserID, cursor); err != nil {
+				return err
+			}
+		}
+		if len(changes) < projectionPageSize {
+			break
+		}
+	}
+
+	return w.ackProjection(ctx, item)
+}
+
+func (w *Worker) projectChange(ctx context.Context, userID string, change models.ChangeEvent) error {
+	switch change.EntityType {
+	case "category":
+		if change.Op == "delete" {
+			return w.projection.DeleteCategory(ctx, userID, change.EntityID)
+		}
+		var category models.Category
+		if err := json.Unmarshal(change.Payload, &category); err != nil {
+			return err
+		}
+		return w.projection.UpsertCategory(ctx, userID, category)
+	case "statement":
+		var statement models.Statement
+		if err := json.Unmarshal(change.Payload, &statement); err != nil {
+			return err
+		}
+		if change.Op == "delete" {
+			if statement.CategoryID == "" {
+				slog.Warn("projection skipped statement delete without category", "user_id", userID, "statement_id", change.EntityID)
+				return nil
+			}
+			return w.projection.DeleteStatement(ctx, userID, statement.CategoryID, change.EntityID)
+		}
+		return w.projection.UpsertStatement(ctx, userID, statement)
+	case "category_order", "statement_order":
+		var order models.OrderChange
+		if err := json.Unmarshal(change.Payload, &order); err != nil {
+			return err
+		}
+		if change.EntityType == "category_order" {
+			return w.projection.SetCategoryPositions(ctx, userID, order.Positions)
+		}
+		return w.projection.SetStatementPositions(ctx, userID, order.CategoryID, order.Positions)
+	case "quickes":
+		var quickes []string
+		if err := json.Unmarshal(change.Payload, &quickes); err != nil {
+			return err
+		}
+		return w.projection.SetQuickes(ctx, userID, quickes)
+	case "user_state":
+		var state models.UserState
+		if err := json.Unmarshal(change.Payload, &state); err != nil {
+			return err
+		}
+		return w.projection.SetUserState(ctx, userID, state)
+	default:
+		// Dialog data and other entities have no RTDB representation.
+		return nil
+	}
+}
+
+func (w *Worker) listPendingProjections(ctx context.Context, limit int) ([]pendingProjection, error) {
+	query := w.withPrefix(`
+DECLARE $lo AS Int64;
+DECLARE $hi AS Int64;
+DECLARE $limit AS Uint64;
+SELECT bucket, user_id, cursor
+FROM sync_projection_pending
+WHERE bucket >= $lo AND bucket < $hi
+ORDER BY updated_at
+LIMIT $limit;`)
+	params := table.NewQueryParameters(
+		table.ValueParam("$lo", types.Int64Value(int64(w.shard.Lo))),
+		table.ValueParam("$hi", types.Int64Value(int64(w.shard.Hi))),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	)
+
+	var pending []pendingProjection
+	err := w.read(ctx, query, params, func(res result.Result) error {
+		pending = pending[:0]
+		for res.NextRow() {
+			var item pendingProjection
+			if err := res.ScanNamed(
+				named.Required("bucket", &item.Bucket),
+				named.Required("user_id", &item.UserID),
+				named.Required("cursor", &item.Cursor),
+			); err != nil {
+				return err
+			}
+			pending = append(pending, item)
+		}
+		return res.Err()
+	})
+	return pending, err
+}
+
+// ackProjection removes the queue entry unless a newer change arrived
+// while the user was being projected.
+func (w *Worker) ackProjection(ctx context.Context, item pendingProjection) error {
+	query := w.withPrefix(`
+DECLARE $bucket AS Int64;
+DECLARE $user_id AS Utf8;
+DECLARE $cursor AS Utf8;
+DELETE FROM sync_projection_pending
+WHERE bucket = $bucket AND user_id = $user_id AND cursor <= $cursor;`)
+	params := table.NewQueryParameters(
+		table.ValueParam("$bucket", types.Int64Value(item.Bucket)),
+		table.ValueParam("$user_id", types.UTF8Value(item.UserID)),
+		table.ValueParam("$cursor", types.UTF8Value(item.Cursor)),
+	)
+	return w.exec(ctx, query, params)
+}
+
+// deferProjection moves a failing user to the back of the queue.
+func (w *Worker) deferProjection(ctx context.Context, item pendingProjection) error {
+	query := w.withPrefix(`
+DECLARE $bucket AS Int64;
+DECLARE $user_id AS Utf8;
+DECLARE $updated_at AS Int64;
+UPDATE sync_projection_pending
+SET updated_at = $updated_at
+WHERE bucket = $bucket AND user_id = $user_id;`)
+	params := table.NewQueryParameters(
+		table.ValueParam("$bucket", types.Int64Value(item.Bucket)),
+		table.ValueParam("$user_id", types.UTF8Value(item.UserID)),
+		table.ValueParam("$updated_at", types.Int64Value(time.Now().UnixMilli())),
+	)
+	return w.exec(ctx, query, params)
+}
+
+func (w *Worker) getProjectionCheckpoint(ctx context.Context, userID string) (string, error) {
+	query := w.withPrefix(`
+DECLARE $user_id AS Utf8;
+SELECT cursor
+FROM sync_projection_checkpoints
+WHERE user_id = $user_id;`)
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+	)
+
+	var cursor string
+	err := w.read(ctx, query, params, func(res result.Result) error {
+		cursor = ""
+		if res.NextRow() {
+			if err := res.ScanNamed(named.Required("cursor", &cursor)); err != nil {
+				return err
+			}
+		}
+		return res.Err()
+	})
+	return cursor, err
+}
+
+func (w *Worker) setProjectionCheckpoint(ctx context.Context, userID, cursor string) error {
+	query := w.withPrefix(`
+DECLARE $user_id AS Utf8;
+DECLARE $cursor AS Utf8;
+DECLARE $updated_at AS Int64;
+UPSERT INTO sync_projection_checkpoints (user_id, cursor, updated_at)
+VALUES ($user_id, $cursor, $updated_at);`)
+	params := table.NewQueryParameters(
+		table.ValueParam("$user_id", types.UTF8Value(userID)),
+		table.ValueParam("$cursor", types.UTF8Value(cursor)),
+		table.ValueParam("$updated_at", types.Int64Value(time.Now().UnixMilli())),
+	)
+	return w.exec(ctx, query, params)
+}
@@ -0,0 +1,271 @@
+package syncworker
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/shard"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+const (
+	fullScanCheckpoint = "users/full_scan"
+	sweepCheckpoint    = "users/sweep/"
+	enqueueChunkSize   = 500
+)
+
+type dirtyUser struct {
+	Bucket     int64
+	UserID     string
+	Reason     string
+	EnqueuedAt int64
+}
+
+// markDirty queues a user for the next incremental pass.
+func (w *Worker) markDirty(ctx context.Context, userID, reason string) error {
+	return w.enqueueUsers(ctx, []string{userID}, reason)
+}
+
+func (w *Worker) enqueueUsers(ctx context.Context, userIDs []string, reason string) error {
+	query := w.withPrefix(`
+DECLARE $rows AS List<Struct<bucket: Int64, user_id: Utf8, reason: Utf8, enqueued_at: Int64>>;
+UPSERT INTO sync_dirty_users
+SELECT bucket, user_id, reason, enqueued_at FROM AS_TABLE($rows);`)
+
+	now := time.Now().UnixMilli()
+	for start := 0; start < len(userIDs); start += enqueueChunkSize {
+		end := start + enqueueChunkSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		rows := make([]types.Value, 0, end-start)
+		for _, userID := range userIDs[start:end] {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("bucket", types.Int64Value(int64(shard.Bucket(userID)))),
+				types.StructFieldValue("user_id", types.UTF8Value(userID)),
+				types.StructFieldValue("reason", types.UTF8Value(reason)),
+				types.StructFieldValue("enqueued_at", types.Int64Value(now)),
+			))
+		}
+		params := table.NewQueryParameters(
+			table.ValueParam("$rows", types.ListValue(rows...)),
+		)
+		if err := w.exec(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) listDirtyUsers(ctx context.Context, limit int) ([]dirtyUser, error) {
+	query := w.withPrefix(`
+DECLARE $lo AS Int64;
+DECLARE $hi AS Int64;
+DECLARE $limit AS Uint64;
+SELECT bucket, user_id, reason, enqueued_at
+FROM sync_dirty_users
+WHERE bucket >= $lo AND bucket < $hi
+ORDER BY enqueued_at
+LIMIT $limit;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$lo", types.Int64Value(int64(w.shard.Lo))),
+		table.ValueParam("$hi", types.Int64Value(int64(w.shard.Hi))),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	)
+
+	var out []dirtyUser
+	err := w.read(ctx, query, params, func(res result.Result) error {
+		out = out[:0]
+		for res.NextRow() {
+			var item dirtyUser
+			if err := res.ScanNamed(
+				named.Required("bucket", &item.Bucket),
+				named.Required("user_id", &item.UserID),
+				named.Required("reason", &item.Reason),
+				named.Required("enqueued_at", &item.EnqueuedAt),
+			); err != nil {
+				return err
+			}
+			out = append(out, item)
+		}
+		return res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ackDirtyUser removes a queue entry unless it was re-enqueued after we read it.
+func (w *Worker) ackDirtyUser(ctx context.Context, item dirtyUser) error {
+	query := w.withPrefix(`
+DECLARE $bucket AS Int64;
+DECLARE $user_id AS Utf8;
+DECLARE $enqueued_at AS Int64;
+DELETE FROM sync_dirty_users
+WHERE bucket = $bucket AND user_id = $user_id AND enqueued_at <= $enqueued_at;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$bucket", types.Int64Value(item.Bucket)),
+		table.ValueParam("$user_id", types.UTF8Value(item.UserID)),
+		table.ValueParam("$enqueued_at", types.Int64Value(item.EnqueuedAt)),
+	)
+	return w.exec(ctx, query, params)
+}
+
+// syncDirtyUsers drains the queue for this instance's hash range.
+func (w *Worker) syncDirtyUsers(ctx context.Context) error {
+	for {
+		batch, err := w.listDirtyUsers(ctx, w.batchSize)
+		if err != nil {
+			return err
+		}
+
+		progressed := 0
+		for _, item := range batch {
+			if err := w.syncUser(ctx, item.UserID); err != nil {
+				slog.Warn("user sync failed", "user_id", item.UserID, "reason", item.Reason, "error", err)
+				// Re-enqueue to move the user behind healthy entries.
+				if err := w.markDirty(ctx, item.UserID, "retry"); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := w.ackDirtyUser(ctx, item); err != nil {
+				return err
+			}
+			progressed++
+		}
+
+		if len(batch) < w.batchSize || progressed == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// scheduleFullScan enqueues every Firebase user once per FullScanInterval.
+// Only the leader calls it; the scan itself is a shallow key listing.
+func (w *Worker) scheduleFullScan(ctx context.Context) error {
+	if w.fullScanInterval <= 0 {
+		return nil
+	}
+	last, err := w.getCheckpoint(ctx, fullScanCheckpoint)
+	if err != nil {
+		return err
+	}
+	lastAt, _ := strconv.ParseInt(last, 10, 64)
+	now := time.Now()
+	if lastAt > 0 && now.Sub(time.UnixMilli(lastAt)) < w.fullScanInterval {
+		return nil
+	}
+
+	var raw map[string]any
+	if err := w.firebase.NewRef("users").GetShallow(ctx, &raw); err != nil {
+		return err
+	}
+	userIDs := make([]string, 0, len(raw))
+	for userID := range raw {
+		userIDs = append(userIDs, userID)
+	}
+	userIDs, err = w.withoutDecommissioned(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	if err := w.enqueueUsers(ctx, userIDs, "full_scan"); err != nil {
+		return err
+	}
+	slog.Info("full user scan scheduled", "users", len(userIDs))
+
+	return w.setCheckpoint(ctx, fullScanCheckpoint, strconv.FormatInt(now.UnixMilli(), 10))
+}
+
+// sweepShard enqueues every Firebase user in this instance's hash range
+// once per SweepInterval. It stands in for the stream when streaming is
+// off; syncUser only writes rows that changed, so a sweep of unchanged
+// users costs reads only.
+func (w *Worker) sweepShard(ctx context.Context) error {
+	if w.sweepInterval <= 0 {
+		return nil
+	}
+	checkpoint := sweepCheckpoint + w.shard.String()
+	last, err := w.getCheckpoint(ctx, checkpoint)
+	if err != nil {
+		return err
+	}
+	lastAt, _ := strconv.ParseInt(last, 10, 64)
+	now := time.Now()
+	if lastAt > 0 && now.Sub(time.UnixMilli(lastAt)) < w.sweepInterval {
+		return nil
+	}
+
+	var raw map[string]any
+	if err := w.firebase.NewRef("users").GetShallow(ctx, &raw); err != nil {
+		return err
+	}
+	userIDs := make([]string, 0, len(raw))
+	for userID := range raw {
+		if w.shard.Contains(userID) {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	userIDs, err = w.withoutDecommissioned(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	if err := w.enqueueUsers(ctx, userIDs, "sweep"); err != nil {
+		return err
+	}
+	return w.setCheckpoint(ctx, checkpoint, strconv.FormatInt(now.UnixMilli(), 10))
+}
+
+func (w *Worker) getCheckpoint(ctx context.Context, name string) (string, error) {
+	query := w.withPrefix(`
+DECLARE $name AS Utf8;
+SELECT value
+FROM sync_checkpoints
+WHERE name = $name;`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$name", types.UTF8Value(name)),
+	)
+
+	var value string
+	err := w.read(ctx, query, params, func(res result.Result) error {
+		value = ""
+		if res.NextRow() {
+			if err := res.ScanNamed(named.Required("value", &value)); err != nil {
+				return err
+			}
+		}
+		return res.Err()
+	})
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (w *Worker) setCheckpoint(ctx context.Context, name, value string) error {
+	query := w.withPrefix(`
+DECLARE $name AS Utf8;
+DECLARE $value AS Utf8;
+DECLARE $updated_at AS Int64;
+UPSERT INTO sync_checkpoints (name, value, updated_at)
+VALUES ($name, $value, $updated_at);`)
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$name", types.UTF8Value(name)),
+		table.ValueParam("$value", types.UTF8Value(value)),
+		table.ValueParam("$updated_at", types.Int64Value(time.Now().UnixMilli())),
+	)
+	return w.exec(ctx, query, params)
+}
@@ -0,0 +1,349 @@
+package syncworker
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/linkasu/linka.type-backend/internal/httpapi"
+)
+
+// Stream path types used as metric labels.
+const (
+	pathUser      = "user"
+	pathCategory  = "category"
+	pathStatement = "statement"
+	pathQuickes   = "quickes"
+	pathInited    = "inited"
+	pathOther     = "other"
+)
+
+// Stream error kinds used as metric labels.
+const (
+	streamErrDecode = "decode"
+	streamErrApply  = "apply"
+	streamErrUser   = "user_apply"
+)
+
+// status holds in-memory counters exposed by the status server.
+type status struct {
+	mu sync.Mutex
+
+	startedAt time.Time
+
+	lastSyncAt       time.Time
+	lastSyncDuration time.Duration
+	lastSyncError    string
+	lastSyncErrorAt  time.Time
+	syncRuns         int64
+	syncFailures     int64
+	leader           bool
+
+	streamEnabled     bool
+	streamConnected   bool
+	streamChangedAt   time.Time
+	streamConnects    int64
+	streamLastEventAt time.Time
+
+	events      map[string]int64
+	errors      map[string]int64
+	projected   map[string]int64
+	lastLag     time.Duration
+	maxLag      time.Duration
+	lagSum      time.Duration
+	lagSamples  int64
+	lastApplyAt time.Time
+}
+
+func newStatus() *status {
+	now := time.Now()
+	return &status{
+		startedAt:       now,
+		streamChangedAt: now,
+		events:          make(map[string]int64),
+		errors:          make(map[string]int64),
+		projected:       make(map[string]int64),
+	}
+}
+
+func (s *status) recordSync(started time.Time, leader bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.syncRuns++
+	s.leader = leader
+	if err != nil {
+		s.syncFailures++
+		s.lastSyncError = err.Error()
+		s.lastSyncErrorAt = time.Now()
+		return
+	}
+	s.lastSyncAt = time.Now()
+	s.lastSyncDuration = s.lastSyncAt.Sub(started)
+}
+
+func (s *status) setStreamEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streamEnabled = enabled
+}
+
+func (s *status) setStreamConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.streamConnected == connected {
+		return
+	}
+	s.streamConnected = connected
+	s.streamChangedAt = time.Now()
+	if connected {
+		s.streamConnects++
+	}
+}
+
+// recordEvent counts an applied stream event. The lag runs from receivedAt,
+// when the worker read the event, to now; RTDB payloads carry no reliable
+// change time, so it covers the worker's own delay only.
+func (s *status) recordEvent(pathType string, receivedAt time.Time) {
+	now := time.Now()
+	lag := now.Sub(receivedAt)
+	if lag < 0 || receivedAt.IsZero() {
+		lag = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events[pathType]++
+	s.streamLastEventAt = now
+	s.lastApplyAt = now
+	s.lastLag = lag
+	s.lagSum += lag
+	s.lagSamples++
+	if lag > s.maxLag {
+		s.maxLag = lag
+	}
+}
+
+func (s *status) recordStreamError(kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[kind]++
+}
+
+func (s *status) recordProjection(entityType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.projected[entityType]++
+}
+
+// streamDownFor reports how long the stream has been disconnected.
+func (s *status) streamDownFor(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.streamEnabled || s.streamConnected {
+		return 0
+	}
+	return now.Sub(s.streamChangedAt)
+}
+
+type statusSnapshot struct {
+	StartedAt int64             `json:"startedAt"`
+	Leader    bool              `json:"leader"`
+	Sync      syncSnapshot      `json:"sync"`
+	Stream    streamSnapshot    `json:"stream"`
+	Events    map[string]int64  `json:"events"`
+	Errors    map[string]int64  `json:"errors"`
+	Projected map[string]int64  `json:"projected"`
+	Lag       lagSnapshot       `json:"lag"`
+	Shard     map[string]string `json:"shard"`
+}
+
+type syncSnapshot struct {
+	LastSuccessAt  int64  `json:"lastSuccessAt,omitempty"`
+	LastDurationMs int64  `json:"lastDurationMs"`
+	LastError      string `json:"lastError,omitempty"`
+	LastErrorAt    int64  `json:"lastErrorAt,omitempty"`
+	Runs           int64  `json:"runs"`
+	Failures       int64  `json:"failures"`
+}
+
+type streamSnapshot struct {
+	Enabled     bool  `json:"enabled"`
+	Connected   bool  `json:"connected"`
+	ChangedAt   int64 `json:"changedAt"`
+	Connects    int64 `json:"connects"`
+	LastEventAt int64 `json:"lastEventAt,omitempty"`
+}
+
+type lagSnapshot struct {
+	LastMs      int64 `json:"lastMs"`
+	MaxMs       int64 `json:"maxMs"`
+	AvgMs       int64 `json:"avgMs"`
+	Samples     int64 `json:"samples"`
+	LastApplyAt int64 `json:"lastApplyAt,omitempty"`
+}
+
+func (s *status) snapshot() statusSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := statusSnapshot{
+		StartedAt: s.startedAt.UnixMilli(),
+		Leader:    s.leader,
+		Sync: syncSnapshot{
+			LastSuccessAt:  unixMilli(s.lastSyncAt),
+			LastDurationMs: s.lastSyncDuration.Milliseconds(),
+			LastError:      s.lastSyncError,
+			LastErrorAt:    unixMilli(s.lastSyncErrorAt),
+			Runs:           s.syncRuns,
+			Failures:       s.syncFailures,
+		},
+		Stream: streamSnapshot{
+			Enabled:     s.streamEnabled,
+			Connected:   s.streamConnected,
+			ChangedAt:   s.streamChangedAt.UnixMilli(),
+			Connects:    s.streamConnects,
+			LastEventAt: unixMilli(s.streamLastEventAt),
+		},
+		Events:    make(map[string]int64, len(s.events)),
+		Errors:    make(map[string]int64, len(s.errors)),
+		Projected: make(map[string]int64, len(s.projected)),
+		Lag: lagSnapshot{
+			LastMs:      s.lastLag.Milliseconds(),
+			MaxMs:       s.maxLag.Milliseconds(),
+			Samples:     s.lagSamples,
+			LastApplyAt: unixMilli(s.lastApplyAt),
+		},
+	}
+	for k, v := range s.events {
+		snap.Events[k] = v
+	}
+	for k, v := range s.errors {
+		snap.Errors[k] = v
+	}
+	for k, v := range s.projected {
+		snap.Projected[k] = v
+	}
+	if s.lagSamples > 0 {
+		snap.Lag.AvgMs = (s.lagSum / time.Duration(s.lagSamples)).Milliseconds()
+	}
+	return snap
+}
+
+// StatusHandler serves /healthz, /readyz, /status (JSON) and /metrics
+// (Prometheus text format). Readiness fails once the RTDB stream has been
+// down longer than streamDownThreshold; a zero threshold disables the check.
+func (w *Worker) StatusHandler(streamDownThreshold time.Duration) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/healthz", func(rw http.ResponseWriter, _ *http.Request) {
+		httpapi.WriteJSON(rw, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	r.Get("/readyz", func(rw http.ResponseWriter, _ *http.Request) {
+		down := w.status.streamDownFor(time.Now())
+		if streamDownThreshold > 0 && down > streamDownThreshold {
+			httpapi.WriteError(rw, http.StatusServiceUnavailable, "stream_down",
+				fmt.Sprintf("rtdb stream down for %s", down.Round(time.Second)))
+			return
+		}
+		httpapi.WriteJSON(rw, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	r.Get("/status", func(rw http.ResponseWriter, _ *http.Request) {
+		httpapi.WriteJSON(rw, http.StatusOK, w.statusSnapshot())
+	})
+
+	r.Get("/metrics", func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = rw.Write([]byte(formatMetrics(w.statusSnapshot())))
+	})
+
+	return r
+}
+
+func (w *Worker) statusSnapshot() statusSnapshot {
+	snap := w.status.snapshot()
+	snap.Shard = map[string]string{
+		"instance": w.instanceID,
+		"range":    w.shard.String(),
+	}
+	return snap
+}
+
+func formatMetrics(snap statusSnapshot) string {
+	var b strings.Builder
+
+	gauge := func(name, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+	total := func(name, help string, value int64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+	}
+	counter := func(name, help, label string, values map[string]int64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s{%s=%q} %d\n", name, label, k, values[k])
+		}
+	}
+
+	gauge("sync_worker_leader", "Whether this instance holds the global lease.", boolMetric(snap.Leader))
+	gauge("sync_worker_last_sync_success_timestamp_ms", "Time of the last successful sync pass.", snap.Sync.LastSuccessAt)
+	gauge("sync_worker_last_sync_duration_ms", "Duration of the last successful sync pass.", snap.Sync.LastDurationMs)
+	total("sync_worker_sync_runs_total", "Sync passes started.", snap.Sync.Runs)
+	total("sync_worker_sync_failures_total", "Sync passes that failed.", snap.Sync.Failures)
+	gauge("sync_worker_stream_connected", "Whether the RTDB stream is connected.", boolMetric(snap.Stream.Connected))
+	total("sync_worker_stream_connects_total", "RTDB stream connections established.", snap.Stream.Connects)
+	counter("sync_worker_stream_events_total", "Stream events applied by path type.", "path_type", snap.Events)
+	counter("sync_worker_stream_errors_total", "Stream event errors by kind.", "kind", snap.Errors)
+	counter("sync_worker_projected_changes_total", "YDB changes projected into RTDB by entity type.", "entity_type", snap.Projected)
+	gauge("sync_worker_apply_lag_last_ms", "Time from receiving the last stream event to applying it in YDB.", snap.Lag.LastMs)
+	gauge("sync_worker_apply_lag_max_ms", "Maximum observed apply lag.", snap.Lag.MaxMs)
+	gauge("sync_worker_apply_lag_avg_ms", "Average apply lag.", snap.Lag.AvgMs)
+
+	return b.String()
+}
+
+// classifyUserPath maps a path relative to users/{uid} onto a metric label.
+func classifyUserPath(parts []string) string {
+	switch {
+	case len(parts) <= 1:
+		return pathUser
+	case parts[1] == "Category" && len(parts) >= 4 && parts[3] == "statements":
+		return pathStatement
+	case parts[1] == "Category":
+		return pathCategory
+	case parts[1] == "quickes":
+		return pathQuickes
+	case parts[1] == "inited":
+		return pathInited
+	default:
+		return pathOther
+	}
+}
+
+func boolMetric(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
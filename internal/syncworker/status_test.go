@@ -0,0 +1,82 @@
+package syncworker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linkasu/linka.type-backend/internal/shard"
+)
+
+func TestClassifyUserPath(t *testing.T) {
+	cases := map[string]string{
+		"uid":                           pathUser,
+		"uid/Category/cat":              pathCategory,
+		"uid/Category/cat/label":        pathCategory,
+		"uid/Category/cat/statements":   pathStatement,
+		"uid/Category/cat/statements/s": pathStatement,
+		"uid/quickes":                   pathQuickes,
+		"uid/inited":                    pathInited,
+		"uid/email":                     pathOther,
+	}
+	for path, want := range cases {
+		if got := classifyUserPath(strings.Split(path, "/")); got != want {
+			t.Fatalf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestStatusHandlerReadiness(t *testing.T) {
+	w := &Worker{instanceID: "test", shard: shard.All, status: newStatus()}
+	handler := w.StatusHandler(time.Minute)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Fatalf("expected ready without a stream, got %d", rec.Code)
+	}
+
+	w.status.setStreamEnabled(true)
+	w.status.streamChangedAt = time.Now().Add(-2 * time.Minute)
+	if rec := get("/readyz"); rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "stream_down") {
+		t.Fatalf("expected 503 stream_down, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	w.status.setStreamConnected(true)
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Fatalf("expected ready once the stream connects, got %d", rec.Code)
+	}
+}
+
+func TestStatusHandlerMetrics(t *testing.T) {
+	w := &Worker{instanceID: "test", shard: shard.All, status: newStatus()}
+	w.status.recordEvent(pathQuickes, time.Now().Add(-250*time.Millisecond))
+	w.status.recordEvent(pathInited, time.Now().Add(-50*time.Millisecond))
+	w.status.recordStreamError(streamErrDecode)
+
+	rec := httptest.NewRecorder()
+	w.StatusHandler(0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		`sync_worker_stream_events_total{path_type="quickes"} 1`,
+		`sync_worker_stream_events_total{path_type="inited"} 1`,
+		`sync_worker_stream_errors_total{kind="decode"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Fatalf("expected %q in metrics:\n%s", line, body)
+		}
+	}
+
+	snap := w.statusSnapshot()
+	if snap.Lag.Samples != 2 || snap.Lag.MaxMs < 250 || snap.Lag.LastMs < 50 {
+		t.Fatalf("expected lag from receive time for quickes and inited events, got %+v", snap.Lag)
+	}
+}
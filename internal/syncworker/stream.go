@@ -14,6 +14,7 @@ import (
 
 	"github.com/linkasu/linka.type-backend/internal/defaults"
 	"github.com/linkasu/linka.type-backend/internal/id"
+	"github.com/linkasu/linka.type-backend/internal/locale"
 	"github.com/linkasu/linka.type-backend/internal/models"
 	"golang.org/x/oauth2"
 )
@@ -67,18 +68,22 @@ func (w *Worker) streamOnce(ctx context.Context) error {
 		return fmt.Errorf("stream status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
+	w.status.setStreamConnected(true)
+	defer w.status.setStreamConnected(false)
+
 	scanner := bufio.NewScanner(resp.Body)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 
 	var eventName string
 	var data strings.Builder
+	var receivedAt time.Time
 
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" {
 			payload := strings.TrimSpace(data.String())
 			if eventName != "" && payload != "" {
-				if err := w.handleStreamEvent(ctx, eventName, payload); err != nil {
+				if err := w.handleStreamEvent(ctx, eventName, payload, receivedAt); err != nil {
 					return err
 				}
 			}
@@ -88,6 +93,7 @@ func (w *Worker) streamOnce(ctx context.Context) error {
 		}
 		if strings.HasPrefix(line, "event:") {
 			eventName = strings.TrimSpace(line[len("event:"):])
+			receivedAt = time.Now()
 			continue
 		}
 		if strings.HasPrefix(line, "data:") {
@@ -105,7 +111,9 @@ func (w *Worker) streamOnce(ctx context.Context) error {
 	return fmt.Errorf("stream ended")
 }
 
-func (w *Worker) handleStreamEvent(ctx context.Context, event, payload string) error {
+// handleStreamEvent applies one stream event; receivedAt is when its first
+// line was read and is the start of the apply lag.
+func (w *Worker) handleStreamEvent(ctx context.Context, event, payload string, receivedAt time.Time) error {
 	switch event {
 	case "put", "patch":
 		// continue
@@ -117,12 +125,17 @@ func (w *Worker) handleStreamEvent(ctx context.Context, event, payload string) e
 
 	var msg streamMessage
 	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		w.status.recordStreamError(streamErrDecode)
+		return err
+	}
+	if err := w.applyStreamEvent(ctx, msg.Path, msg.Data, receivedAt); err != nil {
+		w.status.recordStreamError(streamErrApply)
 		return err
 	}
-	return w.applyStreamEvent(ctx, msg.Path, msg.Data)
+	return nil
 }
 
-func (w *Worker) applyStreamEvent(ctx context.Context, path string, data json.RawMessage) error {
+func (w *Worker) applyStreamEvent(ctx context.Context, path string, data json.RawMessage, receivedAt time.Time) error {
 	root := strings.Trim(w.streamPath, "/")
 	relative := strings.Trim(path, "/")
 	if root == "" {
@@ -131,18 +144,18 @@ func (w *Worker) applyStreamEvent(ctx context.Context, path string, data json.Ra
 		}
 		relative = strings.TrimPrefix(relative, "users")
 		relative = strings.Trim(relative, "/")
-		return w.applyUserPath(ctx, relative, data)
+		return w.applyUserPath(ctx, relative, data, receivedAt)
 	}
 
 	if root == "users" {
-		return w.applyUserPath(ctx, relative, data)
+		return w.applyUserPath(ctx, relative, data, receivedAt)
 	}
 
 	// Unsupported stream root.
 	return nil
 }
 
-func (w *Worker) applyUserPath(ctx context.Context, path string, data json.RawMessage) error {
+func (w *Worker) applyUserPath(ctx context.Context, path string, data json.RawMessage, receivedAt time.Time) error {
 	if path == "" {
 		return nil
 	}
@@ -152,7 +165,37 @@ func (w *Worker) applyUserPath(ctx context.Context, path string, data json.RawMe
 	if userID == "" {
 		return nil
 	}
+	if !w.shard.Contains(userID) {
+		return nil
+	}
 
+	mode, err := w.migrationSync(ctx, userID)
+	if err != nil {
+		w.status.recordStreamError(streamErrUser)
+		slog.Warn("migration state lookup failed", "user_id", userID, "error", err)
+		return w.markDirty(ctx, userID, "stream_error")
+	}
+	switch {
+	case !mode.Pull:
+		return nil
+	case !mode.Overwrite:
+		// The event may not overwrite YDB; an add-only user sync picks up
+		// rows YDB never had.
+		return w.markDirty(ctx, userID, "stream")
+	}
+
+	if err := w.applyUserChange(ctx, userID, parts, data); err != nil {
+		// A failed event must not stall the stream; the dirty queue resyncs
+		// the whole user on the next pass.
+		w.status.recordStreamError(streamErrUser)
+		slog.Warn("rtdb stream apply failed", "user_id", userID, "path", path, "error", err)
+		return w.markDirty(ctx, userID, "stream_error")
+	}
+	w.status.recordEvent(classifyUserPath(parts), receivedAt)
+	return nil
+}
+
+func (w *Worker) applyUserChange(ctx context.Context, userID string, parts []string, data json.RawMessage) error {
 	if len(parts) == 1 {
 		if isNullData(data) {
 			return w.deleteUser(ctx, userID)
@@ -276,6 +319,11 @@ func (w *Worker) upsertCategoryFromMap(ctx context.Context, userID, categoryID s
 		Created:   int64From(raw["created"], now),
 		Default:   boolPtrFrom(raw["default"]),
 		AIUse:     aiUse != nil && *aiUse,
+		ParentID:  str(raw["parentId"], ""),
+		Position:  str(raw["position"], ""),
+		Color:     str(raw["color"], ""),
+		Icon:      str(raw["icon"], ""),
+		ImageID:   str(raw["imageId"], ""),
 		UpdatedAt: now,
 	}
 
@@ -309,6 +357,10 @@ func (w *Worker) upsertStatementFromMap(ctx context.Context, userID, categoryID,
 		CategoryID: str(raw["categoryId"], categoryID),
 		Text:       str(raw["text"], ""),
 		Created:    int64From(raw["created"], now),
+		Position:   str(raw["position"], ""),
+		Color:      str(raw["color"], ""),
+		Icon:       str(raw["icon"], ""),
+		ImageID:    str(raw["imageId"], ""),
 		UpdatedAt:  now,
 	}
 
@@ -369,7 +421,11 @@ func (w *Worker) applyQuickes(ctx context.Context, userID string, data json.RawM
 	}
 	quickes := parseQuickes(raw)
 	if len(quickes) == 0 {
-		quickes = defaults.DefaultQuickes
+		state, err := w.store.GetUserState(ctx, userID)
+		if err != nil {
+			return err
+		}
+		quickes = defaults.Quickes(locale.Resolve(state.Preferences, ""))
 	}
 	updatedAt := time.Now().UnixMilli()
 	updated, err := w.store.SetQuickes(ctx, userID, quickes, updatedAt)
@@ -413,6 +469,7 @@ func (w *Worker) appendChange(ctx context.Context, userID, entityType, entityID,
 		Op:         op,
 		Payload:    data,
 		UpdatedAt:  updatedAt,
+		Origin:     OriginRTDB,
 	})
 }
 
@@ -3,34 +3,94 @@ package syncworker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"slices"
 	"time"
 
 	"firebase.google.com/go/v4/db"
-	"github.com/linkasu/linka.type-backend/internal/defaults"
+	"github.com/linkasu/linka.type-backend/internal/feature"
 	"github.com/linkasu/linka.type-backend/internal/models"
+	"github.com/linkasu/linka.type-backend/internal/shard"
 	"github.com/linkasu/linka.type-backend/internal/store"
 	"github.com/linkasu/linka.type-backend/internal/ydb"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
 	"golang.org/x/oauth2"
 )
 
 // Worker syncs Firebase RTDB data into YDB.
 type Worker struct {
-	ydbClient       *ydb.Client
-	store           store.Store
-	firebase        *db.Client
-	legacy          store.LegacyReader
-	streamBaseURL   string
-	streamPath      string
-	streamReconnect time.Duration
-	tokenSource     oauth2.TokenSource
+	ydbClient        *ydb.Client
+	store            store.Store
+	firebase         *db.Client
+	legacy           store.LegacyReader
+	streamBaseURL    string
+	streamPath       string
+	streamReconnect  time.Duration
+	tokenSource      oauth2.TokenSource
+	instanceID       string
+	shard            shard.Range
+	leaseTTL         time.Duration
+	batchSize        int
+	fullScanInterval time.Duration
+	sweepInterval    time.Duration
+	status           *status
+	recorder         *Recorder
+	projection       store.LegacyWriter
+}
+
+// Options controls leader election and user sharding.
+type Options struct {
+	InstanceID       string
+	Shard            shard.Range
+	LeaseTTL         time.Duration
+	BatchSize        int
+	FullScanInterval time.Duration
+	SweepInterval    time.Duration
 }
 
 // New creates a sync worker.
 func New(ydbClient *ydb.Client, store store.Store, firebase *db.Client, legacyReader store.LegacyReader) *Worker {
-	return &Worker{ydbClient: ydbClient, store: store, firebase: firebase, legacy: legacyReader}
+	instanceID, _ := os.Hostname()
+	if instanceID == "" {
+		instanceID = "sync-worker"
+	}
+	return &Worker{
+		ydbClient:        ydbClient,
+		store:            store,
+		firebase:         firebase,
+		legacy:           legacyReader,
+		instanceID:       instanceID,
+		shard:            shard.All,
+		leaseTTL:         30 * time.Second,
+		batchSize:        100,
+		fullScanInterval: 24 * time.Hour,
+		sweepInterval:    15 * time.Minute,
+		status:           newStatus(),
+	}
+}
+
+// Configure sets the instance identity, its user hash range and queue settings.
+func (w *Worker) Configure(opts Options) {
+	if opts.InstanceID != "" {
+		w.instanceID = opts.InstanceID
+	}
+	if opts.Shard.Hi > opts.Shard.Lo {
+		w.shard = opts.Shard
+	}
+	if opts.LeaseTTL > 0 {
+		w.leaseTTL = opts.LeaseTTL
+	}
+	if opts.BatchSize > 0 {
+		w.batchSize = opts.BatchSize
+	}
+	w.fullScanInterval = opts.FullScanInterval
+	w.sweepInterval = opts.SweepInterval
 }
 
 // EnableStream configures RTDB streaming for incremental updates.
@@ -41,6 +101,11 @@ func (w *Worker) EnableStream(baseURL string, tokenSource oauth2.TokenSource, pa
 	if reconnect > 0 {
 		w.streamReconnect = reconnect
 	}
+	w.status.setStreamEnabled(baseURL != "" && tokenSource != nil)
+}
+
+func (w *Worker) streaming() bool {
+	return w.streamBaseURL != "" && w.tokenSource != nil
 }
 
 // Run starts the periodic sync loop.
@@ -48,11 +113,19 @@ func (w *Worker) Run(ctx context.Context, interval time.Duration) error {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := w.releaseLease(releaseCtx, globalLease); err != nil {
+			slog.Warn("failed to release lease", "lease", globalLease, "error", err)
+		}
+	}()
+
 	if err := w.SyncOnce(ctx); err != nil {
 		return err
 	}
 
-	if w.streamBaseURL != "" && w.tokenSource != nil {
+	if w.streaming() {
 		go w.runStream(ctx)
 	}
 
@@ -68,224 +141,257 @@ func (w *Worker) Run(ctx context.Context, interval time.Duration) error {
 	}
 }
 
-// SyncOnce performs a full sync pass.
+// SyncOnce performs one sync pass.
+// Global data is synced only by the lease holder; users are synced
+// incrementally from the dirty queue within this instance's hash range,
+// and pending YDB changes are projected back into RTDB when enabled.
+// Without the stream nothing else fills the queue, so the range is swept
+// into it once per SweepInterval.
 func (w *Worker) SyncOnce(ctx context.Context) error {
+	started := time.Now()
+	leader, err := w.syncOnce(ctx)
+	w.status.recordSync(started, leader, err)
+	return err
+}
+
+func (w *Worker) syncOnce(ctx context.Context) (bool, error) {
 	if w.firebase == nil {
-		return fmt.Errorf("firebase db client is nil")
+		return false, fmt.Errorf("firebase db client is nil")
 	}
-	if err := w.syncAdmins(ctx); err != nil {
-		return err
+
+	leader, err := w.acquireLease(ctx, globalLease)
+	if err != nil {
+		return false, err
 	}
-	if err := w.syncFactoryQuestions(ctx); err != nil {
-		return err
+	if leader {
+		if err := w.syncAdmins(ctx); err != nil {
+			return leader, err
+		}
+		if err := w.syncFactoryQuestions(ctx); err != nil {
+			return leader, err
+		}
+		if err := w.syncGlobalCategories(ctx); err != nil {
+			return leader, err
+		}
+		if err := w.scheduleFullScan(ctx); err != nil {
+			return leader, err
+		}
 	}
-	if err := w.syncGlobalCategories(ctx); err != nil {
-		return err
+
+	if !w.streaming() {
+		if err := w.sweepShard(ctx); err != nil {
+			return leader, err
+		}
 	}
-	if err := w.syncUsers(ctx); err != nil {
-		return err
+	if err := w.syncDirtyUsers(ctx); err != nil {
+		return leader, err
 	}
-	return nil
+	return leader, w.projectChanges(ctx)
 }
 
+// syncAdmins applies the difference between RTDB and YDB admin lists.
 func (w *Worker) syncAdmins(ctx context.Context) error {
 	ref := w.firebase.NewRef("admins")
 	var raw map[string]any
 	if err := ref.Get(ctx, &raw); err != nil {
 		return err
 	}
-	if err := w.exec(ctx, w.withPrefix("DELETE FROM admins;"), nil); err != nil {
+
+	current, err := w.store.ListAdmins(ctx)
+	if err != nil {
 		return err
 	}
+	existing := make(map[string]struct{}, len(current))
+	for _, userID := range current {
+		existing[userID] = struct{}{}
+	}
+
 	for userID := range raw {
-		query := w.withPrefix(`
-DECLARE $user_id AS Utf8;
-UPSERT INTO admins (user_id) VALUES ($user_id);`)
-		params := table.NewQueryParameters(
-			table.ValueParam("$user_id", types.UTF8Value(userID)),
-		)
-		if err := w.exec(ctx, query, params); err != nil {
+		if _, ok := existing[userID]; ok {
+			continue
+		}
+		if err := w.store.AddAdmin(ctx, userID); err != nil {
+			return err
+		}
+	}
+	for _, userID := range current {
+		if _, ok := raw[userID]; ok {
+			continue
+		}
+		if err := w.store.RemoveAdmin(ctx, userID); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (w *Worker) syncFactoryQuestions(ctx context.Context) error {
-	ref := w.firebase.NewRef("factory/questions")
-	var raw any
-	if err := ref.Get(ctx, &raw); err != nil {
+func factoryQuestionParams(q models.FactoryQuestion, extra ...table.ParameterOption) (*table.QueryParameters, error) {
+	phrases, err := json.Marshal(q.Phrases)
+	if err != nil {
+		return nil, err
+	}
+	return table.NewQueryParameters(append([]table.ParameterOption{
+		table.ValueParam("$question_id", types.UTF8Value(q.ID)),
+		table.ValueParam("$label", types.UTF8Value(q.Label)),
+		table.ValueParam("$phrases", types.JSONDocumentValue(string(phrases))),
+		table.ValueParam("$category", types.UTF8Value(q.Category)),
+		table.ValueParam("$type", types.UTF8Value(q.Type)),
+		table.ValueParam("$order_index", types.Int64Value(int64(q.OrderIndex))),
+	}, extra...)...), nil
+}
+
+func globalCategoryParams(cat models.GlobalCategory, extra ...table.ParameterOption) *table.QueryParameters {
+	return table.NewQueryParameters(append([]table.ParameterOption{
+		table.ValueParam("$category_id", types.UTF8Value(cat.ID)),
+		table.ValueParam("$label", types.UTF8Value(cat.Label)),
+		table.ValueParam("$created_at", types.Int64Value(cat.Created)),
+		table.ValueParam("$is_default", optionalBool(cat.Default)),
+		table.ValueParam("$updated_at", types.Int64Value(cat.UpdatedAt)),
+	}, extra...)...)
+}
+
+func globalStatementParams(stmt models.Statement, extra ...table.ParameterOption) *table.QueryParameters {
+	return table.NewQueryParameters(append([]table.ParameterOption{
+		table.ValueParam("$category_id", types.UTF8Value(stmt.CategoryID)),
+		table.ValueParam("$statement_id", types.UTF8Value(stmt.ID)),
+		table.ValueParam("$text", types.UTF8Value(stmt.Text)),
+		table.ValueParam("$created_at", types.Int64Value(stmt.Created)),
+		table.ValueParam("$updated_at", types.Int64Value(stmt.UpdatedAt)),
+	}, extra...)...)
+}
+
+// syncUser copies one user's RTDB data into YDB as far as their migration
+// state allows. Only rows whose RTDB content differs from YDB are written;
+// see planUserRows.
+func (w *Worker) syncUser(ctx context.Context, userID string) error {
+	mode, err := w.migrationSync(ctx, userID)
+	if err != nil {
 		return err
 	}
-	questions := parseFactoryQuestions(raw)
+	if !mode.Pull {
+		return nil
+	}
 
-	if err := w.exec(ctx, w.withPrefix("DELETE FROM factory_questions;"), nil); err != nil {
+	categories, statements, err := w.fetchUserData(ctx, userID)
+	if err != nil {
+		return err
+	}
+	storedCategories, err := w.store.ListCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	storedStatements, err := w.store.ListAllStatements(ctx, userID)
+	if err != nil {
 		return err
 	}
 
-	for _, q := range questions {
-		phrases, err := json.Marshal(q.Phrases)
+	now := time.Now().UnixMilli()
+	var plan userRows
+	if mode.Overwrite {
+		plan = planUserRows(categories, statements, storedCategories, storedStatements, now)
+	} else {
+		knownCategories := make(map[string]bool, len(storedCategories))
+		for _, cat := range storedCategories {
+			knownCategories[cat.ID] = true
+		}
+		knownStatements := make(map[string]bool, len(storedStatements))
+		for _, stmt := range storedStatements {
+			knownStatements[stmt.ID] = true
+		}
+		trashedCategories, err := w.store.ListDeletedCategories(ctx, userID)
 		if err != nil {
 			return err
 		}
-		query := w.withPrefix(`
-DECLARE $question_id AS Utf8;
-DECLARE $label AS Utf8;
-DECLARE $phrases AS JsonDocument;
-DECLARE $category AS Utf8;
-DECLARE $type AS Utf8;
-DECLARE $order_index AS Int64;
-UPSERT INTO factory_questions (question_id, label, phrases, category, type, order_index)
-VALUES ($question_id, $label, $phrases, $category, $type, $order_index);`)
-
-		params := table.NewQueryParameters(
-			table.ValueParam("$question_id", types.UTF8Value(q.ID)),
-			table.ValueParam("$label", types.UTF8Value(q.Label)),
-			table.ValueParam("$phrases", types.JSONDocumentValue(string(phrases))),
-			table.ValueParam("$category", types.UTF8Value(q.Category)),
-			table.ValueParam("$type", types.UTF8Value(q.Type)),
-			table.ValueParam("$order_index", types.Int64Value(int64(q.OrderIndex))),
-		)
-		if err := w.exec(ctx, query, params); err != nil {
+		trashedStatements, err := w.store.ListDeletedStatements(ctx, userID)
+		if err != nil {
 			return err
 		}
+		trashedIDs(trashedCategories, trashedStatements, knownCategories, knownStatements)
+		plan = planMissingRows(categories, statements, knownCategories, knownStatements, now)
 	}
-
-	return nil
-}
-
-func (w *Worker) syncGlobalCategories(ctx context.Context) error {
-	ref := w.firebase.NewRef("global/Category")
-	var raw map[string]any
-	if err := ref.Get(ctx, &raw); err != nil {
-		return err
+	for _, cat := range plan.Categories {
+		if _, err := w.store.UpsertCategory(ctx, userID, cat); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range plan.Statements {
+		if _, err := w.store.UpsertStatement(ctx, userID, stmt); err != nil {
+			return err
+		}
+	}
+	if !mode.Overwrite {
+		return nil
 	}
 
-	if err := w.exec(ctx, w.withPrefix("DELETE FROM global_categories;"), nil); err != nil {
+	remoteState, err := w.fetchUserState(ctx, userID)
+	if err != nil {
 		return err
 	}
-	if err := w.exec(ctx, w.withPrefix("DELETE FROM global_statements;"), nil); err != nil {
+	storedState, err := w.store.GetUserState(ctx, userID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
 		return err
 	}
-
-	now := time.Now().UnixMilli()
-	for key, rawCat := range raw {
-		cat, ok := rawCat.(map[string]any)
-		if !ok {
-			continue
-		}
-		categoryID := str(cat["id"], key)
-		label := str(cat["label"], "")
-		created := int64From(cat["created"], now)
-		updatedAt := created
-		isDefault := boolPtrFrom(cat["default"])
-
-		query := w.withPrefix(`
-DECLARE $category_id AS Utf8;
-DECLARE $label AS Utf8;
-DECLARE $created_at AS Int64;
-DECLARE $is_default AS Bool?;
-DECLARE $updated_at AS Int64;
-UPSERT INTO global_categories (category_id, label, created_at, is_default, updated_at)
-VALUES ($category_id, $label, $created_at, $is_default, $updated_at);`)
-		params := table.NewQueryParameters(
-			table.ValueParam("$category_id", types.UTF8Value(categoryID)),
-			table.ValueParam("$label", types.UTF8Value(label)),
-			table.ValueParam("$created_at", types.Int64Value(created)),
-			table.ValueParam("$is_default", optionalBool(isDefault)),
-			table.ValueParam("$updated_at", types.Int64Value(updatedAt)),
-		)
-		if err := w.exec(ctx, query, params); err != nil {
+	if state, changed := mergeUserState(storedState, remoteState); changed {
+		if _, err := w.store.SetUserState(ctx, userID, state, now); err != nil {
 			return err
 		}
-
-		statements, _ := cat["statements"].(map[string]any)
-		for stmtKey, rawStmt := range statements {
-			stmtMap, ok := rawStmt.(map[string]any)
-			if !ok {
-				continue
-			}
-			statementID := str(stmtMap["id"], stmtKey)
-			text := str(stmtMap["text"], "")
-			stmtCreated := int64From(stmtMap["created"], now)
-			stmtUpdated := stmtCreated
-
-			stmtQuery := w.withPrefix(`
-DECLARE $category_id AS Utf8;
-DECLARE $statement_id AS Utf8;
-DECLARE $text AS Utf8;
-DECLARE $created_at AS Int64;
-DECLARE $updated_at AS Int64;
-UPSERT INTO global_statements (category_id, statement_id, text, created_at, updated_at)
-VALUES ($category_id, $statement_id, $text, $created_at, $updated_at);`)
-			stmtParams := table.NewQueryParameters(
-				table.ValueParam("$category_id", types.UTF8Value(categoryID)),
-				table.ValueParam("$statement_id", types.UTF8Value(statementID)),
-				table.ValueParam("$text", types.UTF8Value(text)),
-				table.ValueParam("$created_at", types.Int64Value(stmtCreated)),
-				table.ValueParam("$updated_at", types.Int64Value(stmtUpdated)),
-			)
-			if err := w.exec(ctx, stmtQuery, stmtParams); err != nil {
-				return err
-			}
-		}
 	}
-
 	return nil
 }
 
-func (w *Worker) syncUsers(ctx context.Context) error {
-	ref := w.firebase.NewRef("users")
-	var raw map[string]any
-	if err := ref.Get(ctx, &raw); err != nil {
-		return err
+// migrationSync resolves what the worker may do for the user. Users without
+// a migration row follow the read-source flag, which never maps past dual,
+// so they sync fully.
+func (w *Worker) migrationSync(ctx context.Context, userID string) (migrationSync, error) {
+	migration, err := w.store.GetUserMigration(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return syncForState(feature.MigrationFirebase), nil
 	}
-	if raw == nil {
-		return nil
+	if err != nil {
+		return migrationSync{}, err
 	}
+	return syncForState(migration.State), nil
+}
 
-	for userID := range raw {
-		categories, statements, err := w.fetchUserData(ctx, userID)
-		if err != nil {
-			return err
-		}
-		state, err := w.fetchUserState(ctx, userID)
-		if err != nil {
-			return err
-		}
-
-		for _, cat := range categories {
-			if cat.Created == 0 {
-				cat.Created = time.Now().UnixMilli()
-			}
-			if cat.UpdatedAt == 0 {
-				cat.UpdatedAt = cat.Created
-			}
-			if _, err := w.store.UpsertCategory(ctx, userID, cat); err != nil {
-				return err
-			}
+// withoutDecommissioned drops users whose Firebase copy is decommissioned,
+// so sweeps and full scans do not queue them.
+func (w *Worker) withoutDecommissioned(ctx context.Context, userIDs []string) ([]string, error) {
+	query := w.withPrefix(`
+DECLARE $user_ids AS List<Utf8>;
+DECLARE $state AS Utf8;
+SELECT user_id
+FROM user_migration
+WHERE user_id IN $user_ids AND state = $state;`)
+
+	skip := make(map[string]bool)
+	for start := 0; start < len(userIDs); start += enqueueChunkSize {
+		end := min(start+enqueueChunkSize, len(userIDs))
+		ids := make([]types.Value, 0, end-start)
+		for _, userID := range userIDs[start:end] {
+			ids = append(ids, types.UTF8Value(userID))
 		}
-		for _, stmt := range statements {
-			if stmt.Created == 0 {
-				stmt.Created = time.Now().UnixMilli()
-			}
-			if stmt.UpdatedAt == 0 {
-				stmt.UpdatedAt = stmt.Created
-			}
-			if _, err := w.store.UpsertStatement(ctx, userID, stmt); err != nil {
-				return err
+		params := table.NewQueryParameters(
+			table.ValueParam("$user_ids", types.ListValue(ids...)),
+			table.ValueParam("$state", types.UTF8Value(feature.MigrationFirebaseDecommissioned)),
+		)
+		err := w.read(ctx, query, params, func(res result.Result) error {
+			for res.NextRow() {
+				var userID string
+				if err := res.ScanNamed(named.Required("user_id", &userID)); err != nil {
+					return err
+				}
+				skip[userID] = true
 			}
-		}
-
-		if len(state.Quickes) == 0 {
-			state.Quickes = defaults.DefaultQuickes
-		}
-		if _, err := w.store.SetUserState(ctx, userID, state, time.Now().UnixMilli()); err != nil {
-			return err
+			return res.Err()
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
-
-	return nil
+	if len(skip) == 0 {
+		return userIDs, nil
+	}
+	return slices.DeleteFunc(userIDs, func(userID string) bool { return skip[userID] }), nil
 }
 
 func (w *Worker) fetchUserData(ctx context.Context, userID string) ([]models.Category, []models.Statement, error) {
@@ -307,12 +413,33 @@ func (w *Worker) withPrefix(query string) string {
 }
 
 func (w *Worker) exec(ctx context.Context, query string, params *table.QueryParameters) error {
+	if w.recorder != nil {
+		w.recorder.recordQuery(query, params)
+		return nil
+	}
 	return w.ydbClient.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
 		_, _, err := sess.Execute(ctx, table.DefaultTxControl(), query, params)
 		return err
 	}, table.WithIdempotent())
 }
 
+// read runs a read-only query and hands the first result set to scan.
+// scan may be called again on retry and must reset its accumulators.
+func (w *Worker) read(ctx context.Context, query string, params *table.QueryParameters, scan func(res result.Result) error) error {
+	return w.ydbClient.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+		_, res, err := sess.Execute(ctx, table.OnlineReadOnlyTxControl(), query, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		if err := res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		return scan(res)
+	}, table.WithIdempotent())
+}
+
 func optionalBool(val *bool) types.Value {
 	if val == nil {
 		return types.NullValue(types.TypeBool)
@@ -8,6 +8,8 @@ import (
 
 type ctxKey struct{}
 
+type actorKey struct{}
+
 // With stores auth user in context.
 func With(ctx context.Context, user auth.User) context.Context {
 	return context.WithValue(ctx, ctxKey{}, user)
@@ -18,3 +20,15 @@ func From(ctx context.Context) (auth.User, bool) {
 	user, ok := ctx.Value(ctxKey{}).(auth.User)
 	return user, ok
 }
+
+// WithActor stores the delegate acting for the user in context.
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// Actor returns the delegate acting for the user, or "" for the user
+// themselves.
+func Actor(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorKey{}).(string)
+	return actorID
+}
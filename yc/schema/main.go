@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/linkasu/linka.type-backend/internal/config"
@@ -32,6 +33,11 @@ var schema = []string{
   created_at Int64 NOT NULL,
   is_default Optional<Bool>,
   ai_use Optional<Bool>,
+  parent_id Optional<Utf8>,
+  position Optional<Utf8>,
+  color Optional<Utf8>,
+  icon Optional<Utf8>,
+  image_id Optional<Utf8>,
   updated_at Int64 NOT NULL,
   deleted_at Optional<Int64>,
   PRIMARY KEY (user_id, category_id)
@@ -42,6 +48,10 @@ var schema = []string{
   statement_id Utf8 NOT NULL,
   text Utf8 NOT NULL,
   created_at Int64 NOT NULL,
+  position Optional<Utf8>,
+  color Optional<Utf8>,
+  icon Optional<Utf8>,
+  image_id Optional<Utf8>,
   updated_at Int64 NOT NULL,
   deleted_at Optional<Int64>,
   PRIMARY KEY (user_id, category_id, statement_id)
@@ -58,6 +68,7 @@ var schema = []string{
   label Utf8 NOT NULL,
   created_at Int64 NOT NULL,
   is_default Optional<Bool>,
+  parent_id Optional<Utf8>,
   updated_at Int64 NOT NULL,
   deleted_at Optional<Int64>,
   PRIMARY KEY (category_id)
@@ -69,7 +80,15 @@ var schema = []string{
   created_at Int64 NOT NULL,
   updated_at Int64 NOT NULL,
   deleted_at Optional<Int64>,
+  position Optional<Utf8>,
   PRIMARY KEY (category_id, statement_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS global_imports (
+  user_id Utf8 NOT NULL,
+  category_id Utf8 NOT NULL,
+  version Int64 NOT NULL,
+  imported_at Int64 NOT NULL,
+  PRIMARY KEY (user_id, category_id)
 );`,
 	`CREATE TABLE IF NOT EXISTS factory_questions (
   question_id Utf8 NOT NULL,
@@ -79,6 +98,23 @@ var schema = []string{
   type Utf8 NOT NULL,
   order_index Int64 NOT NULL,
   PRIMARY KEY (question_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS global_translations (
+  kind Utf8 NOT NULL,
+  entity_id Utf8 NOT NULL,
+  locale Utf8 NOT NULL,
+  text Utf8 NOT NULL,
+  phrases JsonDocument,
+  category Utf8,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (kind, entity_id, locale)
+);`,
+	`CREATE TABLE IF NOT EXISTS onboarding_answers (
+  user_id Utf8 NOT NULL,
+  question_id Utf8 NOT NULL,
+  answer JsonDocument NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (user_id, question_id)
 );`,
 	`CREATE TABLE IF NOT EXISTS changes (
   user_id Utf8 NOT NULL,
@@ -88,6 +124,8 @@ var schema = []string{
   op Utf8 NOT NULL,
   payload JsonDocument NOT NULL,
   updated_at Int64 NOT NULL,
+  origin Optional<Utf8>,
+  actor_id Optional<Utf8>,
   PRIMARY KEY (user_id, cursor)
 );`,
 	`CREATE TABLE IF NOT EXISTS client_keys (
@@ -153,6 +191,218 @@ var schema = []string{
   updated_at Int64 NOT NULL,
   PRIMARY KEY (user_id, month)
 );`,
+	`CREATE TABLE IF NOT EXISTS sync_leases (
+  name Utf8 NOT NULL,
+  holder Utf8 NOT NULL,
+  expires_at Int64 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (name)
+);`,
+	`CREATE TABLE IF NOT EXISTS sync_dirty_users (
+  bucket Int64 NOT NULL,
+  user_id Utf8 NOT NULL,
+  reason Utf8 NOT NULL,
+  enqueued_at Int64 NOT NULL,
+  PRIMARY KEY (bucket, user_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS sync_checkpoints (
+  name Utf8 NOT NULL,
+  value Utf8 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (name)
+);`,
+	`CREATE TABLE IF NOT EXISTS sync_projection_pending (
+  bucket Int64 NOT NULL,
+  user_id Utf8 NOT NULL,
+  cursor Utf8 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (bucket, user_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS sync_projection_checkpoints (
+  user_id Utf8 NOT NULL,
+  cursor Utf8 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (user_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS user_migration (
+  user_id Utf8 NOT NULL,
+  state Utf8 NOT NULL,
+  note Optional<Utf8>,
+  verified_at Optional<Int64>,
+  updated_at Int64 NOT NULL,
+  updated_by Optional<Utf8>,
+  PRIMARY KEY (user_id),
+  INDEX idx_state GLOBAL ON (state)
+);`,
+	`CREATE TABLE IF NOT EXISTS statement_usage (
+  user_id Utf8 NOT NULL,
+  statement_id Utf8 NOT NULL,
+  spoken_count Int64 NOT NULL,
+  last_spoken_at Int64 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (user_id, statement_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS quicke_usage (
+  user_id Utf8 NOT NULL,
+  slot Int64 NOT NULL,
+  text Utf8 NOT NULL,
+  spoken_count Int64 NOT NULL,
+  last_spoken_at Int64 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (user_id, slot)
+);`,
+	`CREATE TABLE IF NOT EXISTS spoken_events (
+  user_id Utf8 NOT NULL,
+  event_id Utf8 NOT NULL,
+  recorded_at Uint64 NOT NULL,
+  PRIMARY KEY (user_id, event_id)
+) WITH (TTL = Interval("P30D") ON recorded_at AS MILLISECONDS);`,
+	`CREATE TABLE IF NOT EXISTS search_terms (
+  user_id Utf8 NOT NULL,
+  entity_type Utf8 NOT NULL,
+  entity_id Utf8 NOT NULL,
+  term Utf8 NOT NULL,
+  parent_id Utf8 NOT NULL,
+  weight Int64 NOT NULL,
+  PRIMARY KEY (user_id, entity_type, entity_id, term),
+  INDEX idx_term GLOBAL ON (user_id, term) COVER (parent_id, weight)
+);`,
+	`CREATE TABLE IF NOT EXISTS media (
+  user_id Utf8 NOT NULL,
+  media_id Utf8 NOT NULL,
+  content_type Utf8 NOT NULL,
+  size Int64 NOT NULL,
+  width Int64 NOT NULL,
+  height Int64 NOT NULL,
+  blob_key Utf8 NOT NULL,
+  thumbnail_key Utf8 NOT NULL,
+  created_at Int64 NOT NULL,
+  PRIMARY KEY (user_id, media_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS revisions (
+  user_id Utf8 NOT NULL,
+  entity_type Utf8 NOT NULL,
+  entity_id Utf8 NOT NULL,
+  revision_id Utf8 NOT NULL,
+  op Utf8 NOT NULL,
+  snapshot JsonDocument NOT NULL,
+  device Utf8,
+  created_at Int64 NOT NULL,
+  PRIMARY KEY (user_id, entity_type, entity_id, revision_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS export_jobs (
+  user_id Utf8 NOT NULL,
+  job_id Utf8 NOT NULL,
+  format Utf8 NOT NULL,
+  status Utf8 NOT NULL,
+  size Int64,
+  error Utf8,
+  blob_key Utf8,
+  created_at Int64 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (user_id, job_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS shares (
+  share_token Utf8 NOT NULL,
+  owner_id Utf8 NOT NULL,
+  category_id Utf8 NOT NULL,
+  mode Utf8 NOT NULL,
+  expires_at Int64 NOT NULL,
+  created_at Int64 NOT NULL,
+  PRIMARY KEY (share_token),
+  INDEX idx_owner GLOBAL ON (owner_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS share_subscriptions (
+  owner_id Utf8 NOT NULL,
+  recipient_id Utf8 NOT NULL,
+  category_id Utf8 NOT NULL,
+  share_token Utf8 NOT NULL,
+  created_at Int64 NOT NULL,
+  PRIMARY KEY (owner_id, recipient_id, category_id),
+  INDEX idx_recipient GLOBAL ON (recipient_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS share_sync_pending (
+  owner_id Utf8 NOT NULL,
+  due_at Int64 NOT NULL,
+  claimed_until Optional<Int64>,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (owner_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS grants (
+  owner_id Utf8 NOT NULL,
+  delegate_id Utf8 NOT NULL,
+  access Utf8 NOT NULL,
+  created_at Int64 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (owner_id, delegate_id),
+  INDEX idx_delegate GLOBAL ON (delegate_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS organizations (
+  org_id Utf8 NOT NULL,
+  name Utf8 NOT NULL,
+  created_at Int64 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (org_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS org_members (
+  org_id Utf8 NOT NULL,
+  user_id Utf8 NOT NULL,
+  role Utf8 NOT NULL,
+  status Utf8 NOT NULL,
+  created_at Int64 NOT NULL,
+  PRIMARY KEY (org_id, user_id),
+  INDEX idx_user GLOBAL ON (user_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS org_categories (
+  org_id Utf8 NOT NULL,
+  category_id Utf8 NOT NULL,
+  label Utf8 NOT NULL,
+  parent_id Utf8,
+  color Utf8,
+  icon Utf8,
+  created_at Int64 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (org_id, category_id)
+);`,
+	`CREATE TABLE IF NOT EXISTS org_statements (
+  org_id Utf8 NOT NULL,
+  statement_id Utf8 NOT NULL,
+  category_id Utf8 NOT NULL,
+  text Utf8 NOT NULL,
+  color Utf8,
+  icon Utf8,
+  created_at Int64 NOT NULL,
+  updated_at Int64 NOT NULL,
+  PRIMARY KEY (org_id, statement_id)
+);`,
+}
+
+// alterations add columns and settings to tables created by earlier schema
+// versions. Re-running them reports the column as existing, which is
+// ignored; re-applying a TTL is a no-op.
+var alterations = []string{
+	`ALTER TABLE changes ADD COLUMN origin Utf8;`,
+	`ALTER TABLE categories ADD COLUMN position Utf8;`,
+	`ALTER TABLE statements ADD COLUMN position Utf8;`,
+	`ALTER TABLE categories ADD COLUMN parent_id Utf8;`,
+	`ALTER TABLE global_categories ADD COLUMN parent_id Utf8;`,
+	`ALTER TABLE categories ADD COLUMN color Utf8;`,
+	`ALTER TABLE categories ADD COLUMN icon Utf8;`,
+	`ALTER TABLE categories ADD COLUMN image_id Utf8;`,
+	`ALTER TABLE statements ADD COLUMN color Utf8;`,
+	`ALTER TABLE statements ADD COLUMN icon Utf8;`,
+	`ALTER TABLE statements ADD COLUMN image_id Utf8;`,
+	`ALTER TABLE changes ADD COLUMN actor_id Utf8;`,
+	`ALTER TABLE global_statements ADD COLUMN position Utf8;`,
+	`ALTER TABLE factory_questions ADD COLUMN options JsonDocument;`,
+	`ALTER TABLE factory_questions ADD COLUMN placeholder Utf8;`,
+	`ALTER TABLE factory_questions ADD COLUMN show_if JsonDocument;`,
+	`ALTER TABLE global_translations ADD COLUMN options JsonDocument;`,
+	`ALTER TABLE global_categories ADD COLUMN source_hash Utf8;`,
+	`ALTER TABLE global_statements ADD COLUMN source_hash Utf8;`,
+	`ALTER TABLE factory_questions ADD COLUMN deleted_at Int64;`,
+	`ALTER TABLE factory_questions ADD COLUMN source_hash Utf8;`,
+	`ALTER TABLE spoken_events SET (TTL = Interval("P30D") ON recorded_at AS MILLISECONDS);`,
 }
 
 func main() {
@@ -183,6 +433,17 @@ func main() {
 		}
 	}
 
+	for _, stmt := range alterations {
+		query := withPrefix(client.Database(), stmt)
+		err := client.Table().Do(ctx, func(ctx context.Context, sess table.Session) error {
+			return sess.ExecuteSchemeQuery(ctx, query)
+		}, table.WithIdempotent())
+		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already exist") {
+			fmt.Fprintf(os.Stderr, "schema alter failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	fmt.Println("schema applied")
 }
 